@@ -0,0 +1,241 @@
+package main
+
+import (
+    "archive/zip"
+    "encoding/csv"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/archive"
+    "go-blog-backend/services"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// commandUsage describes the commands accepted in place of starting the
+// server.
+const commandUsage = `usage: go-blog-backend [command] [flags]
+
+Without a command, the server is started. Commands:
+  import-hugo -author <user id> [-dry-run] [-json] <site directory or ZIP archive>
+        import the Markdown posts of a Hugo site
+  import-wordpress [-author <user id>] [-dry-run] [-json] [-redirects <CSV file>] <WXR export>
+        import the posts, pages and comments of a WordPress export
+  export-hugo <directory or ZIP archive>
+        export the published posts and their images as Hugo content`
+
+// runCommand runs the command with the given name and arguments instead of
+// the server.
+func runCommand(name string, args []string, importService *services.ImportService, exportService *services.ExportService) error {
+    switch name {
+    case "import-hugo":
+        return importHugo(args, importService)
+    case "import-wordpress":
+        return importWordPress(args, importService)
+    case "export-hugo":
+        return exportHugo(args, exportService)
+    case "help", "-h", "-help", "--help":
+        fmt.Println(commandUsage)
+        return nil
+    default:
+        return fmt.Errorf("unknown command %q\n%s", name, commandUsage)
+    }
+}
+
+// importHugo imports the Hugo site given as a directory or a ZIP archive and
+// prints the report of the import.
+func importHugo(args []string, importService *services.ImportService) error {
+    flags := flag.NewFlagSet("import-hugo", flag.ContinueOnError)
+    authorID := flags.String("author", "", "ID of the user the imported posts belong to")
+    dryRun := flags.Bool("dry-run", false, "report what would be imported without writing anything")
+    asJSON := flags.Bool("json", false, "print the report as JSON")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if *authorID == "" || flags.NArg() != 1 {
+        return fmt.Errorf("usage: go-blog-backend import-hugo -author <user id> [-dry-run] [-json] <site directory or ZIP archive>")
+    }
+
+    site, closeSite, err := openSite(flags.Arg(0))
+    if err != nil {
+        return err
+    }
+    defer closeSite()
+
+    report, err := importService.ImportHugo(site, models.ImportOptions{
+        AuthorID: *authorID,
+        DryRun:   *dryRun,
+    })
+    if err != nil {
+        return err
+    }
+
+    return writeReport(report, *asJSON)
+}
+
+// importWordPress imports the given WordPress export and prints the report
+// of the import, optionally writing the old address and new slug of every
+// imported post to a CSV file.
+func importWordPress(args []string, importService *services.ImportService) error {
+    flags := flag.NewFlagSet("import-wordpress", flag.ContinueOnError)
+    authorID := flags.String("author", "", "ID of the user the posts of authors missing from the export belong to")
+    dryRun := flags.Bool("dry-run", false, "report what would be imported without writing anything")
+    asJSON := flags.Bool("json", false, "print the report as JSON")
+    redirects := flags.String("redirects", "", "CSV file to write the old address and new slug of every post to")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return fmt.Errorf("usage: go-blog-backend import-wordpress [-author <user id>] [-dry-run] [-json] [-redirects <CSV file>] <WXR export>")
+    }
+
+    export, err := os.Open(flags.Arg(0))
+    if err != nil {
+        return err
+    }
+    defer export.Close()
+
+    report, err := importService.ImportWordPress(export, models.ImportOptions{
+        AuthorID: *authorID,
+        DryRun:   *dryRun,
+    })
+    if err != nil {
+        return err
+    }
+
+    if *redirects != "" {
+        if err := writeRedirects(*redirects, report); err != nil {
+            return err
+        }
+    }
+    return writeReport(report, *asJSON)
+}
+
+// exportHugo exports the published posts to the given directory, or to a
+// ZIP archive when the path ends with .zip, and prints the totals.
+func exportHugo(args []string, exportService *services.ExportService) error {
+    if len(args) != 1 {
+        return fmt.Errorf("usage: go-blog-backend export-hugo <directory or ZIP archive>")
+    }
+    target := args[0]
+
+    var writer archive.Writer
+    if strings.EqualFold(filepath.Ext(target), ".zip") {
+        file, err := os.Create(target)
+        if err != nil {
+            return err
+        }
+        defer file.Close()
+        writer = archive.NewZip(file)
+    } else {
+        dir, err := archive.NewDir(target)
+        if err != nil {
+            return err
+        }
+        writer = dir
+    }
+
+    report, err := exportService.ExportHugo(writer)
+    if err != nil {
+        return err
+    }
+    if err := writer.Close(); err != nil {
+        return err
+    }
+
+    for _, warning := range report.Warnings {
+        fmt.Printf("warning: %s\n", warning)
+    }
+    fmt.Printf("%d post(s) and %d image(s) exported to %s\n", report.Posts, report.Images, target)
+    return nil
+}
+
+// writeRedirects writes the old address and the new slug of every post of
+// the report that was imported to a CSV file with the given name.
+func writeRedirects(name string, report *models.ImportReport) error {
+    file, err := os.Create(name)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    writer := csv.NewWriter(file)
+    writer.Write([]string{"old_url", "slug"})
+    for _, item := range report.Items {
+        if item.OldURL == "" || item.Slug == "" || item.Action == models.ImportFailed || item.Action == models.ImportSkipped {
+            continue
+        }
+        writer.Write([]string{item.OldURL, item.Slug})
+    }
+    writer.Flush()
+    if err := writer.Error(); err != nil {
+        return err
+    }
+    return file.Close()
+}
+
+// writeReport prints the report of an import, as JSON or as text.
+func writeReport(report *models.ImportReport, asJSON bool) error {
+    if asJSON {
+        encoder := json.NewEncoder(os.Stdout)
+        encoder.SetIndent("", "  ")
+        return encoder.Encode(report)
+    }
+    printReport(report)
+    return nil
+}
+
+// openSite opens the directory or ZIP archive at the given path, returning a
+// function that closes it.
+func openSite(path string) (fs.FS, func() error, error) {
+    info, err := os.Stat(path)
+    if err != nil {
+        return nil, nil, err
+    }
+    if info.IsDir() {
+        return os.DirFS(path), func() error { return nil }, nil
+    }
+
+    archive, err := zip.OpenReader(path)
+    if err != nil {
+        return nil, nil, fmt.Errorf("%s is neither a directory nor a ZIP archive: %v", path, err)
+    }
+    return archive, archive.Close, nil
+}
+
+// printReport prints a line for every imported file or entry, followed by
+// its warnings, and the totals of the import.
+func printReport(report *models.ImportReport) {
+    for _, item := range report.Items {
+        source := item.Source
+        if item.OldURL != "" {
+            source = item.OldURL
+        }
+        switch item.Action {
+        case models.ImportFailed, models.ImportSkipped:
+            fmt.Printf("%-9s  %s: %s\n", item.Action, source, item.Error)
+        default:
+            fmt.Printf("%-9s  %s -> %s\n", item.Action, source, item.Slug)
+        }
+        for _, warning := range item.Warnings {
+            fmt.Printf("           warning: %s\n", warning)
+        }
+    }
+
+    uploaded, created := "uploaded", "created"
+    if report.DryRun {
+        uploaded, created = "to upload", "to create"
+    }
+    summary := fmt.Sprintf("%d created, %d updated, %d unchanged, %d skipped, %d failed, %d image(s) %s",
+        report.Created, report.Updated, report.Unchanged, report.Skipped, report.Failed, report.Images, uploaded)
+    if report.Users > 0 || report.Categories > 0 || report.Comments > 0 {
+        summary += fmt.Sprintf("; %d user(s), %d category(ies) and %d comment(s) %s", report.Users, report.Categories, report.Comments, created)
+    }
+    if report.DryRun {
+        summary = "Dry run, nothing was written: " + summary
+    }
+    fmt.Println(summary)
+}
@@ -2,6 +2,9 @@ package config
 
 import (
     "os"
+    "strconv"
+    "strings"
+    "time"
     "github.com/joho/godotenv"
 )
 
@@ -14,6 +17,30 @@ type Config struct {
     R2AccessKeySecret string
     R2BucketName    string
     R2PublicURL     string // Đổi tên từ PublicURL thành R2PublicURL
+    PublishInterval time.Duration // How often scheduled posts are checked
+    SearchEngine    string        // "mongo" (default) or "bleve"
+    SearchIndexPath string        // Where the bleve index is stored
+    RevisionRetention int         // Revisions kept per post, 0 keeps them all
+    TrashRetention  time.Duration // How long deleted posts stay in the trash
+    PurgeInterval   time.Duration // How often the trash is purged
+    RequireIfMatch  bool          // Whether updates and deletes must send If-Match
+    CommentTrustedUsers []string  // IDs of the users whose comments are always approved
+    CommentAutoApproveAfter int   // Approved comments after which a user's comments are approved, 0 never
+    SpamThreshold   float64       // Spam score from which a comment is marked as spam
+    SpamMaxLinks    int           // Links allowed in a comment, 0 for no limit
+    SpamBlocklist   []string      // Words and phrases that mark a comment as spam
+    SpamVelocityLimit int         // Comments a user may post within SpamVelocityWindow, 0 for no limit
+    SpamVelocityWindow time.Duration
+    ReactionReconcileInterval time.Duration // How often reaction counts are checked against the reactions
+    ViewFlushInterval time.Duration // How often buffered post views are written
+    ViewBufferSize  int           // Buffered views that trigger an early flush, 0 for none
+    RelatedRefreshInterval time.Duration // How often the related posts of every post are ranked again
+    ImportMaxSize   int           // Largest archive or export, in MB, accepted by the import endpoints
+    PostAccessTTL   time.Duration // How long the access token of a password-protected post is valid
+    UnlockIPAttempts int          // Wrong post passwords from an IP address before it has to wait, 0 for no limit
+    UnlockPostAttempts int        // Wrong passwords on a post before every client has to wait, 0 for no limit
+    UnlockBackoff   time.Duration // First wait once the attempts are used up, doubling with every wrong password
+    UnlockMaxBackoff time.Duration // Longest wait, after which wrong passwords are forgotten
 }
 
 // LoadConfig loads configuration from environment variables. It returns a Config
@@ -35,5 +62,97 @@ func LoadConfig() (*Config, error) {
         R2AccessKeySecret: os.Getenv("R2_SECRET_KEY"),
         R2BucketName:     os.Getenv("R2_BUCKET"),
         R2PublicURL:      os.Getenv("R2_PUBLIC_URL"),
+        PublishInterval:  getDuration("PUBLISH_INTERVAL", time.Minute),
+        SearchEngine:     getString("SEARCH_ENGINE", "mongo"),
+        SearchIndexPath:  getString("SEARCH_INDEX_PATH", "data/search.bleve"),
+        RevisionRetention: getInt("REVISION_RETENTION", 50),
+        TrashRetention:   getDuration("TRASH_RETENTION", 30*24*time.Hour),
+        PurgeInterval:    getDuration("PURGE_INTERVAL", time.Hour),
+        RequireIfMatch:   getBool("REQUIRE_IF_MATCH", false),
+        CommentTrustedUsers: getList("COMMENT_TRUSTED_USERS"),
+        CommentAutoApproveAfter: getInt("COMMENT_AUTO_APPROVE_AFTER", 1),
+        SpamThreshold:    getFloat("SPAM_THRESHOLD", 0.6),
+        SpamMaxLinks:     getInt("SPAM_MAX_LINKS", 2),
+        SpamBlocklist:    getList("SPAM_BLOCKLIST"),
+        SpamVelocityLimit: getInt("SPAM_VELOCITY_LIMIT", 5),
+        SpamVelocityWindow: getDuration("SPAM_VELOCITY_WINDOW", 10*time.Minute),
+        ReactionReconcileInterval: getDuration("REACTION_RECONCILE_INTERVAL", 6*time.Hour),
+        ViewFlushInterval: getDuration("VIEW_FLUSH_INTERVAL", 30*time.Second),
+        ViewBufferSize:   getInt("VIEW_BUFFER_SIZE", 10000),
+        RelatedRefreshInterval: getDuration("RELATED_REFRESH_INTERVAL", 6*time.Hour),
+        ImportMaxSize:    getInt("IMPORT_MAX_MB", 512),
+        PostAccessTTL:    getDuration("POST_ACCESS_TOKEN_TTL", time.Hour),
+        UnlockIPAttempts: getInt("UNLOCK_IP_ATTEMPTS", 5),
+        UnlockPostAttempts: getInt("UNLOCK_POST_ATTEMPTS", 50),
+        UnlockBackoff:    getDuration("UNLOCK_BACKOFF", 30*time.Second),
+        UnlockMaxBackoff: getDuration("UNLOCK_MAX_BACKOFF", time.Hour),
     }, nil
-}
\ No newline at end of file
+}
+
+// getString reads the environment variable with the given key, returning def
+// if it is unset or empty.
+func getString(key, def string) string {
+    if value := os.Getenv(key); value != "" {
+        return value
+    }
+    return def
+}
+
+// getDuration reads a duration such as "30s" or "5m" from the environment
+// variable with the given key. It returns def if the variable is unset or
+// cannot be parsed.
+func getDuration(key string, def time.Duration) time.Duration {
+    if value := os.Getenv(key); value != "" {
+        if d, err := time.ParseDuration(value); err == nil && d > 0 {
+            return d
+        }
+    }
+    return def
+}
+
+// getInt reads a non-negative integer from the environment variable with the
+// given key. It returns def if the variable is unset or cannot be parsed.
+func getInt(key string, def int) int {
+    if value := os.Getenv(key); value != "" {
+        if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+            return n
+        }
+    }
+    return def
+}
+
+// getBool reads a boolean such as "true" or "0" from the environment variable
+// with the given key. It returns def if the variable is unset or cannot be
+// parsed.
+func getBool(key string, def bool) bool {
+    if value := os.Getenv(key); value != "" {
+        if b, err := strconv.ParseBool(value); err == nil {
+            return b
+        }
+    }
+    return def
+}
+
+// getFloat reads a non-negative number such as "0.5" from the environment
+// variable with the given key. It returns def if the variable is unset or
+// cannot be parsed.
+func getFloat(key string, def float64) float64 {
+    if value := os.Getenv(key); value != "" {
+        if f, err := strconv.ParseFloat(value, 64); err == nil && f >= 0 {
+            return f
+        }
+    }
+    return def
+}
+
+// getList reads a comma-separated list from the environment variable with the
+// given key, dropping empty items. It returns nil if the variable is unset.
+func getList(key string) []string {
+    var items []string
+    for _, item := range strings.Split(os.Getenv(key), ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
+}
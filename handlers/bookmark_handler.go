@@ -0,0 +1,77 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "net/http"
+)
+
+type BookmarkHandler struct {
+    bookmarkService BookmarkService
+}
+
+// NewBookmarkHandler returns a new BookmarkHandler instance, given a
+// BookmarkService.
+func NewBookmarkHandler(bookmarkService BookmarkService) *BookmarkHandler {
+    return &BookmarkHandler{
+        bookmarkService: bookmarkService,
+    }
+}
+
+// List retrieves the bookmarks of the authenticated user, newest first.
+//
+// The request parameters may include cursor, page, limit and total, as for
+// post lists.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of Bookmark instances with their "post" on success. A
+//     bookmarked post the user can no longer read comes without its post.
+//   - pagination: The next_cursor, has_more and optional total of the list.
+func (h *BookmarkHandler) List(c *gin.Context) {
+    page, err := h.bookmarkService.List(currentViewer(c), parsePageRequest(c))
+    if err != nil {
+        respondError(c, err, "Failed to fetch bookmarks")
+        return
+    }
+
+    respondPage(c, page.Bookmarks, page.Pagination)
+}
+
+// Create bookmarks the post with the given ID for the authenticated user.
+// Bookmarking a post twice is harmless.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The Bookmark instance with its "post" on success.
+func (h *BookmarkHandler) Create(c *gin.Context) {
+    bookmark, err := h.bookmarkService.Bookmark(currentViewer(c), c.Param("id"))
+    if err != nil {
+        respondError(c, err, "Failed to bookmark post")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   bookmark,
+    })
+}
+
+// Delete removes the authenticated user's bookmark on the post with the given
+// ID.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+func (h *BookmarkHandler) Delete(c *gin.Context) {
+    if err := h.bookmarkService.Unbookmark(currentUserID(c), c.Param("id")); err != nil {
+        respondError(c, err, "Failed to remove bookmark")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Bookmark removed successfully",
+    })
+}
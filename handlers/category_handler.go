@@ -0,0 +1,159 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "go-blog-backend/models"
+    "net/http"
+)
+
+type CategoryHandler struct {
+    categoryService CategoryService
+}
+
+// NewCategoryHandler returns a new CategoryHandler instance, given a
+// CategoryService.
+func NewCategoryHandler(categoryService CategoryService) *CategoryHandler {
+    return &CategoryHandler{
+        categoryService: categoryService,
+    }
+}
+
+// List retrieves the category tree.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The root categories, each with its subcategories nested in "children".
+func (h *CategoryHandler) List(c *gin.Context) {
+    categories, err := h.categoryService.Tree()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, Response{
+            Status:  "error",
+            Message: "Failed to fetch categories",
+        })
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   categories,
+    })
+}
+
+type CreateCategoryRequest struct {
+    Name        string `json:"name" binding:"required"`
+    Slug        string `json:"slug,omitempty"`
+    Description string `json:"description,omitempty"`
+    ParentID    string `json:"parent_id,omitempty"`
+}
+
+// Create creates a new category.
+//
+// The request body should contain a JSON object with the following fields:
+//   - name: The name of the category.
+//   - slug: An optional slug. Generated from the name if omitted.
+//   - description: An optional description.
+//   - parent_id: The optional ID of the parent category. Omit it for a root category.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+//   - data: The newly created Category instance, or nil if an error occurred.
+func (h *CategoryHandler) Create(c *gin.Context) {
+    var req CreateCategoryRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    category := &models.Category{
+        Name:        req.Name,
+        Slug:        req.Slug,
+        Description: req.Description,
+    }
+
+    if err := h.categoryService.Create(category, req.ParentID); err != nil {
+        respondError(c, err, "Failed to create category")
+        return
+    }
+
+    c.JSON(http.StatusCreated, Response{
+        Status: "success",
+        Data:   category,
+    })
+}
+
+type UpdateCategoryRequest struct {
+    Name        string  `json:"name,omitempty"`
+    Slug        string  `json:"slug,omitempty"`
+    Description *string `json:"description,omitempty"`
+    ParentID    *string `json:"parent_id,omitempty"`
+}
+
+// Update updates the category with the given ID.
+//
+// The request body should contain a JSON object with any of the following fields:
+//   - name: The new name of the category.
+//   - slug: The new slug of the category.
+//   - description: The new description of the category.
+//   - parent_id: The ID of the new parent category, or an empty string to
+//     move the category to the root. Subcategories move along.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+func (h *CategoryHandler) Update(c *gin.Context) {
+    var req UpdateCategoryRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    updates := make(map[string]interface{})
+    if req.Name != "" {
+        updates["name"] = req.Name
+    }
+    if req.Slug != "" {
+        updates["slug"] = req.Slug
+    }
+    if req.Description != nil {
+        updates["description"] = *req.Description
+    }
+    if req.ParentID != nil {
+        updates["parent_id"] = *req.ParentID
+    }
+
+    if err := h.categoryService.Update(c.Param("id"), updates); err != nil {
+        respondError(c, err, "Failed to update category")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Category updated successfully",
+    })
+}
+
+// Delete deletes the category with the given ID. Its posts become
+// uncategorized. Categories that still have subcategories cannot be deleted.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+func (h *CategoryHandler) Delete(c *gin.Context) {
+    if err := h.categoryService.Delete(c.Param("id")); err != nil {
+        respondError(c, err, "Failed to delete category")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Category deleted successfully",
+    })
+}
\ No newline at end of file
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "net/http"
+)
+
+type CoAuthorHandler struct {
+    coAuthorService CoAuthorService
+}
+
+// NewCoAuthorHandler returns a new CoAuthorHandler instance, given a
+// CoAuthorService.
+func NewCoAuthorHandler(coAuthorService CoAuthorService) *CoAuthorHandler {
+    return &CoAuthorHandler{
+        coAuthorService: coAuthorService,
+    }
+}
+
+type InviteCoAuthorRequest struct {
+    UserID string `json:"user_id" binding:"required"`
+}
+
+type ReorderCoAuthorsRequest struct {
+    UserIDs []string `json:"user_ids" binding:"required"`
+}
+
+// List retrieves the authors of the post with the given ID, in order, and
+// the pending invitations to co-author it. Only the post's author and
+// co-authors may see them.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A PostCoAuthors instance on success.
+func (h *CoAuthorHandler) List(c *gin.Context) {
+    coAuthors, err := h.coAuthorService.CoAuthors(c.Param("id"), currentUserID(c))
+    if err != nil {
+        respondError(c, err, "Failed to fetch co-authors")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   coAuthors,
+    })
+}
+
+// Invite invites a user to co-author the post with the given ID. Only the
+// post's author may invite co-authors, and the user only becomes a co-author
+// once they accept.
+//
+// The request body should contain a JSON object with the following field:
+//   - user_id: The ID of the user to invite.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The newly created CoAuthorInvitation instance on success.
+func (h *CoAuthorHandler) Invite(c *gin.Context) {
+    var req InviteCoAuthorRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    invitation, err := h.coAuthorService.Invite(c.Param("id"), currentUserID(c), req.UserID)
+    if err != nil {
+        respondError(c, err, "Failed to invite co-author")
+        return
+    }
+
+    c.JSON(http.StatusCreated, Response{
+        Status: "success",
+        Data:   invitation,
+    })
+}
+
+// Remove removes the user with the ID given as the userId URL parameter from
+// the co-authors of the post with the given ID, or withdraws their pending
+// invitation. The post's author may remove anyone; a co-author may remove
+// themselves to leave the post.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+func (h *CoAuthorHandler) Remove(c *gin.Context) {
+    if err := h.coAuthorService.Remove(c.Param("id"), currentUserID(c), c.Param("userId")); err != nil {
+        respondError(c, err, "Failed to remove co-author")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Co-author removed successfully",
+    })
+}
+
+// Reorder puts the co-authors of the post with the given ID in a new order.
+// Only the post's author may reorder them.
+//
+// The request body should contain a JSON object with the following field:
+//   - user_ids: The IDs of every co-author of the post, each once, in the new order.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated PostCoAuthors instance on success.
+func (h *CoAuthorHandler) Reorder(c *gin.Context) {
+    var req ReorderCoAuthorsRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    coAuthors, err := h.coAuthorService.Reorder(c.Param("id"), currentUserID(c), req.UserIDs)
+    if err != nil {
+        respondError(c, err, "Failed to reorder co-authors")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   coAuthors,
+    })
+}
+
+// Invitations retrieves the pending invitations of the authenticated user to
+// co-author posts, newest first.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of CoAuthorInvitation instances, with the posts they are about, on success.
+func (h *CoAuthorHandler) Invitations(c *gin.Context) {
+    invitations, err := h.coAuthorService.Invitations(currentUserID(c))
+    if err != nil {
+        respondError(c, err, "Failed to fetch invitations")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   invitations,
+    })
+}
+
+// Accept accepts the invitation with the given ID, making the authenticated
+// user a co-author of its post.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The co-authored Post instance on success.
+func (h *CoAuthorHandler) Accept(c *gin.Context) {
+    post, err := h.coAuthorService.Accept(c.Param("id"), currentUserID(c))
+    if err != nil {
+        respondError(c, err, "Failed to accept invitation")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   post,
+    })
+}
+
+// Decline turns down the invitation with the given ID.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+func (h *CoAuthorHandler) Decline(c *gin.Context) {
+    if err := h.coAuthorService.Decline(c.Param("id"), currentUserID(c)); err != nil {
+        respondError(c, err, "Failed to decline invitation")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Invitation declined",
+    })
+}
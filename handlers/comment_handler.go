@@ -0,0 +1,239 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "go-blog-backend/models"
+    "net/http"
+)
+
+type CommentHandler struct {
+    commentService CommentService
+}
+
+// NewCommentHandler returns a new CommentHandler instance, given a
+// CommentService.
+func NewCommentHandler(commentService CommentService) *CommentHandler {
+    return &CommentHandler{
+        commentService: commentService,
+    }
+}
+
+// List retrieves the comments of the post with the given ID as a tree:
+// top-level comments, oldest first, each with its nested "replies". Approved
+// comments are visible to whoever can read the post, and pending comments to
+// their author.
+//
+// The request parameters may include cursor, page, limit and total, as for
+// post lists. They page through top-level comments; each thread is returned
+// in full.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of top-level Comment instances on success. Deleted
+//     comments that have replies are kept with an empty body and a deleted_at.
+//   - pagination: The next_cursor, has_more and optional total of the list.
+func (h *CommentHandler) List(c *gin.Context) {
+    page, err := h.commentService.List(c.Param("id"), currentViewer(c), parsePageRequest(c))
+    if err != nil {
+        respondError(c, err, "Failed to fetch comments")
+        return
+    }
+
+    respondPage(c, page.Comments, page.Pagination)
+}
+
+type CommentRequest struct {
+    Body     string `json:"body" binding:"required"`
+    ParentID string `json:"parent_id,omitempty"`
+}
+
+// Create adds a comment by the authenticated user to the post with the given
+// ID.
+//
+// The request body should contain a JSON object with the following fields:
+//   - body: The comment, in Markdown. It is rendered to sanitized HTML, returned as body_html.
+//   - parent_id: The ID of the comment this one replies to, if any.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The newly created Comment instance on success. Its status is
+//     "approved", or "pending" until an editor approves it, or "spam".
+func (h *CommentHandler) Create(c *gin.Context) {
+    var req CommentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    comment, err := h.commentService.Create(c.Param("id"), currentViewer(c), req.ParentID, req.Body)
+    if err != nil {
+        respondError(c, err, "Failed to create comment")
+        return
+    }
+
+    c.JSON(http.StatusCreated, Response{
+        Status: "success",
+        Data:   comment,
+    })
+}
+
+type UpdateCommentRequest struct {
+    Body string `json:"body" binding:"required"`
+}
+
+// Update replaces the body of the comment with the given ID. Only the
+// comment's author may edit it.
+//
+// The request body should contain a JSON object with the following field:
+//   - body: The new comment, in Markdown.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated Comment instance on success.
+func (h *CommentHandler) Update(c *gin.Context) {
+    var req UpdateCommentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    comment, err := h.commentService.Update(c.Param("id"), currentUserID(c), req.Body)
+    if err != nil {
+        respondError(c, err, "Failed to update comment")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   comment,
+    })
+}
+
+// Delete deletes the comment with the given ID. Only the comment's author may
+// delete it. A comment with replies is kept as an empty placeholder.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+func (h *CommentHandler) Delete(c *gin.Context) {
+    if err := h.commentService.Delete(c.Param("id"), currentUserID(c)); err != nil {
+        respondError(c, err, "Failed to delete comment")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Comment deleted successfully",
+    })
+}
+
+// Close closes comments on the post with the given ID. Existing comments stay
+// visible. Only the post's author may close comments.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated Post instance on success.
+func (h *CommentHandler) Close(c *gin.Context) {
+    post, err := h.commentService.SetClosed(c.Param("id"), currentUserID(c), true)
+    h.respondClosed(c, post, err)
+}
+
+// Open reopens comments on the post with the given ID. Only the post's author
+// may reopen comments.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated Post instance on success.
+func (h *CommentHandler) Open(c *gin.Context) {
+    post, err := h.commentService.SetClosed(c.Param("id"), currentUserID(c), false)
+    h.respondClosed(c, post, err)
+}
+
+// respondClosed writes the response of Close and Open.
+func (h *CommentHandler) respondClosed(c *gin.Context, post *models.Post, err error) {
+    if err != nil {
+        respondError(c, err, "Failed to update comment settings")
+        return
+    }
+
+    setETag(c, post.Version)
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   post,
+    })
+}
+
+// Queue lists the comments awaiting moderation, on any post, oldest first.
+// Only editors and admins may moderate comments.
+//
+// The request parameters may include:
+//   - status: The moderation status to list: pending (default), approved, rejected or spam.
+//   - cursor, page, limit and total, as for post lists.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of Comment instances, without their replies, on success.
+//   - pagination: The next_cursor, has_more and optional total of the list.
+func (h *CommentHandler) Queue(c *gin.Context) {
+    page, err := h.commentService.Queue(c.Query("status"), parsePageRequest(c))
+    if err != nil {
+        respondError(c, err, "Failed to fetch comments")
+        return
+    }
+
+    respondPage(c, page.Comments, page.Pagination)
+}
+
+type ModerateCommentsRequest struct {
+    IDs    []string `json:"ids" binding:"required"`
+    Status string   `json:"status" binding:"required"`
+}
+
+// Moderate gives several comments the same moderation status at once, to
+// approve or reject them in bulk. Only editors and admins may moderate
+// comments.
+//
+// The request body should contain a JSON object with the following fields:
+//   - ids: The IDs of the comments, at most 100.
+//   - status: The new status: approved, rejected, spam or pending.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: An object with the number of "updated_comments" on success.
+//     Comments that already had the status are not counted.
+func (h *CommentHandler) Moderate(c *gin.Context) {
+    var req ModerateCommentsRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    updated, err := h.commentService.Moderate(req.IDs, req.Status, currentUserID(c))
+    if err != nil {
+        respondError(c, err, "Failed to moderate comments")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data: map[string]int64{
+            "updated_comments": updated,
+        },
+    })
+}
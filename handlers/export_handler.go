@@ -0,0 +1,59 @@
+package handlers
+
+import (
+    "fmt"
+    "github.com/gin-gonic/gin"
+    "go-blog-backend/pkg/archive"
+    "net/http"
+    "time"
+)
+
+type ExportHandler struct {
+    exportService ExportService
+}
+
+// NewExportHandler returns a new ExportHandler instance, given an
+// ExportService.
+func NewExportHandler(exportService ExportService) *ExportHandler {
+    return &ExportHandler{
+        exportService: exportService,
+    }
+}
+
+// Hugo downloads every published post as a ZIP archive laid out as the
+// content directory of a Hugo site: a page bundle per post under
+// content/posts/<slug>/, with an index.md holding the front matter and the
+// Markdown source of the post, or an index.html for posts written in HTML,
+// and the uploaded images of the post. The archive can be imported back with
+// POST /api/admin/import/hugo.
+//
+// The archive is streamed as it is written. On success, the response is the
+// archive itself; an error before anything was sent gets the usual JSON
+// response, with the following fields:
+//   - status: Will be "error".
+//   - message: A human-readable message describing the error.
+//
+// An error after the archive started leaves it truncated.
+func (h *ExportHandler) Hugo(c *gin.Context) {
+    filename := fmt.Sprintf("blog-export-%s.zip", time.Now().UTC().Format("20060102"))
+    c.Header("Content-Type", "application/zip")
+    c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+    zip := archive.NewZip(c.Writer)
+    _, err := h.exportService.ExportHugo(zip)
+    if err == nil {
+        err = zip.Close()
+    }
+    if err != nil {
+        if !c.Writer.Written() {
+            c.Writer.Header().Del("Content-Type")
+            c.Writer.Header().Del("Content-Disposition")
+            respondError(c, err, "Failed to export the posts")
+            return
+        }
+        c.Error(err)
+        c.Abort()
+        return
+    }
+    c.Status(http.StatusOK)
+}
@@ -1,25 +1,151 @@
 package handlers
 
-import "go-blog-backend/models"
+import (
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/archive"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "io"
+    "io/fs"
+    "time"
+)
 
 type Response struct {
-    Status  string      `json:"status"`
-    Message string      `json:"message,omitempty"`
-    Data    interface{} `json:"data,omitempty"`
+    Status     string             `json:"status"`
+    Message    string             `json:"message,omitempty"`
+    Data       interface{}        `json:"data,omitempty"`
+    Pagination *models.Pagination `json:"pagination,omitempty"`
 }
 
 type UserService interface {
     Register(username, email, password string) (*models.User, error)
     Login(email, password string) (string, error) // returns JWT token
-    Update(userID string, updates map[string]interface{}) error
+    Update(userID string, version *int64, updates map[string]interface{}) (*models.User, error)
     Delete(userID string) error
     GetByID(userID string) (*models.User, error)
 }
 
 type PostService interface {
-    Create(post *models.Post) error
-    Update(postID string, updates map[string]interface{}) error
-    Delete(postID string) error
-    Get(postID string) (*models.Post, error)
-    List(page, limit int) ([]*models.Post, error)
-}
\ No newline at end of file
+    Create(post *models.Post, password string) error
+    Update(postID, userID string, version *int64, updates map[string]interface{}) (*models.Post, error)
+    Delete(postID, userID, role string, version *int64) error
+    Restore(postID, userID, role string) (*models.Post, error)
+    ListTrash(userID, role string, req models.PageRequest) (*models.PostPage, error)
+    Get(postID string, viewer models.Viewer, languages []string) (*models.Post, error)
+    GetBySlug(slug string, viewer models.Viewer) (*models.Post, string, error)
+    Unlock(postID, password string) (*models.PostAccessToken, error)
+    List(filter models.PostFilter, req models.PageRequest, languages []string) (*models.PostPage, error)
+    ListByAuthor(authorID string, filter models.PostFilter, req models.PageRequest) (*models.PostPage, error)
+    Publish(postID, userID string, publishAt *time.Time) (*models.Post, error)
+    Unpublish(postID, userID string) (*models.Post, error)
+    Archive(postID, userID string) (*models.Post, error)
+    RestoreRevision(postID, userID string, number int) (*models.Post, error)
+    SetTranslation(postID, userID string, version *int64, variant *models.PostVariant) (*models.Post, error)
+    DeleteTranslation(postID, userID, language string, version *int64) (*models.Post, error)
+    Sitemap(req models.PageRequest) (*models.SitemapPage, error)
+}
+
+type CategoryService interface {
+    Create(category *models.Category, parentID string) error
+    Tree() ([]*models.Category, error)
+    Update(categoryID string, updates map[string]interface{}) error
+    Delete(categoryID string) error
+}
+
+type TagService interface {
+    List() ([]*models.TagCount, error)
+    Rename(from, to, userID string) (int64, error)
+    Merge(from, into, userID string) (int64, error)
+}
+
+type SearchService interface {
+    Search(query models.SearchQuery) ([]*models.SearchResult, int64, error)
+}
+
+type RevisionService interface {
+    List(postID, userID string) ([]*models.PostRevision, error)
+    Get(postID, userID string, number int) (*models.PostRevision, error)
+    Diff(postID, userID string, from, to int, mode string) (*models.RevisionDiff, error)
+}
+
+type CommentService interface {
+    List(postID string, viewer models.Viewer, req models.PageRequest) (*models.CommentPage, error)
+    Create(postID string, viewer models.Viewer, parentID, body string) (*models.Comment, error)
+    Update(commentID, userID, body string) (*models.Comment, error)
+    Delete(commentID, userID string) error
+    SetClosed(postID, userID string, closed bool) (*models.Post, error)
+    Queue(status string, req models.PageRequest) (*models.CommentPage, error)
+    Moderate(commentIDs []string, status, moderatorID string) (int64, error)
+}
+
+type ReactionService interface {
+    React(postID string, viewer models.Viewer, reactionType string) (*models.PostReactions, error)
+    Unreact(postID string, viewer models.Viewer, reactionType string) (*models.PostReactions, error)
+    Reacted(postID primitive.ObjectID, userID string) (map[string]bool, error)
+}
+
+type ViewService interface {
+    Record(post *models.Post, viewerID, ip, userAgent, referrer, host string)
+    Series(postID, userID, role string, from, to time.Time) ([]*models.ViewPoint, error)
+    TopPosts(userID, role string, from, to time.Time, limit int) ([]*models.PostViews, error)
+    Referrers(userID, role, postID string, from, to time.Time, limit int) ([]*models.ReferrerViews, error)
+}
+
+// UnlockLimiter limits the attempts at guessing the password of a post.
+type UnlockLimiter interface {
+    Wait(ip, postID string) time.Duration
+    Failed(ip, postID string)
+    Succeeded(ip string)
+}
+
+type BookmarkService interface {
+    Bookmark(viewer models.Viewer, postID string) (*models.Bookmark, error)
+    Unbookmark(userID, postID string) error
+    List(viewer models.Viewer, req models.PageRequest) (*models.BookmarkPage, error)
+}
+
+type ReadingListService interface {
+    Create(userID, name, description string, shared bool) (*models.ReadingList, error)
+    List(userID string) ([]*models.ReadingList, error)
+    Get(listID string, viewer models.Viewer) (*models.ReadingList, error)
+    GetShared(token string) (*models.ReadingList, error)
+    Update(listID string, viewer models.Viewer, name, description *string, shared *bool) (*models.ReadingList, error)
+    Delete(listID, userID string) error
+    AddPost(listID string, viewer models.Viewer, postID string) (*models.ReadingList, error)
+    RemovePost(listID string, viewer models.Viewer, postID string) (*models.ReadingList, error)
+    Reorder(listID string, viewer models.Viewer, postIDs []string) (*models.ReadingList, error)
+}
+
+type SeriesService interface {
+    Create(userID, title, description string, postIDs []string) (*models.Series, error)
+    Get(seriesID, viewerID string) (*models.Series, error)
+    List(userID string) ([]*models.Series, error)
+    Update(seriesID, userID string, title, description *string) (*models.Series, error)
+    Delete(seriesID, userID string) error
+    AddPost(seriesID, userID, postID string, position int) (*models.Series, error)
+    RemovePost(seriesID, userID, postID string) (*models.Series, error)
+    Reorder(seriesID, userID string, postIDs []string) (*models.Series, error)
+    ForPost(post *models.Post, viewerID string) (*models.PostSeries, error)
+}
+
+type CoAuthorService interface {
+    Invite(postID, userID, inviteeID string) (*models.CoAuthorInvitation, error)
+    CoAuthors(postID, viewerID string) (*models.PostCoAuthors, error)
+    Remove(postID, userID, targetID string) error
+    Reorder(postID, userID string, coAuthorIDs []string) (*models.PostCoAuthors, error)
+    Invitations(userID string) ([]*models.CoAuthorInvitation, error)
+    Accept(invitationID, userID string) (*models.Post, error)
+    Decline(invitationID, userID string) error
+}
+
+type RelatedService interface {
+    Related(postID string, viewer models.Viewer) ([]*models.RelatedPost, error)
+}
+
+type ImportService interface {
+    ImportHugo(site fs.FS, opts models.ImportOptions) (*models.ImportReport, error)
+    ImportWordPress(export io.Reader, opts models.ImportOptions) (*models.ImportReport, error)
+}
+
+type ExportService interface {
+    ExportHugo(w archive.Writer) (*models.ExportReport, error)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "github.com/gin-gonic/gin"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "net/http"
+    "strconv"
+    "strings"
+)
+
+// currentUserID returns the ID of the authenticated user, as set in the
+// context by the auth middlewares, or an empty string for anonymous requests.
+func currentUserID(c *gin.Context) string {
+    userID, _ := c.Get("user_id")
+    id, _ := userID.(string)
+    return id
+}
+
+// currentUserRole returns the role of the authenticated user, as set in the
+// context by the auth middlewares, or an empty string for anonymous requests.
+func currentUserRole(c *gin.Context) string {
+    role, _ := c.Get("role")
+    r, _ := role.(string)
+    return r
+}
+
+// currentViewer returns who reads a post: the authenticated user and their
+// role, if any, and the access token unlocking a password-protected post,
+// sent in the X-Post-Token header.
+func currentViewer(c *gin.Context) models.Viewer {
+    return models.Viewer{
+        UserID:      currentUserID(c),
+        Role:        currentUserRole(c),
+        AccessToken: c.GetHeader("X-Post-Token"),
+    }
+}
+
+// setETag sets the ETag header of the response to the given version of the
+// returned resource.
+func setETag(c *gin.Context, version int64) {
+    c.Header("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// parseIfMatch reads the If-Match header of a write request. It returns nil
+// when the header is absent or "*", in which case the write is unconditional,
+// and the version carried by the ETag otherwise. A weak ETag is accepted.
+//
+// An ETag that was not issued by setETag can never match; a 412 response is
+// written and false is returned.
+func parseIfMatch(c *gin.Context) (*int64, bool) {
+    header := strings.TrimSpace(c.GetHeader("If-Match"))
+    if header == "" || header == "*" {
+        return nil, true
+    }
+
+    tag := strings.TrimPrefix(header, "W/")
+    version, err := strconv.ParseInt(strings.Trim(tag, `"`), 10, 64)
+    if err != nil || len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+        respondError(c, models.ErrVersionConflict, "")
+        return nil, false
+    }
+    return &version, true
+}
+
+// requestLanguages returns the languages the client asked for, most preferred
+// first: the lang query parameter if set, or else those of the
+// Accept-Language header. As the response depends on the header, it is added
+// to the Vary header. The returned error message is meant for the client.
+func requestLanguages(c *gin.Context) ([]string, error) {
+    c.Header("Vary", "Accept-Language")
+    if lang := c.Query("lang"); lang != "" {
+        language, err := utils.NormalizeLanguage(lang)
+        if err != nil {
+            return nil, fmt.Errorf("invalid language %q", lang)
+        }
+        return []string{language}, nil
+    }
+    return utils.ParseAcceptLanguage(c.GetHeader("Accept-Language")), nil
+}
+
+// respondError writes an error response for err. Validation and conflict
+// errors carry a message meant for the client, which is passed through; any
+// other error is reported with the given fallback message.
+func respondError(c *gin.Context, err error, fallback string) {
+    status := errorStatus(err)
+    message := fallback
+    switch status {
+    case http.StatusBadRequest, http.StatusConflict, http.StatusUnauthorized:
+        message = err.Error()
+    case http.StatusPreconditionFailed:
+        message = "The resource was modified since it was read; fetch it again and retry"
+    }
+
+    c.JSON(status, Response{
+        Status:  "error",
+        Message: message,
+    })
+}
+
+// errorStatus maps the errors shared through the models package to the
+// matching HTTP status code. Unknown errors are reported as 500.
+func errorStatus(err error) int {
+    switch {
+    case errors.Is(err, models.ErrNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, models.ErrForbidden):
+        return http.StatusForbidden
+    case errors.Is(err, models.ErrPasswordRequired):
+        return http.StatusUnauthorized
+    case errors.Is(err, models.ErrInvalidInput):
+        return http.StatusBadRequest
+    case errors.Is(err, models.ErrConflict):
+        return http.StatusConflict
+    case errors.Is(err, models.ErrVersionConflict):
+        return http.StatusPreconditionFailed
+    case errors.Is(err, models.ErrTooManyAttempts):
+        return http.StatusTooManyRequests
+    default:
+        return http.StatusInternalServerError
+    }
+}
\ No newline at end of file
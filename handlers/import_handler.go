@@ -0,0 +1,153 @@
+package handlers
+
+import (
+    "archive/zip"
+    "fmt"
+    "github.com/gin-gonic/gin"
+    "go-blog-backend/models"
+    "mime/multipart"
+    "net/http"
+    "strconv"
+)
+
+type ImportHandler struct {
+    importService ImportService
+    maxSize       int64
+}
+
+// NewImportHandler returns a new ImportHandler instance, given an
+// ImportService and the largest archive, in bytes, that may be uploaded.
+func NewImportHandler(importService ImportService, maxSize int64) *ImportHandler {
+    return &ImportHandler{
+        importService: importService,
+        maxSize:       maxSize,
+    }
+}
+
+// Hugo imports the posts of a Hugo site uploaded as a ZIP archive, of the
+// whole site or of its content directory, under the "file" field of a
+// multipart form. The optional "author_id" field gives the author of the
+// imported posts, the admin making the request by default, and "dry_run"
+// set to true reports what the import would do without writing anything.
+//
+// Posts already imported from the same file are updated rather than
+// duplicated, so an archive can be imported again after changes.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: An ImportReport on success, counting the posts created, updated,
+//     unchanged and failed and the images uploaded, with an item for every
+//     file giving its post, or its error, and any warnings.
+func (h *ImportHandler) Hugo(c *gin.Context) {
+    header, opts, ok := h.parseUpload(c)
+    if !ok {
+        return
+    }
+    if opts.AuthorID == "" {
+        opts.AuthorID = currentUserID(c)
+    }
+
+    file, err := header.Open()
+    if err != nil {
+        respondError(c, err, "Failed to read the archive")
+        return
+    }
+    defer file.Close()
+
+    archive, err := zip.NewReader(file, header.Size)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "The file is not a ZIP archive",
+        })
+        return
+    }
+
+    report, err := h.importService.ImportHugo(archive, opts)
+    if err != nil {
+        respondError(c, err, "Failed to import the posts")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   report,
+    })
+}
+
+// WordPress imports the posts and pages of a WordPress export (Tools >
+// Export, a WXR file) uploaded under the "file" field of a multipart form,
+// with their authors, categories, tags, comments and images. The images are
+// downloaded from the blog, which must still be online. The optional
+// "author_id" field gives the author of the posts whose author is missing
+// from the export, and "dry_run" set to true reports what the import would
+// do without writing anything.
+//
+// Posts and comments already imported from the same blog are not
+// duplicated, so an export can be imported again after changes.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: An ImportReport on success, as for Hugo, also counting the
+//     users, categories and comments created, and giving the old address
+//     of every post next to its new slug, for redirects.
+func (h *ImportHandler) WordPress(c *gin.Context) {
+    header, opts, ok := h.parseUpload(c)
+    if !ok {
+        return
+    }
+
+    file, err := header.Open()
+    if err != nil {
+        respondError(c, err, "Failed to read the export")
+        return
+    }
+    defer file.Close()
+
+    report, err := h.importService.ImportWordPress(file, opts)
+    if err != nil {
+        respondError(c, err, "Failed to import the posts")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   report,
+    })
+}
+
+// parseUpload reads the uploaded file and the options of an import request.
+// On invalid input, an error response is written and false is returned.
+func (h *ImportHandler) parseUpload(c *gin.Context) (*multipart.FileHeader, models.ImportOptions, bool) {
+    var opts models.ImportOptions
+    header, err := c.FormFile("file")
+    if err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "No file uploaded",
+        })
+        return nil, opts, false
+    }
+    if header.Size > h.maxSize {
+        c.JSON(http.StatusRequestEntityTooLarge, Response{
+            Status:  "error",
+            Message: fmt.Sprintf("The file is larger than %d MB", h.maxSize>>20),
+        })
+        return nil, opts, false
+    }
+
+    opts.AuthorID = c.PostForm("author_id")
+    if dryRun := c.PostForm("dry_run"); dryRun != "" {
+        opts.DryRun, err = strconv.ParseBool(dryRun)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, Response{
+                Status:  "error",
+                Message: fmt.Sprintf("invalid dry_run value %q", dryRun),
+            })
+            return nil, opts, false
+        }
+    }
+    return header, opts, true
+}
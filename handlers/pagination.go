@@ -0,0 +1,94 @@
+package handlers
+
+import (
+    "fmt"
+    "github.com/gin-gonic/gin"
+    "go-blog-backend/models"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+)
+
+// parsePagination reads the page and limit query parameters, falling back to
+// page 1 and 10 items per page. The limit is capped at models.MaxPageLimit.
+func parsePagination(c *gin.Context) (int, int) {
+    page := 1
+    limit := models.DefaultPageLimit
+
+    if pageStr := c.Query("page"); pageStr != "" {
+        if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+            page = p
+        }
+    }
+
+    if limitStr := c.Query("limit"); limitStr != "" {
+        if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+            limit = l
+        }
+    }
+    if limit > models.MaxPageLimit {
+        limit = models.MaxPageLimit
+    }
+
+    return page, limit
+}
+
+// parsePageRequest reads the cursor, page, limit and total query parameters
+// of a paginated list.
+func parsePageRequest(c *gin.Context) models.PageRequest {
+    page, limit := parsePagination(c)
+    includeTotal, _ := strconv.ParseBool(c.Query("total"))
+
+    return models.PageRequest{
+        Cursor:       c.Query("cursor"),
+        Page:         page,
+        Limit:        limit,
+        IncludeTotal: includeTotal,
+    }
+}
+
+// respondPage writes a successful response for a page of items, with the
+// pagination details in the body and as RFC 8288 Link headers.
+func respondPage(c *gin.Context, items interface{}, pagination models.Pagination) {
+    var links []string
+    if pagination.NextCursor != "" {
+        links = append(links, fmt.Sprintf(`<%s>; rel="next"`, cursorURL(c, pagination.NextCursor)))
+    }
+    if pagination.PrevCursor != "" {
+        links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, cursorURL(c, pagination.PrevCursor)))
+    }
+    if len(links) > 0 {
+        c.Header("Link", strings.Join(links, ", "))
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status:     "success",
+        Data:       items,
+        Pagination: &pagination,
+    })
+}
+
+// cursorURL returns the absolute URL of the current request with its page
+// number replaced by the given cursor.
+func cursorURL(c *gin.Context, cursor string) string {
+    query := c.Request.URL.Query()
+    query.Del("page")
+    query.Set("cursor", cursor)
+
+    scheme := "http"
+    if c.Request.TLS != nil {
+        scheme = "https"
+    }
+    if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+        scheme = proto
+    }
+
+    link := url.URL{
+        Scheme:   scheme,
+        Host:     c.Request.Host,
+        Path:     c.Request.URL.Path,
+        RawQuery: query.Encode(),
+    }
+    return link.String()
+}
\ No newline at end of file
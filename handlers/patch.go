@@ -0,0 +1,163 @@
+package handlers
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/gin-gonic/gin"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "mime"
+    "reflect"
+    "sort"
+)
+
+// Media types of the patch documents accepted by PATCH endpoints.
+const (
+    mediaTypeMergePatch = "application/merge-patch+json"
+    mediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+// parsePatch reads the body of a PATCH request and turns it into the updates
+// to apply, checked against the given patchable fields.
+//
+// The body is an RFC 7396 JSON Merge Patch by default: the members of the
+// object are the new values, and null clears a field. With the
+// application/json-patch+json content type, it is an RFC 6902 JSON Patch,
+// applied to the JSON representation of current, which is then compared to
+// the original to find the changed fields.
+//
+// Cleared fields are returned as their empty value: an empty string, or an
+// empty list. The returned error message is meant for the client.
+func parsePatch(c *gin.Context, current interface{}, fields map[string]models.PatchField) (map[string]interface{}, error) {
+    mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+
+    var changes map[string]interface{}
+    switch mediaType {
+    case mediaTypeJSONPatch:
+        var ops []utils.JSONPatchOp
+        if err := json.NewDecoder(c.Request.Body).Decode(&ops); err != nil {
+            return nil, fmt.Errorf("invalid JSON Patch document")
+        }
+
+        var err error
+        changes, err = applyJSONPatch(current, ops)
+        if err != nil {
+            return nil, err
+        }
+    case mediaTypeMergePatch, "application/json", "":
+        if err := json.NewDecoder(c.Request.Body).Decode(&changes); err != nil || changes == nil {
+            return nil, fmt.Errorf("invalid JSON Merge Patch document")
+        }
+    default:
+        return nil, fmt.Errorf("unsupported patch format %q, use %s or %s", mediaType, mediaTypeMergePatch, mediaTypeJSONPatch)
+    }
+
+    // Report problems in a stable order.
+    names := make([]string, 0, len(changes))
+    for name := range changes {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    updates := make(map[string]interface{}, len(changes))
+    for _, name := range names {
+        field, ok := fields[name]
+        if !ok {
+            return nil, fmt.Errorf("field %q cannot be patched", name)
+        }
+
+        value, err := patchValue(name, field, changes[name])
+        if err != nil {
+            return nil, err
+        }
+        updates[name] = value
+    }
+
+    return updates, nil
+}
+
+// applyJSONPatch applies a JSON Patch to the JSON representation of current
+// and returns the top-level members it changed. Removed members are
+// returned as nil.
+func applyJSONPatch(current interface{}, ops []utils.JSONPatchOp) (map[string]interface{}, error) {
+    var before, doc map[string]interface{}
+    encoded, err := json.Marshal(current)
+    if err != nil {
+        return nil, err
+    }
+    // The original and the patched document must not share maps, as
+    // patching changes the document in place.
+    if err := json.Unmarshal(encoded, &before); err != nil {
+        return nil, fmt.Errorf("cannot apply JSON Patch: %v", err)
+    }
+    if err := json.Unmarshal(encoded, &doc); err != nil {
+        return nil, fmt.Errorf("cannot apply JSON Patch: %v", err)
+    }
+    if doc == nil {
+        return nil, fmt.Errorf("cannot apply JSON Patch: the resource is not a JSON object")
+    }
+
+    patched, err := utils.ApplyJSONPatch(doc, ops)
+    if err != nil {
+        return nil, fmt.Errorf("cannot apply JSON Patch: %v", err)
+    }
+    after, ok := patched.(map[string]interface{})
+    if !ok {
+        return nil, fmt.Errorf("cannot apply JSON Patch: the document must remain an object")
+    }
+
+    changes := map[string]interface{}{}
+    for name, value := range after {
+        if old, ok := before[name]; !ok || !reflect.DeepEqual(old, value) {
+            changes[name] = value
+        }
+    }
+    for name := range before {
+        if _, ok := after[name]; !ok {
+            changes[name] = nil
+        }
+    }
+    return changes, nil
+}
+
+// patchValue converts the decoded JSON value of a patched field to the type
+// the services expect, a string or a []string.
+func patchValue(name string, field models.PatchField, value interface{}) (interface{}, error) {
+    if value == nil {
+        if !field.Clearable {
+            return nil, fmt.Errorf("field %q cannot be cleared", name)
+        }
+        if field.List {
+            return []string{}, nil
+        }
+        return "", nil
+    }
+
+    if !field.List {
+        s, ok := value.(string)
+        if !ok {
+            return nil, fmt.Errorf("field %q must be a string", name)
+        }
+        if s == "" && !field.Clearable {
+            return nil, fmt.Errorf("field %q cannot be cleared", name)
+        }
+        return s, nil
+    }
+
+    items, ok := value.([]interface{})
+    if !ok {
+        return nil, fmt.Errorf("field %q must be a list of strings", name)
+    }
+    list := make([]string, len(items))
+    for i, item := range items {
+        s, ok := item.(string)
+        if !ok {
+            return nil, fmt.Errorf("field %q must be a list of strings", name)
+        }
+        list[i] = s
+    }
+    if len(list) == 0 && !field.Clearable {
+        return nil, fmt.Errorf("field %q cannot be cleared", name)
+    }
+    return list, nil
+}
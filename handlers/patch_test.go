@@ -0,0 +1,96 @@
+package handlers
+
+import (
+    "encoding/json"
+    "reflect"
+    "testing"
+
+    "go-blog-backend/pkg/utils"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+    type resource struct {
+        Title string   `json:"title"`
+        Tags  []string `json:"tags"`
+    }
+
+    tests := []struct {
+        name    string
+        current interface{}
+        patch   string
+        want    map[string]interface{}
+        wantErr bool
+    }{
+        {
+            name:    "changed member",
+            current: resource{Title: "Hello", Tags: []string{"go"}},
+            patch:   `[{"op": "replace", "path": "/title", "value": "Bye"}]`,
+            want:    map[string]interface{}{"title": "Bye"},
+        },
+        {
+            name:    "removed member",
+            current: resource{Title: "Hello", Tags: []string{"go"}},
+            patch:   `[{"op": "remove", "path": "/tags"}]`,
+            want:    map[string]interface{}{"tags": nil},
+        },
+        {
+            name:    "changed array",
+            current: resource{Title: "Hello", Tags: []string{"go"}},
+            patch:   `[{"op": "add", "path": "/tags/-", "value": "web"}]`,
+            want:    map[string]interface{}{"tags": []interface{}{"go", "web"}},
+        },
+        {
+            name:    "no change",
+            current: resource{Title: "Hello"},
+            patch:   `[{"op": "test", "path": "/title", "value": "Hello"}]`,
+            want:    map[string]interface{}{},
+        },
+        {
+            name:    "failed test",
+            current: resource{Title: "Hello"},
+            patch:   `[{"op": "test", "path": "/title", "value": "Bye"}]`,
+            wantErr: true,
+        },
+        {
+            name:    "replaced by a non-object",
+            current: resource{Title: "Hello"},
+            patch:   `[{"op": "replace", "path": "", "value": [1]}]`,
+            wantErr: true,
+        },
+        {
+            name:    "resource is not an object",
+            current: []string{"a"},
+            patch:   `[{"op": "add", "path": "/0", "value": "b"}]`,
+            wantErr: true,
+        },
+        {
+            name:    "resource is null",
+            current: nil,
+            patch:   `[{"op": "add", "path": "/title", "value": "Hello"}]`,
+            wantErr: true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var ops []utils.JSONPatchOp
+            if err := json.Unmarshal([]byte(tt.patch), &ops); err != nil {
+                t.Fatal(err)
+            }
+
+            got, err := applyJSONPatch(tt.current, ops)
+            if tt.wantErr {
+                if err == nil {
+                    t.Fatalf("applyJSONPatch() = %v, want an error", got)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("applyJSONPatch() error = %v", err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("applyJSONPatch() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
@@ -1,28 +1,52 @@
 package handlers
 
 import (
+    "errors"
     "github.com/gin-gonic/gin"
+    "math"
     "net/http"
     "go-blog-backend/models"
     "go.mongodb.org/mongo-driver/bson/primitive"
     "strconv"
+    "time"
 )
 
 type PostHandler struct {
-    postService PostService
+    postService     PostService
+    reactionService ReactionService
+    viewService     ViewService
+    seriesService   SeriesService
+    unlockLimiter   UnlockLimiter
 }
 
-// NewPostHandler returns a new PostHandler instance, given a PostService.
-func NewPostHandler(postService PostService) *PostHandler {
+// NewPostHandler returns a new PostHandler instance, given a PostService,
+// the ReactionService telling readers how they reacted to a post, the
+// ViewService counting the views of posts, the SeriesService placing a
+// post in its series, and the UnlockLimiter slowing down password guessing.
+func NewPostHandler(postService PostService, reactionService ReactionService, viewService ViewService, seriesService SeriesService, unlockLimiter UnlockLimiter) *PostHandler {
     return &PostHandler{
-        postService: postService,
+        postService:     postService,
+        reactionService: reactionService,
+        viewService:     viewService,
+        seriesService:   seriesService,
+        unlockLimiter:   unlockLimiter,
     }
 }
 
 type CreatePostRequest struct {
-    Title    string `json:"title" binding:"required"`
-    Content  string `json:"content" binding:"required"`
-    ImageURL string `json:"image_url,omitempty"`
+    Title         string     `json:"title" binding:"required"`
+    Content       string     `json:"content" binding:"required"`
+    ContentFormat string     `json:"content_format,omitempty" binding:"omitempty,oneof=markdown html plain"`
+    Excerpt       string     `json:"excerpt,omitempty"`
+    ImageURL      string     `json:"image_url,omitempty"`
+    Slug          string     `json:"slug,omitempty"`
+    Language      string     `json:"language,omitempty"`
+    Tags          []string   `json:"tags,omitempty"`
+    CategoryID    string     `json:"category_id,omitempty"`
+    Status        string     `json:"status,omitempty" binding:"omitempty,oneof=draft scheduled published"`
+    PublishAt     *time.Time `json:"publish_at,omitempty"`
+    Visibility    string     `json:"visibility,omitempty" binding:"omitempty,oneof=public unlisted private password"`
+    Password      string     `json:"password,omitempty"`
 }
 
 // Create creates a new post in the "posts" collection in the MongoDB database.
@@ -30,12 +54,27 @@ type CreatePostRequest struct {
 // The request body should contain a JSON object with the following fields:
 //   - title: The title of the post.
 //   - content: The content of the post.
+//   - content_format: The format of the content, one of "markdown" (default),
+//     "html" or "plain". The rendered, sanitized HTML is returned as content_html.
+//   - excerpt: An optional summary of the post, up to 500 characters. Derived
+//     from the content if omitted.
 //   - image_url: An optional URL to an image associated with the post.
+//   - slug: An optional custom slug. Generated from the title if omitted.
+//   - language: The optional language of the post, as a BCP 47 tag such as "vi" or "en-US".
+//   - tags: Optional free-form tags. They are normalized, e.g. "Lập trình" becomes "lap-trinh".
+//   - category_id: The optional ID of the post's category.
+//   - status: An optional status, one of "draft" (default), "scheduled" or "published".
+//   - publish_at: The time a scheduled post goes live. Required for "scheduled".
+//   - visibility: Who can read the post once published, one of "public"
+//     (default), "unlisted", "private" or "password".
+//   - password: The password of a "password" post. Required for "password".
 //
 // The response will be a JSON object with the following fields:
 //   - status: The status of the request. Will be "success" on success, or "error" on error.
 //   - message: A human-readable message describing the result of the request.
-//   - data: The newly created Post instance, or nil if an error occurred.
+//   - data: The newly created Post instance, or nil if an error occurred. It
+//     carries the excerpt, word_count, reading_time and toc derived from the
+//     content.
 func (h *PostHandler) Create(c *gin.Context) {
     var req CreatePostRequest
     if err := c.ShouldBindJSON(&req); err != nil {
@@ -46,19 +85,46 @@ func (h *PostHandler) Create(c *gin.Context) {
         return
     }
 
-    userID, _ := c.Get("user_id")
+    authorID, err := primitive.ObjectIDFromHex(currentUserID(c))
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, Response{
+            Status:  "error",
+            Message: "Invalid user",
+        })
+        return
+    }
+
+    var categoryID *primitive.ObjectID
+    if req.CategoryID != "" {
+        id, err := primitive.ObjectIDFromHex(req.CategoryID)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, Response{
+                Status:  "error",
+                Message: "Invalid category ID",
+            })
+            return
+        }
+        categoryID = &id
+    }
+
     post := &models.Post{
-        Title:    req.Title,
-        Content:  req.Content,
-        ImageURL: req.ImageURL,
-        AuthorID: userID.(primitive.ObjectID),
+        Title:         req.Title,
+        Content:       req.Content,
+        ContentFormat: req.ContentFormat,
+        Excerpt:       req.Excerpt,
+        ImageURL:      req.ImageURL,
+        Slug:          req.Slug,
+        Language:      req.Language,
+        Tags:          req.Tags,
+        CategoryID:    categoryID,
+        AuthorID:      authorID,
+        Status:        req.Status,
+        PublishedAt:   req.PublishAt,
+        Visibility:    req.Visibility,
     }
 
-    if err := h.postService.Create(post); err != nil {
-        c.JSON(http.StatusInternalServerError, Response{
-            Status:  "error",
-            Message: "Failed to create post",
-        })
+    if err := h.postService.Create(post, req.Password); err != nil {
+        respondError(c, err, "Failed to create post")
         return
     }
 
@@ -70,16 +136,49 @@ func (h *PostHandler) Create(c *gin.Context) {
 
 // Get retrieves a post by its ID from the "posts" collection.
 //
-// The ID should be provided as a URL parameter.
+// The ID should be provided as a URL parameter. Posts that are not published
+// are only returned to their author and co-authors. Published posts are
+// returned according to their visibility: unlisted posts to anyone, private
+// posts to their authors, editors and admins, and password-protected posts
+// to their authors, editors, admins and readers sending the token from
+// Unlock as the X-Post-Token header. Without it, a password-protected post
+// gets a 401 response. The ETag header carries the post's
+// version, to be sent back as If-Match when updating or deleting it. Every
+// successful read counts as a view of the post.
+//
+// A post translated into other languages is returned in the language asked
+// for by the optional lang query parameter, such as "vi", or else by the
+// Accept-Language header, falling back to the post's own language. Its
+// title, slug, content and excerpt are then those of the translation, and
+// the Content-Language header gives its language.
 //
 // The response will be a JSON object with the following fields:
 //   - status: The status of the request. Will be "success" on success, or "error" on error.
 //   - message: A human-readable message describing the result of the request, if an error occurs.
-//   - data: The requested Post instance on success, or nil if not found.
+//   - data: The requested Post instance on success, or nil if not found. For
+//     an authenticated reader, its "reacted" flags tell which reaction type
+//     the reader reacted with. A post in a series carries a "series" object
+//     with the series' id and title, the post's position among the total,
+//     and the previous and next posts. A translated post carries its
+//     "language" and "translations", listing the language, slug and title
+//     of every language it can be read in, the post's own flagged as
+//     "canonical".
 func (h *PostHandler) Get(c *gin.Context) {
     postID := c.Param("id")
+    languages, err := requestLanguages(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: err.Error(),
+        })
+        return
+    }
 
-    post, err := h.postService.Get(postID)
+    post, err := h.postService.Get(postID, currentViewer(c), languages)
+    if errors.Is(err, models.ErrPasswordRequired) {
+        respondError(c, err, "Password required")
+        return
+    }
     if err != nil {
         c.JSON(http.StatusNotFound, Response{
             Status:  "error",
@@ -88,6 +187,133 @@ func (h *PostHandler) Get(c *gin.Context) {
         return
     }
 
+    h.respondPost(c, post)
+}
+
+// GetBySlug retrieves a post by its slug, applying the same visibility rules
+// as Get.
+//
+// The slug should be provided as a URL parameter. If the slug used to belong
+// to a post that has since been given a new one, the response is a 301
+// redirect hint whose Location header points to the current slug.
+//
+// The response will be a JSON object with the following fields:
+//   - status: "success", "redirect" for an old slug, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The requested Post instance on success, or an object with the
+//     post's "id" and current "slug" for a redirect.
+func (h *PostHandler) GetBySlug(c *gin.Context) {
+    post, newSlug, err := h.postService.GetBySlug(c.Param("slug"), currentViewer(c))
+    if errors.Is(err, models.ErrPasswordRequired) {
+        respondError(c, err, "Password required")
+        return
+    }
+    if err != nil {
+        c.JSON(errorStatus(err), Response{
+            Status:  "error",
+            Message: "Post not found",
+        })
+        return
+    }
+
+    if newSlug != "" {
+        c.Header("Location", "/api/posts/by-slug/"+newSlug)
+        c.JSON(http.StatusMovedPermanently, Response{
+            Status:  "redirect",
+            Message: "Post has moved to a new slug",
+            Data: map[string]string{
+                "id":   post.ID.Hex(),
+                "slug": newSlug,
+            },
+        })
+        return
+    }
+
+    h.respondPost(c, post)
+}
+
+type UnlockPostRequest struct {
+    Password string `json:"password" binding:"required"`
+}
+
+// Unlock exchanges the password of a published password-protected post for
+// an access token, to be sent as the X-Post-Token header when reading the
+// post. The token is valid until it expires or the password changes.
+//
+// The ID should be provided as a URL parameter, and the request body should
+// contain a JSON object with the following field:
+//   - password: The password of the post.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+//   - data: An object with the "token" and its "expires_at" time on success.
+//     A wrong password gets a 403 response. After too many wrong passwords
+//     from the client's IP address or on the post, attempts get a 429
+//     response until the time given by the Retry-After header has passed.
+func (h *PostHandler) Unlock(c *gin.Context) {
+    var req UnlockPostRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    postID, ip := c.Param("id"), c.ClientIP()
+    if wait := h.unlockLimiter.Wait(ip, postID); wait > 0 {
+        c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+        respondError(c, models.ErrTooManyAttempts, "Too many wrong passwords, try again later")
+        return
+    }
+
+    token, err := h.postService.Unlock(postID, req.Password)
+    if errors.Is(err, models.ErrForbidden) {
+        h.unlockLimiter.Failed(ip, postID)
+        respondError(c, err, "Wrong password")
+        return
+    }
+    if err != nil {
+        respondError(c, err, "Failed to unlock post")
+        return
+    }
+    h.unlockLimiter.Succeeded(ip)
+
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Post unlocked",
+        Data:    token,
+    })
+}
+
+// respondPost writes the response of Get and GetBySlug, adding the reacted
+// flags of an authenticated reader and the post's series, and records the
+// view.
+func (h *PostHandler) respondPost(c *gin.Context, post *models.Post) {
+    userID := currentUserID(c)
+    if userID != "" {
+        reacted, err := h.reactionService.Reacted(post.ID, userID)
+        if err != nil {
+            respondError(c, err, "Failed to fetch post")
+            return
+        }
+        post.Reacted = reacted
+    }
+
+    series, err := h.seriesService.ForPost(post, userID)
+    if err != nil {
+        respondError(c, err, "Failed to fetch post")
+        return
+    }
+    post.Series = series
+
+    h.viewService.Record(post, userID, c.ClientIP(), c.Request.UserAgent(), c.Request.Referer(), c.Request.Host)
+
+    if post.Language != "" {
+        c.Header("Content-Language", post.Language)
+    }
+    setETag(c, post.Version)
     c.JSON(http.StatusOK, Response{
         Status: "success",
         Data:   post,
@@ -95,24 +321,53 @@ func (h *PostHandler) Get(c *gin.Context) {
 }
 
 type UpdatePostRequest struct {
-    Title    string `json:"title,omitempty"`
-    Content  string `json:"content,omitempty"`
-    ImageURL string `json:"image_url,omitempty"`
+    Title         string    `json:"title,omitempty"`
+    Content       string    `json:"content,omitempty"`
+    ContentFormat string    `json:"content_format,omitempty" binding:"omitempty,oneof=markdown html plain"`
+    Excerpt       *string   `json:"excerpt,omitempty"`
+    ImageURL      string    `json:"image_url,omitempty"`
+    Slug          string    `json:"slug,omitempty"`
+    Language      *string   `json:"language,omitempty"`
+    Tags          *[]string `json:"tags,omitempty"`
+    CategoryID    *string   `json:"category_id,omitempty"`
+    Visibility    string    `json:"visibility,omitempty" binding:"omitempty,oneof=public unlisted private password"`
+    Password      string    `json:"password,omitempty"`
 }
 
-// Update updates the fields of the post with the given ID in the "posts" collection.
+// Update updates the fields of the post with the given ID in the "posts"
+// collection. Only the post's author and co-authors may update it, and every
+// update is recorded as a new revision.
+//
+// An If-Match header with the ETag returned by Get makes the update
+// conditional: if the post was changed in the meantime, nothing is written
+// and the response has a 412 status.
 //
 // The request body should contain a JSON object with any of the following fields:
 //   - title: The new title for the post.
 //   - content: The new content for the post.
+//   - content_format: The new format of the content.
+//   - excerpt: The new summary of the post, or an empty string to derive it from the content again.
 //   - image_url: The new image URL for the post.
+//   - slug: A new slug for the post. The previous slug redirects to the new one.
+//   - language: The language of the post, or an empty string to remove it.
+//   - tags: The new list of tags, replacing the current one. An empty list removes all tags.
+//   - category_id: The ID of the new category, or an empty string to remove the category.
+//   - visibility: Who can read the post, one of "public", "unlisted", "private" or "password".
+//   - password: The new password of a "password" post. Required when switching to "password".
+//
+// Only the post's author may change its visibility and password. Changing
+// the password revokes the access tokens given for the previous one.
 //
 // The response will be a JSON object with the following fields:
 //   - status: The status of the request. Will be "success" on success, or "error" on error.
 //   - message: A human-readable message describing the result of the request.
+//   - data: The updated Post instance on success. Its new version is also sent as the ETag header.
 func (h *PostHandler) Update(c *gin.Context) {
     postID := c.Param("id")
-    _, _ = c.Get("user_id")
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
 
     var req UpdatePostRequest
     if err := c.ShouldBindJSON(&req); err != nil {
@@ -130,26 +385,112 @@ func (h *PostHandler) Update(c *gin.Context) {
     if req.Content != "" {
         updates["content"] = req.Content
     }
+    if req.ContentFormat != "" {
+        updates["content_format"] = req.ContentFormat
+    }
+    if req.Excerpt != nil {
+        updates["excerpt"] = *req.Excerpt
+    }
     if req.ImageURL != "" {
         updates["image_url"] = req.ImageURL
     }
+    if req.Slug != "" {
+        updates["slug"] = req.Slug
+    }
+    if req.Language != nil {
+        updates["language"] = *req.Language
+    }
+    if req.Tags != nil {
+        updates["tags"] = *req.Tags
+    }
+    if req.CategoryID != nil {
+        updates["category_id"] = *req.CategoryID
+    }
+    if req.Visibility != "" {
+        updates["visibility"] = req.Visibility
+    }
+    if req.Password != "" {
+        updates["password"] = req.Password
+    }
+
+    post, err := h.postService.Update(postID, currentUserID(c), version, updates)
+    if err != nil {
+        respondError(c, err, "Failed to update post")
+        return
+    }
+
+    setETag(c, post.Version)
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Post updated successfully",
+        Data:    post,
+    })
+}
 
-    if err := h.postService.Update(postID, updates); err != nil {
-        c.JSON(http.StatusInternalServerError, Response{
+// Patch changes some fields of the post with the given ID, and can clear
+// optional ones. Only the post's author and co-authors may patch it, and
+// every change is recorded as a new revision.
+//
+// The request body is either an RFC 7396 JSON Merge Patch, sent as
+// application/merge-patch+json (or application/json), or an RFC 6902 JSON
+// Patch, sent as application/json-patch+json. Only title, content,
+// content_format, excerpt, image_url, slug, language, tags, category_id,
+// visibility and password may be changed, and only excerpt, image_url,
+// language, tags and category_id may be cleared,
+// e.g.:
+//
+//	{"image_url": null, "tags": ["go"]}
+//
+// As for Update, an If-Match header makes the change conditional. A JSON
+// Patch is always applied to the current version of the post.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+//   - data: The updated Post instance on success. Its new version is also sent as the ETag header.
+func (h *PostHandler) Patch(c *gin.Context) {
+    postID := c.Param("id")
+    userID := currentUserID(c)
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
+
+    current, err := h.postService.Get(postID, models.Viewer{UserID: userID}, nil)
+    if err != nil {
+        respondError(c, err, "Failed to update post")
+        return
+    }
+    if version == nil {
+        version = &current.Version
+    }
+
+    updates, err := parsePatch(c, current, models.PostPatchFields)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, Response{
             Status:  "error",
-            Message: "Failed to update post",
+            Message: err.Error(),
         })
         return
     }
 
+    post, err := h.postService.Update(postID, userID, version, updates)
+    if err != nil {
+        respondError(c, err, "Failed to update post")
+        return
+    }
+
+    setETag(c, post.Version)
     c.JSON(http.StatusOK, Response{
         Status:  "success",
         Message: "Post updated successfully",
+        Data:    post,
     })
 }
 
-// Delete deletes the post with the given ID from the "posts" collection in the
-// MongoDB database.
+// Delete moves the post with the given ID to the trash. Only the post's author
+// or an admin may delete it, and it can be restored until it is purged. As
+// for Update, an If-Match header makes the deletion conditional.
 //
 // The request body should contain no data.
 //
@@ -158,59 +499,342 @@ func (h *PostHandler) Update(c *gin.Context) {
 //   - message: A human-readable message describing the result of the request.
 func (h *PostHandler) Delete(c *gin.Context) {
     postID := c.Param("id")
-    _, _ = c.Get("user_id")
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
 
-    if err := h.postService.Delete(postID); err != nil {
-        c.JSON(http.StatusInternalServerError, Response{
-            Status:  "error",
-            Message: "Failed to delete post",
-        })
+    if err := h.postService.Delete(postID, currentUserID(c), currentUserRole(c), version); err != nil {
+        respondError(c, err, "Failed to delete post")
         return
     }
 
     c.JSON(http.StatusOK, Response{
         Status:  "success",
-        Message: "Post deleted successfully",
+        Message: "Post moved to trash",
     })
 }
 
-// List retrieves a list of posts from the "posts" collection.
+// Restore takes the post with the given ID out of the trash. Only the post's
+// author or an admin may restore it.
 //
-// The request parameters should include:
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+//   - data: The restored Post instance on success.
+func (h *PostHandler) Restore(c *gin.Context) {
+    post, err := h.postService.Restore(c.Param("id"), currentUserID(c), currentUserRole(c))
+    if err != nil {
+        respondError(c, err, "Failed to restore post")
+        return
+    }
+
+    setETag(c, post.Version)
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Post restored successfully",
+        Data:    post,
+    })
+}
+
+// Trash retrieves the deleted posts of the authenticated user, or every
+// deleted post for an admin. Deleted posts are purged after a retention
+// period.
+//
+// The request parameters may include cursor, page, limit and total, as for
+// List.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of Post instances, with their deleted_at and deleted_by and trimmed as for List, on success.
+//   - pagination: The pagination details, as for List.
+func (h *PostHandler) Trash(c *gin.Context) {
+    page, err := h.postService.ListTrash(currentUserID(c), currentUserRole(c), parsePageRequest(c))
+    if err != nil {
+        respondError(c, err, "Failed to fetch trash")
+        return
+    }
+
+    respondPage(c, page.Posts, page.Pagination)
+}
+
+// List retrieves a list of published posts from the "posts" collection.
+//
+// The request parameters may include:
+//   - author: An author ID to filter on. Posts the author co-wrote are
+//     included.
+//   - tag: A tag to filter on. Repeat it, or separate tags with commas, to
+//     only get posts having all the given tags.
+//   - category: A category slug. Posts of its subcategories are included.
+//   - status: Only "published" is accepted here.
+//   - visibility: Only "public" is accepted here. Unlisted, private and
+//     password-protected posts are never listed.
+//   - created_after, created_before, updated_after, updated_before: Inclusive
+//     date bounds, as RFC 3339 timestamps or YYYY-MM-DD dates.
+//   - has_image: "true" or "false" to keep posts with or without an image.
+//   - sort: created, updated, title or popularity, prefixed with "-" for
+//     descending order. Defaults to "-created".
+//   - cursor: An opaque cursor from a previous response. Takes precedence over page.
 //   - page: The page number to retrieve. Defaults to 1 if not specified.
-//   - limit: The number of posts per page. Defaults to 10 if not specified.
+//   - limit: The number of posts per page. Defaults to 10, at most 100.
+//   - total: Set to "true" to count the matching posts.
+//   - lang: The language to show translated posts in, as for Get. Defaults
+//     to the languages of the Accept-Language header.
+//
+// Unknown parameters and malformed values are rejected with a 400 status.
 //
 // The response will be a JSON object with the following fields:
 //   - status: The status of the request. Will be "success" on success, or "error" on error.
 //   - message: A human-readable message describing the result of the request, if an error occurs.
-//   - data: A slice of Post instances on success.
+//   - data: A slice of Post instances on success. Posts carry their excerpt
+//     instead of their content, content_html and toc, and translated posts
+//     their translations, as for Get.
+//   - pagination: The next_cursor, prev_cursor, has_more and optional total
+//     of the list. The cursors are also sent as RFC 8288 Link headers.
 func (h *PostHandler) List(c *gin.Context) {
-    page := 1
-    limit := 10
+    filter, req, err := parsePostListQuery(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: err.Error(),
+        })
+        return
+    }
 
-    if pageStr := c.Query("page"); pageStr != "" {
-        if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-            page = p
-        }
+    languages, err := requestLanguages(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: err.Error(),
+        })
+        return
     }
 
-    if limitStr := c.Query("limit"); limitStr != "" {
-        if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-            limit = l
-        }
+    page, err := h.postService.List(filter, req, languages)
+    if err != nil {
+        respondError(c, err, "Failed to fetch posts")
+        return
     }
 
-    posts, err := h.postService.List(page, limit)
+    respondPage(c, page.Posts, page.Pagination)
+}
+
+// ListMine retrieves the posts of the authenticated user in every status and
+// visibility, including drafts, scheduled, archived and private posts, and
+// the posts they co-author.
+//
+// The request parameters are the same as for List, except for author and
+// lang, and status and visibility may be any post status and visibility. Posts are listed in their own
+// language.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of Post instances on success, trimmed as for List.
+//   - pagination: The pagination details, as for List.
+func (h *PostHandler) ListMine(c *gin.Context) {
+    filter, req, err := parsePostListQuery(c, "author", "lang")
     if err != nil {
-        c.JSON(http.StatusInternalServerError, Response{
+        c.JSON(http.StatusBadRequest, Response{
             Status:  "error",
-            Message: "Failed to fetch posts",
+            Message: err.Error(),
         })
         return
     }
 
+    page, err := h.postService.ListByAuthor(currentUserID(c), filter, req)
+    if err != nil {
+        respondError(c, err, "Failed to fetch posts")
+        return
+    }
+
+    respondPage(c, page.Posts, page.Pagination)
+}
+
+type PublishPostRequest struct {
+    PublishAt *time.Time `json:"publish_at,omitempty"`
+}
+
+// Publish publishes the post with the given ID. Only the post's author may
+// publish it.
+//
+// The request body may contain a JSON object with the following field:
+//   - publish_at: An optional time in the future at which the post goes live.
+//     Without it, the post is published immediately.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated Post instance on success.
+func (h *PostHandler) Publish(c *gin.Context) {
+    var req PublishPostRequest
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, Response{
+                Status:  "error",
+                Message: "Invalid request data",
+            })
+            return
+        }
+    }
+
+    post, err := h.postService.Publish(c.Param("id"), currentUserID(c), req.PublishAt)
+    h.respondStatusChange(c, post, err, "Failed to publish post")
+}
+
+// Unpublish moves the post with the given ID back to draft, cancelling any
+// pending schedule. Only the post's author may unpublish it.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated Post instance on success.
+func (h *PostHandler) Unpublish(c *gin.Context) {
+    post, err := h.postService.Unpublish(c.Param("id"), currentUserID(c))
+    h.respondStatusChange(c, post, err, "Failed to unpublish post")
+}
+
+// Archive hides the post with the given ID from readers. Only the post's
+// author may archive it.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated Post instance on success.
+func (h *PostHandler) Archive(c *gin.Context) {
+    post, err := h.postService.Archive(c.Param("id"), currentUserID(c))
+    h.respondStatusChange(c, post, err, "Failed to archive post")
+}
+
+// respondStatusChange writes the response shared by Publish, Unpublish and
+// Archive.
+func (h *PostHandler) respondStatusChange(c *gin.Context, post *models.Post, err error, failure string) {
+    if err != nil {
+        respondError(c, err, failure)
+        return
+    }
+
+    setETag(c, post.Version)
     c.JSON(http.StatusOK, Response{
         Status: "success",
-        Data:   posts,
+        Data:   post,
+    })
+}
+
+type TranslatePostRequest struct {
+    Title         string `json:"title" binding:"required"`
+    Content       string `json:"content" binding:"required"`
+    ContentFormat string `json:"content_format,omitempty" binding:"omitempty,oneof=markdown html plain"`
+    Excerpt       string `json:"excerpt,omitempty"`
+    Slug          string `json:"slug,omitempty"`
+}
+
+// Translate creates or replaces the translation of the post with the given
+// ID into the language given as a URL parameter, a BCP 47 tag such as "vi"
+// or "en-US". Only the post's author and co-authors may translate it. The
+// translation shares everything but its title, slug, content and excerpt
+// with the post. As for Update, an If-Match header makes the change
+// conditional.
+//
+// The request body should contain a JSON object with the following fields:
+//   - title: The translated title.
+//   - content: The translated content.
+//   - content_format: The format of the content. Defaults to the post's.
+//   - excerpt: An optional translated summary. Derived from the content if omitted.
+//   - slug: An optional slug for the translation. Kept if the translation
+//     exists, and generated from the title otherwise.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+//   - data: The Post instance in the new language on success, with its
+//     translations. Its new version is also sent as the ETag header.
+func (h *PostHandler) Translate(c *gin.Context) {
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
+
+    var req TranslatePostRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    post, err := h.postService.SetTranslation(c.Param("id"), currentUserID(c), version, &models.PostVariant{
+        Language:      c.Param("lang"),
+        Title:         req.Title,
+        Content:       req.Content,
+        ContentFormat: req.ContentFormat,
+        Excerpt:       req.Excerpt,
+        Slug:          req.Slug,
+    })
+    if err != nil {
+        respondError(c, err, "Failed to translate post")
+        return
+    }
+
+    setETag(c, post.Version)
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Translation saved successfully",
+        Data:    post,
     })
-}
\ No newline at end of file
+}
+
+// DeleteTranslation removes the translation of the post with the given ID
+// into the language given as a URL parameter. Only the post's author and
+// co-authors may remove it. The slug of the translation then redirects to
+// the post. As for Update, an If-Match header makes the change conditional.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+//   - data: The Post instance on success, in its own language.
+func (h *PostHandler) DeleteTranslation(c *gin.Context) {
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
+
+    post, err := h.postService.DeleteTranslation(c.Param("id"), currentUserID(c), c.Param("lang"), version)
+    if err != nil {
+        respondError(c, err, "Failed to delete translation")
+        return
+    }
+
+    setETag(c, post.Version)
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Translation deleted successfully",
+        Data:    post,
+    })
+}
+
+// Sitemap lists the published posts for sitemaps and feeds, with an entry
+// for every language a post can be read in. Each entry gives the post_id,
+// language, slug, title and updated_at of the version, and the hreflang
+// alternates to link from it: one per language, and "x-default" for the
+// post in its own language. Posts without translations have no alternates.
+//
+// The request parameters may include cursor, page, limit and total, as for
+// List; limit counts posts, so a page may hold more entries.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of sitemap entries on success.
+//   - pagination: The pagination details, as for List.
+func (h *PostHandler) Sitemap(c *gin.Context) {
+    page, err := h.postService.Sitemap(parsePageRequest(c))
+    if err != nil {
+        respondError(c, err, "Failed to fetch sitemap")
+        return
+    }
+
+    respondPage(c, page.Entries, page.Pagination)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+    "go-blog-backend/middleware"
+    "go-blog-backend/models"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// stubPostService records the versions the handlers pass on, and fails with
+// err if set. Methods the tests do not use are left to the nil interface.
+type stubPostService struct {
+    PostService
+    err      error
+    called   bool
+    version  *int64
+    response *models.Post
+}
+
+func (s *stubPostService) Update(postID, userID string, version *int64, updates map[string]interface{}) (*models.Post, error) {
+    s.called, s.version = true, version
+    if s.err != nil {
+        return nil, s.err
+    }
+    return s.response, nil
+}
+
+func (s *stubPostService) Delete(postID, userID, role string, version *int64) error {
+    s.called, s.version = true, version
+    return s.err
+}
+
+func TestPostHandlerIfMatch(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    postID := primitive.NewObjectID().Hex()
+
+    tests := []struct {
+        name        string
+        method      string
+        ifMatch     string
+        required    bool
+        serviceErr  error
+        wantStatus  int
+        wantCalled  bool
+        wantVersion *int64
+        wantETag    string
+    }{
+        {name: "update without If-Match", method: http.MethodPut, wantStatus: http.StatusOK, wantCalled: true, wantETag: `"4"`},
+        {name: "update with ETag", method: http.MethodPut, ifMatch: `"3"`, wantStatus: http.StatusOK, wantCalled: true, wantVersion: int64Ptr(3), wantETag: `"4"`},
+        {name: "update with weak ETag", method: http.MethodPut, ifMatch: `W/"3"`, wantStatus: http.StatusOK, wantCalled: true, wantVersion: int64Ptr(3), wantETag: `"4"`},
+        {name: "update with any version", method: http.MethodPut, ifMatch: "*", wantStatus: http.StatusOK, wantCalled: true, wantETag: `"4"`},
+        {name: "update with stale ETag", method: http.MethodPut, ifMatch: `"2"`, serviceErr: models.ErrVersionConflict, wantStatus: http.StatusPreconditionFailed, wantCalled: true, wantVersion: int64Ptr(2)},
+        {name: "update with unquoted ETag", method: http.MethodPut, ifMatch: "3", wantStatus: http.StatusPreconditionFailed},
+        {name: "update with foreign ETag", method: http.MethodPut, ifMatch: `"abc"`, wantStatus: http.StatusPreconditionFailed},
+        {name: "update without required If-Match", method: http.MethodPut, required: true, wantStatus: http.StatusPreconditionRequired},
+        {name: "update with required If-Match", method: http.MethodPut, ifMatch: `"3"`, required: true, wantStatus: http.StatusOK, wantCalled: true, wantVersion: int64Ptr(3), wantETag: `"4"`},
+        {name: "delete with ETag", method: http.MethodDelete, ifMatch: `"3"`, wantStatus: http.StatusOK, wantCalled: true, wantVersion: int64Ptr(3)},
+        {name: "delete with stale ETag", method: http.MethodDelete, ifMatch: `"2"`, serviceErr: models.ErrVersionConflict, wantStatus: http.StatusPreconditionFailed, wantCalled: true, wantVersion: int64Ptr(2)},
+        {name: "delete without required If-Match", method: http.MethodDelete, required: true, wantStatus: http.StatusPreconditionRequired},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            service := &stubPostService{err: tt.serviceErr, response: &models.Post{Title: "Hello", Version: 4}}
+            handler := NewPostHandler(service, nil, nil, nil, nil)
+
+            router := gin.New()
+            authenticated := func(c *gin.Context) {
+                c.Set("user_id", primitive.NewObjectID().Hex())
+                c.Next()
+            }
+            requireIfMatch := middleware.RequireIfMatch(tt.required)
+            router.PUT("/posts/:id", authenticated, requireIfMatch, handler.Update)
+            router.DELETE("/posts/:id", authenticated, requireIfMatch, handler.Delete)
+
+            req := httptest.NewRequest(tt.method, "/posts/"+postID, strings.NewReader(`{"title": "Hello"}`))
+            req.Header.Set("Content-Type", "application/json")
+            if tt.ifMatch != "" {
+                req.Header.Set("If-Match", tt.ifMatch)
+            }
+            rec := httptest.NewRecorder()
+            router.ServeHTTP(rec, req)
+
+            if rec.Code != tt.wantStatus {
+                t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+            }
+            if service.called != tt.wantCalled {
+                t.Fatalf("service called = %v, want %v", service.called, tt.wantCalled)
+            }
+            if (service.version == nil) != (tt.wantVersion == nil) || (service.version != nil && *service.version != *tt.wantVersion) {
+                t.Errorf("version = %v, want %v", service.version, tt.wantVersion)
+            }
+            if got := rec.Header().Get("ETag"); got != tt.wantETag {
+                t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+            }
+        })
+    }
+}
+
+func int64Ptr(n int64) *int64 {
+    return &n
+}
+
+func (s *stubPostService) Unlock(postID, password string) (*models.PostAccessToken, error) {
+    s.called = true
+    if s.err != nil {
+        return nil, s.err
+    }
+    return &models.PostAccessToken{Token: "token"}, nil
+}
+
+// stubUnlockLimiter makes clients wait for wait, and records the wrong
+// passwords and successes reported to it.
+type stubUnlockLimiter struct {
+    wait      time.Duration
+    failed    []string
+    succeeded []string
+}
+
+func (l *stubUnlockLimiter) Wait(ip, postID string) time.Duration {
+    return l.wait
+}
+
+func (l *stubUnlockLimiter) Failed(ip, postID string) {
+    l.failed = append(l.failed, postID)
+}
+
+func (l *stubUnlockLimiter) Succeeded(ip string) {
+    l.succeeded = append(l.succeeded, ip)
+}
+
+func TestPostHandlerUnlockRateLimit(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    postID := primitive.NewObjectID().Hex()
+
+    tests := []struct {
+        name           string
+        wait           time.Duration
+        serviceErr     error
+        wantStatus     int
+        wantCalled     bool
+        wantRetryAfter string
+        wantFailed     int
+        wantSucceeded  int
+    }{
+        {name: "right password", wantStatus: http.StatusOK, wantCalled: true, wantSucceeded: 1},
+        {name: "wrong password", serviceErr: models.ErrForbidden, wantStatus: http.StatusForbidden, wantCalled: true, wantFailed: 1},
+        {name: "unknown post", serviceErr: models.ErrNotFound, wantStatus: http.StatusNotFound, wantCalled: true},
+        {name: "backing off", wait: 1500 * time.Millisecond, wantStatus: http.StatusTooManyRequests, wantRetryAfter: "2"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            service := &stubPostService{err: tt.serviceErr}
+            limiter := &stubUnlockLimiter{wait: tt.wait}
+            handler := NewPostHandler(service, nil, nil, nil, limiter)
+
+            router := gin.New()
+            router.POST("/posts/:id/unlock", handler.Unlock)
+
+            req := httptest.NewRequest(http.MethodPost, "/posts/"+postID+"/unlock", strings.NewReader(`{"password": "secret"}`))
+            req.Header.Set("Content-Type", "application/json")
+            rec := httptest.NewRecorder()
+            router.ServeHTTP(rec, req)
+
+            if rec.Code != tt.wantStatus {
+                t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+            }
+            if service.called != tt.wantCalled {
+                t.Errorf("service called = %v, want %v", service.called, tt.wantCalled)
+            }
+            if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+                t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+            }
+            if len(limiter.failed) != tt.wantFailed || len(limiter.succeeded) != tt.wantSucceeded {
+                t.Errorf("failed %v and succeeded %v, want %d and %d", limiter.failed, limiter.succeeded, tt.wantFailed, tt.wantSucceeded)
+            }
+        })
+    }
+}
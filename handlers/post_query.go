@@ -0,0 +1,174 @@
+package handlers
+
+import (
+    "fmt"
+    "github.com/gin-gonic/gin"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// postSortFields maps the values accepted by the sort query parameter to the
+// post fields they sort on.
+var postSortFields = map[string]string{
+    "created":    models.PostSortCreated,
+    "updated":    models.PostSortUpdated,
+    "title":      models.PostSortTitle,
+    "popularity": models.PostSortPopularity,
+}
+
+// postStatuses lists the values accepted by the status query parameter.
+var postStatuses = map[string]bool{
+    models.PostStatusDraft:     true,
+    models.PostStatusScheduled: true,
+    models.PostStatusPublished: true,
+    models.PostStatusArchived:  true,
+}
+
+// postVisibilities lists the values accepted by the visibility query
+// parameter.
+var postVisibilities = map[string]bool{
+    models.VisibilityPublic:   true,
+    models.VisibilityUnlisted: true,
+    models.VisibilityPrivate:  true,
+    models.VisibilityPassword: true,
+}
+
+// postListParams lists the query parameters understood by the post list
+// endpoints, besides the pagination parameters.
+var postListParams = map[string]bool{
+    "author": true, "tag": true, "category": true, "status": true, "visibility": true,
+    "created_after": true, "created_before": true,
+    "updated_after": true, "updated_before": true,
+    "has_image": true, "sort": true, "lang": true,
+}
+
+// paginationParams lists the query parameters read by parsePageRequest.
+var paginationParams = map[string]bool{
+    "cursor": true, "page": true, "limit": true, "total": true,
+}
+
+// parsePostListQuery reads and validates the filter, sort and pagination
+// query parameters of a post list:
+//   - author: an author ID.
+//   - tag: a tag; repeat it or separate tags with commas to require several.
+//   - category: a category slug.
+//   - status: a post status; repeat it or separate statuses with commas.
+//   - visibility: a post visibility; repeat it or separate visibilities with
+//     commas.
+//   - created_after, created_before, updated_after, updated_before: inclusive
+//     date bounds, as RFC 3339 timestamps or YYYY-MM-DD dates.
+//   - has_image: "true" or "false".
+//   - sort: one of created, updated, title or popularity, prefixed with "-"
+//     for descending order.
+//
+// Parameters listed in excluded, or unknown to the list, are rejected, as are
+// malformed values. The returned error message is meant for the client.
+func parsePostListQuery(c *gin.Context, excluded ...string) (models.PostFilter, models.PageRequest, error) {
+    var filter models.PostFilter
+    req := parsePageRequest(c)
+    query := c.Request.URL.Query()
+
+    var unknown []string
+    for name := range query {
+        if !(postListParams[name] || paginationParams[name]) || containsString(excluded, name) {
+            unknown = append(unknown, name)
+        }
+    }
+    if len(unknown) > 0 {
+        sort.Strings(unknown)
+        return filter, req, fmt.Errorf("unknown query parameter(s): %s", strings.Join(unknown, ", "))
+    }
+
+    if author := query.Get("author"); author != "" {
+        authorID, err := primitive.ObjectIDFromHex(author)
+        if err != nil {
+            return filter, req, fmt.Errorf("invalid author ID %q", author)
+        }
+        filter.AuthorID = authorID
+    }
+
+    filter.Tags = splitListParam(query["tag"])
+    filter.Category = query.Get("category")
+
+    for _, status := range splitListParam(query["status"]) {
+        if !postStatuses[status] {
+            return filter, req, fmt.Errorf("invalid status %q", status)
+        }
+        filter.Statuses = append(filter.Statuses, status)
+    }
+
+    for _, visibility := range splitListParam(query["visibility"]) {
+        if !postVisibilities[visibility] {
+            return filter, req, fmt.Errorf("invalid visibility %q", visibility)
+        }
+        filter.Visibilities = append(filter.Visibilities, visibility)
+    }
+
+    dates := []struct {
+        name     string
+        target   **time.Time
+        endOfDay bool
+    }{
+        {"created_after", &filter.CreatedAfter, false},
+        {"created_before", &filter.CreatedBefore, true},
+        {"updated_after", &filter.UpdatedAfter, false},
+        {"updated_before", &filter.UpdatedBefore, true},
+    }
+    for _, date := range dates {
+        value, err := parseDateParam(query.Get(date.name), date.endOfDay)
+        if err != nil {
+            return filter, req, fmt.Errorf("invalid %s date %q", date.name, query.Get(date.name))
+        }
+        *date.target = value
+    }
+
+    if hasImage := query.Get("has_image"); hasImage != "" {
+        value, err := strconv.ParseBool(hasImage)
+        if err != nil {
+            return filter, req, fmt.Errorf("invalid has_image value %q", hasImage)
+        }
+        filter.HasImage = &value
+    }
+
+    if sortParam := query.Get("sort"); sortParam != "" {
+        name := strings.TrimPrefix(sortParam, "-")
+        field, ok := postSortFields[name]
+        if !ok {
+            return filter, req, fmt.Errorf("invalid sort %q, expected one of created, updated, title or popularity", sortParam)
+        }
+        req.Sort = models.Sort{
+            Field:      field,
+            Descending: strings.HasPrefix(sortParam, "-"),
+        }
+    }
+
+    return filter, req, nil
+}
+
+// splitListParam returns the values of a repeatable query parameter, also
+// splitting comma-separated values. Empty values are dropped.
+func splitListParam(values []string) []string {
+    var items []string
+    for _, value := range values {
+        for _, item := range strings.Split(value, ",") {
+            if item = strings.TrimSpace(item); item != "" {
+                items = append(items, item)
+            }
+        }
+    }
+    return items
+}
+
+// containsString reports whether value is in values.
+func containsString(values []string, value string) bool {
+    for _, candidate := range values {
+        if candidate == value {
+            return true
+        }
+    }
+    return false
+}
\ No newline at end of file
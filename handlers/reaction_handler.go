@@ -0,0 +1,59 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "go-blog-backend/models"
+    "net/http"
+)
+
+type ReactionHandler struct {
+    reactionService ReactionService
+}
+
+// NewReactionHandler returns a new ReactionHandler instance, given a
+// ReactionService.
+func NewReactionHandler(reactionService ReactionService) *ReactionHandler {
+    return &ReactionHandler{
+        reactionService: reactionService,
+    }
+}
+
+// React sets the authenticated user's reaction to the post with the given ID
+// to the reaction type given as a URL parameter: like, love, laugh, wow, sad
+// or celebrate. A user has a single reaction per post, so this replaces any
+// other reaction of the user to the post. Only published posts can be
+// reacted to.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The post's "reactions" counts and the user's "reacted" flags on success.
+func (h *ReactionHandler) React(c *gin.Context) {
+    reactions, err := h.reactionService.React(c.Param("id"), currentViewer(c), c.Param("type"))
+    h.respondReactions(c, reactions, err)
+}
+
+// Unreact removes the authenticated user's reaction of the type given as a
+// URL parameter from the post with the given ID.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The post's "reactions" counts and the user's "reacted" flags on success.
+func (h *ReactionHandler) Unreact(c *gin.Context) {
+    reactions, err := h.reactionService.Unreact(c.Param("id"), currentViewer(c), c.Param("type"))
+    h.respondReactions(c, reactions, err)
+}
+
+// respondReactions writes the response of React and Unreact.
+func (h *ReactionHandler) respondReactions(c *gin.Context, reactions *models.PostReactions, err error) {
+    if err != nil {
+        respondError(c, err, "Failed to update reaction")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   reactions,
+    })
+}
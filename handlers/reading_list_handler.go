@@ -0,0 +1,215 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "go-blog-backend/models"
+    "net/http"
+)
+
+type ReadingListHandler struct {
+    readingListService ReadingListService
+}
+
+// NewReadingListHandler returns a new ReadingListHandler instance, given a
+// ReadingListService.
+func NewReadingListHandler(readingListService ReadingListService) *ReadingListHandler {
+    return &ReadingListHandler{
+        readingListService: readingListService,
+    }
+}
+
+type ReadingListRequest struct {
+    Name        *string `json:"name"`
+    Description *string `json:"description"`
+    Shared      *bool   `json:"shared"`
+}
+
+// List retrieves the reading lists of the authenticated user, newest first.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of ReadingList instances, whose items carry post IDs only, on success.
+func (h *ReadingListHandler) List(c *gin.Context) {
+    lists, err := h.readingListService.List(currentUserID(c))
+    if err != nil {
+        respondError(c, err, "Failed to fetch reading lists")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   lists,
+    })
+}
+
+// Create creates an empty reading list for the authenticated user.
+//
+// The request body should contain a JSON object with the following fields:
+//   - name: The name of the list.
+//   - description: An optional description.
+//   - shared: Whether the list can be read by anyone with its share_token. Defaults to false.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The newly created ReadingList instance on success.
+func (h *ReadingListHandler) Create(c *gin.Context) {
+    var req ReadingListRequest
+    if err := c.ShouldBindJSON(&req); err != nil || req.Name == nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    var description string
+    if req.Description != nil {
+        description = *req.Description
+    }
+    shared := req.Shared != nil && *req.Shared
+
+    list, err := h.readingListService.Create(currentUserID(c), *req.Name, description, shared)
+    if err != nil {
+        respondError(c, err, "Failed to create reading list")
+        return
+    }
+
+    c.JSON(http.StatusCreated, Response{
+        Status: "success",
+        Data:   list,
+    })
+}
+
+// Get retrieves the reading list with the given ID with its posts, in order.
+// Only the list's owner may read it this way; others use its share link.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The ReadingList instance, whose items carry their "post", on success.
+func (h *ReadingListHandler) Get(c *gin.Context) {
+    list, err := h.readingListService.Get(c.Param("id"), currentViewer(c))
+    h.respondList(c, list, err, "Failed to fetch reading list")
+}
+
+// GetShared retrieves a shared reading list by the share token given as a URL
+// parameter. It is public, and only includes published posts.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The ReadingList instance, whose items carry their "post", on success.
+func (h *ReadingListHandler) GetShared(c *gin.Context) {
+    list, err := h.readingListService.GetShared(c.Param("token"))
+    h.respondList(c, list, err, "Failed to fetch reading list")
+}
+
+// Update changes the name, description or sharing of the reading list with
+// the given ID. Only the list's owner may change it.
+//
+// The request body should contain a JSON object with any of the fields of
+// Create. Sharing a list gives it a new share_token; unsharing it revokes the
+// old link.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated ReadingList instance on success.
+func (h *ReadingListHandler) Update(c *gin.Context) {
+    var req ReadingListRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    list, err := h.readingListService.Update(c.Param("id"), currentViewer(c), req.Name, req.Description, req.Shared)
+    h.respondList(c, list, err, "Failed to update reading list")
+}
+
+// Delete deletes the reading list with the given ID. Only the list's owner
+// may delete it.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+func (h *ReadingListHandler) Delete(c *gin.Context) {
+    if err := h.readingListService.Delete(c.Param("id"), currentUserID(c)); err != nil {
+        respondError(c, err, "Failed to delete reading list")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Reading list deleted successfully",
+    })
+}
+
+// AddPost appends the post with the ID given as the postId URL parameter to
+// the reading list with the given ID.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated ReadingList instance on success.
+func (h *ReadingListHandler) AddPost(c *gin.Context) {
+    list, err := h.readingListService.AddPost(c.Param("id"), currentViewer(c), c.Param("postId"))
+    h.respondList(c, list, err, "Failed to add post to reading list")
+}
+
+// RemovePost removes the post with the ID given as the postId URL parameter
+// from the reading list with the given ID.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated ReadingList instance on success.
+func (h *ReadingListHandler) RemovePost(c *gin.Context) {
+    list, err := h.readingListService.RemovePost(c.Param("id"), currentViewer(c), c.Param("postId"))
+    h.respondList(c, list, err, "Failed to remove post from reading list")
+}
+
+type ReorderReadingListRequest struct {
+    PostIDs []string `json:"post_ids" binding:"required"`
+}
+
+// Reorder puts the posts of the reading list with the given ID in a new
+// order.
+//
+// The request body should contain a JSON object with the following field:
+//   - post_ids: The IDs of every post of the list, each once, in the new order.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated ReadingList instance on success.
+func (h *ReadingListHandler) Reorder(c *gin.Context) {
+    var req ReorderReadingListRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    list, err := h.readingListService.Reorder(c.Param("id"), currentViewer(c), req.PostIDs)
+    h.respondList(c, list, err, "Failed to reorder reading list")
+}
+
+// respondList writes the response of the handlers returning a single list.
+func (h *ReadingListHandler) respondList(c *gin.Context, list *models.ReadingList, err error, failure string) {
+    if err != nil {
+        respondError(c, err, failure)
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   list,
+    })
+}
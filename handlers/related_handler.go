@@ -0,0 +1,42 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "net/http"
+)
+
+type RelatedHandler struct {
+    relatedService RelatedService
+}
+
+// NewRelatedHandler returns a new RelatedHandler instance, given a
+// RelatedService.
+func NewRelatedHandler(relatedService RelatedService) *RelatedHandler {
+    return &RelatedHandler{
+        relatedService: relatedService,
+    }
+}
+
+// List retrieves the published posts related to the post with the given ID,
+// best first. Posts sharing tags and vocabulary with it come first, followed
+// by recent posts by the same author when too few posts are similar enough.
+// The post itself is visible as for PostHandler.Get.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of RelatedPost instances on success, each with its post,
+//     trimmed as in post lists, its score and the reason it is listed,
+//     "similar" or "same_author".
+func (h *RelatedHandler) List(c *gin.Context) {
+    related, err := h.relatedService.Related(c.Param("id"), currentViewer(c))
+    if err != nil {
+        respondError(c, err, "Failed to fetch related posts")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   related,
+    })
+}
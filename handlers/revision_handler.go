@@ -0,0 +1,144 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "net/http"
+    "strconv"
+)
+
+type RevisionHandler struct {
+    revisionService RevisionService
+    postService     PostService
+}
+
+// NewRevisionHandler returns a new RevisionHandler instance, given a
+// RevisionService to read the history of posts and the PostService used to
+// restore revisions.
+func NewRevisionHandler(revisionService RevisionService, postService PostService) *RevisionHandler {
+    return &RevisionHandler{
+        revisionService: revisionService,
+        postService:     postService,
+    }
+}
+
+// List retrieves the revision history of the post with the given ID, newest
+// first. Only the post's author and co-authors may read it.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of PostRevision instances, without their content, on success.
+func (h *RevisionHandler) List(c *gin.Context) {
+    revisions, err := h.revisionService.List(c.Param("id"), currentUserID(c))
+    if err != nil {
+        respondError(c, err, "Failed to fetch revisions")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   revisions,
+    })
+}
+
+// Get retrieves a single revision, including its content. The post ID and
+// revision number are given as URL parameters.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The requested PostRevision instance on success.
+func (h *RevisionHandler) Get(c *gin.Context) {
+    number, ok := parseRevisionNumber(c, c.Param("rev"))
+    if !ok {
+        return
+    }
+
+    revision, err := h.revisionService.Get(c.Param("id"), currentUserID(c), number)
+    if err != nil {
+        respondError(c, err, "Failed to fetch revision")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   revision,
+    })
+}
+
+// Diff compares two revisions of the post with the given ID.
+//
+// The request parameters should include:
+//   - from: The number of the older revision.
+//   - to: The number of the newer revision.
+//   - mode: "line" (default) or "word", how the content is compared.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: An object with "from", "to", "mode", and the "title" and "content"
+//     changes as lists of {"op", "text"} runs, where op is "equal", "insert" or "delete".
+func (h *RevisionHandler) Diff(c *gin.Context) {
+    from, ok := parseRevisionNumber(c, c.Query("from"))
+    if !ok {
+        return
+    }
+    to, ok := parseRevisionNumber(c, c.Query("to"))
+    if !ok {
+        return
+    }
+
+    diff, err := h.revisionService.Diff(c.Param("id"), currentUserID(c), from, to, c.Query("mode"))
+    if err != nil {
+        respondError(c, err, "Failed to compare revisions")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   diff,
+    })
+}
+
+// Restore brings the post with the given ID back to the revision given as a
+// URL parameter. The slug and status of the post are kept, and the restore is
+// recorded as a new revision. Only the post's author and co-authors may
+// restore revisions.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+//   - data: The restored Post instance on success.
+func (h *RevisionHandler) Restore(c *gin.Context) {
+    number, ok := parseRevisionNumber(c, c.Param("rev"))
+    if !ok {
+        return
+    }
+
+    post, err := h.postService.RestoreRevision(c.Param("id"), currentUserID(c), number)
+    if err != nil {
+        respondError(c, err, "Failed to restore revision")
+        return
+    }
+
+    setETag(c, post.Version)
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Revision restored successfully",
+        Data:    post,
+    })
+}
+
+// parseRevisionNumber parses a revision number. On failure, it writes a 400
+// response and returns false.
+func parseRevisionNumber(c *gin.Context, value string) (int, bool) {
+    number, err := strconv.Atoi(value)
+    if err != nil || number < 1 {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid revision number",
+        })
+        return 0, false
+    }
+    return number, true
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "net/http"
+    "time"
+)
+
+type SearchHandler struct {
+    searchService SearchService
+}
+
+// NewSearchHandler returns a new SearchHandler instance, given a SearchService.
+func NewSearchHandler(searchService SearchService) *SearchHandler {
+    return &SearchHandler{
+        searchService: searchService,
+    }
+}
+
+// Search runs a full-text search over the published posts.
+//
+// The request parameters should include:
+//   - q: The search query. Matches in the title rank higher than in the content.
+//   - author: An optional author ID to filter on.
+//   - tag: An optional tag to filter on. Repeat it, or separate tags with commas.
+//   - from, to: An optional publication date range, as RFC 3339 timestamps or
+//     YYYY-MM-DD dates. Both ends are inclusive.
+//   - page: The page number to retrieve. Defaults to 1 if not specified.
+//   - limit: The number of results per page. Defaults to 10 if not specified.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: An object with the ranked "results", each with the post, its
+//     score and a highlighted snippet, and the "total" number of matches.
+func (h *SearchHandler) Search(c *gin.Context) {
+    page, limit := parsePagination(c)
+    query := models.SearchQuery{
+        Text:  c.Query("q"),
+        Page:  page,
+        Limit: limit,
+    }
+
+    if author := c.Query("author"); author != "" {
+        authorID, err := primitive.ObjectIDFromHex(author)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, Response{
+                Status:  "error",
+                Message: "Invalid author ID",
+            })
+            return
+        }
+        query.AuthorID = authorID
+    }
+
+    query.Tags = splitListParam(c.QueryArray("tag"))
+
+    var err error
+    if query.From, err = parseDateParam(c.Query("from"), false); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid from date",
+        })
+        return
+    }
+    if query.To, err = parseDateParam(c.Query("to"), true); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid to date",
+        })
+        return
+    }
+
+    results, total, err := h.searchService.Search(query)
+    if err != nil {
+        respondError(c, err, "Failed to search posts")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data: map[string]interface{}{
+            "results": results,
+            "total":   total,
+        },
+    })
+}
+
+// parseDateParam parses a query parameter holding an RFC 3339 timestamp or a
+// YYYY-MM-DD date. A bare date is read as the start of the day, or as its end
+// when endOfDay is true, so that date ranges include their last day. An empty
+// value yields nil.
+func parseDateParam(value string, endOfDay bool) (*time.Time, error) {
+    if value == "" {
+        return nil, nil
+    }
+
+    if t, err := time.Parse(time.RFC3339, value); err == nil {
+        return &t, nil
+    }
+
+    t, err := time.Parse("2006-01-02", value)
+    if err != nil {
+        return nil, err
+    }
+    if endOfDay {
+        t = t.Add(24*time.Hour - time.Nanosecond)
+    }
+    return &t, nil
+}
\ No newline at end of file
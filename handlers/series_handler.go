@@ -0,0 +1,219 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "go-blog-backend/models"
+    "net/http"
+    "strconv"
+)
+
+type SeriesHandler struct {
+    seriesService SeriesService
+}
+
+// NewSeriesHandler returns a new SeriesHandler instance, given a
+// SeriesService.
+func NewSeriesHandler(seriesService SeriesService) *SeriesHandler {
+    return &SeriesHandler{
+        seriesService: seriesService,
+    }
+}
+
+type CreateSeriesRequest struct {
+    Title       string   `json:"title" binding:"required"`
+    Description string   `json:"description,omitempty"`
+    PostIDs     []string `json:"post_ids,omitempty"`
+}
+
+type UpdateSeriesRequest struct {
+    Title       *string `json:"title"`
+    Description *string `json:"description"`
+}
+
+type ReorderSeriesRequest struct {
+    PostIDs []string `json:"post_ids" binding:"required"`
+}
+
+// Create creates a series of posts written by the authenticated user.
+//
+// The request body should contain a JSON object with the following fields:
+//   - title: The title of the series.
+//   - description: An optional description.
+//   - post_ids: The IDs of the posts of the series, in order. Each post may
+//     belong to a single series.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The newly created Series instance, with its numbered posts, on success.
+func (h *SeriesHandler) Create(c *gin.Context) {
+    var req CreateSeriesRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    series, err := h.seriesService.Create(currentUserID(c), req.Title, req.Description, req.PostIDs)
+    if err != nil {
+        respondError(c, err, "Failed to create series")
+        return
+    }
+
+    c.JSON(http.StatusCreated, Response{
+        Status: "success",
+        Data:   series,
+    })
+}
+
+// Get retrieves the series with the given ID with its posts, in order.
+// Posts that are not published are only listed for the series' author.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The Series instance, with its numbered posts, on success.
+func (h *SeriesHandler) Get(c *gin.Context) {
+    series, err := h.seriesService.Get(c.Param("id"), currentUserID(c))
+    h.respondSeries(c, series, err, "Failed to fetch series")
+}
+
+// ListMine retrieves the series of the authenticated user, newest first.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of Series instances, with their numbered posts, on success.
+func (h *SeriesHandler) ListMine(c *gin.Context) {
+    series, err := h.seriesService.List(currentUserID(c))
+    if err != nil {
+        respondError(c, err, "Failed to fetch series")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   series,
+    })
+}
+
+// Update changes the title or description of the series with the given ID.
+// Only the series' author may change it.
+//
+// The request body should contain a JSON object with the title or
+// description to change.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated Series instance on success.
+func (h *SeriesHandler) Update(c *gin.Context) {
+    var req UpdateSeriesRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    series, err := h.seriesService.Update(c.Param("id"), currentUserID(c), req.Title, req.Description)
+    h.respondSeries(c, series, err, "Failed to update series")
+}
+
+// Delete deletes the series with the given ID, leaving its posts untouched.
+// Only the series' author may delete it.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+func (h *SeriesHandler) Delete(c *gin.Context) {
+    if err := h.seriesService.Delete(c.Param("id"), currentUserID(c)); err != nil {
+        respondError(c, err, "Failed to delete series")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "Series deleted successfully",
+    })
+}
+
+// AddPost adds the post with the ID given as the postId URL parameter to the
+// series with the given ID.
+//
+// The request parameters may include position, the 1-indexed position to
+// insert the post at. The post is added at the end by default.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated Series instance on success.
+func (h *SeriesHandler) AddPost(c *gin.Context) {
+    position := 0
+    if value := c.Query("position"); value != "" {
+        p, err := strconv.Atoi(value)
+        if err != nil || p < 1 {
+            c.JSON(http.StatusBadRequest, Response{
+                Status:  "error",
+                Message: "Invalid position",
+            })
+            return
+        }
+        position = p
+    }
+
+    series, err := h.seriesService.AddPost(c.Param("id"), currentUserID(c), c.Param("postId"), position)
+    h.respondSeries(c, series, err, "Failed to add post to series")
+}
+
+// RemovePost removes the post with the ID given as the postId URL parameter
+// from the series with the given ID. The posts after it move up.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated Series instance on success.
+func (h *SeriesHandler) RemovePost(c *gin.Context) {
+    series, err := h.seriesService.RemovePost(c.Param("id"), currentUserID(c), c.Param("postId"))
+    h.respondSeries(c, series, err, "Failed to remove post from series")
+}
+
+// Reorder puts the posts of the series with the given ID in a new order.
+//
+// The request body should contain a JSON object with the following field:
+//   - post_ids: The IDs of every post of the series, each once, in the new order.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: The updated Series instance on success.
+func (h *SeriesHandler) Reorder(c *gin.Context) {
+    var req ReorderSeriesRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    series, err := h.seriesService.Reorder(c.Param("id"), currentUserID(c), req.PostIDs)
+    h.respondSeries(c, series, err, "Failed to reorder series")
+}
+
+// respondSeries writes the response of the handlers returning a single
+// series.
+func (h *SeriesHandler) respondSeries(c *gin.Context, series *models.Series, err error, failure string) {
+    if err != nil {
+        respondError(c, err, failure)
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   series,
+    })
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "net/http"
+)
+
+type TagHandler struct {
+    tagService TagService
+}
+
+// NewTagHandler returns a new TagHandler instance, given a TagService.
+func NewTagHandler(tagService TagService) *TagHandler {
+    return &TagHandler{
+        tagService: tagService,
+    }
+}
+
+// List retrieves every tag used by published posts, most used first.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of objects with the fields "tag" and "post_count".
+func (h *TagHandler) List(c *gin.Context) {
+    tags, err := h.tagService.List()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, Response{
+            Status:  "error",
+            Message: "Failed to fetch tags",
+        })
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   tags,
+    })
+}
+
+type RenameTagRequest struct {
+    Name string `json:"name" binding:"required"`
+}
+
+// Rename renames the tag given as a URL parameter on every post, atomically.
+//
+// The request body should contain a JSON object with the following field:
+//   - name: The new name of the tag. It must not be used yet; merge the tags otherwise.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+//   - data: An object with the number of "updated_posts".
+func (h *TagHandler) Rename(c *gin.Context) {
+    var req RenameTagRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    updated, err := h.tagService.Rename(c.Param("tag"), req.Name, currentUserID(c))
+    h.respondTagChange(c, updated, err, "Failed to rename tag")
+}
+
+type MergeTagRequest struct {
+    Into string `json:"into" binding:"required"`
+}
+
+// Merge merges the tag given as a URL parameter into another existing tag on
+// every post, atomically.
+//
+// The request body should contain a JSON object with the following field:
+//   - into: The tag to merge into.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+//   - data: An object with the number of "updated_posts".
+func (h *TagHandler) Merge(c *gin.Context) {
+    var req MergeTagRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, Response{
+            Status:  "error",
+            Message: "Invalid request data",
+        })
+        return
+    }
+
+    updated, err := h.tagService.Merge(c.Param("tag"), req.Into, currentUserID(c))
+    h.respondTagChange(c, updated, err, "Failed to merge tags")
+}
+
+// respondTagChange writes the response shared by Rename and Merge.
+func (h *TagHandler) respondTagChange(c *gin.Context, updated int64, err error, failure string) {
+    if err != nil {
+        respondError(c, err, failure)
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data: map[string]int64{
+            "updated_posts": updated,
+        },
+    })
+}
\ No newline at end of file
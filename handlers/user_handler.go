@@ -2,6 +2,7 @@ package handlers
 
 import (
     "github.com/gin-gonic/gin"
+    "go-blog-backend/models"
     "net/http"
 )
 
@@ -118,6 +119,10 @@ type UpdateUserRequest struct {
 
 // Update updates the user with the given ID in the "users" collection.
 //
+// An If-Match header with the ETag returned by GetMe makes the update
+// conditional: if the profile was changed in the meantime, nothing is written
+// and the response has a 412 status.
+//
 // The request body should contain a JSON object with the following fields:
 //   - username: The new username for the user, or null if no change is desired.
 //   - email: The new email address for the user, or null if no change is desired.
@@ -126,9 +131,13 @@ type UpdateUserRequest struct {
 // The response will be a JSON object with the following fields:
 //   - status: The status of the request. Will be "success" on success, or "error" on error.
 //   - message: A human-readable message describing the result of the request.
+//   - data: The updated User instance on success. Its new version is also sent as the ETag header.
 func (h *UserHandler) Update(c *gin.Context) {
-    userID, _ := c.Get("user_id")
-    
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
+
     var req UpdateUserRequest
     if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(http.StatusBadRequest, Response{
@@ -149,17 +158,70 @@ func (h *UserHandler) Update(c *gin.Context) {
         updates["password"] = req.Password
     }
 
-    if err := h.userService.Update(userID.(string), updates); err != nil {
-        c.JSON(http.StatusInternalServerError, Response{
+    user, err := h.userService.Update(currentUserID(c), version, updates)
+    if err != nil {
+        respondError(c, err, "Failed to update user")
+        return
+    }
+
+    setETag(c, user.Version)
+    c.JSON(http.StatusOK, Response{
+        Status:  "success",
+        Message: "User updated successfully",
+        Data:    user,
+    })
+}
+
+// Patch changes some fields of the authenticated user's profile.
+//
+// The request body is either an RFC 7396 JSON Merge Patch, sent as
+// application/merge-patch+json (or application/json), or an RFC 6902 JSON
+// Patch, sent as application/json-patch+json. Only username, email and
+// password may be changed, and none of them may be cleared.
+//
+// As for Update, an If-Match header makes the change conditional. A JSON
+// Patch is always applied to the current version of the profile.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request.
+//   - data: The updated User instance on success. Its new version is also sent as the ETag header.
+func (h *UserHandler) Patch(c *gin.Context) {
+    userID := currentUserID(c)
+    version, ok := parseIfMatch(c)
+    if !ok {
+        return
+    }
+
+    current, err := h.userService.GetByID(userID)
+    if err != nil {
+        respondError(c, err, "Failed to update user")
+        return
+    }
+    if version == nil {
+        version = &current.Version
+    }
+
+    updates, err := parsePatch(c, current, models.UserPatchFields)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, Response{
             Status:  "error",
-            Message: "Failed to update user",
+            Message: err.Error(),
         })
         return
     }
 
+    user, err := h.userService.Update(userID, version, updates)
+    if err != nil {
+        respondError(c, err, "Failed to update user")
+        return
+    }
+
+    setETag(c, user.Version)
     c.JSON(http.StatusOK, Response{
         Status:  "success",
         Message: "User updated successfully",
+        Data:    user,
     })
 }
 
@@ -200,6 +262,7 @@ func (h *UserHandler) GetMe(c *gin.Context) {
         return
     }
 
+    setETag(c, user.Version)
     c.JSON(http.StatusOK, Response{
         Status: "success",
         Data:   user,
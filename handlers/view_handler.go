@@ -0,0 +1,137 @@
+package handlers
+
+import (
+    "github.com/gin-gonic/gin"
+    "net/http"
+    "time"
+)
+
+// defaultViewPeriod is the period view statistics cover when no dates are
+// given: the last 30 days, today included.
+const defaultViewPeriod = 30 * 24 * time.Hour
+
+type ViewHandler struct {
+    viewService ViewService
+}
+
+// NewViewHandler returns a new ViewHandler instance, given a ViewService.
+func NewViewHandler(viewService ViewService) *ViewHandler {
+    return &ViewHandler{
+        viewService: viewService,
+    }
+}
+
+// Series retrieves the daily views of the post with the given ID. Only the
+// post's author or an admin may read them.
+//
+// The request parameters may include from and to, the first and last days of
+// the period as YYYY-MM-DD dates, defaulting to the last 30 days.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of ViewPoint instances with the "day", "views" and
+//     "uniques" of each day that has views, oldest first, on success.
+func (h *ViewHandler) Series(c *gin.Context) {
+    from, to, ok := parseViewPeriod(c)
+    if !ok {
+        return
+    }
+
+    points, err := h.viewService.Series(c.Param("id"), currentUserID(c), currentUserRole(c), from, to)
+    if err != nil {
+        respondError(c, err, "Failed to fetch views")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   points,
+    })
+}
+
+// TopPosts retrieves the most viewed posts of the authenticated user, or of
+// every author for an admin.
+//
+// The request parameters may include from and to, as for Series, and limit,
+// the number of posts returned (default 10, at most 100).
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of PostViews instances, most viewed first, on success.
+func (h *ViewHandler) TopPosts(c *gin.Context) {
+    from, to, ok := parseViewPeriod(c)
+    if !ok {
+        return
+    }
+    _, limit := parsePagination(c)
+
+    posts, err := h.viewService.TopPosts(currentUserID(c), currentUserRole(c), from, to, limit)
+    if err != nil {
+        respondError(c, err, "Failed to fetch views")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   posts,
+    })
+}
+
+// Referrers retrieves the sites bringing the most views to the posts of the
+// authenticated user, or of every author for an admin.
+//
+// The request parameters may include from, to and limit, as for TopPosts,
+// and post, the ID of a single post to report on.
+//
+// The response will be a JSON object with the following fields:
+//   - status: The status of the request. Will be "success" on success, or "error" on error.
+//   - message: A human-readable message describing the result of the request, if an error occurs.
+//   - data: A slice of ReferrerViews instances, most views first, on success.
+//     Direct visits are reported under an empty referrer.
+func (h *ViewHandler) Referrers(c *gin.Context) {
+    from, to, ok := parseViewPeriod(c)
+    if !ok {
+        return
+    }
+    _, limit := parsePagination(c)
+
+    referrers, err := h.viewService.Referrers(currentUserID(c), currentUserRole(c), c.Query("post"), from, to, limit)
+    if err != nil {
+        respondError(c, err, "Failed to fetch referrers")
+        return
+    }
+
+    c.JSON(http.StatusOK, Response{
+        Status: "success",
+        Data:   referrers,
+    })
+}
+
+// parseViewPeriod reads the from and to query parameters of the view
+// statistics, defaulting to the last 30 days. On a malformed date, a 400
+// response is written and false is returned.
+func parseViewPeriod(c *gin.Context) (time.Time, time.Time, bool) {
+    to := time.Now().UTC()
+    from := to.Add(-defaultViewPeriod + 24*time.Hour)
+
+    for _, param := range []struct {
+        name  string
+        value *time.Time
+    }{{"from", &from}, {"to", &to}} {
+        t, err := parseDateParam(c.Query(param.name), false)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, Response{
+                Status:  "error",
+                Message: "Invalid " + param.name + " date, expected YYYY-MM-DD",
+            })
+            return from, to, false
+        }
+        if t != nil {
+            *param.value = *t
+        }
+    }
+
+    return from, to, true
+}
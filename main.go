@@ -6,13 +6,18 @@ import (
     "go-blog-backend/config"
     "go-blog-backend/handlers"
     "go-blog-backend/middleware"
+    "go-blog-backend/models"
     "go-blog-backend/services"
     "go-blog-backend/repositories"
     "go-blog-backend/pkg/cloudflare"
+    "go-blog-backend/pkg/jobs"
+    "go-blog-backend/pkg/search"
+    "go-blog-backend/pkg/utils"
     "github.com/gin-gonic/gin"
     "go.mongodb.org/mongo-driver/mongo"
     "go.mongodb.org/mongo-driver/mongo/options"
     "log"
+    "os"
 )
 
 // UploadServiceAdapter adapts UploadService to handlers.UploadService interface
@@ -75,15 +80,223 @@ func main() {
     // Setup repositories
     userRepo := repositories.NewUserRepository(db)
     postRepo := repositories.NewPostRepository(db)
+    slugRepo := repositories.NewSlugRepository(db)
+    categoryRepo := repositories.NewCategoryRepository(db)
+    revisionRepo := repositories.NewRevisionRepository(db, cfg.RevisionRetention)
+    commentRepo := repositories.NewCommentRepository(db)
+    reactionRepo := repositories.NewReactionRepository(db)
+    viewRepo := repositories.NewViewRepository(db)
+    bookmarkRepo := repositories.NewBookmarkRepository(db)
+    readingListRepo := repositories.NewReadingListRepository(db)
+    seriesRepo := repositories.NewSeriesRepository(db)
+    coAuthorRepo := repositories.NewCoAuthorRepository(db)
+    relatedRepo := repositories.NewRelatedRepository(db)
+
+    if err := postRepo.EnsureIndexes(); err != nil {
+        log.Fatal("Cannot create post indexes:", err)
+    }
+    if err := slugRepo.EnsureIndexes(); err != nil {
+        log.Fatal("Cannot create slug indexes:", err)
+    }
+    if err := categoryRepo.EnsureIndexes(); err != nil {
+        log.Fatal("Cannot create category indexes:", err)
+    }
+    if err := revisionRepo.EnsureIndexes(); err != nil {
+        log.Fatal("Cannot create revision indexes:", err)
+    }
+    if err := commentRepo.EnsureIndexes(); err != nil {
+        log.Fatal("Cannot create comment indexes:", err)
+    }
+    if err := reactionRepo.EnsureIndexes(); err != nil {
+        log.Fatal("Cannot create reaction indexes:", err)
+    }
+    if err := viewRepo.EnsureIndexes(); err != nil {
+        log.Fatal("Cannot create view indexes:", err)
+    }
+    if err := bookmarkRepo.EnsureIndexes(); err != nil {
+        log.Fatal("Cannot create bookmark indexes:", err)
+    }
+    if err := readingListRepo.EnsureIndexes(); err != nil {
+        log.Fatal("Cannot create reading list indexes:", err)
+    }
+    if err := seriesRepo.EnsureIndexes(); err != nil {
+        log.Fatal("Cannot create series indexes:", err)
+    }
+    if err := coAuthorRepo.EnsureIndexes(); err != nil {
+        log.Fatal("Cannot create co-author invitation indexes:", err)
+    }
 
     // Setup services
     userService := services.NewUserService(userRepo, cfg.JWTSecret)
-    postService := services.NewPostService(postRepo)
+    renderer := utils.NewContentRenderer()
+    postService := services.NewPostService(postRepo, slugRepo, categoryRepo, revisionRepo, userRepo, renderer, cfg.JWTSecret, cfg.PostAccessTTL)
+    categoryService := services.NewCategoryService(categoryRepo, postRepo)
+    revisionService := services.NewRevisionService(revisionRepo, postRepo)
+    spamScorer := services.NewHeuristicSpamScorer(commentRepo, services.SpamRules{
+        MaxLinks:       cfg.SpamMaxLinks,
+        Blocklist:      cfg.SpamBlocklist,
+        VelocityLimit:  cfg.SpamVelocityLimit,
+        VelocityWindow: cfg.SpamVelocityWindow,
+    })
+    commentService := services.NewCommentService(commentRepo, postRepo, postService, renderer, services.CommentModeration{
+        TrustedUsers:     cfg.CommentTrustedUsers,
+        AutoApproveAfter: cfg.CommentAutoApproveAfter,
+        Scorer:           spamScorer,
+        SpamThreshold:    cfg.SpamThreshold,
+    })
+
+    reactionService := services.NewReactionService(reactionRepo, postRepo, postService)
+    viewService := services.NewViewService(viewRepo, postRepo, cfg.ViewBufferSize)
+    unlockLimiter := services.NewUnlockLimiter(services.UnlockLimits{
+        IPAttempts:   cfg.UnlockIPAttempts,
+        PostAttempts: cfg.UnlockPostAttempts,
+        Backoff:      cfg.UnlockBackoff,
+        MaxBackoff:   cfg.UnlockMaxBackoff,
+    })
+    bookmarkService := services.NewBookmarkService(bookmarkRepo, postRepo, postService)
+    readingListService := services.NewReadingListService(readingListRepo, postRepo, postService)
+    seriesService := services.NewSeriesService(seriesRepo, postRepo)
+    relatedService := services.NewRelatedService(relatedRepo, postRepo, postService)
+    postService.AddListener(relatedService)
+    coAuthorService := services.NewCoAuthorService(coAuthorRepo, postRepo, postService, userRepo)
+
+    var searchIndex services.SearchIndex
+    rebuildSearchIndex := false
+    switch cfg.SearchEngine {
+    case "bleve":
+        bleveIndex, created, err := search.NewBleveIndex(cfg.SearchIndexPath)
+        if err != nil {
+            log.Fatal("Cannot open search index:", err)
+        }
+        defer bleveIndex.Close()
+        searchIndex = bleveIndex
+        rebuildSearchIndex = created
+    default:
+        mongoIndex := repositories.NewMongoSearchIndex(db)
+        if err := mongoIndex.EnsureIndexes(); err != nil {
+            log.Fatal("Cannot create search indexes:", err)
+        }
+        searchIndex = mongoIndex
+    }
+    searchService := services.NewSearchService(searchIndex, postRepo)
+    postService.AddListener(searchService)
+
+    tagService := services.NewTagService(postRepo, postService)
     uploadService := services.NewUploadService(r2Client)
+    trashService := services.NewTrashService(postRepo, uploadService, cfg.R2PublicURL, cfg.TrashRetention, revisionRepo, commentRepo, reactionRepo, viewRepo, coAuthorRepo, relatedRepo, slugRepo, bookmarkRepo, readingListRepo, seriesRepo)
+    importService := services.NewImportService(postService, commentService, uploadService, userRepo, categoryRepo, categoryService)
+    exportService := services.NewExportService(postRepo, categoryRepo, userRepo, uploadService, cfg.R2PublicURL)
+
+    // Commands, such as imports, run instead of the server. A search index
+    // created by a command is filled first, as the server only fills the
+    // indexes it creates itself.
+    if len(os.Args) > 1 {
+        if rebuildSearchIndex {
+            if _, err := searchService.Rebuild(); err != nil {
+                log.Fatal("Cannot build search index:", err)
+            }
+        }
+        if err := runCommand(os.Args[1], os.Args[2:], importService, exportService); err != nil {
+            log.Fatal(err)
+        }
+        return
+    }
+
+    // Background jobs
+    jobsCtx, stopJobs := context.WithCancel(context.Background())
+    defer stopJobs()
+
+    if rebuildSearchIndex {
+        go func() {
+            indexed, err := searchService.Rebuild()
+            if err != nil {
+                log.Println("Cannot build search index:", err)
+                return
+            }
+            log.Printf("Indexed %d post(s) for search", indexed)
+        }()
+    }
+
+    // Posts written before they were summarized get their excerpt, word
+    // count, reading time and table of contents in the background.
+    go func() {
+        summarized, err := postService.SummarizeExisting()
+        if err != nil {
+            log.Println("Cannot summarize existing posts:", err)
+        }
+        if summarized > 0 {
+            log.Printf("Summarized %d existing post(s)", summarized)
+        }
+    }()
+
+    // Posts written before posts had a slug get one in the background
+    go func() {
+        slugged, err := postService.SlugExisting()
+        if err != nil {
+            log.Println("Cannot give existing posts a slug:", err)
+        }
+        if slugged > 0 {
+            log.Printf("Gave %d existing post(s) a slug", slugged)
+        }
+    }()
+
+    go jobs.Every(jobsCtx, "publish-scheduled", cfg.PublishInterval, func() error {
+        published, err := postService.PublishScheduled()
+        if published > 0 {
+            log.Printf("Published %d scheduled post(s)", published)
+        }
+        return err
+    })
+
+    go jobs.Every(jobsCtx, "purge-trash", cfg.PurgeInterval, func() error {
+        purged, err := trashService.Purge()
+        if purged > 0 {
+            log.Printf("Purged %d deleted post(s)", purged)
+        }
+        return err
+    })
+
+    go jobs.Every(jobsCtx, "reconcile-reactions", cfg.ReactionReconcileInterval, func() error {
+        repaired, err := reactionService.Reconcile()
+        if repaired > 0 {
+            log.Printf("Repaired the reaction counts of %d post(s)", repaired)
+        }
+        return err
+    })
+
+    go jobs.Every(jobsCtx, "flush-views", cfg.ViewFlushInterval, func() error {
+        _, err := viewService.Flush()
+        return err
+    })
+
+    go jobs.Every(jobsCtx, "prune-unlock-attempts", cfg.UnlockMaxBackoff, func() error {
+        unlockLimiter.Prune()
+        return nil
+    })
+
+    go relatedService.Run(jobsCtx)
+    go jobs.Every(jobsCtx, "refresh-related-posts", cfg.RelatedRefreshInterval, func() error {
+        _, err := relatedService.RefreshAll()
+        return err
+    })
 
     // Setup handlers
     userHandler := handlers.NewUserHandler(userService)
-    postHandler := handlers.NewPostHandler(postService)
+    postHandler := handlers.NewPostHandler(postService, reactionService, viewService, seriesService, unlockLimiter)
+    revisionHandler := handlers.NewRevisionHandler(revisionService, postService)
+    commentHandler := handlers.NewCommentHandler(commentService)
+    reactionHandler := handlers.NewReactionHandler(reactionService)
+    viewHandler := handlers.NewViewHandler(viewService)
+    bookmarkHandler := handlers.NewBookmarkHandler(bookmarkService)
+    readingListHandler := handlers.NewReadingListHandler(readingListService)
+    seriesHandler := handlers.NewSeriesHandler(seriesService)
+    coAuthorHandler := handlers.NewCoAuthorHandler(coAuthorService)
+    relatedHandler := handlers.NewRelatedHandler(relatedService)
+    importHandler := handlers.NewImportHandler(importService, int64(cfg.ImportMaxSize)<<20)
+    exportHandler := handlers.NewExportHandler(exportService)
+    categoryHandler := handlers.NewCategoryHandler(categoryService)
+    tagHandler := handlers.NewTagHandler(tagService)
+    searchHandler := handlers.NewSearchHandler(searchService)
     uploadHandler := handlers.NewUploadHandler(&UploadServiceAdapter{
         Service: uploadService,
     })
@@ -94,8 +307,9 @@ func main() {
     // CORS middleware
     r.Use(func(c *gin.Context) {
         c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-        c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-        c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Authorization, Content-Type")
+        c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+        c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Authorization, Content-Type, If-Match, X-Post-Token")
+        c.Writer.Header().Set("Access-Control-Expose-Headers", "Link, ETag")
         if c.Request.Method == "OPTIONS" {
             c.AbortWithStatus(204)
             return
@@ -110,25 +324,129 @@ func main() {
         api.POST("/register", userHandler.Register)
         api.POST("/login", userHandler.Login)
         api.GET("/posts", postHandler.List)
-        api.GET("/posts/:id", postHandler.Get)
+        api.GET("/posts/:id", middleware.OptionalAuthMiddleware(cfg.JWTSecret), postHandler.Get)
+        api.GET("/posts/by-slug/:slug", middleware.OptionalAuthMiddleware(cfg.JWTSecret), postHandler.GetBySlug)
+        api.POST("/posts/:id/unlock", postHandler.Unlock)
+        api.GET("/posts/:id/comments", middleware.OptionalAuthMiddleware(cfg.JWTSecret), commentHandler.List)
+        api.GET("/posts/:id/related", middleware.OptionalAuthMiddleware(cfg.JWTSecret), relatedHandler.List)
+        api.GET("/sitemap", postHandler.Sitemap)
+        api.GET("/tags", tagHandler.List)
+        api.GET("/categories", categoryHandler.List)
+        api.GET("/search", searchHandler.Search)
+        api.GET("/shared/reading-lists/:token", readingListHandler.GetShared)
+        api.GET("/series/:id", middleware.OptionalAuthMiddleware(cfg.JWTSecret), seriesHandler.Get)
 
         // Protected routes
         protected := api.Group("/")
         protected.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+        requireIfMatch := middleware.RequireIfMatch(cfg.RequireIfMatch)
         {
             // User routes
-            protected.PUT("/user", userHandler.Update)
+            protected.PUT("/user", requireIfMatch, userHandler.Update)
+            protected.PATCH("/user", requireIfMatch, userHandler.Patch)
             protected.DELETE("/user", userHandler.Delete)
             protected.GET("/user/me", userHandler.GetMe)
+            protected.GET("/user/posts", postHandler.ListMine)
+            protected.GET("/user/trash", postHandler.Trash)
 
             // Post routes
             protected.POST("/posts", postHandler.Create)
-            protected.PUT("/posts/:id", postHandler.Update)
-            protected.DELETE("/posts/:id", postHandler.Delete)
+            protected.PUT("/posts/:id", requireIfMatch, postHandler.Update)
+            protected.PATCH("/posts/:id", requireIfMatch, postHandler.Patch)
+            protected.DELETE("/posts/:id", requireIfMatch, postHandler.Delete)
+            protected.POST("/posts/:id/restore", postHandler.Restore)
+            protected.POST("/posts/:id/publish", postHandler.Publish)
+            protected.POST("/posts/:id/unpublish", postHandler.Unpublish)
+            protected.POST("/posts/:id/archive", postHandler.Archive)
+
+            // Translation routes
+            protected.PUT("/posts/:id/translations/:lang", requireIfMatch, postHandler.Translate)
+            protected.DELETE("/posts/:id/translations/:lang", requireIfMatch, postHandler.DeleteTranslation)
+
+            // Co-author routes
+            protected.GET("/posts/:id/coauthors", coAuthorHandler.List)
+            protected.POST("/posts/:id/coauthors", coAuthorHandler.Invite)
+            protected.PUT("/posts/:id/coauthors/order", coAuthorHandler.Reorder)
+            protected.DELETE("/posts/:id/coauthors/:userId", coAuthorHandler.Remove)
+            protected.GET("/user/invitations", coAuthorHandler.Invitations)
+            protected.POST("/user/invitations/:id/accept", coAuthorHandler.Accept)
+            protected.POST("/user/invitations/:id/decline", coAuthorHandler.Decline)
+
+            // Revision routes
+            protected.GET("/posts/:id/revisions", revisionHandler.List)
+            protected.GET("/posts/:id/revisions/diff", revisionHandler.Diff)
+            protected.GET("/posts/:id/revisions/:rev", revisionHandler.Get)
+            protected.POST("/posts/:id/revisions/:rev/restore", revisionHandler.Restore)
+
+            // Comment routes
+            protected.POST("/posts/:id/comments", commentHandler.Create)
+            protected.POST("/posts/:id/comments/close", commentHandler.Close)
+            protected.POST("/posts/:id/comments/open", commentHandler.Open)
+            protected.PUT("/comments/:id", commentHandler.Update)
+            protected.DELETE("/comments/:id", commentHandler.Delete)
+
+            // Reaction routes
+            protected.PUT("/posts/:id/reactions/:type", reactionHandler.React)
+            protected.DELETE("/posts/:id/reactions/:type", reactionHandler.Unreact)
+
+            // Series routes
+            protected.GET("/user/series", seriesHandler.ListMine)
+            protected.POST("/series", seriesHandler.Create)
+            protected.PUT("/series/:id", seriesHandler.Update)
+            protected.DELETE("/series/:id", seriesHandler.Delete)
+            protected.PUT("/series/:id/posts/:postId", seriesHandler.AddPost)
+            protected.DELETE("/series/:id/posts/:postId", seriesHandler.RemovePost)
+            protected.PUT("/series/:id/order", seriesHandler.Reorder)
+
+            // Bookmark routes
+            protected.GET("/bookmarks", bookmarkHandler.List)
+            protected.PUT("/bookmarks/:id", bookmarkHandler.Create)
+            protected.DELETE("/bookmarks/:id", bookmarkHandler.Delete)
+            protected.GET("/reading-lists", readingListHandler.List)
+            protected.POST("/reading-lists", readingListHandler.Create)
+            protected.GET("/reading-lists/:id", readingListHandler.Get)
+            protected.PUT("/reading-lists/:id", readingListHandler.Update)
+            protected.DELETE("/reading-lists/:id", readingListHandler.Delete)
+            protected.PUT("/reading-lists/:id/posts/:postId", readingListHandler.AddPost)
+            protected.DELETE("/reading-lists/:id/posts/:postId", readingListHandler.RemovePost)
+            protected.PUT("/reading-lists/:id/order", readingListHandler.Reorder)
+
+            // Analytics routes
+            protected.GET("/analytics/posts/:id/views", viewHandler.Series)
+            protected.GET("/analytics/top-posts", viewHandler.TopPosts)
+            protected.GET("/analytics/referrers", viewHandler.Referrers)
 
             // Upload routes
             protected.POST("/upload", uploadHandler.UploadImage)
         }
+
+        // Editor routes
+        editor := api.Group("/")
+        editor.Use(middleware.AuthMiddleware(cfg.JWTSecret), middleware.RequireRole(models.RoleEditor, models.RoleAdmin))
+        {
+            // Taxonomy routes
+            editor.PUT("/tags/:tag", tagHandler.Rename)
+            editor.POST("/tags/:tag/merge", tagHandler.Merge)
+            editor.POST("/categories", categoryHandler.Create)
+            editor.PUT("/categories/:id", categoryHandler.Update)
+            editor.DELETE("/categories/:id", categoryHandler.Delete)
+
+            // Moderation routes
+            editor.GET("/comments/moderation", commentHandler.Queue)
+            editor.POST("/comments/moderation", commentHandler.Moderate)
+        }
+
+        // Admin routes
+        admin := api.Group("/admin")
+        admin.Use(middleware.AuthMiddleware(cfg.JWTSecret), middleware.RequireRole(models.RoleAdmin))
+        {
+            // Import routes
+            admin.POST("/import/hugo", importHandler.Hugo)
+            admin.POST("/import/wordpress", importHandler.WordPress)
+
+            // Export routes
+            admin.GET("/export/hugo", exportHandler.Hugo)
+        }
     }
 
     // Start server
@@ -8,9 +8,9 @@ import (
 )
 
 // AuthMiddleware is a middleware that checks if the Authorization header is valid
-// and contains a Bearer token. If the token is valid, it extracts the user_id from
-// the token and sets it in the context as "user_id". If the token is invalid or
-// missing, it returns a 401 status code with an error message.
+// and contains a Bearer token. If the token is valid, it extracts the user_id and
+// role from the token and sets them in the context as "user_id" and "role". If the
+// token is invalid or missing, it returns a 401 status code with an error message.
 func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
     return func(c *gin.Context) {
         authHeader := c.GetHeader("Authorization")
@@ -20,19 +20,65 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
             return
         }
 
-        tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-        token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-            return []byte(jwtSecret), nil
-        })
-
-        if err != nil || !token.Valid {
+        claims, ok := parseToken(authHeader, jwtSecret)
+        if !ok {
             c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
             c.Abort()
             return
         }
 
-        claims := token.Claims.(jwt.MapClaims)
         c.Set("user_id", claims["user_id"])
+        c.Set("role", claims["role"])
         c.Next()
     }
+}
+
+// RequireRole is a middleware that only lets through users whose role, as set
+// by AuthMiddleware, is one of the given roles. Other users get a 403 status
+// code with an error message. It must be used after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        role, _ := c.Get("role")
+        for _, allowed := range roles {
+            if role == allowed {
+                c.Next()
+                return
+            }
+        }
+
+        c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+        c.Abort()
+    }
+}
+
+// OptionalAuthMiddleware works like AuthMiddleware, but never rejects the
+// request. If a valid Bearer token is present, the user_id is set in the
+// context; otherwise the request continues anonymously. It is used on public
+// routes whose response depends on who is asking.
+func OptionalAuthMiddleware(jwtSecret string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+            if claims, ok := parseToken(authHeader, jwtSecret); ok {
+                c.Set("user_id", claims["user_id"])
+                c.Set("role", claims["role"])
+            }
+        }
+        c.Next()
+    }
+}
+
+// parseToken validates the Bearer token in the given Authorization header and
+// returns its claims.
+func parseToken(authHeader, jwtSecret string) (jwt.MapClaims, bool) {
+    tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
+    token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+        return []byte(jwtSecret), nil
+    })
+
+    if err != nil || !token.Valid {
+        return nil, false
+    }
+
+    claims, ok := token.Claims.(jwt.MapClaims)
+    return claims, ok
 }
\ No newline at end of file
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+    "github.com/gin-gonic/gin"
+    "net/http"
+)
+
+// RequireIfMatch is a middleware that rejects write requests without an
+// If-Match header with a 428 status code, so that clients cannot overwrite
+// changes they have not seen. With enabled set to false, it lets every
+// request through and If-Match stays optional.
+func RequireIfMatch(enabled bool) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if enabled && c.GetHeader("If-Match") == "" {
+            c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+            c.Abort()
+            return
+        }
+        c.Next()
+    }
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+// MaxReadingListItems is the largest number of posts a reading list holds.
+const MaxReadingListItems = 500
+
+// Bookmark is a post a user saved to read later.
+type Bookmark struct {
+    ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+    PostID    primitive.ObjectID `bson:"post_id" json:"post_id"`
+    CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+    Post      *Post              `bson:"-" json:"post,omitempty"`
+}
+
+// BookmarkPage is a page of bookmarks with its pagination details.
+type BookmarkPage struct {
+    Bookmarks  []*Bookmark
+    Pagination Pagination
+}
+
+// ReadingList is a named, ordered list of posts. A private list is only
+// visible to its owner; a shared list can also be read by anyone who has its
+// share token.
+type ReadingList struct {
+    ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    OwnerID     primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+    Name        string             `bson:"name" json:"name"`
+    Description string             `bson:"description" json:"description"`
+    Shared      bool               `bson:"shared" json:"shared"`
+    ShareToken  string             `bson:"share_token,omitempty" json:"share_token,omitempty"`
+    Items       []ReadingListItem  `bson:"items" json:"items"`
+    CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+    UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// ReadingListItem is a post in a reading list. Items are kept in the order
+// chosen by the list's owner.
+type ReadingListItem struct {
+    PostID  primitive.ObjectID `bson:"post_id" json:"post_id"`
+    AddedAt time.Time          `bson:"added_at" json:"added_at"`
+    Post    *Post              `bson:"-" json:"post,omitempty"`
+}
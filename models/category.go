@@ -0,0 +1,21 @@
+package models
+
+import (
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+// Category is a node in the hierarchical category tree. Ancestors holds the
+// IDs of every parent up to the root, closest last, so the descendants of a
+// category can be found with a single query.
+type Category struct {
+    ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+    Name        string               `bson:"name" json:"name"`
+    Slug        string               `bson:"slug" json:"slug"`
+    Description string               `bson:"description" json:"description"`
+    ParentID    *primitive.ObjectID  `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+    Ancestors   []primitive.ObjectID `bson:"ancestors" json:"-"`
+    Children    []*Category          `bson:"-" json:"children,omitempty"`
+    CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+    UpdatedAt   time.Time            `bson:"updated_at" json:"updated_at"`
+}
\ No newline at end of file
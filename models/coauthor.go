@@ -0,0 +1,54 @@
+package models
+
+import (
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+// MaxCoAuthors is the largest number of co-authors a post has, counting the
+// pending invitations.
+const MaxCoAuthors = 10
+
+// Invitation statuses. A user only becomes a co-author of a post once they
+// accept an invitation from its author.
+const (
+    InvitationStatusPending  = "pending"
+    InvitationStatusAccepted = "accepted"
+    InvitationStatusDeclined = "declined"
+)
+
+// CoAuthorInvitation asks a user to co-author a post.
+type CoAuthorInvitation struct {
+    ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    PostID      primitive.ObjectID `bson:"post_id" json:"post_id"`
+    InviterID   primitive.ObjectID `bson:"inviter_id" json:"inviter_id"`
+    InviteeID   primitive.ObjectID `bson:"invitee_id" json:"invitee_id"`
+    Status      string             `bson:"status" json:"status"`
+    CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+    RespondedAt *time.Time         `bson:"responded_at,omitempty" json:"responded_at,omitempty"`
+    Post        *InvitationPost    `bson:"-" json:"post,omitempty"`     // Set when listing the invitations of a user
+    Invitee     *PostAuthor        `bson:"-" json:"invitee,omitempty"` // Set when listing the invitations of a post
+}
+
+// InvitationPost is the post an invitation is about, as shown to the
+// invitee.
+type InvitationPost struct {
+    ID     primitive.ObjectID `json:"id"`
+    Title  string             `json:"title"`
+    Slug   string             `json:"slug"`
+    Status string             `json:"status"`
+}
+
+// PostAuthor is an author of a post as shown to readers.
+type PostAuthor struct {
+    ID       primitive.ObjectID `json:"id"`
+    Username string             `json:"username"`
+}
+
+// PostCoAuthors lists the authors of a post, in order, and the invitations
+// still waiting for an answer.
+type PostCoAuthors struct {
+    PostID      primitive.ObjectID    `json:"post_id"`
+    Authors     []*PostAuthor         `json:"authors"`
+    Invitations []*CoAuthorInvitation `json:"invitations"`
+}
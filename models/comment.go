@@ -0,0 +1,60 @@
+package models
+
+import (
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+// MaxCommentDepth is the deepest level of replies: top-level comments have
+// depth 0, replies to them depth 1, and so on.
+const MaxCommentDepth = 5
+
+// MaxCommentLength is the longest comment body accepted, in characters.
+const MaxCommentLength = 10000
+
+// Comment moderation statuses. Only approved comments are visible to
+// readers; a pending comment is also visible to its author.
+const (
+    CommentStatusPending  = "pending"
+    CommentStatusApproved = "approved"
+    CommentStatusRejected = "rejected"
+    CommentStatusSpam     = "spam"
+)
+
+// Comment is a reader's response to a post, or a reply to another comment.
+// Every comment belongs to the thread started by its top-level comment.
+type Comment struct {
+    ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+    PostID      primitive.ObjectID  `bson:"post_id" json:"post_id"`
+    AuthorID    primitive.ObjectID  `bson:"author_id" json:"author_id"`
+    ParentID    *primitive.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+    ThreadID    primitive.ObjectID  `bson:"thread_id" json:"thread_id"`
+    Depth       int                 `bson:"depth" json:"depth"`
+    Body        string              `bson:"body" json:"body"`
+    BodyHTML    string              `bson:"body_html" json:"body_html"`
+    Status      string              `bson:"status" json:"status"`
+    SpamScore   float64             `bson:"spam_score" json:"spam_score"` // From 0 to 1, see services.SpamScorer
+    ModeratedBy *primitive.ObjectID `bson:"moderated_by,omitempty" json:"moderated_by,omitempty"`
+    ModeratedAt *time.Time          `bson:"moderated_at,omitempty" json:"moderated_at,omitempty"`
+    SourceID    string              `bson:"source_id,omitempty" json:"-"` // Where an imported comment comes from
+    CreatedAt   time.Time           `bson:"created_at" json:"created_at"`
+    UpdatedAt   time.Time           `bson:"updated_at" json:"updated_at"`
+    // DeletedAt is set on deleted comments that are kept, without their body,
+    // because other comments reply to them.
+    DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+    Replies   []*Comment `bson:"-" json:"replies,omitempty"`
+}
+
+// IsApproved reports whether the comment is visible to readers. Comments
+// created before moderation was introduced have no status and are treated
+// as approved.
+func (c *Comment) IsApproved() bool {
+    return c.Status == CommentStatusApproved || c.Status == ""
+}
+
+// CommentPage is a page of comment threads with its pagination details. Each
+// top-level comment carries its replies.
+type CommentPage struct {
+    Comments   []*Comment
+    Pagination Pagination
+}
@@ -0,0 +1,21 @@
+package models
+
+import "errors"
+
+// Errors shared by the repository, service and handler layers so that
+// handlers can map them to HTTP status codes.
+var (
+    ErrNotFound     = errors.New("not found")
+    ErrForbidden    = errors.New("forbidden")
+    ErrInvalidInput = errors.New("invalid input")
+    ErrConflict     = errors.New("conflict")
+    // ErrVersionConflict is returned by conditional writes when the stored
+    // version no longer matches the one the client last read.
+    ErrVersionConflict = errors.New("version conflict")
+    // ErrPasswordRequired is returned when reading a password-protected post
+    // without a valid access token.
+    ErrPasswordRequired = errors.New("password required")
+    // ErrTooManyAttempts is returned when a client has to wait before trying
+    // again, after too many failed attempts.
+    ErrTooManyAttempts = errors.New("too many attempts")
+)
\ No newline at end of file
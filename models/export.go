@@ -0,0 +1,8 @@
+package models
+
+// ExportReport sums up an export of the blog.
+type ExportReport struct {
+    Posts    int      `json:"posts"`
+    Images   int      `json:"images"`
+    Warnings []string `json:"warnings,omitempty"` // Images that could not be exported, by post
+}
@@ -0,0 +1,62 @@
+package models
+
+// What an import did, or would do in a dry run, with each imported post.
+const (
+    ImportCreated   = "created"
+    ImportUpdated   = "updated"
+    ImportUnchanged = "unchanged"
+    ImportSkipped   = "skipped"
+    ImportFailed    = "failed"
+)
+
+// ImportOptions tell an import who the imported posts belong to, and whether
+// to write anything.
+type ImportOptions struct {
+    AuthorID string // For WordPress, only the posts of authors missing from the export
+    DryRun   bool   // Report what the import would do without writing anything
+}
+
+// ImportReport sums up an import, with an item for every file or entry
+// considered.
+type ImportReport struct {
+    DryRun     bool          `json:"dry_run"`
+    Created    int           `json:"created"`
+    Updated    int           `json:"updated"`
+    Unchanged  int           `json:"unchanged"`
+    Skipped    int           `json:"skipped"`
+    Failed     int           `json:"failed"`
+    Images     int           `json:"images"` // Images uploaded, or to upload in a dry run
+    Users      int           `json:"users,omitempty"` // Placeholder accounts created for authors and commenters
+    Categories int           `json:"categories,omitempty"` // Categories created
+    Comments   int           `json:"comments,omitempty"` // Comments created
+    Items      []*ImportItem `json:"items"`
+}
+
+// ImportItem is the outcome of importing a single file or entry.
+type ImportItem struct {
+    Source   string   `json:"source"` // Path of the file, or type and ID of the entry, in the import
+    OldURL   string   `json:"old_url,omitempty"` // Address of the post on the blog it comes from, if known
+    Action   string   `json:"action"`
+    PostID   string   `json:"post_id,omitempty"`
+    Slug     string   `json:"slug,omitempty"`
+    Title    string   `json:"title,omitempty"`
+    Error    string   `json:"error,omitempty"`
+    Warnings []string `json:"warnings,omitempty"`
+}
+
+// Add records the given item in the report and counts it.
+func (r *ImportReport) Add(item *ImportItem) {
+    r.Items = append(r.Items, item)
+    switch item.Action {
+    case ImportCreated:
+        r.Created++
+    case ImportUpdated:
+        r.Updated++
+    case ImportUnchanged:
+        r.Unchanged++
+    case ImportSkipped:
+        r.Skipped++
+    case ImportFailed:
+        r.Failed++
+    }
+}
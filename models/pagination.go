@@ -0,0 +1,42 @@
+package models
+
+// DefaultPageLimit and MaxPageLimit are the default and largest number of
+// items returned in a single page.
+const (
+    DefaultPageLimit = 10
+    MaxPageLimit     = 100
+)
+
+// Sort orders a list on a single field, with the item ID as a tie-breaker.
+// The zero value is the default order of the list.
+type Sort struct {
+    Field      string
+    Descending bool
+}
+
+// PageRequest selects a page of a sorted list. When Cursor is set, the page
+// starts right after (or, for a backward cursor, right before) the item the
+// cursor points to; otherwise Page is used as a 1-indexed page number. A
+// cursor is only valid for the sort order it was created with.
+type PageRequest struct {
+    Cursor       string
+    Page         int
+    Limit        int
+    IncludeTotal bool
+    Sort         Sort
+}
+
+// Pagination tells clients how to reach the pages around the current one.
+// Cursors are opaque strings; an empty cursor means there is no such page.
+type Pagination struct {
+    NextCursor string `json:"next_cursor,omitempty"`
+    PrevCursor string `json:"prev_cursor,omitempty"`
+    HasMore    bool   `json:"has_more"`
+    Total      *int64 `json:"total,omitempty"`
+}
+
+// PostPage is a page of posts with its pagination details.
+type PostPage struct {
+    Posts      []*Post
+    Pagination Pagination
+}
\ No newline at end of file
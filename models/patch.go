@@ -0,0 +1,34 @@
+package models
+
+// PatchField describes a field clients may change with an update. Fields
+// that are not listed for a model, such as author_id, created_at or the
+// password hash, cannot be written through updates at all.
+type PatchField struct {
+    List      bool // The value is a list of strings rather than a string
+    Clearable bool // The field may be emptied, e.g. by patching it to null
+}
+
+// PostPatchFields are the post fields clients may update. The password is
+// the new plain-text password of a password-protected post, which the post
+// service hashes.
+var PostPatchFields = map[string]PatchField{
+    "title":          {},
+    "content":        {},
+    "content_format": {},
+    "excerpt":        {Clearable: true},
+    "image_url":      {Clearable: true},
+    "slug":           {},
+    "language":       {Clearable: true},
+    "tags":           {List: true, Clearable: true},
+    "category_id":    {Clearable: true},
+    "visibility":     {},
+    "password":       {},
+}
+
+// UserPatchFields are the user fields clients may update. The password is
+// the new plain-text password, which the user service hashes.
+var UserPatchFields = map[string]PatchField{
+    "username": {},
+    "email":    {},
+    "password": {},
+}
@@ -5,12 +5,142 @@ import (
     "time"
 )
 
+// Post statuses. Only published posts are visible to readers; the other
+// statuses are visible to the post's author only.
+const (
+    PostStatusDraft     = "draft"
+    PostStatusScheduled = "scheduled"
+    PostStatusPublished = "published"
+    PostStatusArchived  = "archived"
+)
+
+// Fields posts can be sorted on.
+const (
+    PostSortCreated    = "created_at"
+    PostSortUpdated    = "updated_at"
+    PostSortTitle      = "title"
+    PostSortPopularity = "popularity"
+)
+
 type Post struct {
-    ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-    Title       string            `bson:"title" json:"title"`
-    Content     string            `bson:"content" json:"content"`
-    AuthorID    primitive.ObjectID `bson:"author_id" json:"author_id"`
-    ImageURL    string            `bson:"image_url" json:"image_url"`
-    CreatedAt   time.Time         `bson:"created_at" json:"created_at"`
-    UpdatedAt   time.Time         `bson:"updated_at" json:"updated_at"`
+    ID             primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+    Title          string               `bson:"title" json:"title"`
+    Slug           string               `bson:"slug,omitempty" json:"slug"`
+    Language       string               `bson:"language,omitempty" json:"language,omitempty"` // BCP 47 tag of the title and content
+    Variants       []*PostVariant       `bson:"variants,omitempty" json:"-"` // The post in other languages
+    Translations   []*PostTranslation   `bson:"-" json:"translations,omitempty"` // The languages the post can be read in, set on responses
+    Content        string               `bson:"content" json:"content,omitempty"` // Left out of lists
+    ContentFormat  string               `bson:"content_format" json:"content_format"`
+    ContentHTML    string               `bson:"content_html" json:"content_html,omitempty"` // Left out of lists
+    Excerpt        string               `bson:"excerpt" json:"excerpt"` // Written by an author, or derived from the content
+    CustomExcerpt  bool                 `bson:"custom_excerpt,omitempty" json:"custom_excerpt,omitempty"`
+    WordCount      int                  `bson:"word_count" json:"word_count"`
+    ReadingTime    int                  `bson:"reading_time" json:"reading_time"` // Estimated minutes
+    TOC            []TOCEntry           `bson:"toc,omitempty" json:"toc,omitempty"` // Left out of lists
+    AuthorID       primitive.ObjectID   `bson:"author_id" json:"author_id"`
+    CoAuthors      []primitive.ObjectID `bson:"co_authors,omitempty" json:"co_authors,omitempty"` // In order, after the author
+    Authors        []*PostAuthor        `bson:"-" json:"authors,omitempty"` // The author then the co-authors, set on responses
+    ImageURL       string               `bson:"image_url" json:"image_url"`
+    Tags           []string             `bson:"tags" json:"tags"`
+    CategoryID     *primitive.ObjectID  `bson:"category_id,omitempty" json:"category_id,omitempty"`
+    Popularity     int64                `bson:"popularity" json:"popularity"`
+    CommentCount   int64                `bson:"comment_count" json:"comment_count"`
+    CommentsClosed bool                 `bson:"comments_closed" json:"comments_closed"`
+    Reactions      map[string]int64     `bson:"reactions,omitempty" json:"reactions,omitempty"` // Count of each reaction type
+    Reacted        map[string]bool      `bson:"-" json:"reacted,omitempty"` // Reaction types of the caller, set on single posts
+    Series         *PostSeries          `bson:"-" json:"series,omitempty"` // Set on single posts that belong to a series
+    Status         string               `bson:"status" json:"status"`
+    Visibility     string               `bson:"visibility,omitempty" json:"visibility,omitempty"` // Empty for public posts written before visibilities
+    PasswordHash   string               `bson:"password_hash,omitempty" json:"-"` // Bcrypt hash of the password of a password-protected post
+    Version        int64                `bson:"version" json:"version"` // Incremented on every write
+    PublishedAt    *time.Time           `bson:"published_at,omitempty" json:"published_at,omitempty"`
+    CreatedAt      time.Time            `bson:"created_at" json:"created_at"`
+    UpdatedAt      time.Time            `bson:"updated_at" json:"updated_at"`
+    DeletedAt      *time.Time           `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+    DeletedBy      *primitive.ObjectID  `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+    SourceID       string               `bson:"source_id,omitempty" json:"source_id,omitempty"` // Where an imported post comes from, e.g. "hugo:posts/hello.md"
+}
+
+// IsPublished reports whether the post is published. Posts created before
+// statuses were introduced have no status and are treated as published.
+func (p *Post) IsPublished() bool {
+    return p.Status == PostStatusPublished || p.Status == ""
+}
+
+// IsPublic reports whether the post can be read by anyone, given its link:
+// it is published, and public or unlisted.
+func (p *Post) IsPublic() bool {
+    if !p.IsPublished() {
+        return false
+    }
+    return p.Visibility == "" || p.Visibility == VisibilityPublic || p.Visibility == VisibilityUnlisted
+}
+
+// IsListed reports whether the post may appear in lists, feeds, search
+// results and related posts: it is published and public.
+func (p *Post) IsListed() bool {
+    return p.IsPublic() && p.Visibility != VisibilityUnlisted
+}
+
+// TrimForList drops the full content of the post and its table of contents,
+// so that list responses only carry its excerpt.
+func (p *Post) TrimForList() {
+    p.Content = ""
+    p.ContentHTML = ""
+    p.TOC = nil
+}
+
+// IsAuthor reports whether userID is the author or a co-author of the post.
+// Co-authors may read and edit the post, while publishing, archiving and
+// deleting it is left to its author.
+func (p *Post) IsAuthor(userID string) bool {
+    if userID == "" {
+        return false
+    }
+    if p.AuthorID.Hex() == userID {
+        return true
+    }
+    for _, coAuthor := range p.CoAuthors {
+        if coAuthor.Hex() == userID {
+            return true
+        }
+    }
+    return false
+}
+
+// TOCEntry is a heading of the content of a post, listed in its table of
+// contents. Anchor is the id of the heading in content_html.
+type TOCEntry struct {
+    Level  int    `bson:"level" json:"level"`
+    Text   string `bson:"text" json:"text"`
+    Anchor string `bson:"anchor" json:"anchor"`
+}
+
+// PostFilter narrows down the posts returned by a list query. Zero values
+// mean "no restriction".
+type PostFilter struct {
+    // AuthorID keeps the posts written by the author, including the posts
+    // they co-author unless ExcludeCoAuthored is set.
+    AuthorID          primitive.ObjectID
+    ExcludeCoAuthored bool
+    Statuses          []string
+    // Visibilities keeps the posts with one of the given visibilities;
+    // VisibilityPublic also matches posts without a visibility.
+    Visibilities []string
+    // Tags only keeps posts that have every one of the given tags.
+    Tags []string
+    // Category is the slug of a category. The post service expands it into
+    // CategoryIDs, which holds the category and all of its descendants.
+    Category    string
+    CategoryIDs []primitive.ObjectID
+    // The date ranges are inclusive; nil means unbounded.
+    CreatedAfter  *time.Time
+    CreatedBefore *time.Time
+    UpdatedAfter  *time.Time
+    UpdatedBefore *time.Time
+    // HasImage keeps posts with (true) or without (false) an image.
+    HasImage *bool
+    // Deleted lists the posts in the trash instead of the live ones. Deleted
+    // posts are left out of every other query.
+    Deleted bool
 }
\ No newline at end of file
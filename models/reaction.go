@@ -0,0 +1,45 @@
+package models
+
+import (
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+// Reaction types readers can react to posts with.
+const (
+    ReactionLike      = "like"
+    ReactionLove      = "love"
+    ReactionLaugh     = "laugh"
+    ReactionWow       = "wow"
+    ReactionSad       = "sad"
+    ReactionCelebrate = "celebrate"
+)
+
+// ReactionEmoji maps each reaction type to the emoji clients show for it.
+var ReactionEmoji = map[string]string{
+    ReactionLike:      "👍",
+    ReactionLove:      "❤️",
+    ReactionLaugh:     "😂",
+    ReactionWow:       "😮",
+    ReactionSad:       "😢",
+    ReactionCelebrate: "🎉",
+}
+
+// Reaction is a reader's reaction to a post. A user has at most one reaction
+// per post; reacting again with another type replaces it.
+type Reaction struct {
+    ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    PostID    primitive.ObjectID `bson:"post_id" json:"post_id"`
+    UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+    Type      string             `bson:"type" json:"type"`
+    CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+    UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// PostReactions sums up the reactions to a post: the number of reactions of
+// each type, and which type the caller reacted with, if any.
+type PostReactions struct {
+    PostID    primitive.ObjectID `json:"post_id"`
+    Reactions map[string]int64   `json:"reactions"`
+    Reacted   map[string]bool    `json:"reacted"`
+}
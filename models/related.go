@@ -0,0 +1,37 @@
+package models
+
+import (
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+// MaxRelatedPosts is the number of related posts returned for a post.
+const MaxRelatedPosts = 5
+
+// Reasons a post is listed as related to another.
+const (
+    RelatedReasonSimilar    = "similar"     // Shares tags or vocabulary
+    RelatedReasonSameAuthor = "same_author" // Recent post by the same author, when too few posts are similar
+)
+
+// RelatedPosts caches the published posts most similar to a post, best
+// first, as computed in the background by the related posts service.
+type RelatedPosts struct {
+    PostID     primitive.ObjectID `bson:"_id" json:"post_id"`
+    Scores     []RelatedScore     `bson:"scores" json:"scores"`
+    ComputedAt time.Time          `bson:"computed_at" json:"computed_at"`
+}
+
+// RelatedScore is the similarity, between 0 and 1, of a post to the post
+// whose RelatedPosts it belongs to.
+type RelatedScore struct {
+    PostID primitive.ObjectID `bson:"post_id" json:"post_id"`
+    Score  float64            `bson:"score" json:"score"`
+}
+
+// RelatedPost is a post recommended to the readers of another post.
+type RelatedPost struct {
+    Post   *Post   `json:"post"`
+    Score  float64 `json:"score"`
+    Reason string  `json:"reason"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+// Revision diff modes.
+const (
+    DiffModeLine = "line"
+    DiffModeWord = "word"
+)
+
+// PostRevision is a snapshot of the editable fields of a post, taken every
+// time the post is created or changed. Revisions of a post are numbered from
+// 1 upwards.
+type PostRevision struct {
+    ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+    PostID        primitive.ObjectID  `bson:"post_id" json:"post_id"`
+    Number        int                 `bson:"number" json:"number"`
+    AuthorID      primitive.ObjectID  `bson:"author_id" json:"author_id"` // The user who made the change
+    Title         string              `bson:"title" json:"title"`
+    Content       string              `bson:"content" json:"content,omitempty"`
+    ContentFormat string              `bson:"content_format" json:"content_format"`
+    ImageURL      string              `bson:"image_url" json:"image_url"`
+    Tags          []string            `bson:"tags,omitempty" json:"tags,omitempty"`
+    CategoryID    *primitive.ObjectID `bson:"category_id,omitempty" json:"category_id,omitempty"`
+    CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// DiffOp is a run of text that is unchanged ("equal"), added ("insert") or
+// removed ("delete") between two revisions.
+type DiffOp struct {
+    Op   string `json:"op"`
+    Text string `json:"text"`
+}
+
+// RevisionDiff describes the changes between two revisions of a post.
+type RevisionDiff struct {
+    From    int      `json:"from"`
+    To      int      `json:"to"`
+    Mode    string   `json:"mode"`
+    Title   []DiffOp `json:"title"`
+    Content []DiffOp `json:"content"`
+}
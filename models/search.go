@@ -0,0 +1,33 @@
+package models
+
+import (
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+// SearchQuery describes a full-text search over published posts. Zero values
+// mean "no restriction".
+type SearchQuery struct {
+    Text     string
+    AuthorID primitive.ObjectID
+    Tags     []string
+    From     *time.Time
+    To       *time.Time
+    Page     int
+    Limit    int
+}
+
+// SearchHit is a post matched by a search index, with its relevance score.
+// Higher scores rank first.
+type SearchHit struct {
+    PostID primitive.ObjectID
+    Score  float64
+}
+
+// SearchResult is a ranked search result returned to clients. Snippet is an
+// HTML-escaped excerpt of the post with the matched terms wrapped in <mark>.
+type SearchResult struct {
+    Post    *Post   `json:"post"`
+    Score   float64 `json:"score"`
+    Snippet string  `json:"snippet"`
+}
\ No newline at end of file
@@ -0,0 +1,44 @@
+package models
+
+import (
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+// MaxSeriesPosts is the largest number of posts a series holds.
+const MaxSeriesPosts = 200
+
+// Series is an ordered sequence of posts by the same author, such as the
+// parts of a long tutorial. A post belongs to one series at most, and its
+// position is its 1-indexed place in PostIDs.
+type Series struct {
+    ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+    AuthorID    primitive.ObjectID   `bson:"author_id" json:"author_id"`
+    Title       string               `bson:"title" json:"title"`
+    Description string               `bson:"description" json:"description"`
+    PostIDs     []primitive.ObjectID `bson:"post_ids" json:"-"`
+    Posts       []*SeriesPost        `bson:"-" json:"posts"`
+    CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+    UpdatedAt   time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+// SeriesPost is a post as listed in a series.
+type SeriesPost struct {
+    ID       primitive.ObjectID `json:"id"`
+    Title    string             `json:"title"`
+    Slug     string             `json:"slug"`
+    Status   string             `json:"status"`
+    Position int                `json:"position"`
+}
+
+// PostSeries tells where a post stands in its series. Positions and the
+// previous and next posts only take into account the posts the reader can
+// see.
+type PostSeries struct {
+    ID       primitive.ObjectID `json:"id"`
+    Title    string             `json:"title"`
+    Position int                `json:"position"`
+    Total    int                `json:"total"`
+    Previous *SeriesPost        `json:"previous,omitempty"`
+    Next     *SeriesPost        `json:"next,omitempty"`
+}
@@ -0,0 +1,7 @@
+package models
+
+// TagCount is a tag together with the number of published posts using it.
+type TagCount struct {
+    Tag       string `bson:"_id" json:"tag"`
+    PostCount int    `bson:"count" json:"post_count"`
+}
\ No newline at end of file
@@ -0,0 +1,152 @@
+package models
+
+import (
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+// HrefLangDefault is the hreflang of the version of a page shown to readers
+// whose language matches none of its versions, its canonical version.
+const HrefLangDefault = "x-default"
+
+// PostVariant is a post written in another language than its own. Variants
+// are stored in the post they translate, the canonical post, and share its
+// author, status, tags, category, comments and reactions, while their title,
+// slug and content are their own. The content is rendered and summarized as
+// for posts.
+type PostVariant struct {
+    Language      string     `bson:"language" json:"language"` // BCP 47 tag, e.g. "vi" or "en-US"
+    Title         string     `bson:"title" json:"title"`
+    Slug          string     `bson:"slug" json:"slug"`
+    Content       string     `bson:"content" json:"content"`
+    ContentFormat string     `bson:"content_format" json:"content_format"`
+    ContentHTML   string     `bson:"content_html" json:"content_html"`
+    Excerpt       string     `bson:"excerpt" json:"excerpt"`
+    CustomExcerpt bool       `bson:"custom_excerpt,omitempty" json:"custom_excerpt,omitempty"`
+    WordCount     int        `bson:"word_count" json:"word_count"`
+    ReadingTime   int        `bson:"reading_time" json:"reading_time"`
+    TOC           []TOCEntry `bson:"toc,omitempty" json:"toc,omitempty"`
+    CreatedAt     time.Time  `bson:"created_at" json:"created_at"`
+    UpdatedAt     time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// PostTranslation is a language a post can be read in, listed on post
+// responses.
+type PostTranslation struct {
+    Language  string `json:"language,omitempty"` // Empty for a canonical post without a language
+    Slug      string `json:"slug"`
+    Title     string `json:"title"`
+    Canonical bool   `json:"canonical,omitempty"`
+}
+
+// PostAlternate is a version of a post to link to with an hreflang, as in
+// <link rel="alternate" hreflang="vi" href="..."> or the xhtml:link of a
+// sitemap.
+type PostAlternate struct {
+    HrefLang string `json:"hreflang"`
+    Slug     string `json:"slug"`
+}
+
+// SitemapEntry is a version of a published post, with the alternates to
+// list next to it in a sitemap or feed. Each language of a post has its own
+// entry, and every entry of the post lists all of them.
+type SitemapEntry struct {
+    PostID     primitive.ObjectID `json:"post_id"`
+    Language   string             `json:"language,omitempty"`
+    Slug       string             `json:"slug"`
+    Title      string             `json:"title"`
+    UpdatedAt  time.Time          `json:"updated_at"`
+    Alternates []*PostAlternate   `json:"alternates,omitempty"`
+}
+
+// SitemapPage is a page of sitemap entries with its pagination details. A
+// page holds the entries of a page of posts, so it may hold more entries
+// than the requested limit.
+type SitemapPage struct {
+    Entries    []*SitemapEntry
+    Pagination Pagination
+}
+
+// Variant returns the variant of the post in the given language, or nil if
+// the post has none.
+func (p *Post) Variant(language string) *PostVariant {
+    for _, variant := range p.Variants {
+        if variant.Language == language {
+            return variant
+        }
+    }
+    return nil
+}
+
+// Languages returns the language of the post followed by those of its
+// variants. The first one is empty if the post has no language.
+func (p *Post) Languages() []string {
+    languages := []string{p.Language}
+    for _, variant := range p.Variants {
+        languages = append(languages, variant.Language)
+    }
+    return languages
+}
+
+// Localize turns the post into its variant in the given language, if it has
+// one, and lists the languages the post can be read in as its Translations.
+// Posts without variants are left as they are.
+func (p *Post) Localize(language string) {
+    if len(p.Variants) == 0 {
+        return
+    }
+
+    p.Translations = []*PostTranslation{{Language: p.Language, Slug: p.Slug, Title: p.Title, Canonical: true}}
+    for _, variant := range p.Variants {
+        p.Translations = append(p.Translations, &PostTranslation{Language: variant.Language, Slug: variant.Slug, Title: variant.Title})
+    }
+
+    variant := p.Variant(language)
+    if variant == nil || language == "" {
+        return
+    }
+    p.Language = variant.Language
+    p.Title = variant.Title
+    p.Slug = variant.Slug
+    p.Content = variant.Content
+    p.ContentFormat = variant.ContentFormat
+    p.ContentHTML = variant.ContentHTML
+    p.Excerpt = variant.Excerpt
+    p.CustomExcerpt = variant.CustomExcerpt
+    p.WordCount = variant.WordCount
+    p.ReadingTime = variant.ReadingTime
+    p.TOC = variant.TOC
+}
+
+// Alternates returns the hreflang links of the post: one per language it can
+// be read in, and an x-default link to the canonical post. Posts without
+// variants have none.
+func (p *Post) Alternates() []*PostAlternate {
+    if len(p.Variants) == 0 {
+        return nil
+    }
+
+    var alternates []*PostAlternate
+    if p.Language != "" {
+        alternates = append(alternates, &PostAlternate{HrefLang: p.Language, Slug: p.Slug})
+    }
+    for _, variant := range p.Variants {
+        alternates = append(alternates, &PostAlternate{HrefLang: variant.Language, Slug: variant.Slug})
+    }
+    return append(alternates, &PostAlternate{HrefLang: HrefLangDefault, Slug: p.Slug})
+}
+
+// SitemapEntries returns the sitemap entries of the post, the canonical post
+// first. The post must not be localized.
+func (p *Post) SitemapEntries() []*SitemapEntry {
+    alternates := p.Alternates()
+    entries := []*SitemapEntry{{PostID: p.ID, Language: p.Language, Slug: p.Slug, Title: p.Title, UpdatedAt: p.UpdatedAt, Alternates: alternates}}
+    for _, variant := range p.Variants {
+        updated := variant.UpdatedAt
+        if p.UpdatedAt.After(updated) {
+            updated = p.UpdatedAt
+        }
+        entries = append(entries, &SitemapEntry{PostID: p.ID, Language: variant.Language, Slug: variant.Slug, Title: variant.Title, UpdatedAt: updated, Alternates: alternates})
+    }
+    return entries
+}
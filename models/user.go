@@ -5,11 +5,22 @@ import (
     "time"
 )
 
+// User roles. Editors manage shared content such as the taxonomy, admins can
+// do everything editors can. Users without a role are regular users.
+const (
+    RoleUser   = "user"
+    RoleEditor = "editor"
+    RoleAdmin  = "admin"
+)
+
 type User struct {
-    ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-    Username  string            `bson:"username" json:"username"`
-    Email     string            `bson:"email" json:"email"`
-    Password  string            `bson:"password" json:"-"`
-    CreatedAt time.Time         `bson:"created_at" json:"created_at"`
-    UpdatedAt time.Time         `bson:"updated_at" json:"updated_at"`
+    ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    Username    string             `bson:"username" json:"username"`
+    Email       string             `bson:"email" json:"email"`
+    Password    string             `bson:"password" json:"-"`
+    Role        string             `bson:"role,omitempty" json:"role"`
+    Placeholder bool               `bson:"placeholder,omitempty" json:"placeholder,omitempty"` // Created by an import, without a password
+    Version     int64              `bson:"version" json:"version"` // Incremented on every write
+    CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+    UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
 }
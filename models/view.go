@@ -0,0 +1,62 @@
+package models
+
+import (
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+// MaxViewRange is the longest period view statistics can be requested for.
+const MaxViewRange = 366 * 24 * time.Hour
+
+// DailyViews counts the views of a post on a day, coming from a referrer.
+// Days are UTC midnights, and the referrer is a host name, or empty for
+// direct visits. Each visitor is counted as unique once per post and day,
+// under the referrer of their first view.
+type DailyViews struct {
+    PostID   primitive.ObjectID `bson:"post_id"`
+    AuthorID primitive.ObjectID `bson:"author_id"`
+    Day      time.Time          `bson:"day"`
+    Referrer string             `bson:"referrer"`
+    Views    int64              `bson:"views"`
+    Uniques  int64              `bson:"uniques"`
+}
+
+// ViewVisitor records that a visitor, known only by a salted hash that
+// changes every day, has viewed a post on a day.
+type ViewVisitor struct {
+    PostID  primitive.ObjectID `bson:"post_id"`
+    Day     time.Time          `bson:"day"`
+    Visitor string             `bson:"visitor"`
+}
+
+// ViewFilter selects the views statistics are computed on. Zero IDs mean "no
+// restriction"; From and To are inclusive days.
+type ViewFilter struct {
+    PostID   primitive.ObjectID
+    AuthorID primitive.ObjectID
+    From     time.Time
+    To       time.Time
+}
+
+// ViewPoint is the number of views on a day, formatted as YYYY-MM-DD.
+type ViewPoint struct {
+    Day     string `bson:"_id" json:"day"`
+    Views   int64  `bson:"views" json:"views"`
+    Uniques int64  `bson:"uniques" json:"uniques"`
+}
+
+// PostViews is the number of views of a post over a period.
+type PostViews struct {
+    PostID  primitive.ObjectID `bson:"_id" json:"post_id"`
+    Title   string             `bson:"-" json:"title,omitempty"`
+    Views   int64              `bson:"views" json:"views"`
+    Uniques int64              `bson:"uniques" json:"uniques"`
+}
+
+// ReferrerViews is the number of views coming from a referrer over a period.
+// An empty referrer stands for direct visits.
+type ReferrerViews struct {
+    Referrer string `bson:"_id" json:"referrer"`
+    Views    int64  `bson:"views" json:"views"`
+    Uniques  int64  `bson:"uniques" json:"uniques"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Post visibilities. They apply to published posts; posts in other statuses
+// stay visible to their authors only.
+const (
+    // VisibilityPublic posts are listed and readable by anyone. Posts
+    // created before visibilities were introduced have no visibility and are
+    // public.
+    VisibilityPublic = "public"
+    // VisibilityUnlisted posts are readable by anyone with their link, but
+    // left out of lists, feeds, search and related posts.
+    VisibilityUnlisted = "unlisted"
+    // VisibilityPrivate posts are only readable by their authors, editors
+    // and admins.
+    VisibilityPrivate = "private"
+    // VisibilityPassword posts are unlisted and only readable with an access
+    // token, given in exchange for the password of the post.
+    VisibilityPassword = "password"
+)
+
+// Viewer is who reads a post: an authenticated user, with their role, or an
+// anonymous reader (empty UserID). AccessToken is the token unlocking a
+// password-protected post, if the reader has one.
+type Viewer struct {
+    UserID      string
+    Role        string
+    AccessToken string
+}
+
+// IsEditor reports whether the viewer may read every published post, private
+// and password-protected ones included.
+func (v Viewer) IsEditor() bool {
+    return v.Role == RoleEditor || v.Role == RoleAdmin
+}
+
+// PostAccessToken unlocks a password-protected post until it expires or the
+// password of the post changes.
+type PostAccessToken struct {
+    Token     string    `json:"token"`
+    ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,107 @@
+package archive
+
+import (
+    "archive/zip"
+    "fmt"
+    "io"
+    "os"
+    "path"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+// Writer writes files, one at a time, to a directory tree or an archive.
+// Names are slash-separated paths relative to its root.
+type Writer interface {
+    // Write creates the file with the given name and modification time, and
+    // fills it by calling write.
+    Write(name string, modified time.Time, write func(io.Writer) error) error
+    // Close finishes the tree or archive. Nothing may be written after.
+    Close() error
+}
+
+// Dir writes files below a directory of the local file system, creating
+// their parent directories as needed and replacing existing files.
+type Dir struct {
+    root string
+}
+
+// NewDir returns a Dir writing below the given directory, which is created if
+// it does not exist.
+func NewDir(root string) (*Dir, error) {
+    if err := os.MkdirAll(root, 0o755); err != nil {
+        return nil, err
+    }
+    return &Dir{root: root}, nil
+}
+
+func (d *Dir) Write(name string, modified time.Time, write func(io.Writer) error) error {
+    name, err := cleanName(name)
+    if err != nil {
+        return err
+    }
+    target := filepath.Join(d.root, filepath.FromSlash(name))
+    if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+        return err
+    }
+
+    file, err := os.Create(target)
+    if err != nil {
+        return err
+    }
+    if err := write(file); err != nil {
+        file.Close()
+        return err
+    }
+    if err := file.Close(); err != nil {
+        return err
+    }
+    return os.Chtimes(target, modified, modified)
+}
+
+func (d *Dir) Close() error {
+    return nil
+}
+
+// Zip writes files to a ZIP archive as they come, so that the archive can be
+// streamed without being held in memory.
+type Zip struct {
+    writer *zip.Writer
+}
+
+// NewZip returns a Zip writing the archive to w. The archive is complete
+// once Close has been called; w is not closed.
+func NewZip(w io.Writer) *Zip {
+    return &Zip{writer: zip.NewWriter(w)}
+}
+
+func (z *Zip) Write(name string, modified time.Time, write func(io.Writer) error) error {
+    name, err := cleanName(name)
+    if err != nil {
+        return err
+    }
+    file, err := z.writer.CreateHeader(&zip.FileHeader{
+        Name:     name,
+        Method:   zip.Deflate,
+        Modified: modified,
+    })
+    if err != nil {
+        return err
+    }
+    return write(file)
+}
+
+func (z *Zip) Close() error {
+    return z.writer.Close()
+}
+
+// cleanName returns the given file name cleaned, refusing names that would
+// end up outside the root.
+func cleanName(name string) (string, error) {
+    cleaned := path.Clean("/" + name)[1:]
+    if cleaned == "" || strings.Contains(name, "\\") || cleaned != strings.TrimPrefix(name, "/") {
+        return "", fmt.Errorf("invalid file name %q", name)
+    }
+    return cleaned, nil
+}
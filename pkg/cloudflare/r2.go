@@ -3,7 +3,9 @@ package cloudflare
 import (
     "bytes"
     "context"
+    "errors"
     "fmt"
+    "io"
     "mime/multipart"
     "time"
 
@@ -11,6 +13,7 @@ import (
     "github.com/aws/aws-sdk-go-v2/config"
     "github.com/aws/aws-sdk-go-v2/credentials"
     "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 type R2Client struct {
@@ -91,12 +94,17 @@ func (c *R2Client) UploadFile(file *multipart.FileHeader) (*FileUpload, error) {
     }
 
     filename := fmt.Sprintf("%d-%s", time.Now().UnixNano(), file.Filename)
-    contentType := file.Header.Get("Content-Type")
+    return c.UploadAs(filename, file.Header.Get("Content-Type"), buffer)
+}
 
+// UploadAs uploads the given data to the Cloudflare R2 bucket under the given
+// filename, replacing any file already stored under that name, with the same
+// headers as UploadFile.
+func (c *R2Client) UploadAs(filename, contentType string, data []byte) (*FileUpload, error) {
     input := &s3.PutObjectInput{
         Bucket:       aws.String(c.bucketName),
         Key:          aws.String(filename),
-        Body:         bytes.NewReader(buffer),
+        Body:         bytes.NewReader(data),
         ContentType:  aws.String(contentType),
         CacheControl: aws.String("max-age=31536000"),
     }
@@ -104,7 +112,7 @@ func (c *R2Client) UploadFile(file *multipart.FileHeader) (*FileUpload, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
     defer cancel()
 
-    _, err = c.client.PutObject(ctx, input)
+    _, err := c.client.PutObject(ctx, input)
     if err != nil {
         return nil, err
     }
@@ -112,11 +120,68 @@ func (c *R2Client) UploadFile(file *multipart.FileHeader) (*FileUpload, error) {
     return &FileUpload{
         Filename:    filename,
         ContentType: contentType,
-        Size:        file.Size,
-        URL:         fmt.Sprintf("%s/%s", c.publicURL, filename),
+        Size:        int64(len(data)),
+        URL:         c.URL(filename),
     }, nil
 }
 
+// Exists reports whether a file is stored under the given filename in the
+// Cloudflare R2 bucket.
+func (c *R2Client) Exists(filename string) (bool, error) {
+    input := &s3.HeadObjectInput{
+        Bucket: aws.String(c.bucketName),
+        Key:    aws.String(filename),
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := c.client.HeadObject(ctx, input)
+    var notFound *types.NotFound
+    if errors.As(err, &notFound) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// Open returns the contents of the file stored under the given filename in
+// the Cloudflare R2 bucket, to be read as they are downloaded. The caller
+// must close it.
+func (c *R2Client) Open(filename string) (io.ReadCloser, error) {
+    input := &s3.GetObjectInput{
+        Bucket: aws.String(c.bucketName),
+        Key:    aws.String(filename),
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+    output, err := c.client.GetObject(ctx, input)
+    if err != nil {
+        cancel()
+        return nil, err
+    }
+    return &objectBody{ReadCloser: output.Body, cancel: cancel}, nil
+}
+
+// objectBody is the body of a downloaded file, which releases the context of
+// the download once closed.
+type objectBody struct {
+    io.ReadCloser
+    cancel context.CancelFunc
+}
+
+func (b *objectBody) Close() error {
+    defer b.cancel()
+    return b.ReadCloser.Close()
+}
+
+// URL returns the public URL of the file with the given filename.
+func (c *R2Client) URL(filename string) string {
+    return fmt.Sprintf("%s/%s", c.publicURL, filename)
+}
+
 // DeleteFile deletes the file with the given filename from the Cloudflare R2
 // bucket. The returned error will be non-nil if any error occurred during the
 // delete process.
@@ -0,0 +1,29 @@
+package jobs
+
+import (
+    "context"
+    "log"
+    "time"
+)
+
+// Every runs fn once immediately and then on every tick of interval until ctx
+// is cancelled. Errors returned by fn are logged under the given job name and
+// do not stop the loop.
+//
+// Every blocks, so it is normally started in its own goroutine.
+func Every(ctx context.Context, name string, interval time.Duration, fn func() error) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        if err := fn(); err != nil {
+            log.Printf("job %s failed: %v", name, err)
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
\ No newline at end of file
@@ -0,0 +1,179 @@
+package search
+
+import (
+    "errors"
+    "time"
+
+    "github.com/blevesearch/bleve/v2"
+    "github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+    "github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+    "github.com/blevesearch/bleve/v2/mapping"
+    "github.com/blevesearch/bleve/v2/search/query"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// titleBoost is how much more a match in the title counts than one in the
+// content.
+const titleBoost = 3.0
+
+// document is the representation of a post stored in the Bleve index.
+type document struct {
+    Title       string    `json:"title"`
+    Content     string    `json:"content"`
+    AuthorIDs   []string  `json:"author_id"` // The author and the co-authors
+    Tags        []string  `json:"tags"`
+    PublishedAt time.Time `json:"published_at"`
+}
+
+// BleveIndex is an embedded full-text index stored on the local disk. It
+// needs no external service, which makes it a good fit for single-node
+// deployments, but every server instance has its own copy of the index.
+type BleveIndex struct {
+    index bleve.Index
+}
+
+// NewBleveIndex opens the Bleve index stored at the given path, creating it
+// if it does not exist yet.
+//
+// The returned boolean is true when a new, empty index was created, in which
+// case the caller should index the existing posts.
+func NewBleveIndex(path string) (*BleveIndex, bool, error) {
+    index, err := bleve.Open(path)
+    if err == nil {
+        return &BleveIndex{index: index}, false, nil
+    }
+    if !errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+        return nil, false, err
+    }
+
+    index, err = bleve.New(path, newIndexMapping())
+    if err != nil {
+        return nil, false, err
+    }
+    return &BleveIndex{index: index}, true, nil
+}
+
+// newIndexMapping describes how posts are analyzed: the title and content are
+// full text, while the author and tags are matched exactly.
+func newIndexMapping() mapping.IndexMapping {
+    text := bleve.NewTextFieldMapping()
+    text.Analyzer = standard.Name
+    text.Store = false
+
+    exact := bleve.NewTextFieldMapping()
+    exact.Analyzer = keyword.Name
+    exact.Store = false
+
+    date := bleve.NewDateTimeFieldMapping()
+    date.Store = false
+
+    post := bleve.NewDocumentStaticMapping()
+    post.AddFieldMappingsAt("title", text)
+    post.AddFieldMappingsAt("content", text)
+    post.AddFieldMappingsAt("author_id", exact)
+    post.AddFieldMappingsAt("tags", exact)
+    post.AddFieldMappingsAt("published_at", date)
+
+    indexMapping := bleve.NewIndexMapping()
+    indexMapping.DefaultMapping = post
+    return indexMapping
+}
+
+// Index adds the given post to the index, or replaces it if it is already
+// there. Only the text content of the rendered HTML is indexed.
+func (b *BleveIndex) Index(post *models.Post) error {
+    doc := document{
+        Title:     post.Title,
+        Content:   utils.StripHTML(post.ContentHTML),
+        AuthorIDs: []string{post.AuthorID.Hex()},
+        Tags:      post.Tags,
+    }
+    for _, coAuthor := range post.CoAuthors {
+        doc.AuthorIDs = append(doc.AuthorIDs, coAuthor.Hex())
+    }
+    if post.PublishedAt != nil {
+        doc.PublishedAt = *post.PublishedAt
+    } else {
+        doc.PublishedAt = post.CreatedAt
+    }
+
+    return b.index.Index(post.ID.Hex(), doc)
+}
+
+// Remove deletes the post with the given ID from the index. Removing a post
+// that is not indexed is not an error.
+func (b *BleveIndex) Remove(postID primitive.ObjectID) error {
+    return b.index.Delete(postID.Hex())
+}
+
+// Search returns the posts matching the given query, best matches first,
+// together with the total number of matches. Posts match when any word of
+// the query text appears in their title or content.
+func (b *BleveIndex) Search(q models.SearchQuery) ([]*models.SearchHit, int64, error) {
+    title := bleve.NewMatchQuery(q.Text)
+    title.SetField("title")
+    title.SetBoost(titleBoost)
+
+    content := bleve.NewMatchQuery(q.Text)
+    content.SetField("content")
+
+    conjuncts := []query.Query{
+        bleve.NewDisjunctionQuery(title, content),
+    }
+
+    if !q.AuthorID.IsZero() {
+        author := bleve.NewTermQuery(q.AuthorID.Hex())
+        author.SetField("author_id")
+        conjuncts = append(conjuncts, author)
+    }
+
+    for _, tag := range q.Tags {
+        term := bleve.NewTermQuery(tag)
+        term.SetField("tags")
+        conjuncts = append(conjuncts, term)
+    }
+
+    if q.From != nil || q.To != nil {
+        var from, to time.Time
+        if q.From != nil {
+            from = *q.From
+        }
+        if q.To != nil {
+            to = *q.To
+        }
+        inclusive := true
+        published := bleve.NewDateRangeInclusiveQuery(from, to, &inclusive, &inclusive)
+        published.SetField("published_at")
+        conjuncts = append(conjuncts, published)
+    }
+
+    request := bleve.NewSearchRequestOptions(
+        bleve.NewConjunctionQuery(conjuncts...),
+        q.Limit,
+        (q.Page-1)*q.Limit,
+        false,
+    )
+
+    result, err := b.index.Search(request)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    hits := make([]*models.SearchHit, 0, len(result.Hits))
+    for _, hit := range result.Hits {
+        id, err := primitive.ObjectIDFromHex(hit.ID)
+        if err != nil {
+            continue
+        }
+        hits = append(hits, &models.SearchHit{PostID: id, Score: hit.Score})
+    }
+
+    return hits, int64(result.Total), nil
+}
+
+// Close closes the underlying index files.
+func (b *BleveIndex) Close() error {
+    return b.index.Close()
+}
\ No newline at end of file
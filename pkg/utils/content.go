@@ -0,0 +1,109 @@
+package utils
+
+import (
+    "bytes"
+    "fmt"
+    "html"
+    "regexp"
+    "strings"
+
+    "github.com/microcosm-cc/bluemonday"
+    "github.com/yuin/goldmark"
+    "github.com/yuin/goldmark/extension"
+    goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// Content formats supported by ContentRenderer.
+const (
+    FormatMarkdown = "markdown"
+    FormatHTML     = "html"
+    FormatPlain    = "plain"
+)
+
+// blankLines splits plain text into paragraphs.
+var blankLines = regexp.MustCompile(`\n\s*\n`)
+
+type ContentRenderer struct {
+    markdown goldmark.Markdown
+    policy   *bluemonday.Policy
+}
+
+// NewContentRenderer returns a new instance of ContentRenderer.
+//
+// Markdown is rendered following CommonMark with the GitHub Flavored Markdown
+// extensions (tables, strikethrough, autolinks and task lists) and footnotes.
+// Every rendered document, as well as raw HTML input, goes through an
+// allowlist sanitizer based on bluemonday's UGC policy, so scripts, event
+// handlers and javascript: URLs are stripped. Headings are rendered without
+// ids; AnchorHeadings gives them ids transliterated like slugs, where
+// goldmark's own ids would drop non-ASCII letters.
+func NewContentRenderer() *ContentRenderer {
+    policy := bluemonday.UGCPolicy()
+    // Task list checkboxes.
+    policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+    policy.AllowAttrs("checked", "disabled").OnElements("input")
+    // Footnote references and back links.
+    policy.AllowAttrs("id").Matching(regexp.MustCompile(`^fn(ref)?[:\-\w]*$`)).OnElements("li", "sup", "a")
+    policy.AllowAttrs("class").Matching(regexp.MustCompile(`^(footnotes|footnote-ref|footnote-backref)$`)).OnElements("div", "a", "section")
+    policy.AllowAttrs("role").Matching(regexp.MustCompile(`^doc-(noteref|backlink|endnotes)$`)).OnElements("a", "div", "section")
+    // Table cell alignment.
+    policy.AllowAttrs("style").Matching(regexp.MustCompile(`^text-align:\s*(left|right|center);?$`)).OnElements("th", "td")
+    policy.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|right|center)$`)).OnElements("th", "td")
+
+    return &ContentRenderer{
+        markdown: goldmark.New(
+            goldmark.WithExtensions(extension.GFM, extension.Footnote),
+            // Raw HTML is kept by goldmark and cleaned up by the sanitizer.
+            goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+        ),
+        policy: policy,
+    }
+}
+
+// IsValidFormat reports whether the given content format is supported.
+func (r *ContentRenderer) IsValidFormat(format string) bool {
+    switch format {
+    case FormatMarkdown, FormatHTML, FormatPlain:
+        return true
+    }
+    return false
+}
+
+// Render converts the given source in the given format to sanitized HTML.
+//
+// Markdown is rendered and then sanitized, HTML is sanitized as is, and plain
+// text is escaped and split into paragraphs on blank lines. An error is
+// returned for an unknown format.
+func (r *ContentRenderer) Render(format, source string) (string, error) {
+    switch format {
+    case FormatMarkdown:
+        var buf bytes.Buffer
+        if err := r.markdown.Convert([]byte(source), &buf); err != nil {
+            return "", err
+        }
+        return r.policy.Sanitize(buf.String()), nil
+    case FormatHTML:
+        return r.policy.Sanitize(source), nil
+    case FormatPlain:
+        return renderPlain(source), nil
+    default:
+        return "", fmt.Errorf("unknown content format %q", format)
+    }
+}
+
+// renderPlain escapes plain text and wraps its paragraphs in <p> elements,
+// keeping single line breaks as <br>.
+func renderPlain(source string) string {
+    source = strings.ReplaceAll(source, "\r\n", "\n")
+
+    var b strings.Builder
+    for _, paragraph := range blankLines.Split(strings.TrimSpace(source), -1) {
+        if paragraph == "" {
+            continue
+        }
+        b.WriteString("<p>")
+        b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+        b.WriteString("</p>\n")
+    }
+    return b.String()
+}
\ No newline at end of file
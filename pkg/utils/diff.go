@@ -0,0 +1,159 @@
+package utils
+
+import (
+    "strings"
+    "unicode"
+)
+
+// Diff operations.
+const (
+    DiffEqual  = "equal"
+    DiffInsert = "insert"
+    DiffDelete = "delete"
+)
+
+// maxDiffCells bounds the size of the table used to compute a diff. Word
+// diffs whose changed middle part is larger than that fall back to a line
+// diff of that part, and line diffs report it as entirely replaced.
+const maxDiffCells = 1_000_000
+
+// DiffOp is a run of text that is unchanged, inserted or deleted between two
+// versions of a text.
+type DiffOp struct {
+    Op   string
+    Text string
+}
+
+// DiffLines compares two texts line by line.
+func DiffLines(a, b string) []DiffOp {
+    return diffTokens(splitLines(a), splitLines(b), nil)
+}
+
+// DiffWords compares two texts word by word. Whitespace is kept as separate
+// tokens, so the operations concatenate back to the original texts.
+func DiffWords(a, b string) []DiffOp {
+    return diffTokens(splitWords(a), splitWords(b), DiffLines)
+}
+
+// diffTokens computes the operations turning the tokens of a into the tokens
+// of b, using a longest common subsequence over the part of both texts that
+// remains once their common prefix and suffix are set aside. Consecutive
+// tokens with the same operation are merged.
+//
+// If that part is too large to be diffed token by token, it is diffed with
+// fallback instead, or reported as entirely replaced if fallback is nil.
+func diffTokens(a, b []string, fallback func(a, b string) []DiffOp) []DiffOp {
+    prefix := 0
+    for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+        prefix++
+    }
+    suffix := 0
+    for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+        suffix++
+    }
+
+    // The text of the operation being read is built up in run, and only
+    // stored once the operation changes, so that long runs of tokens are not
+    // copied over and over.
+    ops := []DiffOp{}
+    var run strings.Builder
+    runOp := ""
+    flush := func() {
+        if run.Len() > 0 {
+            ops = append(ops, DiffOp{Op: runOp, Text: run.String()})
+            run.Reset()
+        }
+    }
+    add := func(op, text string) {
+        if text == "" {
+            return
+        }
+        if op != runOp {
+            flush()
+            runOp = op
+        }
+        run.WriteString(text)
+    }
+
+    add(DiffEqual, strings.Join(a[:prefix], ""))
+
+    midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+    if (len(midA)+1)*(len(midB)+1) > maxDiffCells {
+        if fallback != nil {
+            for _, op := range fallback(strings.Join(midA, ""), strings.Join(midB, "")) {
+                add(op.Op, op.Text)
+            }
+        } else {
+            add(DiffDelete, strings.Join(midA, ""))
+            add(DiffInsert, strings.Join(midB, ""))
+        }
+    } else {
+        // lcs[i][j] is the length of the longest common subsequence of
+        // midA[i:] and midB[j:].
+        lcs := make([][]int, len(midA)+1)
+        for i := range lcs {
+            lcs[i] = make([]int, len(midB)+1)
+        }
+        for i := len(midA) - 1; i >= 0; i-- {
+            for j := len(midB) - 1; j >= 0; j-- {
+                if midA[i] == midB[j] {
+                    lcs[i][j] = lcs[i+1][j+1] + 1
+                } else if lcs[i+1][j] >= lcs[i][j+1] {
+                    lcs[i][j] = lcs[i+1][j]
+                } else {
+                    lcs[i][j] = lcs[i][j+1]
+                }
+            }
+        }
+
+        i, j := 0, 0
+        for i < len(midA) && j < len(midB) {
+            switch {
+            case midA[i] == midB[j]:
+                add(DiffEqual, midA[i])
+                i++
+                j++
+            case lcs[i+1][j] >= lcs[i][j+1]:
+                add(DiffDelete, midA[i])
+                i++
+            default:
+                add(DiffInsert, midB[j])
+                j++
+            }
+        }
+        add(DiffDelete, strings.Join(midA[i:], ""))
+        add(DiffInsert, strings.Join(midB[j:], ""))
+    }
+
+    add(DiffEqual, strings.Join(a[len(a)-suffix:], ""))
+    flush()
+
+    return ops
+}
+
+// splitLines splits text into lines, keeping the line breaks.
+func splitLines(text string) []string {
+    if text == "" {
+        return nil
+    }
+    return strings.SplitAfter(text, "\n")
+}
+
+// splitWords splits text into alternating runs of whitespace and
+// non-whitespace characters.
+func splitWords(text string) []string {
+    var tokens []string
+    start, space := 0, false
+    for i, r := range text {
+        isSpace := unicode.IsSpace(r)
+        if i > start && isSpace != space {
+            tokens = append(tokens, text[start:i])
+            start = i
+        }
+        space = isSpace
+    }
+    if start < len(text) {
+        tokens = append(tokens, text[start:])
+    }
+    return tokens
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// joinDiff rebuilds the old and new texts from the operations of a diff.
+func joinDiff(ops []DiffOp) (string, string) {
+    var a, b strings.Builder
+    for _, op := range ops {
+        if op.Op != DiffInsert {
+            a.WriteString(op.Text)
+        }
+        if op.Op != DiffDelete {
+            b.WriteString(op.Text)
+        }
+    }
+    return a.String(), b.String()
+}
+
+func TestDiffWords(t *testing.T) {
+    tests := []struct {
+        name string
+        a, b string
+        want []DiffOp
+    }{
+        {
+            name: "identical",
+            a:    "hello world",
+            b:    "hello world",
+            want: []DiffOp{{DiffEqual, "hello world"}},
+        },
+        {
+            name: "both empty",
+            want: []DiffOp{},
+        },
+        {
+            name: "from empty",
+            b:    "hello",
+            want: []DiffOp{{DiffInsert, "hello"}},
+        },
+        {
+            name: "to empty",
+            a:    "hello",
+            want: []DiffOp{{DiffDelete, "hello"}},
+        },
+        {
+            name: "word replaced",
+            a:    "the quick fox",
+            b:    "the slow fox",
+            want: []DiffOp{{DiffEqual, "the "}, {DiffDelete, "quick"}, {DiffInsert, "slow"}, {DiffEqual, " fox"}},
+        },
+        {
+            name: "word inserted",
+            a:    "the fox",
+            b:    "the brown fox",
+            want: []DiffOp{{DiffEqual, "the "}, {DiffInsert, "brown "}, {DiffEqual, "fox"}},
+        },
+        {
+            name: "word deleted",
+            a:    "the brown fox",
+            b:    "the fox",
+            want: []DiffOp{{DiffEqual, "the "}, {DiffDelete, "brown "}, {DiffEqual, "fox"}},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := DiffWords(tt.a, tt.b)
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("DiffWords(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestDiffLines(t *testing.T) {
+    tests := []struct {
+        name string
+        a, b string
+        want []DiffOp
+    }{
+        {
+            name: "line changed",
+            a:    "one\ntwo\nthree\n",
+            b:    "one\n2\nthree\n",
+            want: []DiffOp{{DiffEqual, "one\n"}, {DiffDelete, "two\n"}, {DiffInsert, "2\n"}, {DiffEqual, "three\n"}},
+        },
+        {
+            name: "line appended without trailing break",
+            a:    "one\n",
+            b:    "one\ntwo",
+            want: []DiffOp{{DiffEqual, "one\n"}, {DiffInsert, "two"}},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := DiffLines(tt.a, tt.b)
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("DiffLines(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestDiffRebuildsTexts(t *testing.T) {
+    tests := []struct {
+        name string
+        a, b string
+    }{
+        {"reordered", "a b c d e", "e d c b a"},
+        {"interleaved", "one two three four", "zero one three five four"},
+        {"whitespace", "a  b\tc\n", "a b\n\nc"},
+        {"unicode", "Xin chào Việt Nam", "Xin chào các bạn"},
+        {"large", strings.Repeat("word ", 3000), strings.Repeat("other ", 3000)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            for _, diff := range []func(string, string) []DiffOp{DiffWords, DiffLines} {
+                ops := diff(tt.a, tt.b)
+                a, b := joinDiff(ops)
+                if a != tt.a || b != tt.b {
+                    t.Errorf("diff rebuilds (%q, %q), want (%q, %q)", a, b, tt.a, tt.b)
+                }
+                for i := 1; i < len(ops); i++ {
+                    if ops[i].Op == ops[i-1].Op {
+                        t.Errorf("operations %d and %d are both %s", i-1, i, ops[i].Op)
+                    }
+                }
+            }
+        })
+    }
+}
+
+func TestDiffWordsFallsBackToLines(t *testing.T) {
+    // Every line changes, too many words to diff word by word, but the
+    // lines in the middle are the same.
+    var a, b strings.Builder
+    for i := 0; i < 400; i++ {
+        a.WriteString("old line of several words\n")
+        b.WriteString("new line of several words\n")
+        if i == 200 {
+            a.WriteString("kept line\n")
+            b.WriteString("kept line\n")
+        }
+    }
+
+    ops := DiffWords(a.String(), b.String())
+    gotA, gotB := joinDiff(ops)
+    if gotA != a.String() || gotB != b.String() {
+        t.Fatal("DiffWords() does not rebuild the texts")
+    }
+
+    kept := false
+    for _, op := range ops {
+        if op.Op == DiffEqual && strings.Contains(op.Text, "kept line\n") {
+            kept = true
+        }
+    }
+    if !kept {
+        t.Error("DiffWords() did not keep the unchanged line")
+    }
+}
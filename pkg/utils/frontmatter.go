@@ -0,0 +1,76 @@
+package utils
+
+import (
+    "bytes"
+    "fmt"
+
+    "github.com/pelletier/go-toml/v2"
+    "gopkg.in/yaml.v3"
+)
+
+// Front matter delimiters, on a line of their own around the metadata at the
+// start of a Markdown file.
+var (
+    yamlDelimiter = []byte("---")
+    tomlDelimiter = []byte("+++")
+)
+
+// SplitFrontMatter splits a Markdown file into its front matter, decoded into
+// a map, and its body. YAML front matter is delimited by "---" lines and TOML
+// front matter by "+++" lines, as in Hugo and Jekyll. A file without front
+// matter is returned whole as the body, with a nil map.
+//
+// An error is returned if the front matter is not closed or cannot be
+// decoded.
+func SplitFrontMatter(source []byte) (map[string]interface{}, []byte, error) {
+    source = bytes.TrimPrefix(source, []byte("\ufeff"))
+    source = bytes.ReplaceAll(source, []byte("\r\n"), []byte("\n"))
+
+    var delimiter []byte
+    switch {
+    case bytes.HasPrefix(source, append(yamlDelimiter, '\n')):
+        delimiter = yamlDelimiter
+    case bytes.HasPrefix(source, append(tomlDelimiter, '\n')):
+        delimiter = tomlDelimiter
+    default:
+        return nil, source, nil
+    }
+
+    // Look for the closing delimiter at the start of a line, with a line
+    // break in front of the metadata so that empty front matter is found too.
+    rest := source[len(delimiter)+1:]
+    padded := append([]byte{'\n'}, rest...)
+    closing := append([]byte{'\n'}, delimiter...)
+    var header, body []byte
+    for offset := 0; ; {
+        i := bytes.Index(padded[offset:], closing)
+        if i < 0 {
+            return nil, nil, fmt.Errorf("front matter is not closed with %q", delimiter)
+        }
+        i += offset
+        after := i + len(closing)
+        if after == len(padded) || padded[after] == '\n' {
+            if i > 0 {
+                header = rest[:i-1]
+            }
+            if after < len(padded) {
+                body = padded[after+1:]
+            }
+            break
+        }
+        offset = after
+    }
+
+    meta := map[string]interface{}{}
+    var err error
+    if bytes.Equal(delimiter, yamlDelimiter) {
+        err = yaml.Unmarshal(header, &meta)
+    } else {
+        err = toml.Unmarshal(header, &meta)
+    }
+    if err != nil {
+        return nil, nil, fmt.Errorf("invalid front matter: %v", err)
+    }
+
+    return meta, body, nil
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+    tests := []struct {
+        name     string
+        source   string
+        wantMeta map[string]interface{}
+        wantBody string
+        wantErr  bool
+    }{
+        {
+            name:     "yaml",
+            source:   "---\ntitle: Hello\ntags: [go, web]\n---\n# Body\n",
+            wantMeta: map[string]interface{}{"title": "Hello", "tags": []interface{}{"go", "web"}},
+            wantBody: "# Body\n",
+        },
+        {
+            name:     "toml",
+            source:   "+++\ntitle = \"Hello\"\ndraft = true\n+++\nBody",
+            wantMeta: map[string]interface{}{"title": "Hello", "draft": true},
+            wantBody: "Body",
+        },
+        {
+            name:     "no front matter",
+            source:   "# Just a title\n\nText\n",
+            wantBody: "# Just a title\n\nText\n",
+        },
+        {
+            name:     "empty front matter",
+            source:   "---\n---\nBody\n",
+            wantMeta: map[string]interface{}{},
+            wantBody: "Body\n",
+        },
+        {
+            name:     "no body",
+            source:   "---\ntitle: Hello\n---",
+            wantMeta: map[string]interface{}{"title": "Hello"},
+            wantBody: "",
+        },
+        {
+            name:     "windows line breaks and byte order mark",
+            source:   "\ufeff---\r\ntitle: Hello\r\n---\r\nBody\r\n",
+            wantMeta: map[string]interface{}{"title": "Hello"},
+            wantBody: "Body\n",
+        },
+        {
+            name:     "delimiter inside a line",
+            source:   "---\ntitle: a --- b\n---\nBody",
+            wantMeta: map[string]interface{}{"title": "a --- b"},
+            wantBody: "Body",
+        },
+        {
+            name:     "longer dash line is not a delimiter",
+            source:   "---\nrule: |\n  ----\n---\nBody",
+            wantMeta: map[string]interface{}{"rule": "----"},
+            wantBody: "Body",
+        },
+        {
+            name:    "not closed",
+            source:  "---\ntitle: Hello\n",
+            wantErr: true,
+        },
+        {
+            name:    "invalid yaml",
+            source:  "---\ntitle: [unclosed\n---\nBody",
+            wantErr: true,
+        },
+        {
+            name:    "invalid toml",
+            source:  "+++\ntitle = \n+++\nBody",
+            wantErr: true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            meta, body, err := SplitFrontMatter([]byte(tt.source))
+            if tt.wantErr {
+                if err == nil {
+                    t.Fatalf("SplitFrontMatter() = %v, %q, want an error", meta, body)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("SplitFrontMatter() error = %v", err)
+            }
+            if !reflect.DeepEqual(meta, tt.wantMeta) {
+                t.Errorf("SplitFrontMatter() meta = %#v, want %#v", meta, tt.wantMeta)
+            }
+            if string(body) != tt.wantBody {
+                t.Errorf("SplitFrontMatter() body = %q, want %q", body, tt.wantBody)
+            }
+        })
+    }
+}
@@ -5,6 +5,7 @@ import (
     "image"
     "image/jpeg"
     "image/png"
+    "io"
     "mime/multipart"
 
     "github.com/nfnt/resize"
@@ -38,8 +39,18 @@ func (p *ImageProcessor) ProcessImage(file *multipart.FileHeader) ([]byte, error
     }
     defer src.Close()
 
+    data, err := io.ReadAll(src)
+    if err != nil {
+        return nil, err
+    }
+
+    return p.Process(data)
+}
+
+// Process resizes and optimizes the given encoded image, as ProcessImage.
+func (p *ImageProcessor) Process(data []byte) ([]byte, error) {
     // Decode image
-    img, format, err := image.Decode(src)
+    img, format, err := image.Decode(bytes.NewReader(data))
     if err != nil {
         return nil, err
     }
@@ -0,0 +1,281 @@
+package utils
+
+import (
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "strconv"
+    "strings"
+)
+
+// JSONPatchOp is a single operation of an RFC 6902 JSON Patch.
+type JSONPatchOp struct {
+    Op    string          `json:"op"`
+    Path  string          `json:"path"`
+    From  string          `json:"from,omitempty"`
+    Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyJSONPatch applies the given RFC 6902 operations, in order, to doc,
+// which must be a document decoded by encoding/json into interface{} values.
+// It returns the patched document; doc itself may be modified.
+//
+// The patch is applied as a whole: the returned error is non-nil, and the
+// patched document must be discarded, if any operation fails, including a
+// failed "test".
+func ApplyJSONPatch(doc interface{}, ops []JSONPatchOp) (interface{}, error) {
+    for i, op := range ops {
+        var err error
+        doc, err = applyJSONPatchOp(doc, op)
+        if err != nil {
+            return nil, fmt.Errorf("operation %d (%s %s): %v", i, op.Op, op.Path, err)
+        }
+    }
+    return doc, nil
+}
+
+// applyJSONPatchOp applies a single operation to doc.
+func applyJSONPatchOp(doc interface{}, op JSONPatchOp) (interface{}, error) {
+    switch op.Op {
+    case "add", "replace", "test":
+        if op.Value == nil {
+            return nil, fmt.Errorf("missing value")
+        }
+        var value interface{}
+        if err := json.Unmarshal(op.Value, &value); err != nil {
+            return nil, fmt.Errorf("invalid value")
+        }
+
+        switch op.Op {
+        case "add":
+            return addAt(doc, op.Path, value)
+        case "replace":
+            if _, err := valueAt(doc, op.Path); err != nil {
+                return nil, err
+            }
+            doc, _, err := removeAt(doc, op.Path)
+            if err != nil {
+                return nil, err
+            }
+            return addAt(doc, op.Path, value)
+        default:
+            current, err := valueAt(doc, op.Path)
+            if err != nil {
+                return nil, err
+            }
+            if !reflect.DeepEqual(current, value) {
+                return nil, fmt.Errorf("test failed")
+            }
+            return doc, nil
+        }
+    case "remove":
+        doc, _, err := removeAt(doc, op.Path)
+        return doc, err
+    case "move":
+        if op.Path == op.From || strings.HasPrefix(op.Path, op.From+"/") {
+            if op.Path == op.From {
+                return doc, nil
+            }
+            return nil, fmt.Errorf("cannot move a value into itself")
+        }
+        doc, value, err := removeAt(doc, op.From)
+        if err != nil {
+            return nil, err
+        }
+        return addAt(doc, op.Path, value)
+    case "copy":
+        value, err := valueAt(doc, op.From)
+        if err != nil {
+            return nil, err
+        }
+        return addAt(doc, op.Path, deepCopy(value))
+    default:
+        return nil, fmt.Errorf("unknown operation")
+    }
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+    if pointer == "" {
+        return nil, nil
+    }
+    if !strings.HasPrefix(pointer, "/") {
+        return nil, fmt.Errorf("invalid path")
+    }
+
+    tokens := strings.Split(pointer[1:], "/")
+    for i, token := range tokens {
+        tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+    }
+    return tokens, nil
+}
+
+// arrayIndex parses the token addressing an element of an array of the given
+// length. With allowEnd, the index may be the length itself, or "-", to
+// address the end of the array.
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+    if token == "-" && allowEnd {
+        return length, nil
+    }
+    index, err := strconv.Atoi(token)
+    if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+        return 0, fmt.Errorf("invalid array index %q", token)
+    }
+    if index > length || (index == length && !allowEnd) {
+        return 0, fmt.Errorf("array index %d out of range", index)
+    }
+    return index, nil
+}
+
+// valueAt returns the value doc holds at the given pointer.
+func valueAt(doc interface{}, pointer string) (interface{}, error) {
+    tokens, err := parsePointer(pointer)
+    if err != nil {
+        return nil, err
+    }
+
+    current := doc
+    for _, token := range tokens {
+        switch node := current.(type) {
+        case map[string]interface{}:
+            value, ok := node[token]
+            if !ok {
+                return nil, fmt.Errorf("path not found")
+            }
+            current = value
+        case []interface{}:
+            index, err := arrayIndex(token, len(node), false)
+            if err != nil {
+                return nil, err
+            }
+            current = node[index]
+        default:
+            return nil, fmt.Errorf("path not found")
+        }
+    }
+    return current, nil
+}
+
+// addAt adds value to doc at the given pointer, replacing an object member
+// or inserting an array element, and returns the resulting document.
+func addAt(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+    tokens, err := parsePointer(pointer)
+    if err != nil {
+        return nil, err
+    }
+    if len(tokens) == 0 {
+        return value, nil
+    }
+
+    parentPointer := pointer[:strings.LastIndex(pointer, "/")]
+    parent, err := valueAt(doc, parentPointer)
+    if err != nil {
+        return nil, err
+    }
+
+    last := tokens[len(tokens)-1]
+    switch node := parent.(type) {
+    case map[string]interface{}:
+        node[last] = value
+        return doc, nil
+    case []interface{}:
+        index, err := arrayIndex(last, len(node), true)
+        if err != nil {
+            return nil, err
+        }
+        node = append(node, nil)
+        copy(node[index+1:], node[index:])
+        node[index] = value
+        return replaceAt(doc, parentPointer, node)
+    default:
+        return nil, fmt.Errorf("path not found")
+    }
+}
+
+// removeAt removes the value doc holds at the given pointer, and returns the
+// resulting document together with the removed value.
+func removeAt(doc interface{}, pointer string) (interface{}, interface{}, error) {
+    tokens, err := parsePointer(pointer)
+    if err != nil {
+        return nil, nil, err
+    }
+    if len(tokens) == 0 {
+        return nil, doc, nil
+    }
+
+    parentPointer := pointer[:strings.LastIndex(pointer, "/")]
+    parent, err := valueAt(doc, parentPointer)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    last := tokens[len(tokens)-1]
+    switch node := parent.(type) {
+    case map[string]interface{}:
+        value, ok := node[last]
+        if !ok {
+            return nil, nil, fmt.Errorf("path not found")
+        }
+        delete(node, last)
+        return doc, value, nil
+    case []interface{}:
+        index, err := arrayIndex(last, len(node), false)
+        if err != nil {
+            return nil, nil, err
+        }
+        value := node[index]
+        node = append(node[:index:index], node[index+1:]...)
+        doc, err = replaceAt(doc, parentPointer, node)
+        return doc, value, err
+    default:
+        return nil, nil, fmt.Errorf("path not found")
+    }
+}
+
+// replaceAt sets the value doc holds at the given, existing pointer. It is
+// used to store arrays whose length changed.
+func replaceAt(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+    tokens, _ := parsePointer(pointer)
+    if len(tokens) == 0 {
+        return value, nil
+    }
+
+    parent, err := valueAt(doc, pointer[:strings.LastIndex(pointer, "/")])
+    if err != nil {
+        return nil, err
+    }
+
+    last := tokens[len(tokens)-1]
+    switch node := parent.(type) {
+    case map[string]interface{}:
+        node[last] = value
+    case []interface{}:
+        index, err := arrayIndex(last, len(node), false)
+        if err != nil {
+            return nil, err
+        }
+        node[index] = value
+    }
+    return doc, nil
+}
+
+// deepCopy returns a copy of a decoded JSON value that shares no maps or
+// slices with it.
+func deepCopy(value interface{}) interface{} {
+    switch v := value.(type) {
+    case map[string]interface{}:
+        copied := make(map[string]interface{}, len(v))
+        for key, item := range v {
+            copied[key] = deepCopy(item)
+        }
+        return copied
+    case []interface{}:
+        copied := make([]interface{}, len(v))
+        for i, item := range v {
+            copied[i] = deepCopy(item)
+        }
+        return copied
+    default:
+        return v
+    }
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+    "encoding/json"
+    "reflect"
+    "testing"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+    tests := []struct {
+        name    string
+        doc     string
+        patch   string
+        want    string
+        wantErr bool
+    }{
+        {
+            name:  "add member",
+            doc:   `{"title": "Hello"}`,
+            patch: `[{"op": "add", "path": "/excerpt", "value": "Hi"}]`,
+            want:  `{"title": "Hello", "excerpt": "Hi"}`,
+        },
+        {
+            name:  "add replaces existing member",
+            doc:   `{"title": "Hello"}`,
+            patch: `[{"op": "add", "path": "/title", "value": "Bye"}]`,
+            want:  `{"title": "Bye"}`,
+        },
+        {
+            name:  "add inserts array element",
+            doc:   `{"tags": ["a", "c"]}`,
+            patch: `[{"op": "add", "path": "/tags/1", "value": "b"}]`,
+            want:  `{"tags": ["a", "b", "c"]}`,
+        },
+        {
+            name:  "add appends with dash",
+            doc:   `{"tags": ["a"]}`,
+            patch: `[{"op": "add", "path": "/tags/-", "value": "b"}]`,
+            want:  `{"tags": ["a", "b"]}`,
+        },
+        {
+            name:  "add replaces whole document",
+            doc:   `{"title": "Hello"}`,
+            patch: `[{"op": "add", "path": "", "value": {"title": "New"}}]`,
+            want:  `{"title": "New"}`,
+        },
+        {
+            name:  "remove member",
+            doc:   `{"title": "Hello", "excerpt": "Hi"}`,
+            patch: `[{"op": "remove", "path": "/excerpt"}]`,
+            want:  `{"title": "Hello"}`,
+        },
+        {
+            name:  "remove array element",
+            doc:   `{"tags": ["a", "b", "c"]}`,
+            patch: `[{"op": "remove", "path": "/tags/1"}]`,
+            want:  `{"tags": ["a", "c"]}`,
+        },
+        {
+            name:  "replace member",
+            doc:   `{"title": "Hello"}`,
+            patch: `[{"op": "replace", "path": "/title", "value": "Bye"}]`,
+            want:  `{"title": "Bye"}`,
+        },
+        {
+            name:  "move member",
+            doc:   `{"a": {"b": 1}, "c": {}}`,
+            patch: `[{"op": "move", "from": "/a/b", "path": "/c/d"}]`,
+            want:  `{"a": {}, "c": {"d": 1}}`,
+        },
+        {
+            name:  "move within array",
+            doc:   `{"tags": ["a", "b", "c"]}`,
+            patch: `[{"op": "move", "from": "/tags/0", "path": "/tags/2"}]`,
+            want:  `{"tags": ["b", "c", "a"]}`,
+        },
+        {
+            name:  "copy is independent of source",
+            doc:   `{"a": {"b": 1}}`,
+            patch: `[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "replace", "path": "/c/b", "value": 2}]`,
+            want:  `{"a": {"b": 1}, "c": {"b": 2}}`,
+        },
+        {
+            name:  "test passes",
+            doc:   `{"version": 3}`,
+            patch: `[{"op": "test", "path": "/version", "value": 3}, {"op": "replace", "path": "/version", "value": 4}]`,
+            want:  `{"version": 4}`,
+        },
+        {
+            name:  "escaped pointer",
+            doc:   `{"a/b": 1, "c~d": 2}`,
+            patch: `[{"op": "remove", "path": "/a~1b"}, {"op": "remove", "path": "/c~0d"}]`,
+            want:  `{}`,
+        },
+        {
+            name:    "test fails",
+            doc:     `{"version": 3}`,
+            patch:   `[{"op": "test", "path": "/version", "value": 2}]`,
+            wantErr: true,
+        },
+        {
+            name:    "replace missing member",
+            doc:     `{}`,
+            patch:   `[{"op": "replace", "path": "/title", "value": "Bye"}]`,
+            wantErr: true,
+        },
+        {
+            name:    "remove missing member",
+            doc:     `{}`,
+            patch:   `[{"op": "remove", "path": "/title"}]`,
+            wantErr: true,
+        },
+        {
+            name:    "add missing value",
+            doc:     `{}`,
+            patch:   `[{"op": "add", "path": "/title"}]`,
+            wantErr: true,
+        },
+        {
+            name:    "array index out of range",
+            doc:     `{"tags": ["a"]}`,
+            patch:   `[{"op": "add", "path": "/tags/2", "value": "b"}]`,
+            wantErr: true,
+        },
+        {
+            name:    "array index with leading zero",
+            doc:     `{"tags": ["a", "b"]}`,
+            patch:   `[{"op": "remove", "path": "/tags/01"}]`,
+            wantErr: true,
+        },
+        {
+            name:    "move into itself",
+            doc:     `{"a": {"b": 1}}`,
+            patch:   `[{"op": "move", "from": "/a", "path": "/a/c"}]`,
+            wantErr: true,
+        },
+        {
+            name:    "path without slash",
+            doc:     `{"title": "Hello"}`,
+            patch:   `[{"op": "remove", "path": "title"}]`,
+            wantErr: true,
+        },
+        {
+            name:    "unknown operation",
+            doc:     `{}`,
+            patch:   `[{"op": "merge", "path": "/a", "value": 1}]`,
+            wantErr: true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var doc interface{}
+            if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+                t.Fatal(err)
+            }
+            var ops []JSONPatchOp
+            if err := json.Unmarshal([]byte(tt.patch), &ops); err != nil {
+                t.Fatal(err)
+            }
+
+            got, err := ApplyJSONPatch(doc, ops)
+            if tt.wantErr {
+                if err == nil {
+                    t.Fatalf("ApplyJSONPatch() = %v, want an error", got)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("ApplyJSONPatch() error = %v", err)
+            }
+
+            var want interface{}
+            if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, want) {
+                t.Errorf("ApplyJSONPatch() = %v, want %v", got, want)
+            }
+        })
+    }
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+    "strings"
+
+    "golang.org/x/text/language"
+)
+
+// NormalizeLanguage returns the canonical form of the given BCP 47 language
+// tag, e.g. "vi_vn" becomes "vi-VN". The returned error is non-nil if the tag
+// is not well-formed.
+func NormalizeLanguage(tag string) (string, error) {
+    parsed, err := language.Parse(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+    if err != nil {
+        return "", err
+    }
+    return parsed.String(), nil
+}
+
+// ParseAcceptLanguage returns the languages listed in an Accept-Language
+// header, most preferred first. Languages with a zero weight and the "*"
+// wildcard are left out, and a malformed header lists no language.
+func ParseAcceptLanguage(header string) []string {
+    tags, _, err := language.ParseAcceptLanguage(header)
+    if err != nil {
+        return nil
+    }
+
+    languages := make([]string, 0, len(tags))
+    for _, tag := range tags {
+        // The "*" wildcard is parsed as "mul", multiple languages
+        if tag == language.Und || tag.String() == "mul" {
+            continue
+        }
+        languages = append(languages, tag.String())
+    }
+    return languages
+}
+
+// MatchLanguage returns the available language that best suits the preferred
+// languages, given most preferred first, or an empty string if none does.
+// Regional variants match each other: a reader preferring "vi" is given
+// "vi-VN", and the other way around. Empty available languages are ignored.
+func MatchLanguage(preferred, available []string) string {
+    var supported []language.Tag
+    var names []string
+    for _, name := range available {
+        tag, err := language.Parse(name)
+        if name == "" || err != nil {
+            continue
+        }
+        supported = append(supported, tag)
+        names = append(names, name)
+    }
+
+    var desired []language.Tag
+    for _, name := range preferred {
+        if tag, err := language.Parse(name); err == nil {
+            desired = append(desired, tag)
+        }
+    }
+    if len(supported) == 0 || len(desired) == 0 {
+        return ""
+    }
+
+    _, index, confidence := language.NewMatcher(supported).Match(desired...)
+    if confidence < language.High {
+        return ""
+    }
+    return names[index]
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+    tests := []struct {
+        tag     string
+        want    string
+        wantErr bool
+    }{
+        {tag: "vi", want: "vi"},
+        {tag: "vi_vn", want: "vi-VN"},
+        {tag: " EN-us ", want: "en-US"},
+        {tag: "zh-hant-tw", want: "zh-Hant-TW"},
+        {tag: "", wantErr: true},
+        {tag: "not a language", wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.tag, func(t *testing.T) {
+            got, err := NormalizeLanguage(tt.tag)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("NormalizeLanguage(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Errorf("NormalizeLanguage(%q) = %q, want %q", tt.tag, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+    tests := []struct {
+        header string
+        want   []string
+    }{
+        {"vi-VN,vi;q=0.9,en;q=0.8", []string{"vi-VN", "vi", "en"}},
+        {"en;q=0.5, fr", []string{"fr", "en"}},
+        {"de, *;q=0.1", []string{"de"}},
+        {"fr;q=0, en", []string{"en"}},
+        {"", []string{}},
+        {";;;", nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.header, func(t *testing.T) {
+            got := ParseAcceptLanguage(tt.header)
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("ParseAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestMatchLanguage(t *testing.T) {
+    tests := []struct {
+        name      string
+        preferred []string
+        available []string
+        want      string
+    }{
+        {"exact", []string{"vi"}, []string{"en", "vi"}, "vi"},
+        {"region to language", []string{"vi-VN"}, []string{"en", "vi"}, "vi"},
+        {"language to region", []string{"vi"}, []string{"en", "vi-VN"}, "vi-VN"},
+        {"preference order", []string{"fr", "en"}, []string{"en", "fr"}, "fr"},
+        {"fallback to next preference", []string{"de", "en"}, []string{"en", "vi"}, "en"},
+        {"no match", []string{"de"}, []string{"en", "vi"}, ""},
+        {"empty available ignored", []string{"vi"}, []string{"", "vi"}, "vi"},
+        {"nothing available", []string{"vi"}, nil, ""},
+        {"nothing preferred", nil, []string{"vi"}, ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := MatchLanguage(tt.preferred, tt.available); got != tt.want {
+                t.Errorf("MatchLanguage(%q, %q) = %q, want %q", tt.preferred, tt.available, got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+    "strings"
+    "unicode"
+
+    "golang.org/x/text/unicode/norm"
+)
+
+// maxSlugLength is the maximum length of a generated slug.
+const maxSlugLength = 80
+
+// transliterations holds the letters that do not decompose into an ASCII
+// letter plus combining marks, such as the Vietnamese "đ".
+var transliterations = map[rune]string{
+    'đ': "d", 'Đ': "d", 'ð': "d", 'Ð': "d",
+    'ß': "ss", 'æ': "ae", 'Æ': "ae", 'œ': "oe", 'Œ': "oe",
+    'ø': "o", 'Ø': "o", 'ł': "l", 'Ł': "l", 'þ': "th", 'Þ': "th",
+    'ı': "i", 'ħ': "h", 'Ħ': "h",
+    // Cyrillic
+    'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+    'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+    'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+    'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+    'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+    'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+    // Greek
+    'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i",
+    'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+    'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+    'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// Slugify turns the given text into a URL-friendly slug made of lowercase
+// ASCII letters, digits and single dashes, e.g. "Xin chào Việt Nam!" becomes
+// "xin-chao-viet-nam".
+//
+// Accented Latin letters are stripped of their diacritics, and Cyrillic and
+// Greek letters are transliterated. Characters that cannot be transliterated
+// are treated as separators. The result is at most 80 characters long and
+// may be empty if the text has no usable characters.
+func Slugify(text string) string {
+    var b strings.Builder
+    pendingDash := false
+
+    write := func(s string) {
+        if s == "" {
+            return
+        }
+        if pendingDash && b.Len() > 0 {
+            b.WriteByte('-')
+        }
+        pendingDash = false
+        b.WriteString(s)
+    }
+
+    for _, r := range norm.NFD.String(text) {
+        if unicode.Is(unicode.Mn, r) {
+            continue
+        }
+
+        lower := unicode.ToLower(r)
+        switch {
+        case lower >= 'a' && lower <= 'z', lower >= '0' && lower <= '9':
+            write(string(lower))
+        case transliterations[lower] != "":
+            write(transliterations[lower])
+        case r == '\'' || r == '’':
+            // Apostrophes join words: "don't" becomes "dont".
+        default:
+            pendingDash = true
+        }
+    }
+
+    return truncateSlug(b.String(), maxSlugLength)
+}
+
+// IsValidSlug reports whether the given slug only contains lowercase ASCII
+// letters, digits and single dashes, and does not start or end with a dash.
+func IsValidSlug(slug string) bool {
+    if slug == "" || len(slug) > maxSlugLength {
+        return false
+    }
+    return Slugify(slug) == slug
+}
+
+// maxTags is the maximum number of tags kept by NormalizeTags.
+const maxTags = 20
+
+// NormalizeTags turns free-form tags into their canonical form by slugifying
+// them, e.g. "Lập trình Go" becomes "lap-trinh-go". Empty and duplicate tags
+// are dropped, the original order is kept, and at most 20 tags are returned.
+func NormalizeTags(tags []string) []string {
+    normalized := make([]string, 0, len(tags))
+    seen := make(map[string]bool, len(tags))
+
+    for _, tag := range tags {
+        tag = Slugify(tag)
+        if tag == "" || seen[tag] {
+            continue
+        }
+        seen[tag] = true
+        normalized = append(normalized, tag)
+        if len(normalized) == maxTags {
+            break
+        }
+    }
+
+    return normalized
+}
+
+// truncateSlug shortens the slug to at most max bytes, cutting at a dash when
+// possible so that words are not split.
+func truncateSlug(slug string, max int) string {
+    if len(slug) <= max {
+        return slug
+    }
+
+    slug = slug[:max]
+    if i := strings.LastIndexByte(slug, '-'); i > 0 {
+        slug = slug[:i]
+    }
+    return strings.Trim(slug, "-")
+}
\ No newline at end of file
@@ -0,0 +1,97 @@
+package utils
+
+import (
+    "fmt"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestSlugify(t *testing.T) {
+    tests := []struct {
+        text string
+        want string
+    }{
+        {"Hello World", "hello-world"},
+        {"Xin chào Việt Nam!", "xin-chao-viet-nam"},
+        {"Đường đến Đà Lạt", "duong-den-da-lat"},
+        {"  --Go  is   fun--  ", "go-is-fun"},
+        {"Don't panic", "dont-panic"},
+        {"It’s fine", "its-fine"},
+        {"Straße", "strasse"},
+        {"Привет мир", "privet-mir"},
+        {"Καλημέρα", "kalimera"},
+        {"Go 1.21 released", "go-1-21-released"},
+        {"日本語", ""},
+        {"", ""},
+        {strings.Repeat("word ", 30), strings.TrimSuffix(strings.Repeat("word-", 16), "-")},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.text, func(t *testing.T) {
+            if got := Slugify(tt.text); got != tt.want {
+                t.Errorf("Slugify(%q) = %q, want %q", tt.text, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestIsValidSlug(t *testing.T) {
+    tests := []struct {
+        slug string
+        want bool
+    }{
+        {"hello-world", true},
+        {"go-1-21", true},
+        {"a", true},
+        {"", false},
+        {"Hello", false},
+        {"hello--world", false},
+        {"-hello", false},
+        {"hello-", false},
+        {"hello_world", false},
+        {"chào", false},
+        {strings.Repeat("a", maxSlugLength), true},
+        {strings.Repeat("a", maxSlugLength+1), false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.slug, func(t *testing.T) {
+            if got := IsValidSlug(tt.slug); got != tt.want {
+                t.Errorf("IsValidSlug(%q) = %v, want %v", tt.slug, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestNormalizeTags(t *testing.T) {
+    many := make([]string, 0, maxTags+5)
+    for i := 0; i < maxTags+5; i++ {
+        many = append(many, fmt.Sprintf("tag %d", i))
+    }
+
+    tests := []struct {
+        name string
+        tags []string
+        want []string
+    }{
+        {"nil", nil, []string{}},
+        {"slugified", []string{"Lập trình Go", "Web Dev"}, []string{"lap-trinh-go", "web-dev"}},
+        {"duplicates dropped", []string{"Go", "go", "GO!"}, []string{"go"}},
+        {"empty dropped", []string{"", "  ", "!!", "go"}, []string{"go"}},
+        {"order kept", []string{"b", "a", "c"}, []string{"b", "a", "c"}},
+        {"limited", many, NormalizeTags(many[:maxTags])},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := NormalizeTags(tt.tags)
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("NormalizeTags(%q) = %q, want %q", tt.tags, got, tt.want)
+            }
+            if len(got) > maxTags {
+                t.Errorf("NormalizeTags() returned %d tags, want at most %d", len(got), maxTags)
+            }
+        })
+    }
+}
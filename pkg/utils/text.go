@@ -0,0 +1,380 @@
+package utils
+
+import (
+    "fmt"
+    "html"
+    "math"
+    "strings"
+    "unicode"
+    "unicode/utf8"
+
+    nethtml "golang.org/x/net/html"
+    "golang.org/x/text/unicode/norm"
+)
+
+// wordsPerMinute is the reading speed assumed by ReadingMinutes.
+const wordsPerMinute = 200
+
+// headingLevels maps the HTML heading elements to their level.
+var headingLevels = map[string]int{
+    "h1": 1, "h2": 2, "h3": 3, "h4": 4, "h5": 5, "h6": 6,
+}
+
+// Heading is a heading of an HTML fragment, with the id it can be linked to
+// with.
+type Heading struct {
+    Level  int
+    Text   string
+    Anchor string
+}
+
+// blockElements are the HTML elements after which StripHTML inserts a line
+// break, so that words of adjacent paragraphs do not run together.
+var blockElements = map[string]bool{
+    "p": true, "div": true, "br": true, "li": true, "tr": true, "td": true, "th": true,
+    "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+    "blockquote": true, "pre": true, "hr": true, "section": true,
+}
+
+// StripHTML returns the text content of the given HTML fragment, with block
+// elements separated by line breaks and runs of spaces collapsed.
+func StripHTML(fragment string) string {
+    var b strings.Builder
+    tokenizer := nethtml.NewTokenizer(strings.NewReader(fragment))
+
+    for {
+        switch tokenizer.Next() {
+        case nethtml.ErrorToken:
+            // The tokenizer stops at io.EOF, or at the first malformed
+            // token; either way, keep what was read so far.
+            return collapseSpaces(b.String())
+        case nethtml.TextToken:
+            b.Write(tokenizer.Text())
+        case nethtml.StartTagToken, nethtml.EndTagToken, nethtml.SelfClosingTagToken:
+            name, _ := tokenizer.TagName()
+            if blockElements[string(name)] {
+                b.WriteByte('\n')
+            }
+        }
+    }
+}
+
+// ImageURLs returns the sources of the images of the given HTML fragment, in
+// order of appearance and without duplicates.
+func ImageURLs(fragment string) []string {
+    var urls []string
+    seen := map[string]bool{}
+    tokenizer := nethtml.NewTokenizer(strings.NewReader(fragment))
+
+    for {
+        switch tokenizer.Next() {
+        case nethtml.ErrorToken:
+            return urls
+        case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+            name, hasAttr := tokenizer.TagName()
+            if string(name) != "img" {
+                continue
+            }
+            for hasAttr {
+                var key, value []byte
+                key, value, hasAttr = tokenizer.TagAttr()
+                if string(key) == "src" && len(value) > 0 && !seen[string(value)] {
+                    seen[string(value)] = true
+                    urls = append(urls, string(value))
+                }
+            }
+        }
+    }
+}
+
+// AnchorHeadings gives every heading of the given HTML fragment an id, and
+// returns the fragment together with its headings, in order, to build a
+// table of contents from.
+//
+// Headings keep the id they already have, such as one written in raw HTML.
+// The others get an id made from their text with Slugify, e.g. "xin-chao" for
+// "Xin chào", numbered ("setup-2") when several headings share the same text.
+func AnchorHeadings(fragment string) (string, []Heading) {
+    var pieces []string
+    var headings []Heading
+    used := map[string]bool{}
+
+    // open is the index of the piece holding the start tag of the heading
+    // being read, or -1 outside headings.
+    open := -1
+    var start nethtml.Token
+    var text strings.Builder
+
+    tokenizer := nethtml.NewTokenizer(strings.NewReader(fragment))
+    for {
+        tokenType := tokenizer.Next()
+        if tokenType == nethtml.ErrorToken {
+            break
+        }
+        raw := string(tokenizer.Raw())
+
+        switch tokenType {
+        case nethtml.StartTagToken:
+            token := tokenizer.Token()
+            if _, ok := headingLevels[token.Data]; ok && open < 0 {
+                open = len(pieces)
+                start = token
+                text.Reset()
+            }
+        case nethtml.TextToken:
+            if open >= 0 {
+                text.Write(tokenizer.Text())
+            }
+        case nethtml.EndTagToken:
+            name, _ := tokenizer.TagName()
+            if open >= 0 && string(name) == start.Data {
+                label := strings.Join(strings.Fields(text.String()), " ")
+                anchor := headingAnchor(&start, label, used)
+                pieces[open] = start.String()
+                headings = append(headings, Heading{Level: headingLevels[start.Data], Text: label, Anchor: anchor})
+                open = -1
+            }
+        }
+        pieces = append(pieces, raw)
+    }
+
+    return strings.Join(pieces, ""), headings
+}
+
+// headingAnchor returns the id of the given heading start tag, setting one
+// made from the heading's text if it has none or its id is already used.
+func headingAnchor(start *nethtml.Token, label string, used map[string]bool) string {
+    index := -1
+    for i, attr := range start.Attr {
+        if attr.Key == "id" {
+            index = i
+            if attr.Val != "" && !used[attr.Val] {
+                used[attr.Val] = true
+                return attr.Val
+            }
+        }
+    }
+
+    base := Slugify(label)
+    if base == "" {
+        base = "section"
+    }
+    anchor := base
+    for i := 2; used[anchor]; i++ {
+        anchor = fmt.Sprintf("%s-%d", base, i)
+    }
+    used[anchor] = true
+
+    if index >= 0 {
+        start.Attr[index].Val = anchor
+    } else {
+        start.Attr = append(start.Attr, nethtml.Attribute{Key: "id", Val: anchor})
+    }
+    return anchor
+}
+
+// Excerpt returns the start of the given text on a single line, cut at a
+// word boundary to at most width characters, and followed by an ellipsis
+// when the text goes on.
+func Excerpt(text string, width int) string {
+    runes := []rune(strings.Join(strings.Fields(text), " "))
+    if len(runes) <= width {
+        return string(runes)
+    }
+
+    end := width
+    for end > 0 && runes[end] != ' ' {
+        end--
+    }
+    if end == 0 {
+        end = width
+    }
+
+    return strings.TrimRight(string(runes[:end]), " ,;:.-–—") + "…"
+}
+
+// CountWords returns the number of words of the given plain text: the runs
+// of non-space characters holding at least one letter or digit.
+func CountWords(text string) int {
+    count := 0
+    for _, field := range strings.Fields(text) {
+        if strings.IndexFunc(field, isWordRune) >= 0 {
+            count++
+        }
+    }
+    return count
+}
+
+// ReadingMinutes estimates how many minutes it takes to read the given
+// number of words, rounding up. Any text takes at least a minute.
+func ReadingMinutes(words int) int {
+    if words <= 0 {
+        return 0
+    }
+    return int(math.Ceil(float64(words) / wordsPerMinute))
+}
+
+// collapseSpaces trims every line of text, collapses runs of spaces, and drops
+// empty lines.
+func collapseSpaces(text string) string {
+    lines := strings.Split(text, "\n")
+    kept := lines[:0]
+    for _, line := range lines {
+        if line = strings.Join(strings.Fields(line), " "); line != "" {
+            kept = append(kept, line)
+        }
+    }
+    return strings.Join(kept, "\n")
+}
+
+// SearchTerms splits a search query into the words to highlight, dropping
+// the quotes and minus signs of the search syntax and negated words.
+func SearchTerms(query string) []string {
+    var terms []string
+    for _, word := range strings.Fields(query) {
+        if strings.HasPrefix(word, "-") {
+            continue
+        }
+        terms = append(terms, strings.FieldsFunc(word, func(r rune) bool {
+            return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+        })...)
+    }
+    return terms
+}
+
+// minTermLength is the shortest word kept by Terms.
+const minTermLength = 2
+
+// Terms splits the given text into the words used to compare texts with each
+// other, lowercased and without diacritics, so "Việt" and "viet" are the same
+// term. Single letters and numbers are left out.
+func Terms(text string) []string {
+    var terms []string
+    for _, word := range strings.FieldsFunc(foldString(text), func(r rune) bool {
+        return !isWordRune(r)
+    }) {
+        if utf8.RuneCountInString(word) < minTermLength || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+            continue
+        }
+        terms = append(terms, word)
+    }
+    return terms
+}
+
+// Highlight returns an excerpt of about width characters of the given plain
+// text, starting shortly before the first occurrence of any of the terms. The
+// excerpt is HTML-escaped and every occurrence of a term is wrapped in <mark>.
+//
+// Terms match at the start of words, ignoring case and diacritics, so "viet"
+// highlights "Việt" and "Vietnamese". Without any match, the excerpt is the
+// start of the text.
+func Highlight(text string, terms []string, width int) string {
+    runes := []rune(text)
+    folded := make([]rune, len(runes))
+    for i, r := range runes {
+        folded[i] = foldRune(r)
+    }
+
+    var foldedTerms [][]rune
+    for _, term := range terms {
+        if term = strings.TrimSpace(term); term != "" {
+            foldedTerms = append(foldedTerms, []rune(foldString(term)))
+        }
+    }
+
+    // matchEnd[i] is the end of the term matched at rune i, or 0.
+    matchEnd := make([]int, len(runes))
+    first := -1
+    for i := range folded {
+        if i > 0 && isWordRune(folded[i-1]) {
+            continue
+        }
+        for _, term := range foldedTerms {
+            if hasRunePrefix(folded[i:], term) && len(term) > matchEnd[i]-i {
+                matchEnd[i] = i + len(term)
+                if first < 0 {
+                    first = i
+                }
+            }
+        }
+    }
+
+    start := 0
+    if first > width/3 {
+        start = first - width/3
+        // Start the excerpt at the beginning of a word.
+        for start < first && isWordRune(folded[start-1]) {
+            start++
+        }
+    }
+    end := start + width
+    if end > len(runes) {
+        end = len(runes)
+    }
+    for end < len(runes) && end > start && isWordRune(folded[end-1]) && isWordRune(folded[end]) {
+        end--
+    }
+
+    var b strings.Builder
+    if start > 0 {
+        b.WriteString("…")
+    }
+    for i := start; i < end; {
+        if matchEnd[i] > 0 {
+            stop := matchEnd[i]
+            if stop > end {
+                stop = end
+            }
+            b.WriteString("<mark>")
+            b.WriteString(html.EscapeString(string(runes[i:stop])))
+            b.WriteString("</mark>")
+            i = stop
+            continue
+        }
+        b.WriteString(html.EscapeString(string(runes[i])))
+        i++
+    }
+    if end < len(runes) {
+        b.WriteString("…")
+    }
+
+    return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// foldRune lowercases the given rune and strips its diacritics.
+func foldRune(r rune) rune {
+    r = unicode.ToLower(r)
+    if r == 'đ' {
+        return 'd'
+    }
+    if r < unicode.MaxASCII {
+        return r
+    }
+    for _, base := range norm.NFD.String(string(r)) {
+        return base
+    }
+    return r
+}
+
+// foldString applies foldRune to every rune of s.
+func foldString(s string) string {
+    return strings.Map(foldRune, s)
+}
+
+// isWordRune reports whether r is part of a word.
+func isWordRune(r rune) bool {
+    return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// hasRunePrefix reports whether s starts with prefix.
+func hasRunePrefix(s, prefix []rune) bool {
+    if len(prefix) > len(s) {
+        return false
+    }
+    for i, r := range prefix {
+        if s[i] != r {
+            return false
+        }
+    }
+    return true
+}
\ No newline at end of file
@@ -0,0 +1,143 @@
+package utils
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestStripHTML(t *testing.T) {
+    tests := []struct {
+        name     string
+        fragment string
+        want     string
+    }{
+        {"paragraphs", "<p>Hello <b>world</b></p><p>Again</p>", "Hello world\nAgain"},
+        {"entities", "<p>Fish &amp; chips</p>", "Fish & chips"},
+        {"spaces collapsed", "<p>  a \t  b  </p>", "a b"},
+        {"line break", "one<br>two", "one\ntwo"},
+        {"empty", "", ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := StripHTML(tt.fragment); got != tt.want {
+                t.Errorf("StripHTML(%q) = %q, want %q", tt.fragment, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestAnchorHeadings(t *testing.T) {
+    tests := []struct {
+        name         string
+        fragment     string
+        wantHTML     string
+        wantHeadings []Heading
+    }{
+        {
+            name:         "anchors added",
+            fragment:     "<h2>Xin chào</h2><p>Text</p><h3>Setup <em>steps</em></h3>",
+            wantHTML:     `<h2 id="xin-chao">Xin chào</h2><p>Text</p><h3 id="setup-steps">Setup <em>steps</em></h3>`,
+            wantHeadings: []Heading{{Level: 2, Text: "Xin chào", Anchor: "xin-chao"}, {Level: 3, Text: "Setup steps", Anchor: "setup-steps"}},
+        },
+        {
+            name:         "duplicates numbered",
+            fragment:     "<h2>Setup</h2><h2>Setup</h2>",
+            wantHTML:     `<h2 id="setup">Setup</h2><h2 id="setup-2">Setup</h2>`,
+            wantHeadings: []Heading{{Level: 2, Text: "Setup", Anchor: "setup"}, {Level: 2, Text: "Setup", Anchor: "setup-2"}},
+        },
+        {
+            name:         "existing id kept",
+            fragment:     `<h1 id="top">Title</h1>`,
+            wantHTML:     `<h1 id="top">Title</h1>`,
+            wantHeadings: []Heading{{Level: 1, Text: "Title", Anchor: "top"}},
+        },
+        {
+            name:         "untitled heading",
+            fragment:     "<h2>日本語</h2>",
+            wantHTML:     `<h2 id="section">日本語</h2>`,
+            wantHeadings: []Heading{{Level: 2, Text: "日本語", Anchor: "section"}},
+        },
+        {
+            name:     "no headings",
+            fragment: "<p>Text</p>",
+            wantHTML: "<p>Text</p>",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            html, headings := AnchorHeadings(tt.fragment)
+            if html != tt.wantHTML {
+                t.Errorf("AnchorHeadings() html = %q, want %q", html, tt.wantHTML)
+            }
+            if !reflect.DeepEqual(headings, tt.wantHeadings) {
+                t.Errorf("AnchorHeadings() headings = %v, want %v", headings, tt.wantHeadings)
+            }
+        })
+    }
+}
+
+func TestExcerpt(t *testing.T) {
+    tests := []struct {
+        name  string
+        text  string
+        width int
+        want  string
+    }{
+        {"short", "Hello world", 20, "Hello world"},
+        {"joined on one line", "Hello\n  world", 20, "Hello world"},
+        {"cut at word", "The quick brown fox jumps", 12, "The quick…"},
+        {"punctuation trimmed", "Hello, world and more", 8, "Hello…"},
+        {"long word cut", "Supercalifragilistic", 5, "Super…"},
+        {"unicode", "Xin chào Việt Nam", 10, "Xin chào…"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := Excerpt(tt.text, tt.width); got != tt.want {
+                t.Errorf("Excerpt(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCountWords(t *testing.T) {
+    tests := []struct {
+        text string
+        want int
+    }{
+        {"", 0},
+        {"Hello world", 2},
+        {"one - two — three", 3},
+        {"Xin chào Việt Nam", 4},
+        {"  spaced\n\tout  ", 2},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.text, func(t *testing.T) {
+            if got := CountWords(tt.text); got != tt.want {
+                t.Errorf("CountWords(%q) = %d, want %d", tt.text, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestReadingMinutes(t *testing.T) {
+    tests := []struct {
+        words int
+        want  int
+    }{
+        {0, 0},
+        {1, 1},
+        {wordsPerMinute, 1},
+        {wordsPerMinute + 1, 2},
+        {10 * wordsPerMinute, 10},
+    }
+
+    for _, tt := range tests {
+        if got := ReadingMinutes(tt.words); got != tt.want {
+            t.Errorf("ReadingMinutes(%d) = %d, want %d", tt.words, got, tt.want)
+        }
+    }
+}
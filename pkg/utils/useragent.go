@@ -0,0 +1,30 @@
+package utils
+
+import "strings"
+
+// botMarkers are fragments found in the user agents of crawlers, link
+// previewers, monitoring services and HTTP libraries.
+var botMarkers = []string{
+    "bot", "crawl", "spider", "slurp", "scrape", "fetch", "preview",
+    "monitor", "pingdom", "uptime", "lighthouse", "headless", "phantomjs",
+    "curl", "wget", "python", "java/", "go-http-client", "okhttp", "axios",
+    "node-fetch", "libwww", "httpclient", "postman", "insomnia",
+    "facebookexternalhit", "whatsapp", "embedly", "quora link preview",
+}
+
+// IsBot reports whether the given user agent most likely belongs to a bot
+// rather than to a person using a browser. Empty user agents, and user
+// agents not claiming to be a browser, are treated as bots.
+func IsBot(userAgent string) bool {
+    ua := strings.ToLower(strings.TrimSpace(userAgent))
+    if ua == "" || !strings.HasPrefix(ua, "mozilla/") && !strings.HasPrefix(ua, "opera/") {
+        return true
+    }
+
+    for _, marker := range botMarkers {
+        if strings.Contains(ua, marker) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+    "context"
+    "fmt"
+    "time"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BookmarkRepository struct {
+    collection *mongo.Collection
+}
+
+// NewBookmarkRepository returns a new instance of BookmarkRepository.
+//
+// The BookmarkRepository is used to interact with the "bookmarks" collection
+// in the MongoDB database.
+func NewBookmarkRepository(db *mongo.Database) *BookmarkRepository {
+    return &BookmarkRepository{
+        collection: db.Collection("bookmarks"),
+    }
+}
+
+// Create bookmarks the post with the given ID for the user with the given ID,
+// and returns the bookmark. Bookmarking a post twice returns the existing
+// bookmark.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *BookmarkRepository) Create(userID, postID primitive.ObjectID) (*models.Bookmark, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    opts := options.FindOneAndUpdate().
+        SetUpsert(true).
+        SetReturnDocument(options.After)
+
+    // Concurrent upserts of the same bookmark can both try to insert it; the
+    // unique index rejects one of them, which then finds the bookmark.
+    var bookmark models.Bookmark
+    var err error
+    for attempt := 0; attempt < 2; attempt++ {
+        err = r.collection.FindOneAndUpdate(
+            ctx,
+            bson.M{"user_id": userID, "post_id": postID},
+            bson.M{"$setOnInsert": bson.M{"created_at": time.Now()}},
+            opts,
+        ).Decode(&bookmark)
+        if !mongo.IsDuplicateKeyError(err) {
+            break
+        }
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &bookmark, nil
+}
+
+// Delete removes the bookmark of the user with the given ID on the post with
+// the given ID.
+//
+// The returned error will be models.ErrNotFound if the user has not
+// bookmarked the post, and non-nil if any other error occurred during the
+// delete process.
+func (r *BookmarkRepository) Delete(userID, postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.DeleteOne(ctx, bson.M{"user_id": userID, "post_id": postID})
+    if err != nil {
+        return err
+    }
+    if result.DeletedCount == 0 {
+        return models.ErrNotFound
+    }
+
+    return nil
+}
+
+// List returns a page of the bookmarks of the user with the given ID, newest
+// first. Bookmarks are paged with forward cursors, or with 1-indexed page
+// numbers when no cursor is given.
+//
+// The returned error will be models.ErrInvalidInput if the cursor is
+// malformed, and non-nil if any other error occurred during the find process.
+func (r *BookmarkRepository) List(userID primitive.ObjectID, req models.PageRequest) (*models.BookmarkPage, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    sort := models.Sort{Field: "created_at", Descending: true}
+    query := bson.M{"user_id": userID}
+    filter := query
+    opts := options.Find().
+        SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+        SetLimit(int64(req.Limit + 1))
+
+    if req.Cursor != "" {
+        c, id, err := decodeCursor(req.Cursor, sort.Field)
+        if err != nil {
+            return nil, err
+        }
+        if c.Backward {
+            return nil, fmt.Errorf("%w: invalid cursor", models.ErrInvalidInput)
+        }
+        filter = bson.M{"$and": bson.A{query, keysetFilter(c, id, sort)}}
+    } else if req.Page > 1 {
+        opts.SetSkip(int64((req.Page - 1) * req.Limit))
+    }
+
+    cursor, err := r.collection.Find(ctx, filter, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    bookmarks := []*models.Bookmark{}
+    if err := cursor.All(ctx, &bookmarks); err != nil {
+        return nil, err
+    }
+
+    page := &models.BookmarkPage{Bookmarks: bookmarks}
+    if len(bookmarks) > req.Limit {
+        page.Bookmarks = bookmarks[:req.Limit]
+        last := page.Bookmarks[len(page.Bookmarks)-1]
+        page.Pagination.NextCursor = encodeKeysetCursor(sort.Field, last.CreatedAt.UnixMilli(), last.ID, false)
+        page.Pagination.HasMore = true
+    }
+
+    if req.IncludeTotal {
+        total, err := r.collection.CountDocuments(ctx, query)
+        if err != nil {
+            return nil, err
+        }
+        page.Pagination.Total = &total
+    }
+
+    return page, nil
+}
+
+// DeleteByPost deletes every bookmark on the post with the given ID.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *BookmarkRepository) DeleteByPost(postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.DeleteMany(ctx, bson.M{"post_id": postID})
+    return err
+}
+
+// EnsureIndexes creates the indexes used by the bookmark queries. The unique
+// index guarantees a single bookmark per user and post. It is safe to call on
+// every start-up, as existing indexes are left untouched.
+func (r *BookmarkRepository) EnsureIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "post_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+        {Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+        {Keys: bson.D{{Key: "post_id", Value: 1}}},
+    })
+    return err
+}
@@ -0,0 +1,189 @@
+package repositories
+
+import (
+    "context"
+    "time"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CategoryRepository struct {
+    collection *mongo.Collection
+}
+
+// NewCategoryRepository returns a new instance of CategoryRepository.
+//
+// The CategoryRepository is used to interact with the "categories" collection
+// in the MongoDB database.
+func NewCategoryRepository(db *mongo.Database) *CategoryRepository {
+    return &CategoryRepository{
+        collection: db.Collection("categories"),
+    }
+}
+
+// Create creates a new category in the "categories" collection.
+//
+// The returned error will be models.ErrConflict if another category already
+// uses the same slug, and non-nil if any other error occurred during the
+// create process.
+func (r *CategoryRepository) Create(category *models.Category) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.InsertOne(ctx, category)
+    if mongo.IsDuplicateKeyError(err) {
+        return models.ErrConflict
+    }
+    if err != nil {
+        return err
+    }
+
+    category.ID = result.InsertedID.(primitive.ObjectID)
+    return nil
+}
+
+// GetByID returns a category by the given ID.
+//
+// The returned error will be models.ErrNotFound if the ID is malformed or no
+// category exists with that ID.
+func (r *CategoryRepository) GetByID(id string) (*models.Category, error) {
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return nil, models.ErrNotFound
+    }
+
+    return r.findOne(bson.M{"_id": objectID})
+}
+
+// GetBySlug returns a category by the given slug.
+//
+// The returned error will be models.ErrNotFound if no category has that slug.
+func (r *CategoryRepository) GetBySlug(slug string) (*models.Category, error) {
+    return r.findOne(bson.M{"slug": slug})
+}
+
+// List returns every category, sorted by name. Categories are few, so the
+// whole tree is always loaded at once.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *CategoryRepository) List() ([]*models.Category, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+    cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    var categories []*models.Category
+    if err = cursor.All(ctx, &categories); err != nil {
+        return nil, err
+    }
+
+    return categories, nil
+}
+
+// GetDescendantIDs returns the IDs of every category below the category with
+// the given ID, at any depth.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *CategoryRepository) GetDescendantIDs(id primitive.ObjectID) ([]primitive.ObjectID, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+    cursor, err := r.collection.Find(ctx, bson.M{"ancestors": id}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    var ids []primitive.ObjectID
+    for cursor.Next(ctx) {
+        var doc struct {
+            ID primitive.ObjectID `bson:"_id"`
+        }
+        if err := cursor.Decode(&doc); err != nil {
+            return nil, err
+        }
+        ids = append(ids, doc.ID)
+    }
+
+    return ids, cursor.Err()
+}
+
+// Update updates the category with the given ID in the "categories"
+// collection.
+//
+// The updates parameter is a map of key-value pairs that should be updated in
+// the category document.
+//
+// The returned error will be models.ErrConflict if the new slug is already
+// used, and non-nil if any other error occurred during the update process.
+func (r *CategoryRepository) Update(id primitive.ObjectID, updates map[string]interface{}) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{"_id": id},
+        bson.M{"$set": updates},
+    )
+    if mongo.IsDuplicateKeyError(err) {
+        return models.ErrConflict
+    }
+    return err
+}
+
+// Delete deletes the category with the given ID from the "categories"
+// collection.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *CategoryRepository) Delete(id primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+    return err
+}
+
+// EnsureIndexes creates the indexes used by the category queries. It is safe
+// to call on every start-up, as existing indexes are left untouched.
+func (r *CategoryRepository) EnsureIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {Keys: bson.D{{Key: "slug", Value: 1}}, Options: options.Index().SetUnique(true)},
+        {Keys: bson.D{{Key: "parent_id", Value: 1}}},
+        {Keys: bson.D{{Key: "ancestors", Value: 1}}},
+    })
+    return err
+}
+
+// findOne returns the single category matching the given query.
+func (r *CategoryRepository) findOne(query bson.M) (*models.Category, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var category models.Category
+    err := r.collection.FindOne(ctx, query).Decode(&category)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &category, nil
+}
\ No newline at end of file
@@ -0,0 +1,194 @@
+package repositories
+
+import (
+    "context"
+    "time"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CoAuthorRepository struct {
+    collection *mongo.Collection
+}
+
+// NewCoAuthorRepository returns a new instance of CoAuthorRepository.
+//
+// The CoAuthorRepository is used to interact with the
+// "coauthor_invitations" collection, which holds the invitations to
+// co-author posts. The co-authors themselves are stored with the post.
+func NewCoAuthorRepository(db *mongo.Database) *CoAuthorRepository {
+    return &CoAuthorRepository{
+        collection: db.Collection("coauthor_invitations"),
+    }
+}
+
+// Create creates a new invitation in the "coauthor_invitations" collection.
+//
+// The returned error will be models.ErrConflict if the invitee already has a
+// pending invitation to the same post, and non-nil if any other error
+// occurred during the create process.
+func (r *CoAuthorRepository) Create(invitation *models.CoAuthorInvitation) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.InsertOne(ctx, invitation)
+    if mongo.IsDuplicateKeyError(err) {
+        return models.ErrConflict
+    }
+    if err != nil {
+        return err
+    }
+
+    invitation.ID = result.InsertedID.(primitive.ObjectID)
+    return nil
+}
+
+// GetByID returns an invitation by the given ID.
+//
+// The returned error will be models.ErrNotFound if the ID is malformed or no
+// invitation exists with that ID.
+func (r *CoAuthorRepository) GetByID(id string) (*models.CoAuthorInvitation, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return nil, models.ErrNotFound
+    }
+
+    var invitation models.CoAuthorInvitation
+    err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&invitation)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &invitation, nil
+}
+
+// ListPendingByInvitee returns the pending invitations of the user with the
+// given ID, newest first.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *CoAuthorRepository) ListPendingByInvitee(inviteeID primitive.ObjectID) ([]*models.CoAuthorInvitation, error) {
+    return r.find(bson.M{"invitee_id": inviteeID, "status": models.InvitationStatusPending},
+        bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+}
+
+// ListPendingByPost returns the pending invitations to co-author the post
+// with the given ID, oldest first.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *CoAuthorRepository) ListPendingByPost(postID primitive.ObjectID) ([]*models.CoAuthorInvitation, error) {
+    return r.find(bson.M{"post_id": postID, "status": models.InvitationStatusPending},
+        bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+}
+
+// SetStatus changes the status of the invitation with the given ID from one
+// status to another, recording when the invitee answered. Nothing is changed
+// if the invitation does not have the expected status anymore, so that an
+// invitation is answered once.
+//
+// The returned bool reports whether the invitation was changed. The returned
+// error will be non-nil if any error occurred during the update process.
+func (r *CoAuthorRepository) SetStatus(id primitive.ObjectID, from, to string, at *time.Time) (bool, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    update := bson.M{"$set": bson.M{"status": to, "responded_at": at}}
+    if at == nil {
+        update = bson.M{"$set": bson.M{"status": to}, "$unset": bson.M{"responded_at": ""}}
+    }
+
+    result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": from}, update)
+    if err != nil {
+        return false, err
+    }
+
+    return result.ModifiedCount > 0, nil
+}
+
+// DeletePending deletes the pending invitation of the user with the given ID
+// to co-author the post with the given ID.
+//
+// The returned error will be models.ErrNotFound if there is no such
+// invitation, and non-nil if any other error occurred during the delete
+// process.
+func (r *CoAuthorRepository) DeletePending(postID, inviteeID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.DeleteOne(ctx, bson.M{
+        "post_id":    postID,
+        "invitee_id": inviteeID,
+        "status":     models.InvitationStatusPending,
+    })
+    if err != nil {
+        return err
+    }
+    if result.DeletedCount == 0 {
+        return models.ErrNotFound
+    }
+
+    return nil
+}
+
+// DeleteByPost deletes every invitation to co-author the post with the given
+// ID.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *CoAuthorRepository) DeleteByPost(postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.DeleteMany(ctx, bson.M{"post_id": postID})
+    return err
+}
+
+// EnsureIndexes creates the indexes used by the invitation queries, including
+// the unique index that keeps a user from being invited twice to the same
+// post at once. It is safe to call on every start-up, as existing indexes are
+// left untouched.
+func (r *CoAuthorRepository) EnsureIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {
+            Keys: bson.D{{Key: "post_id", Value: 1}, {Key: "invitee_id", Value: 1}},
+            Options: options.Index().
+                SetUnique(true).
+                SetPartialFilterExpression(bson.M{"status": models.InvitationStatusPending}),
+        },
+        {Keys: bson.D{{Key: "invitee_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+    })
+    return err
+}
+
+// find returns the invitations matching the given query, in the given
+// order.
+func (r *CoAuthorRepository) find(query bson.M, sort bson.D) ([]*models.CoAuthorInvitation, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(sort))
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    invitations := []*models.CoAuthorInvitation{}
+    if err := cursor.All(ctx, &invitations); err != nil {
+        return nil, err
+    }
+
+    return invitations, nil
+}
@@ -0,0 +1,383 @@
+package repositories
+
+import (
+    "context"
+    "fmt"
+    "time"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommentRepository struct {
+    collection *mongo.Collection
+}
+
+// NewCommentRepository returns a new instance of CommentRepository.
+//
+// The CommentRepository is used to interact with the "comments" collection in
+// the MongoDB database.
+func NewCommentRepository(db *mongo.Database) *CommentRepository {
+    return &CommentRepository{
+        collection: db.Collection("comments"),
+    }
+}
+
+// Create creates a new comment in the "comments" collection. The comment
+// keeps its ID if it already has one, so that a top-level comment can be
+// created with a thread ID equal to its own ID.
+//
+// The returned error will be non-nil if any error occurred during the create
+// process.
+func (r *CommentRepository) Create(comment *models.Comment) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.InsertOne(ctx, comment)
+    if err != nil {
+        return err
+    }
+
+    comment.ID = result.InsertedID.(primitive.ObjectID)
+    return nil
+}
+
+// GetByID returns a comment by the given ID.
+//
+// The returned error will be models.ErrNotFound if the ID is malformed or no
+// comment exists with that ID.
+func (r *CommentRepository) GetByID(id string) (*models.Comment, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return nil, models.ErrNotFound
+    }
+
+    var comment models.Comment
+    err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&comment)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &comment, nil
+}
+
+// GetBySourceID returns the comment imported from the given source.
+//
+// The returned error will be models.ErrNotFound if no comment was imported
+// from that source, and non-nil if any other error occurred during the get
+// process.
+func (r *CommentRepository) GetBySourceID(sourceID string) (*models.Comment, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var comment models.Comment
+    err := r.collection.FindOne(ctx, bson.M{"source_id": sourceID}).Decode(&comment)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &comment, nil
+}
+
+// Update sets the given fields of the comment with the given ID.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *CommentRepository) Update(id primitive.ObjectID, updates map[string]interface{}) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+    return err
+}
+
+// Delete deletes the comment with the given ID.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *CommentRepository) Delete(id primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+    return err
+}
+
+// HasReplies reports whether any comment replies to the comment with the
+// given ID.
+func (r *CommentRepository) HasReplies(id primitive.ObjectID) (bool, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    count, err := r.collection.CountDocuments(ctx, bson.M{"parent_id": id}, options.Count().SetLimit(1))
+    return count > 0, err
+}
+
+// ListThreads returns a page of the top-level comments of the post with the
+// given ID, oldest first, each carrying the whole tree of its replies. Only
+// approved comments are returned, along with the pending comments of the
+// viewer with the given ID, if not zero. Replies to a comment that is not
+// returned are left out with it.
+//
+// Threads are paged with forward cursors, or with 1-indexed page numbers
+// when no cursor is given. Replies are not paged: a thread is always returned
+// in full.
+//
+// The returned error will be models.ErrInvalidInput if the cursor is
+// malformed, and non-nil if any other error occurred during the find process.
+func (r *CommentRepository) ListThreads(postID, viewerID primitive.ObjectID, req models.PageRequest) (*models.CommentPage, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    visible := visibleCommentsFilter(viewerID)
+    page, err := r.findPage(ctx, bson.M{"$and": bson.A{
+        bson.M{"post_id": postID, "parent_id": nil},
+        visible,
+    }}, req)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(page.Comments) == 0 {
+        return page, nil
+    }
+
+    threadIDs := make([]primitive.ObjectID, len(page.Comments))
+    for i, root := range page.Comments {
+        threadIDs[i] = root.ID
+    }
+    replies, err := r.find(ctx, bson.M{"$and": bson.A{
+        bson.M{"thread_id": bson.M{"$in": threadIDs}, "parent_id": bson.M{"$ne": nil}},
+        visible,
+    }}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+    if err != nil {
+        return nil, err
+    }
+
+    // Replies are sorted by creation, so a parent is always seen before its
+    // replies.
+    byID := make(map[primitive.ObjectID]*models.Comment, len(page.Comments)+len(replies))
+    for _, root := range page.Comments {
+        byID[root.ID] = root
+    }
+    for _, reply := range replies {
+        if parent, ok := byID[*reply.ParentID]; ok {
+            byID[reply.ID] = reply
+            parent.Replies = append(parent.Replies, reply)
+        }
+    }
+
+    return page, nil
+}
+
+// ListByStatus returns a page of the comments with the given moderation
+// status, on any post, oldest first. Comments are returned without their
+// replies.
+//
+// Comments are paged with forward cursors, or with 1-indexed page numbers
+// when no cursor is given.
+//
+// The returned error will be models.ErrInvalidInput if the cursor is
+// malformed, and non-nil if any other error occurred during the find process.
+func (r *CommentRepository) ListByStatus(status string, req models.PageRequest) (*models.CommentPage, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    return r.findPage(ctx, bson.M{"status": statusValue(status)}, req)
+}
+
+// GetByIDs returns the comments with the given IDs, in no particular order.
+// IDs that match no comment are ignored.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *CommentRepository) GetByIDs(ids []primitive.ObjectID) ([]*models.Comment, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find())
+}
+
+// SetStatus changes the moderation status of the comment with the given ID
+// from one status to another, recording who moderated it and when. Nothing
+// is changed if the comment does not have the expected status anymore, so
+// that concurrent moderators cannot both count the same change.
+//
+// The returned bool reports whether the comment was changed. The returned
+// error will be non-nil if any error occurred during the update process.
+func (r *CommentRepository) SetStatus(id primitive.ObjectID, from, to string, moderatorID primitive.ObjectID, at time.Time) (bool, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{"_id": id, "status": statusValue(from)},
+        bson.M{"$set": bson.M{
+            "status":       to,
+            "moderated_by": moderatorID,
+            "moderated_at": at,
+        }},
+    )
+    if err != nil {
+        return false, err
+    }
+
+    return result.MatchedCount > 0, nil
+}
+
+// CountByAuthor returns the number of comments by the user with the given ID
+// that have the given moderation status.
+//
+// The returned error will be non-nil if any error occurred during the count
+// process.
+func (r *CommentRepository) CountByAuthor(authorID primitive.ObjectID, status string) (int64, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    return r.collection.CountDocuments(ctx, bson.M{"author_id": authorID, "status": statusValue(status)})
+}
+
+// CountByAuthorSince returns the number of comments the user with the given
+// ID has posted since the given time, whatever their status.
+//
+// The returned error will be non-nil if any error occurred during the count
+// process.
+func (r *CommentRepository) CountByAuthorSince(authorID primitive.ObjectID, since time.Time) (int64, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    return r.collection.CountDocuments(ctx, bson.M{"author_id": authorID, "created_at": bson.M{"$gte": since}})
+}
+
+// DeleteByPost deletes every comment on the post with the given ID.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *CommentRepository) DeleteByPost(postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.DeleteMany(ctx, bson.M{"post_id": postID})
+    return err
+}
+
+// EnsureIndexes creates the indexes used by the comment queries. It is safe
+// to call on every start-up, as existing indexes are left untouched.
+func (r *CommentRepository) EnsureIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {Keys: bson.D{{Key: "post_id", Value: 1}, {Key: "parent_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+        {Keys: bson.D{{Key: "thread_id", Value: 1}, {Key: "created_at", Value: 1}}},
+        {Keys: bson.D{{Key: "parent_id", Value: 1}}},
+        {Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+        {Keys: bson.D{{Key: "author_id", Value: 1}, {Key: "status", Value: 1}}},
+        {Keys: bson.D{{Key: "author_id", Value: 1}, {Key: "created_at", Value: 1}}},
+        {
+            // Only imported comments have a source, and each source is
+            // imported once.
+            Keys: bson.D{{Key: "source_id", Value: 1}},
+            Options: options.Index().
+                SetUnique(true).
+                SetPartialFilterExpression(bson.M{"source_id": bson.M{"$type": "string"}}),
+        },
+    })
+    return err
+}
+
+// findPage returns a page of the comments matching the given query, oldest
+// first.
+func (r *CommentRepository) findPage(ctx context.Context, query bson.M, req models.PageRequest) (*models.CommentPage, error) {
+    sort := models.Sort{Field: "created_at"}
+    filter := query
+    opts := options.Find().
+        SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+        SetLimit(int64(req.Limit + 1))
+
+    if req.Cursor != "" {
+        c, id, err := decodeCursor(req.Cursor, sort.Field)
+        if err != nil {
+            return nil, err
+        }
+        if c.Backward {
+            return nil, fmt.Errorf("%w: invalid cursor", models.ErrInvalidInput)
+        }
+        filter = bson.M{"$and": bson.A{query, keysetFilter(c, id, sort)}}
+    } else if req.Page > 1 {
+        opts.SetSkip(int64((req.Page - 1) * req.Limit))
+    }
+
+    comments, err := r.find(ctx, filter, opts)
+    if err != nil {
+        return nil, err
+    }
+
+    page := &models.CommentPage{Comments: comments}
+    if len(comments) > req.Limit {
+        page.Comments = comments[:req.Limit]
+        last := page.Comments[len(page.Comments)-1]
+        page.Pagination.NextCursor = encodeKeysetCursor(sort.Field, last.CreatedAt.UnixMilli(), last.ID, false)
+        page.Pagination.HasMore = true
+    }
+
+    if req.IncludeTotal {
+        total, err := r.collection.CountDocuments(ctx, query)
+        if err != nil {
+            return nil, err
+        }
+        page.Pagination.Total = &total
+    }
+
+    return page, nil
+}
+
+// find returns the comments matching the given query.
+func (r *CommentRepository) find(ctx context.Context, query bson.M, opts *options.FindOptions) ([]*models.Comment, error) {
+    cursor, err := r.collection.Find(ctx, query, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    comments := []*models.Comment{}
+    if err := cursor.All(ctx, &comments); err != nil {
+        return nil, err
+    }
+
+    return comments, nil
+}
+
+// statusValue returns the condition matching comments with the given
+// moderation status. Comments created before moderation existed have no
+// status field and are considered approved.
+func statusValue(status string) interface{} {
+    if status == models.CommentStatusApproved {
+        return bson.M{"$in": bson.A{status, nil}}
+    }
+    return status
+}
+
+// visibleCommentsFilter returns the condition matching the comments a viewer
+// may read: approved comments, and the viewer's own pending comments.
+func visibleCommentsFilter(viewerID primitive.ObjectID) bson.M {
+    approved := bson.M{"status": statusValue(models.CommentStatusApproved)}
+    if viewerID.IsZero() {
+        return approved
+    }
+    return bson.M{"$or": bson.A{
+        approved,
+        bson.M{"author_id": viewerID, "status": models.CommentStatusPending},
+    }}
+}
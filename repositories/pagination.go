@@ -0,0 +1,142 @@
+package repositories
+
+import (
+    "bytes"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+// cursor is the decoded form of the opaque pagination cursors handed out to
+// clients. It points at an item of a sorted list by the value of its sort
+// field and its ID, and says in which direction to read from there.
+type cursor struct {
+    Field    string      `json:"f"`
+    Value    interface{} `json:"v"`
+    ID       string      `json:"id"`
+    Backward bool        `json:"b,omitempty"`
+}
+
+// postSortFields lists the fields posts may be sorted on. Only these fields
+// ever reach a MongoDB sort or keyset condition.
+var postSortFields = map[string]bool{
+    models.PostSortCreated:    true,
+    models.PostSortUpdated:    true,
+    models.PostSortTitle:      true,
+    models.PostSortPopularity: true,
+}
+
+// resolvePostSort returns the sort to apply to a post list, defaulting to the
+// newest posts first. The returned error wraps models.ErrInvalidInput for a
+// field posts cannot be sorted on.
+func resolvePostSort(sort models.Sort) (models.Sort, error) {
+    if sort.Field == "" {
+        return models.Sort{Field: models.PostSortCreated, Descending: true}, nil
+    }
+    if !postSortFields[sort.Field] {
+        return sort, fmt.Errorf("%w: posts cannot be sorted on %q", models.ErrInvalidInput, sort.Field)
+    }
+    return sort, nil
+}
+
+// postSortValue returns the value of the given sort field for a post, in the
+// form stored in cursors.
+func postSortValue(post *models.Post, field string) interface{} {
+    switch field {
+    case models.PostSortUpdated:
+        return post.UpdatedAt.UnixMilli()
+    case models.PostSortTitle:
+        return post.Title
+    case models.PostSortPopularity:
+        return post.Popularity
+    default:
+        return post.CreatedAt.UnixMilli()
+    }
+}
+
+// encodeCursor returns the opaque cursor pointing at the given post in a list
+// sorted on the given field.
+func encodeCursor(post *models.Post, field string, backward bool) string {
+    return encodeKeysetCursor(field, postSortValue(post, field), post.ID, backward)
+}
+
+// encodeKeysetCursor returns the opaque cursor pointing at the item with the
+// given ID and sort value in a list sorted on the given field. Times are
+// stored as Unix milliseconds.
+func encodeKeysetCursor(field string, value interface{}, id primitive.ObjectID, backward bool) string {
+    data, _ := json.Marshal(cursor{
+        Field:    field,
+        Value:    value,
+        ID:       id.Hex(),
+        Backward: backward,
+    })
+    return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses an opaque cursor made for a list sorted on the given
+// field, and converts its value back to the type stored in MongoDB. The
+// returned error wraps models.ErrInvalidInput if the cursor is malformed or
+// was made for another sort order.
+func decodeCursor(value, field string) (*cursor, primitive.ObjectID, error) {
+    invalid := fmt.Errorf("%w: invalid cursor", models.ErrInvalidInput)
+
+    data, err := base64.RawURLEncoding.DecodeString(value)
+    if err != nil {
+        return nil, primitive.NilObjectID, invalid
+    }
+
+    var c cursor
+    decoder := json.NewDecoder(bytes.NewReader(data))
+    decoder.UseNumber()
+    if err := decoder.Decode(&c); err != nil || c.Field != field {
+        return nil, primitive.NilObjectID, invalid
+    }
+
+    id, err := primitive.ObjectIDFromHex(c.ID)
+    if err != nil {
+        return nil, primitive.NilObjectID, invalid
+    }
+
+    switch field {
+    case models.PostSortTitle:
+        title, ok := c.Value.(string)
+        if !ok {
+            return nil, primitive.NilObjectID, invalid
+        }
+        c.Value = title
+    default:
+        number, ok := c.Value.(json.Number)
+        if !ok {
+            return nil, primitive.NilObjectID, invalid
+        }
+        n, err := number.Int64()
+        if err != nil {
+            return nil, primitive.NilObjectID, invalid
+        }
+        if field == models.PostSortPopularity {
+            c.Value = n
+        } else {
+            c.Value = time.UnixMilli(n)
+        }
+    }
+
+    return &c, id, nil
+}
+
+// keysetFilter returns the condition selecting the items after the cursor in
+// the given sort order, or before it for a backward cursor.
+func keysetFilter(c *cursor, id primitive.ObjectID, sort models.Sort) bson.M {
+    op := "$gt"
+    if sort.Descending != c.Backward {
+        op = "$lt"
+    }
+
+    return bson.M{"$or": bson.A{
+        bson.M{sort.Field: bson.M{op: c.Value}},
+        bson.M{sort.Field: c.Value, "_id": bson.M{op: id}},
+    }}
+}
\ No newline at end of file
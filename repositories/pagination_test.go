@@ -0,0 +1,105 @@
+package repositories
+
+import (
+    "errors"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+    created := time.Date(2024, 5, 1, 12, 30, 0, 123_000_000, time.UTC)
+    post := &models.Post{
+        ID:         primitive.NewObjectID(),
+        Title:      "Xin chào Việt Nam",
+        Popularity: 42,
+        CreatedAt:  created,
+        UpdatedAt:  created.Add(time.Hour),
+    }
+
+    tests := []struct {
+        field     string
+        backward  bool
+        wantValue interface{}
+    }{
+        {models.PostSortCreated, false, time.UnixMilli(created.UnixMilli())},
+        {models.PostSortUpdated, true, time.UnixMilli(created.Add(time.Hour).UnixMilli())},
+        {models.PostSortTitle, false, "Xin chào Việt Nam"},
+        {models.PostSortPopularity, true, int64(42)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.field, func(t *testing.T) {
+            encoded := encodeCursor(post, tt.field, tt.backward)
+
+            c, id, err := decodeCursor(encoded, tt.field)
+            if err != nil {
+                t.Fatalf("decodeCursor() error = %v", err)
+            }
+            if id != post.ID {
+                t.Errorf("decodeCursor() id = %v, want %v", id, post.ID)
+            }
+            if c.Field != tt.field || c.Backward != tt.backward {
+                t.Errorf("decodeCursor() = %+v, want field %q and backward %v", c, tt.field, tt.backward)
+            }
+            if !reflect.DeepEqual(c.Value, tt.wantValue) {
+                t.Errorf("decodeCursor() value = %#v, want %#v", c.Value, tt.wantValue)
+            }
+        })
+    }
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+    post := &models.Post{ID: primitive.NewObjectID(), Title: "Hello"}
+
+    tests := []struct {
+        name   string
+        cursor string
+        field  string
+    }{
+        {"not base64", "!!!", models.PostSortCreated},
+        {"not json", "bm90IGpzb24", models.PostSortCreated},
+        {"other sort field", encodeCursor(post, models.PostSortTitle, false), models.PostSortCreated},
+        {"truncated", encodeCursor(post, models.PostSortTitle, false)[:10], models.PostSortTitle},
+        {"title is not a string", encodeKeysetCursor(models.PostSortTitle, 42, post.ID, false), models.PostSortTitle},
+        {"time is not a number", encodeKeysetCursor(models.PostSortCreated, "yesterday", post.ID, false), models.PostSortCreated},
+        {"popularity is not an integer", encodeKeysetCursor(models.PostSortPopularity, 1.5, post.ID, false), models.PostSortPopularity},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, _, err := decodeCursor(tt.cursor, tt.field)
+            if !errors.Is(err, models.ErrInvalidInput) {
+                t.Errorf("decodeCursor(%q, %q) error = %v, want %v", tt.cursor, tt.field, err, models.ErrInvalidInput)
+            }
+        })
+    }
+}
+
+func TestResolvePostSort(t *testing.T) {
+    tests := []struct {
+        name    string
+        sort    models.Sort
+        want    models.Sort
+        wantErr bool
+    }{
+        {"default", models.Sort{}, models.Sort{Field: models.PostSortCreated, Descending: true}, false},
+        {"title", models.Sort{Field: models.PostSortTitle}, models.Sort{Field: models.PostSortTitle}, false},
+        {"popularity descending", models.Sort{Field: models.PostSortPopularity, Descending: true}, models.Sort{Field: models.PostSortPopularity, Descending: true}, false},
+        {"unknown field", models.Sort{Field: "password_hash"}, models.Sort{Field: "password_hash"}, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := resolvePostSort(tt.sort)
+            if tt.wantErr != errors.Is(err, models.ErrInvalidInput) {
+                t.Fatalf("resolvePostSort() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Errorf("resolvePostSort() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
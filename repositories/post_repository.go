@@ -2,6 +2,9 @@ package repositories
 
 import (
     "context"
+    "fmt"
+    "regexp"
+    "strings"
     "time"
     "go-blog-backend/models"
     "go.mongodb.org/mongo-driver/mongo"
@@ -10,6 +13,9 @@ import (
     "go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// renameBatchSize is the number of posts RenameTag renames at a time.
+const renameBatchSize = 500
+
 type PostRepository struct {
     collection *mongo.Collection
 }
@@ -26,13 +32,17 @@ func NewPostRepository(db *mongo.Database) *PostRepository {
 
 // Create creates a new post in the "posts" collection in the MongoDB database.
 //
-// The returned error will be non-nil if any error occurred during the create
-// process.
+// The returned error will be models.ErrConflict if a post was already
+// imported from the same source, and non-nil if any other error occurred
+// during the create process.
 func (r *PostRepository) Create(post *models.Post) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
     result, err := r.collection.InsertOne(ctx, post)
+    if mongo.IsDuplicateKeyError(err) {
+        return fmt.Errorf("%w: the post already exists", models.ErrConflict)
+    }
     if err != nil {
         return err
     }
@@ -41,56 +51,628 @@ func (r *PostRepository) Create(post *models.Post) error {
     return nil
 }
 
-// GetByID returns a post by the given ID.
+// GetByID returns a post by the given ID. Deleted posts are left out; use
+// GetDeletedByID to read a post from the trash.
 //
-// The returned error will be non-nil if any error occurred during the get
-// process.
+// The returned error will be models.ErrNotFound if the ID is malformed or no
+// post exists with that ID, and non-nil if any other error occurred during
+// the get process.
 func (r *PostRepository) GetByID(id string) (*models.Post, error) {
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return nil, models.ErrNotFound
+    }
+
+    return r.findOne(bson.M{"_id": objectID, "deleted_at": nil})
+}
+
+// GetDeletedByID returns a post in the trash by the given ID.
+//
+// The returned error will be models.ErrNotFound if the ID is malformed or no
+// deleted post exists with that ID.
+func (r *PostRepository) GetDeletedByID(id string) (*models.Post, error) {
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return nil, models.ErrNotFound
+    }
+
+    return r.findOne(bson.M{"_id": objectID, "deleted_at": bson.M{"$ne": nil}})
+}
+
+// GetByIDs returns the posts with the given IDs, in no particular order.
+// IDs without a matching post, and deleted posts, are ignored.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *PostRepository) GetByIDs(ids []primitive.ObjectID) ([]*models.Post, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    objectID, err := primitive.ObjectIDFromHex(id)
+    cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "deleted_at": nil})
     if err != nil {
         return nil, err
     }
+    defer cursor.Close(ctx)
 
-    var post models.Post
-    err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&post)
-    if err != nil {
+    var posts []*models.Post
+    if err = cursor.All(ctx, &posts); err != nil {
         return nil, err
     }
 
-    return &post, nil
+    return posts, nil
+}
+
+// GetBySlug returns the post whose current slug is the given slug. Deleted
+// posts are left out.
+//
+// The returned error will be models.ErrNotFound if no post has that slug, and
+// non-nil if any other error occurred during the get process.
+func (r *PostRepository) GetBySlug(slug string) (*models.Post, error) {
+    return r.findOne(bson.M{"slug": slug, "deleted_at": nil})
+}
+
+// GetBySourceID returns the post imported from the given source, live or in
+// the trash.
+//
+// The returned error will be models.ErrNotFound if no post was imported from
+// that source, and non-nil if any other error occurred during the get
+// process.
+func (r *PostRepository) GetBySourceID(sourceID string) (*models.Post, error) {
+    return r.findOne(bson.M{"source_id": sourceID})
 }
 
 // Update updates the post with the given ID in the "posts" collection in the
-// MongoDB database.
+// MongoDB database, and increments its version.
 //
 // The updates parameter is a map of key-value pairs that should be updated in
 // the post document. The key is the field name and the value is the new value
 // for that field.
 //
+// If version is not nil, the update is conditional: it only applies if the
+// post is still at that version, so that concurrent writers cannot overwrite
+// each other's changes.
+//
+// The returned error will be models.ErrNotFound if no live post exists with
+// the given ID, models.ErrVersionConflict if the post is at another version,
+// and non-nil if any other error occurred during the update process.
+func (r *PostRepository) Update(id string, version *int64, updates map[string]interface{}) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return models.ErrNotFound
+    }
+
+    query := bson.M{"_id": objectID, "deleted_at": nil}
+    if version != nil {
+        query["version"] = versionFilter(*version)
+    }
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        query,
+        bson.M{"$set": updates, "$inc": bson.M{"version": 1}},
+    )
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount == 0 {
+        return r.missingOrConflict(objectID, version)
+    }
+    return nil
+}
+
+// SoftDelete moves the post with the given ID to the trash, recording when
+// and by whom it was deleted, and increments its version. The post stays in
+// the collection until it is purged. As with Update, a non-nil version makes
+// the deletion conditional.
+//
+// The returned error will be models.ErrNotFound if the ID is malformed or no
+// live post exists with that ID, and models.ErrVersionConflict if the post is
+// at another version.
+func (r *PostRepository) SoftDelete(id string, version *int64, deletedBy primitive.ObjectID, at time.Time) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return models.ErrNotFound
+    }
+
+    query := bson.M{"_id": objectID, "deleted_at": nil}
+    if version != nil {
+        query["version"] = versionFilter(*version)
+    }
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        query,
+        bson.M{
+            "$set": bson.M{"deleted_at": at, "deleted_by": deletedBy},
+            "$inc": bson.M{"version": 1},
+        },
+    )
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount == 0 {
+        return r.missingOrConflict(objectID, version)
+    }
+    return nil
+}
+
+// Restore takes the post with the given ID out of the trash.
+//
+// The returned error will be models.ErrNotFound if the ID is malformed or no
+// deleted post exists with that ID.
+func (r *PostRepository) Restore(id string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return models.ErrNotFound
+    }
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{"_id": objectID, "deleted_at": bson.M{"$ne": nil}},
+        bson.M{
+            "$unset": bson.M{"deleted_at": "", "deleted_by": ""},
+            "$inc":   bson.M{"version": 1},
+        },
+    )
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount == 0 {
+        return models.ErrNotFound
+    }
+    return nil
+}
+
+// ListDeletedBefore returns up to limit posts that were moved to the trash
+// before the given time, oldest deletion first.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *PostRepository) ListDeletedBefore(before time.Time, limit int) ([]*models.Post, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    opts := options.Find().
+        SetSort(bson.D{{Key: "deleted_at", Value: 1}}).
+        SetLimit(int64(limit))
+
+    cursor, err := r.collection.Find(ctx, bson.M{"deleted_at": bson.M{"$lt": before}}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    posts := []*models.Post{}
+    if err = cursor.All(ctx, &posts); err != nil {
+        return nil, err
+    }
+
+    return posts, nil
+}
+
+// ReferencedImages reports which of the images with the given URLs any post,
+// live or deleted, uses as its cover image, in its content or in the content
+// of one of its translations. The posts are searched once for all the URLs.
+func (r *PostRepository) ReferencedImages(urls []string) (map[string]bool, error) {
+    referenced := make(map[string]bool, len(urls))
+    if len(urls) == 0 {
+        return referenced, nil
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    quoted := make([]string, len(urls))
+    for i, url := range urls {
+        quoted[i] = regexp.QuoteMeta(url)
+    }
+    pattern := strings.Join(quoted, "|")
+
+    filter := bson.M{"$or": bson.A{
+        bson.M{"image_url": bson.M{"$in": urls}},
+        bson.M{"content_html": bson.M{"$regex": pattern}},
+        bson.M{"variants.content_html": bson.M{"$regex": pattern}},
+    }}
+    opts := options.Find().SetProjection(bson.M{
+        "image_url":             1,
+        "content_html":          1,
+        "variants.content_html": 1,
+    })
+
+    cursor, err := r.collection.Find(ctx, filter, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    for len(referenced) < len(urls) && cursor.Next(ctx) {
+        var post models.Post
+        if err := cursor.Decode(&post); err != nil {
+            return nil, err
+        }
+
+        for _, url := range urls {
+            if referenced[url] {
+                continue
+            }
+            if post.ImageURL == url || strings.Contains(post.ContentHTML, url) {
+                referenced[url] = true
+                continue
+            }
+            for _, variant := range post.Variants {
+                if strings.Contains(variant.ContentHTML, url) {
+                    referenced[url] = true
+                    break
+                }
+            }
+        }
+    }
+
+    return referenced, cursor.Err()
+}
+
+// IncrementCommentCount atomically adds delta to the comment count of the post
+// with the given ID. Counters are not edits, so the version is left as is.
+//
 // The returned error will be non-nil if any error occurred during the update
 // process.
-func (r *PostRepository) Update(id string, updates map[string]interface{}) error {
+func (r *PostRepository) IncrementCommentCount(id primitive.ObjectID, delta int) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    objectID, err := primitive.ObjectIDFromHex(id)
+    _, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{"_id": id},
+        bson.M{"$inc": bson.M{"comment_count": delta}},
+    )
+    return err
+}
+
+// SetCommentsClosed closes or reopens comments on the live post with the
+// given ID. Like the counters, this is not an edit of the post, so its version
+// is left as is and editors holding its ETag can still save it.
+//
+// The returned error will be models.ErrNotFound if no live post exists with
+// that ID.
+func (r *PostRepository) SetCommentsClosed(id primitive.ObjectID, closed bool) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{"_id": id, "deleted_at": nil},
+        bson.M{"$set": bson.M{"comments_closed": closed}},
+    )
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount == 0 {
+        return models.ErrNotFound
+    }
+    return nil
+}
+
+// IncrementReactions atomically adds the given deltas to the reaction counts
+// of the post with the given ID, keyed by reaction type, and their sum to its
+// popularity. It returns the reaction counts after the change. Counters are
+// not edits, so the version is left as is.
+//
+// The returned error will be models.ErrNotFound if the post does not exist,
+// and non-nil if any other error occurred during the update process.
+func (r *PostRepository) IncrementReactions(id primitive.ObjectID, deltas map[string]int64) (map[string]int64, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    inc := bson.M{}
+    var popularity int64
+    for reactionType, delta := range deltas {
+        inc["reactions."+reactionType] = delta
+        popularity += delta
+    }
+    inc["popularity"] = popularity
+
+    opts := options.FindOneAndUpdate().
+        SetProjection(bson.M{"reactions": 1}).
+        SetReturnDocument(options.After)
+
+    var post models.Post
+    err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$inc": inc}, opts).Decode(&post)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return post.Reactions, nil
+}
+
+// ListReactionCounts returns the stored reaction counts of every post that
+// has any, keyed by post ID, including deleted posts.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *PostRepository) ListReactionCounts() (map[primitive.ObjectID]map[string]int64, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+    defer cancel()
+
+    opts := options.Find().SetProjection(bson.M{"reactions": 1})
+
+    cursor, err := r.collection.Find(ctx, bson.M{"reactions": bson.M{"$exists": true}}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    counts := make(map[primitive.ObjectID]map[string]int64)
+    for cursor.Next(ctx) {
+        var post models.Post
+        if err := cursor.Decode(&post); err != nil {
+            return nil, err
+        }
+        counts[post.ID] = post.Reactions
+    }
+
+    return counts, cursor.Err()
+}
+
+// GetReactionCounts returns the reaction counts of the post with the given
+// ID, live or deleted, keyed by reaction type.
+//
+// The returned error will be models.ErrNotFound if the post does not exist.
+func (r *PostRepository) GetReactionCounts(id primitive.ObjectID) (map[string]int64, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    opts := options.FindOne().SetProjection(bson.M{"reactions": 1})
+
+    var post models.Post
+    err := r.collection.FindOne(ctx, bson.M{"_id": id}, opts).Decode(&post)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return post.Reactions, nil
+}
+
+// SetReactions replaces the reaction counts of the post with the given ID,
+// and adds popularityDelta to its popularity, provided its counts are still
+// the expected ones. It is meant to repair counts that drifted from the
+// stored reactions; the version is left as is. It reports whether the counts
+// were replaced: they are not if a reaction was counted in the meantime.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *PostRepository) SetReactions(id primitive.ObjectID, expected, counts map[string]int64, popularityDelta int64) (bool, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    // Counts are matched one by one, since the types of an embedded document
+    // may be stored in any order.
+    filter := bson.M{"_id": id}
+    for reactionType := range models.ReactionEmoji {
+        filter["reactions."+reactionType] = bson.M{"$exists": false}
+    }
+    for reactionType, count := range expected {
+        filter["reactions."+reactionType] = count
+    }
+
+    update := bson.M{"$inc": bson.M{"popularity": popularityDelta}}
+    if len(counts) > 0 {
+        update["$set"] = bson.M{"reactions": counts}
+    } else {
+        update["$unset"] = bson.M{"reactions": ""}
+    }
+
+    result, err := r.collection.UpdateOne(ctx, filter, update)
+    if err != nil {
+        return false, err
+    }
+    return result.MatchedCount > 0, nil
+}
+
+// AddCoAuthor appends the user with the given ID to the co-authors of the
+// post with the given ID. Nothing changes if the user already co-authors the
+// post. Co-authors are not content, so the version is left as is.
+//
+// The returned error will be models.ErrConflict if the user is the post's
+// author or the post already has models.MaxCoAuthors co-authors,
+// models.ErrNotFound if the post does not exist, and non-nil if any other
+// error occurred during the update process.
+func (r *PostRepository) AddCoAuthor(id, userID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{
+            "_id":        id,
+            "deleted_at": nil,
+            "author_id":  bson.M{"$ne": userID},
+            "co_authors": bson.M{"$ne": userID},
+            fmt.Sprintf("co_authors.%d", models.MaxCoAuthors-1): bson.M{"$exists": false},
+        },
+        bson.M{"$push": bson.M{"co_authors": userID}},
+    )
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount > 0 {
+        return nil
+    }
+
+    post, err := r.findOne(bson.M{"_id": id, "deleted_at": nil})
+    if err != nil {
+        return err
+    }
+    if post.AuthorID == userID {
+        return fmt.Errorf("%w: the author of a post cannot co-author it", models.ErrConflict)
+    }
+    for _, coAuthor := range post.CoAuthors {
+        if coAuthor == userID {
+            return nil
+        }
+    }
+    return fmt.Errorf("%w: posts are limited to %d co-authors", models.ErrConflict, models.MaxCoAuthors)
+}
+
+// RemoveCoAuthor removes the user with the given ID from the co-authors of
+// the post with the given ID. The co-authors after them move up.
+//
+// The returned error will be models.ErrNotFound if the user does not
+// co-author the post, and non-nil if any other error occurred during the
+// update process.
+func (r *PostRepository) RemoveCoAuthor(id, userID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{"_id": id, "co_authors": userID},
+        bson.M{"$pull": bson.M{"co_authors": userID}},
+    )
     if err != nil {
         return err
     }
+    if result.MatchedCount == 0 {
+        return models.ErrNotFound
+    }
+
+    return nil
+}
+
+// SetCoAuthors replaces the co-authors of the post with the given ID with the
+// same users in another order. The post is only changed if it still has
+// exactly these co-authors.
+//
+// The returned error will be models.ErrConflict if co-authors were added or
+// removed in the meantime, and non-nil if any other error occurred during the
+// update process.
+func (r *PostRepository) SetCoAuthors(id primitive.ObjectID, userIDs []primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    same := bson.M{"$size": len(userIDs)}
+    if len(userIDs) > 0 {
+        same["$all"] = userIDs
+    }
 
-    _, err = r.collection.UpdateOne(
+    result, err := r.collection.UpdateOne(
         ctx,
-        bson.M{"_id": objectID},
-        bson.M{"$set": updates},
+        bson.M{"_id": id, "deleted_at": nil, "co_authors": same},
+        bson.M{"$set": bson.M{"co_authors": userIDs}},
     )
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount == 0 {
+        return fmt.Errorf("%w: the co-authors changed, fetch them again and retry", models.ErrConflict)
+    }
+
+    return nil
+}
+
+// ListUnsummarized returns up to limit posts, live or deleted, that have no
+// word count yet because they were written before posts were summarized.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *PostRepository) ListUnsummarized(limit int) ([]*models.Post, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    opts := options.Find().SetLimit(int64(limit))
+
+    cursor, err := r.collection.Find(ctx, bson.M{"word_count": bson.M{"$exists": false}}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    posts := []*models.Post{}
+    if err = cursor.All(ctx, &posts); err != nil {
+        return nil, err
+    }
+
+    return posts, nil
+}
+
+// ListWithoutSlug returns up to limit posts, live or deleted, that have no
+// slug because they were written before posts had one.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *PostRepository) ListWithoutSlug(limit int) ([]*models.Post, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    opts := options.Find().SetLimit(int64(limit))
+
+    cursor, err := r.collection.Find(ctx, bson.M{"slug": bson.M{"$in": bson.A{nil, ""}}}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    posts := []*models.Post{}
+    if err = cursor.All(ctx, &posts); err != nil {
+        return nil, err
+    }
+
+    return posts, nil
+}
+
+// SetSlug sets the slug of the post with the given ID, live or deleted, if it
+// still has none, and reports whether it was set. Like SetSummary, it leaves
+// the version of the post untouched.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *PostRepository) SetSlug(id primitive.ObjectID, slug string) (bool, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{"_id": id, "slug": bson.M{"$in": bson.A{nil, ""}}},
+        bson.M{"$set": bson.M{"slug": slug}},
+    )
+    if err != nil {
+        return false, err
+    }
+
+    return result.MatchedCount > 0, nil
+}
+
+// SetSummary sets the given summary fields of the post with the given ID,
+// live or deleted. Like the counters, the summary is derived data, so the
+// version of the post is left untouched.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *PostRepository) SetSummary(id primitive.ObjectID, fields map[string]interface{}) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
     return err
 }
 
-// Delete deletes the post with the given ID from the "posts" collection in the
-// MongoDB database.
+// Delete permanently deletes the post with the given ID from the "posts"
+// collection in the MongoDB database. Posts are normally moved to the trash
+// with SoftDelete first, and only deleted once they have been purged.
 //
 // The returned error will be non-nil if any error occurred during the delete
 // process.
@@ -107,40 +689,416 @@ func (r *PostRepository) Delete(id string) error {
     return err
 }
 
-// List returns a slice of posts, sorted by created_at in descending order,
-// limited to the given number of items, and starting from the given page.
+// List returns a page of posts matching the given filter, in the sort order
+// of the page request, with _id as a tie-breaker. Posts are sorted by
+// created_at in descending order by default. Deleted posts are only listed
+// when the filter asks for the trash.
 //
-// The page and limit parameters are 1-indexed, so the first page should have
-// page = 1 and limit = 10 to get the first 10 results.
+// With a cursor, the page is read with a keyset query starting right after
+// the cursor (or right before it for a backward cursor), which stays fast on
+// deep pages and does not skip or repeat posts when new ones are inserted.
+// Without a cursor, the 1-indexed page number is used. Either way, cursors to
+// the next and previous pages are returned, and the total number of matching
+// posts is counted when requested.
 //
-// The returned error will be non-nil if any error occurred during the find
-// process.
-func (r *PostRepository) List(page, limit int) ([]*models.Post, error) {
+// The returned error will be models.ErrInvalidInput if the sort field is not
+// supported or the cursor is malformed, and non-nil if any other error
+// occurred during the find process.
+func (r *PostRepository) List(filter models.PostFilter, req models.PageRequest) (*models.PostPage, error) {
+    return r.ListFields(filter, req)
+}
+
+// ListFields is List loading only the given fields of the posts, besides
+// their _id and the field they are sorted on, or every field if none is
+// given. It spares reading the content of every post when only a few fields
+// are needed.
+func (r *PostRepository) ListFields(filter models.PostFilter, req models.PageRequest, fields ...string) (*models.PostPage, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    skip := (page - 1) * limit
+    sort, err := resolvePostSort(req.Sort)
+    if err != nil {
+        return nil, err
+    }
+
+    query := buildPostFilter(filter)
+    backward := false
+    opts := options.Find().SetLimit(int64(req.Limit + 1))
 
-    opts := options.Find().
-        SetSort(bson.D{{Key: "created_at", Value: -1}}).
-        SetSkip(int64(skip)).
-        SetLimit(int64(limit))
+    if req.Cursor != "" {
+        c, id, err := decodeCursor(req.Cursor, sort.Field)
+        if err != nil {
+            return nil, err
+        }
+        backward = c.Backward
+        query = bson.M{"$and": bson.A{query, keysetFilter(c, id, sort)}}
+    } else if req.Page > 1 {
+        opts.SetSkip(int64((req.Page - 1) * req.Limit))
+    }
+
+    // Backward pages are read in reverse order, then flipped back.
+    sortOrder := 1
+    if sort.Descending != backward {
+        sortOrder = -1
+    }
+    opts.SetSort(bson.D{{Key: sort.Field, Value: sortOrder}, {Key: "_id", Value: sortOrder}})
+    if len(fields) > 0 {
+        projection := bson.M{sort.Field: 1}
+        for _, field := range fields {
+            projection[field] = 1
+        }
+        opts.SetProjection(projection)
+    }
 
-    cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+    cursor, err := r.collection.Find(ctx, query, opts)
     if err != nil {
         return nil, err
     }
     defer cursor.Close(ctx)
 
-    var posts []*models.Post
+    posts := []*models.Post{}
     if err = cursor.All(ctx, &posts); err != nil {
         return nil, err
     }
 
-    return posts, nil
+    // One post more than requested was fetched to know whether the list
+    // goes on in the reading direction.
+    more := len(posts) > req.Limit
+    if more {
+        posts = posts[:req.Limit]
+    }
+    if backward {
+        for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+            posts[i], posts[j] = posts[j], posts[i]
+        }
+    }
+
+    page := &models.PostPage{Posts: posts}
+    if len(posts) > 0 {
+        first, last := posts[0], posts[len(posts)-1]
+        hasNext := more
+        hasPrev := req.Cursor != "" || req.Page > 1
+        if backward {
+            hasNext, hasPrev = true, more
+        }
+        if hasNext {
+            page.Pagination.NextCursor = encodeCursor(last, sort.Field, false)
+        }
+        if hasPrev {
+            page.Pagination.PrevCursor = encodeCursor(first, sort.Field, true)
+        }
+        page.Pagination.HasMore = hasNext
+    }
+
+    if req.IncludeTotal {
+        total, err := r.collection.CountDocuments(ctx, buildPostFilter(filter))
+        if err != nil {
+            return nil, err
+        }
+        page.Pagination.Total = &total
+    }
+
+    return page, nil
+}
+
+// ClaimDueScheduled atomically flips a single scheduled post whose
+// published_at is at or before now to published, and returns it.
+//
+// Because the status change is done with a single FindOneAndUpdate, a post is
+// claimed by exactly one caller even when several server instances run the
+// scheduler concurrently. The returned post is nil when no post is due.
+func (r *PostRepository) ClaimDueScheduled(now time.Time) (*models.Post, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    opts := options.FindOneAndUpdate().
+        SetSort(bson.D{{Key: "published_at", Value: 1}}).
+        SetReturnDocument(options.After)
+
+    var post models.Post
+    err := r.collection.FindOneAndUpdate(
+        ctx,
+        bson.M{
+            "status":       models.PostStatusScheduled,
+            "published_at": bson.M{"$lte": now},
+            "deleted_at":   nil,
+        },
+        bson.M{
+            "$set": bson.M{
+                "status":     models.PostStatusPublished,
+                "updated_at": now,
+            },
+            "$inc": bson.M{"version": 1},
+        },
+        opts,
+    ).Decode(&post)
+    if err == mongo.ErrNoDocuments {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &post, nil
+}
+
+// ListTags returns every tag used by published public posts, together with
+// the number of such posts using it, most used first.
+//
+// The returned error will be non-nil if any error occurred during the
+// aggregation.
+func (r *PostRepository) ListTags() ([]*models.TagCount, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    pipeline := mongo.Pipeline{
+        {{Key: "$match", Value: buildPostFilter(models.PostFilter{
+            Statuses:     []string{models.PostStatusPublished},
+            Visibilities: []string{models.VisibilityPublic},
+        })}},
+        {{Key: "$unwind", Value: "$tags"}},
+        {{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+        {{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+    }
+
+    cursor, err := r.collection.Aggregate(ctx, pipeline)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    tags := []*models.TagCount{}
+    if err = cursor.All(ctx, &tags); err != nil {
+        return nil, err
+    }
+
+    return tags, nil
+}
+
+// CountByTag returns the number of posts, in any status, that use the given
+// tag.
+//
+// The returned error will be non-nil if any error occurred during the count.
+func (r *PostRepository) CountByTag(tag string) (int64, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    return r.collection.CountDocuments(ctx, bson.M{"tags": tag})
+}
+
+// RenameTag replaces the tag from with the tag to on every post using it, live
+// or deleted, and returns the IDs of the posts changed, which on error are
+// the posts renamed before the error occurred. Posts that already have both
+// tags end up with a single one, which is how two tags are merged. The order
+// of the other tags is kept.
+//
+// Each post is renamed atomically, and the posts are renamed in batches
+// without a transaction, so that a standalone MongoDB server can run it. A
+// rename that fails part way is finished by running it again.
+func (r *PostRepository) RenameTag(from, to string) ([]primitive.ObjectID, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    // Replace the tag in place, then drop the duplicate left behind when the
+    // post already had the target tag.
+    rename := bson.M{"$map": bson.M{
+        "input": "$tags",
+        "in": bson.M{"$cond": bson.A{
+            bson.M{"$eq": bson.A{"$$this", from}}, to, "$$this",
+        }},
+    }}
+    dedupe := bson.M{"$reduce": bson.M{
+        "input":        rename,
+        "initialValue": bson.A{},
+        "in": bson.M{"$cond": bson.A{
+            bson.M{"$in": bson.A{"$$this", "$$value"}},
+            "$$value",
+            bson.M{"$concatArrays": bson.A{"$$value", bson.A{"$$this"}}},
+        }},
+    }}
+    update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+        "tags":    dedupe,
+        "version": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+    }}}}
+
+    opts := options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(renameBatchSize)
+    changed := []primitive.ObjectID{}
+    for {
+        cursor, err := r.collection.Find(ctx, bson.M{"tags": from}, opts)
+        if err != nil {
+            return changed, err
+        }
+        var posts []struct {
+            ID primitive.ObjectID `bson:"_id"`
+        }
+        if err := cursor.All(ctx, &posts); err != nil {
+            return changed, err
+        }
+        if len(posts) == 0 {
+            return changed, nil
+        }
+
+        ids := make([]primitive.ObjectID, len(posts))
+        for i, post := range posts {
+            ids[i] = post.ID
+        }
+
+        // Posts renamed meanwhile no longer match, so they are not renamed
+        // twice.
+        _, err = r.collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "tags": from}, update)
+        if err != nil {
+            return changed, err
+        }
+        changed = append(changed, ids...)
+    }
+}
+
+// UnsetCategory removes the category with the given ID from every post in it.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *PostRepository) UnsetCategory(categoryID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.UpdateMany(
+        ctx,
+        bson.M{"category_id": categoryID},
+        bson.M{"$unset": bson.M{"category_id": ""}, "$inc": bson.M{"version": 1}},
+    )
+    return err
+}
+
+// EnsureIndexes creates the indexes used by the post queries. It is safe to
+// call on every start-up, as existing indexes are left untouched.
+//
+// Posts created before popularity existed are given a popularity of 0 first,
+// so that keyset pagination on popularity does not skip them.
+func (r *PostRepository) EnsureIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.collection.UpdateMany(
+        ctx,
+        bson.M{"popularity": bson.M{"$exists": false}},
+        bson.M{"$set": bson.M{"popularity": 0}},
+    )
+    if err != nil {
+        return err
+    }
+
+    // The list endpoint sorts published posts on any of the sort fields,
+    // with _id as a tie-breaker.
+    _, err = r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+        {Keys: bson.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}},
+        {Keys: bson.D{{Key: "status", Value: 1}, {Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+        {Keys: bson.D{{Key: "status", Value: 1}, {Key: "popularity", Value: -1}, {Key: "_id", Value: -1}}},
+        {Keys: bson.D{{Key: "status", Value: 1}, {Key: "published_at", Value: 1}}},
+        {Keys: bson.D{{Key: "author_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+        {Keys: bson.D{{Key: "co_authors", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+        {Keys: bson.D{{Key: "tags", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+        {Keys: bson.D{{Key: "category_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+        {
+            // Only deleted posts have a deleted_at, so the index stays small.
+            Keys: bson.D{{Key: "deleted_at", Value: 1}},
+            Options: options.Index().
+                SetPartialFilterExpression(bson.M{"deleted_at": bson.M{"$type": "date"}}),
+        },
+        {
+            // Posts created before slugs existed have none, so only string
+            // slugs take part in the uniqueness check.
+            Keys: bson.D{{Key: "slug", Value: 1}},
+            Options: options.Index().
+                SetUnique(true).
+                SetPartialFilterExpression(bson.M{"slug": bson.M{"$type": "string"}}),
+        },
+        {
+            // Only imported posts have a source, and each source is imported
+            // once.
+            Keys: bson.D{{Key: "source_id", Value: 1}},
+            Options: options.Index().
+                SetUnique(true).
+                SetPartialFilterExpression(bson.M{"source_id": bson.M{"$type": "string"}}),
+        },
+    })
+    return err
+}
+
+// buildPostFilter translates a models.PostFilter into a MongoDB query.
+func buildPostFilter(filter models.PostFilter) bson.M {
+    query := bson.M{"deleted_at": nil}
+    if filter.Deleted {
+        query["deleted_at"] = bson.M{"$ne": nil}
+    }
+
+    if !filter.AuthorID.IsZero() {
+        if filter.ExcludeCoAuthored {
+            query["author_id"] = filter.AuthorID
+        } else {
+            query["$or"] = bson.A{
+                bson.M{"author_id": filter.AuthorID},
+                bson.M{"co_authors": filter.AuthorID},
+            }
+        }
+    }
+
+    if len(filter.Statuses) > 0 {
+        statuses := make([]interface{}, 0, len(filter.Statuses)+1)
+        for _, status := range filter.Statuses {
+            statuses = append(statuses, status)
+            // Posts created before statuses existed have no status field
+            // and are considered published.
+            if status == models.PostStatusPublished {
+                statuses = append(statuses, nil)
+            }
+        }
+        query["status"] = bson.M{"$in": statuses}
+    }
+
+    if len(filter.Visibilities) > 0 {
+        visibilities := make([]interface{}, 0, len(filter.Visibilities)+1)
+        for _, visibility := range filter.Visibilities {
+            visibilities = append(visibilities, visibility)
+            // Posts created before visibilities existed have no visibility
+            // field and are public.
+            if visibility == models.VisibilityPublic {
+                visibilities = append(visibilities, nil)
+            }
+        }
+        query["visibility"] = bson.M{"$in": visibilities}
+    }
+
+    if len(filter.Tags) > 0 {
+        query["tags"] = bson.M{"$all": filter.Tags}
+    }
+
+    if len(filter.CategoryIDs) > 0 {
+        query["category_id"] = bson.M{"$in": filter.CategoryIDs}
+    }
+
+    if dates := dateRange(filter.CreatedAfter, filter.CreatedBefore); dates != nil {
+        query["created_at"] = dates
+    }
+
+    if dates := dateRange(filter.UpdatedAfter, filter.UpdatedBefore); dates != nil {
+        query["updated_at"] = dates
+    }
+
+    if filter.HasImage != nil {
+        noImage := bson.A{"", nil}
+        if *filter.HasImage {
+            query["image_url"] = bson.M{"$nin": noImage}
+        } else {
+            query["image_url"] = bson.M{"$in": noImage}
+        }
+    }
+
+    return query
 }
 
 // GetByAuthor returns a slice of posts, filtered by the given author ID.
+// Deleted posts are left out.
 //
 // The returned error will be non-nil if any error occurred during the find
 // process.
@@ -153,7 +1111,7 @@ func (r *PostRepository) GetByAuthor(authorID string) ([]*models.Post, error) {
         return nil, err
     }
 
-    cursor, err := r.collection.Find(ctx, bson.M{"author_id": objectID})
+    cursor, err := r.collection.Find(ctx, bson.M{"author_id": objectID, "deleted_at": nil})
     if err != nil {
         return nil, err
     }
@@ -165,4 +1123,54 @@ func (r *PostRepository) GetByAuthor(authorID string) ([]*models.Post, error) {
     }
 
     return posts, nil
+}
+
+// missingOrConflict tells why a conditional write to the post with the given
+// ID matched nothing: models.ErrVersionConflict if the live post exists at
+// another version, models.ErrNotFound otherwise.
+func (r *PostRepository) missingOrConflict(id primitive.ObjectID, version *int64) error {
+    if version == nil {
+        return models.ErrNotFound
+    }
+
+    if _, err := r.findOne(bson.M{"_id": id, "deleted_at": nil}); err != nil {
+        return err
+    }
+    return models.ErrVersionConflict
+}
+
+// findOne returns the post matching the given query.
+//
+// The returned error will be models.ErrNotFound if no post matches.
+func (r *PostRepository) findOne(query bson.M) (*models.Post, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var post models.Post
+    err := r.collection.FindOne(ctx, query).Decode(&post)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &post, nil
+}
+
+// dateRange returns an inclusive MongoDB range condition between the given
+// times, either of which may be nil, or nil if both are.
+func dateRange(after, before *time.Time) bson.M {
+    if after == nil && before == nil {
+        return nil
+    }
+
+    dates := bson.M{}
+    if after != nil {
+        dates["$gte"] = *after
+    }
+    if before != nil {
+        dates["$lte"] = *before
+    }
+    return dates
 }
\ No newline at end of file
@@ -0,0 +1,203 @@
+package repositories
+
+import (
+    "context"
+    "time"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReactionRepository struct {
+    collection *mongo.Collection
+}
+
+// NewReactionRepository returns a new instance of ReactionRepository.
+//
+// The ReactionRepository is used to interact with the "reactions" collection
+// in the MongoDB database.
+func NewReactionRepository(db *mongo.Database) *ReactionRepository {
+    return &ReactionRepository{
+        collection: db.Collection("reactions"),
+    }
+}
+
+// Set records that the user with the given ID reacts to the post with the
+// given ID with the given reaction type, replacing any previous reaction of
+// the user to the post. It returns the type of the replaced reaction, or an
+// empty string if the user had not reacted to the post yet.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *ReactionRepository) Set(postID, userID primitive.ObjectID, reactionType string) (string, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    now := time.Now()
+    opts := options.FindOneAndUpdate().
+        SetUpsert(true).
+        SetReturnDocument(options.Before)
+
+    // Two concurrent upserts of the same reaction can both try to insert it,
+    // in which case the unique index rejects one of them. By then the
+    // reaction exists, so trying again updates it.
+    var previous models.Reaction
+    var err error
+    for attempt := 0; attempt < 2; attempt++ {
+        err = r.collection.FindOneAndUpdate(
+            ctx,
+            bson.M{"post_id": postID, "user_id": userID},
+            bson.M{
+                "$set":         bson.M{"type": reactionType, "updated_at": now},
+                "$setOnInsert": bson.M{"created_at": now},
+            },
+            opts,
+        ).Decode(&previous)
+        if !mongo.IsDuplicateKeyError(err) {
+            break
+        }
+    }
+    if err == mongo.ErrNoDocuments {
+        return "", nil
+    }
+    if err != nil {
+        return "", err
+    }
+
+    return previous.Type, nil
+}
+
+// Delete deletes the reaction of the given type of the user with the given ID
+// to the post with the given ID.
+//
+// The returned error will be models.ErrNotFound if the user has not reacted to
+// the post with that type, and non-nil if any other error occurred during the
+// delete process.
+func (r *ReactionRepository) Delete(postID, userID primitive.ObjectID, reactionType string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.DeleteOne(ctx, bson.M{
+        "post_id": postID,
+        "user_id": userID,
+        "type":    reactionType,
+    })
+    if err != nil {
+        return err
+    }
+    if result.DeletedCount == 0 {
+        return models.ErrNotFound
+    }
+
+    return nil
+}
+
+// GetType returns the type of the reaction of the user with the given ID to
+// the post with the given ID, or an empty string if the user has not reacted
+// to the post.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *ReactionRepository) GetType(postID, userID primitive.ObjectID) (string, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var reaction models.Reaction
+    err := r.collection.FindOne(ctx, bson.M{"post_id": postID, "user_id": userID}).Decode(&reaction)
+    if err == mongo.ErrNoDocuments {
+        return "", nil
+    }
+    if err != nil {
+        return "", err
+    }
+
+    return reaction.Type, nil
+}
+
+// CountAll returns the number of reactions of each type to every post that
+// has reactions, keyed by post ID.
+//
+// The returned error will be non-nil if any error occurred during the
+// aggregation.
+func (r *ReactionRepository) CountAll() (map[primitive.ObjectID]map[string]int64, error) {
+    return r.count(bson.M{})
+}
+
+// CountByPost returns the number of reactions of each type to the post with
+// the given ID.
+//
+// The returned error will be non-nil if any error occurred during the
+// aggregation.
+func (r *ReactionRepository) CountByPost(postID primitive.ObjectID) (map[string]int64, error) {
+    counts, err := r.count(bson.M{"post_id": postID})
+    if err != nil {
+        return nil, err
+    }
+    return counts[postID], nil
+}
+
+// count returns the number of reactions of each type matching the given
+// filter, keyed by post ID.
+func (r *ReactionRepository) count(filter bson.M) (map[primitive.ObjectID]map[string]int64, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+    defer cancel()
+
+    cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+        {{Key: "$match", Value: filter}},
+        {{Key: "$group", Value: bson.M{
+            "_id":   bson.M{"post_id": "$post_id", "type": "$type"},
+            "count": bson.M{"$sum": 1},
+        }}},
+    })
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    counts := make(map[primitive.ObjectID]map[string]int64)
+    for cursor.Next(ctx) {
+        var row struct {
+            ID struct {
+                PostID primitive.ObjectID `bson:"post_id"`
+                Type   string             `bson:"type"`
+            } `bson:"_id"`
+            Count int64 `bson:"count"`
+        }
+        if err := cursor.Decode(&row); err != nil {
+            return nil, err
+        }
+        if counts[row.ID.PostID] == nil {
+            counts[row.ID.PostID] = make(map[string]int64)
+        }
+        counts[row.ID.PostID][row.ID.Type] = row.Count
+    }
+
+    return counts, cursor.Err()
+}
+
+// DeleteByPost deletes every reaction to the post with the given ID.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *ReactionRepository) DeleteByPost(postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.DeleteMany(ctx, bson.M{"post_id": postID})
+    return err
+}
+
+// EnsureIndexes creates the indexes used by the reaction queries. The unique
+// index guarantees a single reaction per user and post. It is safe to call on
+// every start-up, as existing indexes are left untouched.
+func (r *ReactionRepository) EnsureIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {Keys: bson.D{{Key: "post_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+    })
+    return err
+}
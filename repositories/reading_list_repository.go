@@ -0,0 +1,288 @@
+package repositories
+
+import (
+    "context"
+    "fmt"
+    "time"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReadingListRepository struct {
+    collection *mongo.Collection
+}
+
+// NewReadingListRepository returns a new instance of ReadingListRepository.
+//
+// The ReadingListRepository is used to interact with the "reading_lists"
+// collection in the MongoDB database. The posts of a list are stored in the
+// list document, in order.
+func NewReadingListRepository(db *mongo.Database) *ReadingListRepository {
+    return &ReadingListRepository{
+        collection: db.Collection("reading_lists"),
+    }
+}
+
+// Create creates a new reading list in the "reading_lists" collection.
+//
+// The returned error will be non-nil if any error occurred during the create
+// process.
+func (r *ReadingListRepository) Create(list *models.ReadingList) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.InsertOne(ctx, list)
+    if err != nil {
+        return err
+    }
+
+    list.ID = result.InsertedID.(primitive.ObjectID)
+    return nil
+}
+
+// GetByID returns a reading list by the given ID.
+//
+// The returned error will be models.ErrNotFound if the ID is malformed or no
+// reading list exists with that ID.
+func (r *ReadingListRepository) GetByID(id string) (*models.ReadingList, error) {
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return nil, models.ErrNotFound
+    }
+
+    return r.findOne(bson.M{"_id": objectID})
+}
+
+// GetByShareToken returns the shared reading list with the given share token.
+//
+// The returned error will be models.ErrNotFound if no shared list has that
+// token.
+func (r *ReadingListRepository) GetByShareToken(token string) (*models.ReadingList, error) {
+    return r.findOne(bson.M{"share_token": token, "shared": true})
+}
+
+// ListByOwner returns the reading lists of the user with the given ID, newest
+// first.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *ReadingListRepository) ListByOwner(ownerID primitive.ObjectID) ([]*models.ReadingList, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+    cursor, err := r.collection.Find(ctx, bson.M{"owner_id": ownerID}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    lists := []*models.ReadingList{}
+    if err := cursor.All(ctx, &lists); err != nil {
+        return nil, err
+    }
+
+    return lists, nil
+}
+
+// Update sets the given fields of the reading list with the given ID.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *ReadingListRepository) Update(id primitive.ObjectID, updates map[string]interface{}) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+    return err
+}
+
+// SetSharing shares the reading list with the given ID under the given share
+// token, or stops sharing it if the token is empty. A list that stops being
+// shared loses its token, so that the old link stays dead if the list is
+// shared again.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *ReadingListRepository) SetSharing(id primitive.ObjectID, token string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    update := bson.M{"$set": bson.M{"shared": true, "share_token": token, "updated_at": time.Now()}}
+    if token == "" {
+        update = bson.M{
+            "$set":   bson.M{"shared": false, "updated_at": time.Now()},
+            "$unset": bson.M{"share_token": ""},
+        }
+    }
+
+    _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+    return err
+}
+
+// Delete deletes the reading list with the given ID.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *ReadingListRepository) Delete(id primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+    return err
+}
+
+// AddItem appends the given item to the reading list with the given ID,
+// unless the post is already in the list, in which case nothing changes.
+//
+// The returned error will be models.ErrConflict if the list already holds
+// models.MaxReadingListItems posts, models.ErrNotFound if the list does not
+// exist, and non-nil if any other error occurred during the update process.
+func (r *ReadingListRepository) AddItem(id primitive.ObjectID, item models.ReadingListItem) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{
+            "_id":           id,
+            "items.post_id": bson.M{"$ne": item.PostID},
+            fmt.Sprintf("items.%d", models.MaxReadingListItems-1): bson.M{"$exists": false},
+        },
+        bson.M{
+            "$push": bson.M{"items": item},
+            "$set":  bson.M{"updated_at": item.AddedAt},
+        },
+    )
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount > 0 {
+        return nil
+    }
+
+    list, err := r.findOne(bson.M{"_id": id})
+    if err != nil {
+        return err
+    }
+    for _, existing := range list.Items {
+        if existing.PostID == item.PostID {
+            return nil
+        }
+    }
+    return fmt.Errorf("%w: reading lists are limited to %d posts", models.ErrConflict, models.MaxReadingListItems)
+}
+
+// RemoveItem removes the post with the given ID from the reading list with
+// the given ID.
+//
+// The returned error will be models.ErrNotFound if the post is not in the
+// list, and non-nil if any other error occurred during the update process.
+func (r *ReadingListRepository) RemoveItem(id, postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{"_id": id, "items.post_id": postID},
+        bson.M{
+            "$pull": bson.M{"items": bson.M{"post_id": postID}},
+            "$set":  bson.M{"updated_at": time.Now()},
+        },
+    )
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount == 0 {
+        return models.ErrNotFound
+    }
+
+    return nil
+}
+
+// SetItems replaces the items of the reading list with the given ID with the
+// same posts in another order. The list is only changed if it still holds
+// exactly these posts.
+//
+// The returned error will be models.ErrConflict if posts were added to or
+// removed from the list in the meantime, and non-nil if any other error
+// occurred during the update process.
+func (r *ReadingListRepository) SetItems(id primitive.ObjectID, items []models.ReadingListItem) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    postIDs := make([]primitive.ObjectID, len(items))
+    for i, item := range items {
+        postIDs[i] = item.PostID
+    }
+
+    query := bson.M{"_id": id, "items": bson.M{"$size": len(items)}}
+    if len(postIDs) > 0 {
+        query["items.post_id"] = bson.M{"$all": postIDs}
+    }
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        query,
+        bson.M{"$set": bson.M{"items": items, "updated_at": time.Now()}},
+    )
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount == 0 {
+        return fmt.Errorf("%w: the reading list changed, fetch it again and retry", models.ErrConflict)
+    }
+
+    return nil
+}
+
+// DeleteByPost removes the post with the given ID from every reading list.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *ReadingListRepository) DeleteByPost(postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.collection.UpdateMany(
+        ctx,
+        bson.M{"items.post_id": postID},
+        bson.M{"$pull": bson.M{"items": bson.M{"post_id": postID}}},
+    )
+    return err
+}
+
+// EnsureIndexes creates the indexes used by the reading list queries. It is
+// safe to call on every start-up, as existing indexes are left untouched.
+func (r *ReadingListRepository) EnsureIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}}},
+        {Keys: bson.D{{Key: "share_token", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+        {Keys: bson.D{{Key: "items.post_id", Value: 1}}},
+    })
+    return err
+}
+
+// findOne returns the single reading list matching the given query.
+func (r *ReadingListRepository) findOne(query bson.M) (*models.ReadingList, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var list models.ReadingList
+    err := r.collection.FindOne(ctx, query).Decode(&list)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &list, nil
+}
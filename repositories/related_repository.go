@@ -0,0 +1,78 @@
+package repositories
+
+import (
+    "context"
+    "time"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RelatedRepository struct {
+    collection *mongo.Collection
+}
+
+// NewRelatedRepository returns a new instance of RelatedRepository.
+//
+// The RelatedRepository is used to interact with the "related_posts"
+// collection in the MongoDB database, which caches the related posts of each
+// post under the post's ID.
+func NewRelatedRepository(db *mongo.Database) *RelatedRepository {
+    return &RelatedRepository{
+        collection: db.Collection("related_posts"),
+    }
+}
+
+// Get returns the cached related posts of the post with the given ID.
+//
+// The returned error will be models.ErrNotFound if they were not computed
+// yet, and non-nil if any other error occurred during the get process.
+func (r *RelatedRepository) Get(postID primitive.ObjectID) (*models.RelatedPosts, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var related models.RelatedPosts
+    err := r.collection.FindOne(ctx, bson.M{"_id": postID}).Decode(&related)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &related, nil
+}
+
+// Save stores the given related posts, replacing those previously computed
+// for the same post.
+//
+// The returned error will be non-nil if any error occurred during the save
+// process.
+func (r *RelatedRepository) Save(related *models.RelatedPosts) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.ReplaceOne(
+        ctx,
+        bson.M{"_id": related.PostID},
+        related,
+        options.Replace().SetUpsert(true),
+    )
+    return err
+}
+
+// DeleteByPost deletes the cached related posts of the post with the given
+// ID. Other posts may still list it until they are computed again; readers
+// of the cache skip the posts they can no longer find.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *RelatedRepository) DeleteByPost(postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.DeleteOne(ctx, bson.M{"_id": postID})
+    return err
+}
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+    "context"
+    "time"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxRevisionInsertAttempts is the number of times Create retries when a
+// concurrent write took the revision number it picked.
+const maxRevisionInsertAttempts = 5
+
+type RevisionRepository struct {
+    collection *mongo.Collection
+    retention  int
+}
+
+// NewRevisionRepository returns a new instance of RevisionRepository.
+//
+// The RevisionRepository is used to interact with the "post_revisions"
+// collection in the MongoDB database. At most retention revisions are kept
+// per post, the oldest being dropped first; a retention of zero or less keeps
+// every revision.
+func NewRevisionRepository(db *mongo.Database, retention int) *RevisionRepository {
+    return &RevisionRepository{
+        collection: db.Collection("post_revisions"),
+        retention:  retention,
+    }
+}
+
+// Create stores a new revision, numbering it after the latest revision of
+// the same post, then drops the revisions beyond the retention limit.
+//
+// The returned error will be non-nil if any error occurred during the create
+// process.
+func (r *RevisionRepository) Create(revision *models.PostRevision) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    for attempt := 1; ; attempt++ {
+        latest, err := r.latestNumber(ctx, revision.PostID)
+        if err != nil {
+            return err
+        }
+
+        revision.ID = primitive.NewObjectID()
+        revision.Number = latest + 1
+        _, err = r.collection.InsertOne(ctx, revision)
+        if err == nil {
+            break
+        }
+        if !mongo.IsDuplicateKeyError(err) || attempt == maxRevisionInsertAttempts {
+            return err
+        }
+    }
+
+    return r.prune(ctx, revision.PostID, revision.Number)
+}
+
+// Exists reports whether the post with the given ID has any revision.
+func (r *RevisionRepository) Exists(postID primitive.ObjectID) (bool, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    count, err := r.collection.CountDocuments(ctx, bson.M{"post_id": postID}, options.Count().SetLimit(1))
+    return count > 0, err
+}
+
+// List returns the revisions of the post with the given ID, newest first.
+// The content of the revisions is left out.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *RevisionRepository) List(postID primitive.ObjectID) ([]*models.PostRevision, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    opts := options.Find().
+        SetSort(bson.D{{Key: "number", Value: -1}}).
+        SetProjection(bson.M{"content": 0})
+
+    cursor, err := r.collection.Find(ctx, bson.M{"post_id": postID}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    revisions := []*models.PostRevision{}
+    if err := cursor.All(ctx, &revisions); err != nil {
+        return nil, err
+    }
+
+    return revisions, nil
+}
+
+// GetByNumber returns the revision with the given number of the post with the
+// given ID.
+//
+// The returned error will be models.ErrNotFound if there is no such revision.
+func (r *RevisionRepository) GetByNumber(postID primitive.ObjectID, number int) (*models.PostRevision, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var revision models.PostRevision
+    err := r.collection.FindOne(ctx, bson.M{"post_id": postID, "number": number}).Decode(&revision)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &revision, nil
+}
+
+// DeleteByPost deletes every revision of the post with the given ID.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *RevisionRepository) DeleteByPost(postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.DeleteMany(ctx, bson.M{"post_id": postID})
+    return err
+}
+
+// EnsureIndexes creates the indexes used by the revision queries. The unique
+// index also keeps concurrent writes from giving two revisions the same
+// number. It is safe to call on every start-up, as existing indexes are left
+// untouched.
+func (r *RevisionRepository) EnsureIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys:    bson.D{{Key: "post_id", Value: 1}, {Key: "number", Value: -1}},
+        Options: options.Index().SetUnique(true),
+    })
+    return err
+}
+
+// latestNumber returns the number of the latest revision of the given post,
+// or 0 if it has none.
+func (r *RevisionRepository) latestNumber(ctx context.Context, postID primitive.ObjectID) (int, error) {
+    opts := options.FindOne().
+        SetSort(bson.D{{Key: "number", Value: -1}}).
+        SetProjection(bson.M{"number": 1})
+
+    var latest models.PostRevision
+    err := r.collection.FindOne(ctx, bson.M{"post_id": postID}, opts).Decode(&latest)
+    if err == mongo.ErrNoDocuments {
+        return 0, nil
+    }
+    if err != nil {
+        return 0, err
+    }
+
+    return latest.Number, nil
+}
+
+// prune deletes the revisions of the given post that fall out of the
+// retention limit, given the number of its latest revision.
+func (r *RevisionRepository) prune(ctx context.Context, postID primitive.ObjectID, latest int) error {
+    if r.retention <= 0 || latest <= r.retention {
+        return nil
+    }
+
+    _, err := r.collection.DeleteMany(ctx, bson.M{
+        "post_id": postID,
+        "number":  bson.M{"$lte": latest - r.retention},
+    })
+    return err
+}
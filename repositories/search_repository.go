@@ -0,0 +1,110 @@
+package repositories
+
+import (
+    "context"
+    "time"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSearchIndex searches posts with a MongoDB text index on the "posts"
+// collection. MongoDB keeps the text index up to date by itself, so Index and
+// Remove do nothing.
+type MongoSearchIndex struct {
+    collection *mongo.Collection
+}
+
+// NewMongoSearchIndex returns a new instance of MongoSearchIndex.
+//
+// The MongoSearchIndex runs text searches on the "posts" collection in the
+// MongoDB database.
+func NewMongoSearchIndex(db *mongo.Database) *MongoSearchIndex {
+    return &MongoSearchIndex{
+        collection: db.Collection("posts"),
+    }
+}
+
+// Index does nothing, as MongoDB maintains the text index on writes.
+func (r *MongoSearchIndex) Index(post *models.Post) error {
+    return nil
+}
+
+// Remove does nothing, as MongoDB maintains the text index on writes.
+func (r *MongoSearchIndex) Remove(postID primitive.ObjectID) error {
+    return nil
+}
+
+// Search returns the published public posts matching the given query, best
+// matches first, together with the total number of matches.
+//
+// The query text follows the MongoDB $text syntax: words are OR-ed, "quoted
+// phrases" must appear as is, and -words are excluded.
+func (r *MongoSearchIndex) Search(query models.SearchQuery) ([]*models.SearchHit, int64, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    filter := buildPostFilter(models.PostFilter{
+        AuthorID:     query.AuthorID,
+        Statuses:     []string{models.PostStatusPublished},
+        Visibilities: []string{models.VisibilityPublic},
+        Tags:         query.Tags,
+    })
+    filter["$text"] = bson.M{"$search": query.Text}
+
+    if published := dateRange(query.From, query.To); published != nil {
+        filter["published_at"] = published
+    }
+
+    total, err := r.collection.CountDocuments(ctx, filter)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    score := bson.M{"$meta": "textScore"}
+    opts := options.Find().
+        SetProjection(bson.M{"_id": 1, "score": score}).
+        SetSort(bson.D{{Key: "score", Value: score}, {Key: "created_at", Value: -1}}).
+        SetSkip(int64((query.Page - 1) * query.Limit)).
+        SetLimit(int64(query.Limit))
+
+    cursor, err := r.collection.Find(ctx, filter, opts)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer cursor.Close(ctx)
+
+    hits := []*models.SearchHit{}
+    for cursor.Next(ctx) {
+        var doc struct {
+            ID    primitive.ObjectID `bson:"_id"`
+            Score float64            `bson:"score"`
+        }
+        if err := cursor.Decode(&doc); err != nil {
+            return nil, 0, err
+        }
+        hits = append(hits, &models.SearchHit{PostID: doc.ID, Score: doc.Score})
+    }
+
+    return hits, total, cursor.Err()
+}
+
+// EnsureIndexes creates the text index on the title and content of posts,
+// with the title weighted ten times higher. Language-specific stemming is
+// disabled so that Vietnamese and English posts are tokenized the same way.
+func (r *MongoSearchIndex) EnsureIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys: bson.D{{Key: "title", Value: "text"}, {Key: "content", Value: "text"}},
+        Options: options.Index().
+            SetName("posts_text").
+            SetWeights(bson.M{"title": 10, "content": 1}).
+            SetDefaultLanguage("none").
+            SetLanguageOverride("search_language"),
+    })
+    return err
+}
\ No newline at end of file
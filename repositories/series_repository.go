@@ -0,0 +1,265 @@
+package repositories
+
+import (
+    "context"
+    "fmt"
+    "time"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SeriesRepository struct {
+    collection *mongo.Collection
+}
+
+// NewSeriesRepository returns a new instance of SeriesRepository.
+//
+// The SeriesRepository is used to interact with the "series" collection in
+// the MongoDB database. The posts of a series are stored in the series
+// document, in order.
+func NewSeriesRepository(db *mongo.Database) *SeriesRepository {
+    return &SeriesRepository{
+        collection: db.Collection("series"),
+    }
+}
+
+// Create creates a new series in the "series" collection.
+//
+// The returned error will be non-nil if any error occurred during the create
+// process.
+func (r *SeriesRepository) Create(series *models.Series) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.InsertOne(ctx, series)
+    if err != nil {
+        return err
+    }
+
+    series.ID = result.InsertedID.(primitive.ObjectID)
+    return nil
+}
+
+// GetByID returns a series by the given ID.
+//
+// The returned error will be models.ErrNotFound if the ID is malformed or no
+// series exists with that ID.
+func (r *SeriesRepository) GetByID(id string) (*models.Series, error) {
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return nil, models.ErrNotFound
+    }
+
+    return r.findOne(bson.M{"_id": objectID})
+}
+
+// GetByPost returns the series the post with the given ID belongs to.
+//
+// The returned error will be models.ErrNotFound if the post is in no series.
+func (r *SeriesRepository) GetByPost(postID primitive.ObjectID) (*models.Series, error) {
+    return r.findOne(bson.M{"post_ids": postID})
+}
+
+// ListByAuthor returns the series of the author with the given ID, newest
+// first.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *SeriesRepository) ListByAuthor(authorID primitive.ObjectID) ([]*models.Series, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+    cursor, err := r.collection.Find(ctx, bson.M{"author_id": authorID}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    series := []*models.Series{}
+    if err := cursor.All(ctx, &series); err != nil {
+        return nil, err
+    }
+
+    return series, nil
+}
+
+// Update sets the given fields of the series with the given ID.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *SeriesRepository) Update(id primitive.ObjectID, updates map[string]interface{}) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+    return err
+}
+
+// Delete deletes the series with the given ID. Its posts are left untouched.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *SeriesRepository) Delete(id primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+    return err
+}
+
+// AddPost inserts the post with the given ID into the series with the given
+// ID, at the given 0-indexed position, or at the end if position is negative
+// or past the end. Nothing changes if the post is already in the series.
+//
+// The returned error will be models.ErrConflict if the series already holds
+// models.MaxSeriesPosts posts, models.ErrNotFound if the series does not
+// exist, and non-nil if any other error occurred during the update process.
+func (r *SeriesRepository) AddPost(id, postID primitive.ObjectID, position int) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    each := bson.M{"$each": bson.A{postID}}
+    if position >= 0 {
+        each["$position"] = position
+    }
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{
+            "_id":      id,
+            "post_ids": bson.M{"$ne": postID},
+            fmt.Sprintf("post_ids.%d", models.MaxSeriesPosts-1): bson.M{"$exists": false},
+        },
+        bson.M{
+            "$push": bson.M{"post_ids": each},
+            "$set":  bson.M{"updated_at": time.Now()},
+        },
+    )
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount > 0 {
+        return nil
+    }
+
+    series, err := r.findOne(bson.M{"_id": id})
+    if err != nil {
+        return err
+    }
+    for _, existing := range series.PostIDs {
+        if existing == postID {
+            return nil
+        }
+    }
+    return fmt.Errorf("%w: series are limited to %d posts", models.ErrConflict, models.MaxSeriesPosts)
+}
+
+// RemovePost removes the post with the given ID from the series with the
+// given ID. The posts after it move up one position.
+//
+// The returned error will be models.ErrNotFound if the post is not in the
+// series, and non-nil if any other error occurred during the update process.
+func (r *SeriesRepository) RemovePost(id, postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{"_id": id, "post_ids": postID},
+        bson.M{
+            "$pull": bson.M{"post_ids": postID},
+            "$set":  bson.M{"updated_at": time.Now()},
+        },
+    )
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount == 0 {
+        return models.ErrNotFound
+    }
+
+    return nil
+}
+
+// SetPosts replaces the posts of the series with the given ID with the same
+// posts in another order. The series is only changed if it still holds
+// exactly these posts.
+//
+// The returned error will be models.ErrConflict if posts were added to or
+// removed from the series in the meantime, and non-nil if any other error
+// occurred during the update process.
+func (r *SeriesRepository) SetPosts(id primitive.ObjectID, postIDs []primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    same := bson.M{"$size": len(postIDs)}
+    if len(postIDs) > 0 {
+        same["$all"] = postIDs
+    }
+
+    result, err := r.collection.UpdateOne(
+        ctx,
+        bson.M{"_id": id, "post_ids": same},
+        bson.M{"$set": bson.M{"post_ids": postIDs, "updated_at": time.Now()}},
+    )
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount == 0 {
+        return fmt.Errorf("%w: the series changed, fetch it again and retry", models.ErrConflict)
+    }
+
+    return nil
+}
+
+// DeleteByPost removes the post with the given ID from any series it belongs
+// to.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *SeriesRepository) DeleteByPost(postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.UpdateMany(
+        ctx,
+        bson.M{"post_ids": postID},
+        bson.M{"$pull": bson.M{"post_ids": postID}},
+    )
+    return err
+}
+
+// EnsureIndexes creates the indexes used by the series queries. It is safe
+// to call on every start-up, as existing indexes are left untouched.
+func (r *SeriesRepository) EnsureIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {Keys: bson.D{{Key: "author_id", Value: 1}, {Key: "created_at", Value: -1}}},
+        {Keys: bson.D{{Key: "post_ids", Value: 1}}},
+    })
+    return err
+}
+
+// findOne returns the single series matching the given query.
+func (r *SeriesRepository) findOne(query bson.M) (*models.Series, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var series models.Series
+    err := r.collection.FindOne(ctx, query).Decode(&series)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &series, nil
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+    "context"
+    "time"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// postSlug is a slug that is, or once was, used by a post. Keeping old slugs
+// lets renamed posts be found by their previous URL.
+type postSlug struct {
+    Slug      string             `bson:"_id"`
+    PostID    primitive.ObjectID `bson:"post_id"`
+    CreatedAt time.Time          `bson:"created_at"`
+}
+
+type SlugRepository struct {
+    collection *mongo.Collection
+}
+
+// NewSlugRepository returns a new instance of SlugRepository.
+//
+// The SlugRepository is used to interact with the "post_slugs" collection in
+// the MongoDB database, which records every slug ever assigned to a post.
+// The slug is the document ID, so a slug can never belong to two posts.
+func NewSlugRepository(db *mongo.Database) *SlugRepository {
+    return &SlugRepository{
+        collection: db.Collection("post_slugs"),
+    }
+}
+
+// Reserve assigns the given slug to the post with the given ID, and reports
+// whether the slug is new to the post.
+//
+// Reserving a slug that already belongs to the same post succeeds, so a post
+// can go back to one of its previous slugs. The returned error will be
+// models.ErrConflict if the slug belongs to another post.
+func (r *SlugRepository) Reserve(slug string, postID primitive.ObjectID) (bool, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.InsertOne(ctx, postSlug{
+        Slug:      slug,
+        PostID:    postID,
+        CreatedAt: time.Now(),
+    })
+    if err == nil {
+        return true, nil
+    }
+    if !mongo.IsDuplicateKeyError(err) {
+        return false, err
+    }
+
+    owner, err := r.FindPostID(slug)
+    if err != nil {
+        return false, err
+    }
+    if owner != postID {
+        return false, models.ErrConflict
+    }
+    return false, nil
+}
+
+// Release gives up the given slug of the post with the given ID, so it can be
+// used by other posts. It is meant for slugs reserved for a change that then
+// failed; a slug belonging to another post is left untouched.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *SlugRepository) Release(slug string, postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.DeleteOne(ctx, bson.M{"_id": slug, "post_id": postID})
+    return err
+}
+
+// FindPostID returns the ID of the post the given slug belongs to, whether it
+// is the post's current slug or a previous one.
+//
+// The returned error will be models.ErrNotFound if the slug was never used.
+func (r *SlugRepository) FindPostID(slug string) (primitive.ObjectID, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var record postSlug
+    err := r.collection.FindOne(ctx, bson.M{"_id": slug}).Decode(&record)
+    if err == mongo.ErrNoDocuments {
+        return primitive.NilObjectID, models.ErrNotFound
+    }
+    if err != nil {
+        return primitive.NilObjectID, err
+    }
+
+    return record.PostID, nil
+}
+
+// DeleteByPost releases every slug of the post with the given ID, so they can
+// be used by other posts.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *SlugRepository) DeleteByPost(postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := r.collection.DeleteMany(ctx, bson.M{"post_id": postID})
+    return err
+}
+
+// EnsureIndexes creates the indexes used by the slug queries. It is safe to
+// call on every start-up, as existing indexes are left untouched.
+func (r *SlugRepository) EnsureIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys: bson.D{{Key: "post_id", Value: 1}},
+    })
+    return err
+}
\ No newline at end of file
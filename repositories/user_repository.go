@@ -45,14 +45,17 @@ func (r *UserRepository) Create(user *models.User) error {
 
 // GetByEmail returns a user by the given email.
 //
-// If no user is found with the given email, the returned error will be nil,
-// and the returned user will be nil.
+// The returned error will be models.ErrNotFound if no user is found with the
+// given email.
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
     var user models.User
     err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
     if err != nil {
         return nil, err
     }
@@ -62,17 +65,22 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 
 // GetByID returns a user by the given ID.
 //
+// The returned error will be models.ErrNotFound if the ID is malformed or no
+// user exists with that ID.
 func (r *UserRepository) GetByID(id string) (*models.User, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
     objectID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return nil, err
+        return nil, models.ErrNotFound
     }
 
     var user models.User
     err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
+    if err == mongo.ErrNoDocuments {
+        return nil, models.ErrNotFound
+    }
     if err != nil {
         return nil, err
     }
@@ -80,30 +88,78 @@ func (r *UserRepository) GetByID(id string) (*models.User, error) {
     return &user, nil
 }
 
+// GetByIDs returns the users with the given IDs, in no particular order. IDs
+// without a matching user are ignored.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (r *UserRepository) GetByIDs(ids []primitive.ObjectID) ([]*models.User, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    var users []*models.User
+    if err = cursor.All(ctx, &users); err != nil {
+        return nil, err
+    }
+
+    return users, nil
+}
+
 // Update updates the user with the given ID in the "users" collection in the
-// MongoDB database.
+// MongoDB database, and increments its version.
 //
 // The updates parameter is a map of key-value pairs that should be updated in
 // the user document. The key is the field name and the value is the new value
 // for that field.
 //
-// The returned error will be non-nil if any error occurred during the update
-// process.
-func (r *UserRepository) Update(id string, updates map[string]interface{}) error {
+// If version is not nil, the update only applies if the user is still at that
+// version.
+//
+// The returned error will be models.ErrNotFound if no user exists with the
+// given ID, models.ErrVersionConflict if the user is at another version, and
+// non-nil if any other error occurred during the update process.
+func (r *UserRepository) Update(id string, version *int64, updates map[string]interface{}) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
     objectID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return err
+        return models.ErrNotFound
+    }
+
+    query := bson.M{"_id": objectID}
+    if version != nil {
+        query["version"] = versionFilter(*version)
     }
 
-    _, err = r.collection.UpdateOne(
+    result, err := r.collection.UpdateOne(
         ctx,
-        bson.M{"_id": objectID},
-        bson.M{"$set": updates},
+        query,
+        bson.M{"$set": updates, "$inc": bson.M{"version": 1}},
     )
-    return err
+    if err != nil {
+        return err
+    }
+    if result.MatchedCount == 0 {
+        if version == nil {
+            return models.ErrNotFound
+        }
+        count, err := r.collection.CountDocuments(ctx, bson.M{"_id": objectID})
+        if err != nil {
+            return err
+        }
+        if count == 0 {
+            return models.ErrNotFound
+        }
+        return models.ErrVersionConflict
+    }
+    return nil
 }
 
 // Delete deletes the user with the given ID from the "users" collection in the
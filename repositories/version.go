@@ -0,0 +1,15 @@
+package repositories
+
+import (
+    "go.mongodb.org/mongo-driver/bson"
+)
+
+// versionFilter returns the condition matching documents at the given
+// version. Documents written before versions existed have no version field
+// and count as version 0.
+func versionFilter(version int64) interface{} {
+    if version == 0 {
+        return bson.M{"$in": bson.A{0, nil}}
+    }
+    return version
+}
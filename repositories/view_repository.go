@@ -0,0 +1,273 @@
+package repositories
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "errors"
+    "time"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// visitorRetention is how long visitor hashes and salts are kept. A day's
+// visitors are only needed until all the views of that day are flushed.
+const visitorRetention = 48 * time.Hour
+
+type ViewRepository struct {
+    daily    *mongo.Collection
+    visitors *mongo.Collection
+    salts    *mongo.Collection
+}
+
+// NewViewRepository returns a new instance of ViewRepository.
+//
+// The ViewRepository is used to interact with the "post_views" collection,
+// which holds the daily view counts of posts, and with the short-lived
+// "view_visitors" and "view_salts" collections used to count unique visitors
+// without storing who they are.
+func NewViewRepository(db *mongo.Database) *ViewRepository {
+    return &ViewRepository{
+        daily:    db.Collection("post_views"),
+        visitors: db.Collection("view_visitors"),
+        salts:    db.Collection("view_salts"),
+    }
+}
+
+// Salt returns the random salt used to hash visitors on the given day,
+// creating it on first use. Every server instance gets the same salt for a
+// day, and salts are deleted soon after their day ends, so that visitor
+// hashes cannot be linked across days.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *ViewRepository) Salt(day time.Time) (string, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    random := make([]byte, 32)
+    if _, err := rand.Read(random); err != nil {
+        return "", err
+    }
+
+    opts := options.FindOneAndUpdate().
+        SetUpsert(true).
+        SetReturnDocument(options.After)
+
+    var doc struct {
+        Salt string `bson:"salt"`
+    }
+    key := day.Format("2006-01-02")
+    update := bson.M{"$setOnInsert": bson.M{
+        "salt":       hex.EncodeToString(random),
+        "expires_at": day.Add(visitorRetention),
+    }}
+
+    // Two instances creating the salt of a new day at once can race on the
+    // upsert; the loser reads the winner's salt on the second attempt.
+    var err error
+    for attempt := 0; attempt < 2; attempt++ {
+        err = r.salts.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&doc)
+        if !mongo.IsDuplicateKeyError(err) {
+            break
+        }
+    }
+    if err != nil {
+        return "", err
+    }
+
+    return doc.Salt, nil
+}
+
+// AddVisitors records the given visitors, and reports for each of them
+// whether it is new, that is, whether it had not been recorded yet for the
+// same post and day.
+//
+// The returned error will be non-nil if any error other than a duplicate
+// visitor occurred during the insert process.
+func (r *ViewRepository) AddVisitors(visitors []models.ViewVisitor) ([]bool, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    isNew := make([]bool, len(visitors))
+    if len(visitors) == 0 {
+        return isNew, nil
+    }
+
+    docs := make([]interface{}, len(visitors))
+    for i, visitor := range visitors {
+        docs[i] = bson.M{
+            "post_id":    visitor.PostID,
+            "day":        visitor.Day,
+            "visitor":    visitor.Visitor,
+            "expires_at": visitor.Day.Add(visitorRetention),
+        }
+        isNew[i] = true
+    }
+
+    _, err := r.visitors.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+    var bulkErr mongo.BulkWriteException
+    if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+        for _, writeErr := range bulkErr.WriteErrors {
+            if writeErr.Code != 11000 {
+                return nil, err
+            }
+            isNew[writeErr.Index] = false
+        }
+        return isNew, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return isNew, nil
+}
+
+// IncrementDaily adds the given counts to the daily view counts, creating
+// the counts of a new post, day and referrer as needed.
+//
+// The returned error will be non-nil if any error occurred during the update
+// process.
+func (r *ViewRepository) IncrementDaily(counts []*models.DailyViews) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    if len(counts) == 0 {
+        return nil
+    }
+
+    writes := make([]mongo.WriteModel, len(counts))
+    for i, count := range counts {
+        writes[i] = mongo.NewUpdateOneModel().
+            SetFilter(bson.M{"post_id": count.PostID, "day": count.Day, "referrer": count.Referrer}).
+            SetUpdate(bson.M{
+                "$inc":         bson.M{"views": count.Views, "uniques": count.Uniques},
+                "$setOnInsert": bson.M{"author_id": count.AuthorID},
+            }).
+            SetUpsert(true)
+    }
+
+    _, err := r.daily.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+    return err
+}
+
+// Series returns the views matching the given filter for each day that has
+// any, oldest first.
+//
+// The returned error will be non-nil if any error occurred during the
+// aggregation.
+func (r *ViewRepository) Series(filter models.ViewFilter) ([]*models.ViewPoint, error) {
+    points := []*models.ViewPoint{}
+    err := r.aggregate(filter, bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$day"}},
+        bson.D{{Key: "_id", Value: 1}}, 0, &points)
+    return points, err
+}
+
+// TopPosts returns the posts with the most views matching the given filter,
+// most viewed first, limited to limit posts.
+//
+// The returned error will be non-nil if any error occurred during the
+// aggregation.
+func (r *ViewRepository) TopPosts(filter models.ViewFilter, limit int) ([]*models.PostViews, error) {
+    posts := []*models.PostViews{}
+    err := r.aggregate(filter, "$post_id",
+        bson.D{{Key: "views", Value: -1}, {Key: "_id", Value: 1}}, limit, &posts)
+    return posts, err
+}
+
+// TopReferrers returns the referrers bringing the most views matching the
+// given filter, most views first, limited to limit referrers.
+//
+// The returned error will be non-nil if any error occurred during the
+// aggregation.
+func (r *ViewRepository) TopReferrers(filter models.ViewFilter, limit int) ([]*models.ReferrerViews, error) {
+    referrers := []*models.ReferrerViews{}
+    err := r.aggregate(filter, "$referrer",
+        bson.D{{Key: "views", Value: -1}, {Key: "_id", Value: 1}}, limit, &referrers)
+    return referrers, err
+}
+
+// DeleteByPost deletes the view counts of the post with the given ID.
+//
+// The returned error will be non-nil if any error occurred during the delete
+// process.
+func (r *ViewRepository) DeleteByPost(postID primitive.ObjectID) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    if _, err := r.daily.DeleteMany(ctx, bson.M{"post_id": postID}); err != nil {
+        return err
+    }
+    _, err := r.visitors.DeleteMany(ctx, bson.M{"post_id": postID})
+    return err
+}
+
+// EnsureIndexes creates the indexes used by the view queries, including the
+// TTL indexes expiring visitor hashes and salts. It is safe to call on every
+// start-up, as existing indexes are left untouched.
+func (r *ViewRepository) EnsureIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    _, err := r.daily.Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {Keys: bson.D{{Key: "post_id", Value: 1}, {Key: "day", Value: 1}, {Key: "referrer", Value: 1}}, Options: options.Index().SetUnique(true)},
+        {Keys: bson.D{{Key: "author_id", Value: 1}, {Key: "day", Value: 1}}},
+        {Keys: bson.D{{Key: "day", Value: 1}}},
+    })
+    if err != nil {
+        return err
+    }
+
+    _, err = r.visitors.Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {Keys: bson.D{{Key: "post_id", Value: 1}, {Key: "day", Value: 1}, {Key: "visitor", Value: 1}}, Options: options.Index().SetUnique(true)},
+        {Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+    })
+    if err != nil {
+        return err
+    }
+
+    _, err = r.salts.Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys:    bson.D{{Key: "expires_at", Value: 1}},
+        Options: options.Index().SetExpireAfterSeconds(0),
+    })
+    return err
+}
+
+// aggregate sums the views and uniques matching the given filter, grouped by
+// the given expression, and decodes the sorted groups into results.
+func (r *ViewRepository) aggregate(filter models.ViewFilter, group interface{}, sort bson.D, limit int, results interface{}) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    match := bson.M{"day": bson.M{"$gte": filter.From, "$lte": filter.To}}
+    if !filter.PostID.IsZero() {
+        match["post_id"] = filter.PostID
+    }
+    if !filter.AuthorID.IsZero() {
+        match["author_id"] = filter.AuthorID
+    }
+
+    pipeline := mongo.Pipeline{
+        {{Key: "$match", Value: match}},
+        {{Key: "$group", Value: bson.M{
+            "_id":     group,
+            "views":   bson.M{"$sum": "$views"},
+            "uniques": bson.M{"$sum": "$uniques"},
+        }}},
+        {{Key: "$sort", Value: sort}},
+    }
+    if limit > 0 {
+        pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+    }
+
+    cursor, err := r.daily.Aggregate(ctx, pipeline)
+    if err != nil {
+        return err
+    }
+    defer cursor.Close(ctx)
+
+    return cursor.All(ctx, results)
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BookmarkRepository interface {
+    Create(userID, postID primitive.ObjectID) (*models.Bookmark, error)
+    Delete(userID, postID primitive.ObjectID) error
+    List(userID primitive.ObjectID, req models.PageRequest) (*models.BookmarkPage, error)
+}
+
+// BookmarkPostRepository is the part of the post storage bookmarks and
+// reading lists need to check and show the posts they point to.
+type BookmarkPostRepository interface {
+    GetByID(id string) (*models.Post, error)
+    GetByIDs(ids []primitive.ObjectID) ([]*models.Post, error)
+}
+
+// BookmarkService lets readers save posts to read later. A post in the trash
+// is hidden from the bookmarks on it, which go away when the post is purged.
+type BookmarkService struct {
+    repo   BookmarkRepository
+    posts  BookmarkPostRepository
+    access PostAccess
+}
+
+// NewBookmarkService returns a new BookmarkService instance, given a
+// BookmarkRepository, the post storage and the PostAccess deciding who may
+// read a post.
+func NewBookmarkService(repo BookmarkRepository, posts BookmarkPostRepository, access PostAccess) *BookmarkService {
+    return &BookmarkService{
+        repo:   repo,
+        posts:  posts,
+        access: access,
+    }
+}
+
+// Bookmark saves the post with the given ID for the given viewer, who must be
+// able to read it. Bookmarking a post twice is harmless.
+func (s *BookmarkService) Bookmark(viewer models.Viewer, postID string) (*models.Bookmark, error) {
+    userObjectID, err := primitive.ObjectIDFromHex(viewer.UserID)
+    if err != nil {
+        return nil, models.ErrForbidden
+    }
+
+    post, err := getReadablePost(s.posts, s.access, postID, viewer)
+    if err != nil {
+        return nil, err
+    }
+
+    bookmark, err := s.repo.Create(userObjectID, post.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    bookmark.Post = post
+    return bookmark, nil
+}
+
+// Unbookmark removes the bookmark of userID on the post with the given ID.
+//
+// The returned error will be models.ErrNotFound if the user has not
+// bookmarked the post.
+func (s *BookmarkService) Unbookmark(userID, postID string) error {
+    userObjectID, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return models.ErrForbidden
+    }
+    postObjectID, err := primitive.ObjectIDFromHex(postID)
+    if err != nil {
+        return models.ErrNotFound
+    }
+
+    return s.repo.Delete(userObjectID, postObjectID)
+}
+
+// List returns a page of the bookmarks of the given viewer, newest first,
+// with their posts. A bookmarked post the viewer can no longer read, for
+// instance because it was unpublished or is in the trash, is returned
+// without its post.
+func (s *BookmarkService) List(viewer models.Viewer, req models.PageRequest) (*models.BookmarkPage, error) {
+    userObjectID, err := primitive.ObjectIDFromHex(viewer.UserID)
+    if err != nil {
+        return nil, models.ErrForbidden
+    }
+
+    page, err := s.repo.List(userObjectID, req)
+    if err != nil {
+        return nil, err
+    }
+
+    ids := make([]primitive.ObjectID, len(page.Bookmarks))
+    for i, bookmark := range page.Bookmarks {
+        ids[i] = bookmark.PostID
+    }
+    posts, err := getReadablePosts(s.posts, s.access, ids, viewer)
+    if err != nil {
+        return nil, err
+    }
+    for _, bookmark := range page.Bookmarks {
+        bookmark.Post = posts[bookmark.PostID]
+    }
+
+    return page, nil
+}
+
+// getReadablePost loads the post with the given ID, applying the same
+// visibility rules as PostService.Get.
+func getReadablePost(posts BookmarkPostRepository, access PostAccess, postID string, viewer models.Viewer) (*models.Post, error) {
+    post, err := posts.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := access.CanRead(post, viewer); err != nil {
+        return nil, err
+    }
+
+    return post, nil
+}
+
+// getReadablePosts loads the posts with the given IDs that the given viewer
+// can read, keyed by ID and trimmed for listing. An anonymous viewer only
+// gets published public and unlisted posts.
+func getReadablePosts(posts BookmarkPostRepository, access PostAccess, ids []primitive.ObjectID, viewer models.Viewer) (map[primitive.ObjectID]*models.Post, error) {
+    readable := make(map[primitive.ObjectID]*models.Post, len(ids))
+    if len(ids) == 0 {
+        return readable, nil
+    }
+
+    found, err := posts.GetByIDs(ids)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, post := range found {
+        if access.CanRead(post, viewer) == nil {
+            readable[post.ID] = post
+            post.TrimForList()
+        }
+    }
+
+    return readable, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "testing"
+    "time"
+)
+
+// fakeBookmarkRepo is an in-memory BookmarkRepository.
+type fakeBookmarkRepo struct {
+    bookmarks []*models.Bookmark
+}
+
+func (r *fakeBookmarkRepo) Create(userID, postID primitive.ObjectID) (*models.Bookmark, error) {
+    for _, bookmark := range r.bookmarks {
+        if bookmark.UserID == userID && bookmark.PostID == postID {
+            found := *bookmark
+            return &found, nil
+        }
+    }
+    bookmark := &models.Bookmark{ID: primitive.NewObjectID(), UserID: userID, PostID: postID, CreatedAt: time.Now()}
+    r.bookmarks = append(r.bookmarks, bookmark)
+    created := *bookmark
+    return &created, nil
+}
+
+func (r *fakeBookmarkRepo) Delete(userID, postID primitive.ObjectID) error {
+    for i, bookmark := range r.bookmarks {
+        if bookmark.UserID == userID && bookmark.PostID == postID {
+            r.bookmarks = append(r.bookmarks[:i], r.bookmarks[i+1:]...)
+            return nil
+        }
+    }
+    return models.ErrNotFound
+}
+
+func (r *fakeBookmarkRepo) List(userID primitive.ObjectID, req models.PageRequest) (*models.BookmarkPage, error) {
+    page := &models.BookmarkPage{Bookmarks: []*models.Bookmark{}}
+    for i := len(r.bookmarks) - 1; i >= 0; i-- {
+        if r.bookmarks[i].UserID == userID {
+            found := *r.bookmarks[i]
+            page.Bookmarks = append(page.Bookmarks, &found)
+        }
+    }
+    return page, nil
+}
+
+func (r *fakeBookmarkRepo) DeleteByPost(postID primitive.ObjectID) error {
+    kept := r.bookmarks[:0]
+    for _, bookmark := range r.bookmarks {
+        if bookmark.PostID != postID {
+            kept = append(kept, bookmark)
+        }
+    }
+    r.bookmarks = kept
+    return nil
+}
+
+func TestBookmarkServiceTrashedPost(t *testing.T) {
+    f := newPostFixture(t)
+    bookmarks := &fakeBookmarkRepo{}
+    service := NewBookmarkService(bookmarks, f.posts, f.service)
+    trash := NewTrashService(f.posts, nil, "", 0, bookmarks)
+
+    post := f.create(t, &models.Post{Status: models.PostStatusPublished}, "")
+    reader := models.Viewer{UserID: primitive.NewObjectID().Hex(), Role: models.RoleUser}
+    if _, err := service.Bookmark(reader, post.ID.Hex()); err != nil {
+        t.Fatalf("Bookmark() error = %v", err)
+    }
+
+    list := func() *models.BookmarkPage {
+        t.Helper()
+        page, err := service.List(reader, models.PageRequest{})
+        if err != nil {
+            t.Fatalf("List() error = %v", err)
+        }
+        return page
+    }
+
+    // A post in the trash keeps its bookmarks, but is hidden from them.
+    if err := f.service.Delete(post.ID.Hex(), post.AuthorID.Hex(), models.RoleUser, nil); err != nil {
+        t.Fatalf("Delete() error = %v", err)
+    }
+    if page := list(); len(page.Bookmarks) != 1 || page.Bookmarks[0].Post != nil {
+        t.Fatalf("List() after Delete() = %d bookmarks, want 1 without its post", len(page.Bookmarks))
+    }
+
+    if _, err := f.service.Restore(post.ID.Hex(), post.AuthorID.Hex(), models.RoleUser); err != nil {
+        t.Fatalf("Restore() error = %v", err)
+    }
+    if page := list(); len(page.Bookmarks) != 1 || page.Bookmarks[0].Post == nil {
+        t.Fatalf("List() after Restore() = %d bookmarks, want 1 with its post", len(page.Bookmarks))
+    }
+
+    // Purging the post from the trash removes its bookmarks.
+    if err := f.service.Delete(post.ID.Hex(), post.AuthorID.Hex(), models.RoleUser, nil); err != nil {
+        t.Fatalf("Delete() error = %v", err)
+    }
+    if purged, err := trash.Purge(); err != nil || purged != 1 {
+        t.Fatalf("Purge() = %d, %v, want 1, nil", purged, err)
+    }
+    if page := list(); len(page.Bookmarks) != 0 {
+        t.Errorf("List() after Purge() = %d bookmarks, want none", len(page.Bookmarks))
+    }
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+type CategoryRepository interface {
+    Create(category *models.Category) error
+    GetByID(id string) (*models.Category, error)
+    GetBySlug(slug string) (*models.Category, error)
+    List() ([]*models.Category, error)
+    GetDescendantIDs(id primitive.ObjectID) ([]primitive.ObjectID, error)
+    Update(id primitive.ObjectID, updates map[string]interface{}) error
+    Delete(id primitive.ObjectID) error
+}
+
+// CategorizedPostRepository is the part of the post storage the category
+// service needs to detach posts from a deleted category.
+type CategorizedPostRepository interface {
+    UnsetCategory(categoryID primitive.ObjectID) error
+}
+
+type CategoryService struct {
+    repo  CategoryRepository
+    posts CategorizedPostRepository
+}
+
+// NewCategoryService returns a new CategoryService instance, given a
+// CategoryRepository and the post storage used to detach posts from deleted
+// categories.
+func NewCategoryService(repo CategoryRepository, posts CategorizedPostRepository) *CategoryService {
+    return &CategoryService{
+        repo:  repo,
+        posts: posts,
+    }
+}
+
+// Create creates a new category below the category with the given parent ID,
+// or at the root if parentID is empty.
+//
+// The slug is generated from the name unless one is set on the category. The
+// returned error will be models.ErrConflict if the slug is already used.
+func (s *CategoryService) Create(category *models.Category, parentID string) error {
+    if category.Slug == "" {
+        category.Slug = utils.Slugify(category.Name)
+    }
+    if !utils.IsValidSlug(category.Slug) {
+        return fmt.Errorf("%w: the category needs a name or slug made of letters or digits", models.ErrInvalidInput)
+    }
+
+    category.ParentID = nil
+    category.Ancestors = []primitive.ObjectID{}
+    if parentID != "" {
+        parent, err := s.repo.GetByID(parentID)
+        if err != nil {
+            return s.parentError(err)
+        }
+        category.ParentID = &parent.ID
+        category.Ancestors = append(append(category.Ancestors, parent.Ancestors...), parent.ID)
+    }
+
+    category.CreatedAt = time.Now()
+    category.UpdatedAt = time.Now()
+    return s.repo.Create(category)
+}
+
+// Tree returns every root category, with their subcategories nested in
+// Children.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (s *CategoryService) Tree() ([]*models.Category, error) {
+    categories, err := s.repo.List()
+    if err != nil {
+        return nil, err
+    }
+
+    byID := make(map[primitive.ObjectID]*models.Category, len(categories))
+    for _, category := range categories {
+        byID[category.ID] = category
+    }
+
+    roots := []*models.Category{}
+    for _, category := range categories {
+        if category.ParentID == nil || byID[*category.ParentID] == nil {
+            roots = append(roots, category)
+            continue
+        }
+        parent := byID[*category.ParentID]
+        parent.Children = append(parent.Children, category)
+    }
+
+    return roots, nil
+}
+
+// Update updates the fields of the category with the given ID.
+//
+// The updates parameter may contain "name", "slug" and "description". A
+// "parent_id" entry moves the category, and all of its subcategories, below
+// another category; an empty parent ID moves it to the root. A category cannot
+// be moved below itself or one of its own subcategories.
+//
+// The returned error will be models.ErrConflict if the new slug is already
+// used.
+func (s *CategoryService) Update(categoryID string, updates map[string]interface{}) error {
+    category, err := s.repo.GetByID(categoryID)
+    if err != nil {
+        return err
+    }
+
+    if slug, ok := updates["slug"].(string); ok && !utils.IsValidSlug(slug) {
+        return fmt.Errorf("%w: slugs may only contain lowercase letters, digits and dashes", models.ErrInvalidInput)
+    }
+
+    parentID, moving := updates["parent_id"].(string)
+    delete(updates, "parent_id")
+    if moving {
+        if err := s.move(category, parentID); err != nil {
+            return err
+        }
+        updates["parent_id"] = category.ParentID
+        updates["ancestors"] = category.Ancestors
+    }
+
+    updates["updated_at"] = time.Now()
+    return s.repo.Update(category.ID, updates)
+}
+
+// Delete deletes the category with the given ID. Posts in the category are
+// left uncategorized.
+//
+// The returned error will be models.ErrConflict if the category still has
+// subcategories.
+func (s *CategoryService) Delete(categoryID string) error {
+    category, err := s.repo.GetByID(categoryID)
+    if err != nil {
+        return err
+    }
+
+    descendants, err := s.repo.GetDescendantIDs(category.ID)
+    if err != nil {
+        return err
+    }
+    if len(descendants) > 0 {
+        return fmt.Errorf("%w: the category still has subcategories", models.ErrConflict)
+    }
+
+    if err := s.posts.UnsetCategory(category.ID); err != nil {
+        return err
+    }
+    return s.repo.Delete(category.ID)
+}
+
+// move sets the parent and ancestors of the given category for its new
+// position below parentID, and rewrites the ancestors of its subcategories.
+// The category itself is not saved.
+func (s *CategoryService) move(category *models.Category, parentID string) error {
+    ancestors := []primitive.ObjectID{}
+    var parentObjectID *primitive.ObjectID
+
+    if parentID != "" {
+        parent, err := s.repo.GetByID(parentID)
+        if err != nil {
+            return s.parentError(err)
+        }
+        if parent.ID == category.ID || containsID(parent.Ancestors, category.ID) {
+            return fmt.Errorf("%w: a category cannot be moved below itself", models.ErrInvalidInput)
+        }
+        ancestors = append(append(ancestors, parent.Ancestors...), parent.ID)
+        parentObjectID = &parent.ID
+    }
+
+    categories, err := s.repo.List()
+    if err != nil {
+        return err
+    }
+
+    for _, descendant := range categories {
+        i := indexOfID(descendant.Ancestors, category.ID)
+        if i < 0 {
+            continue
+        }
+        // Keep the path below the moved category, replace the path above it.
+        path := append(append([]primitive.ObjectID{}, ancestors...), category.ID)
+        path = append(path, descendant.Ancestors[i+1:]...)
+        if err := s.repo.Update(descendant.ID, map[string]interface{}{"ancestors": path}); err != nil {
+            return err
+        }
+    }
+
+    category.ParentID = parentObjectID
+    category.Ancestors = ancestors
+    return nil
+}
+
+// parentError reports a missing parent category as invalid input rather than
+// as a missing category.
+func (s *CategoryService) parentError(err error) error {
+    if errors.Is(err, models.ErrNotFound) {
+        return fmt.Errorf("%w: parent category not found", models.ErrInvalidInput)
+    }
+    return err
+}
+
+// containsID reports whether id is in ids.
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+    return indexOfID(ids, id) >= 0
+}
+
+// indexOfID returns the index of id in ids, or -1 if it is not there.
+func indexOfID(ids []primitive.ObjectID, id primitive.ObjectID) int {
+    for i, candidate := range ids {
+        if candidate == id {
+            return i
+        }
+    }
+    return -1
+}
\ No newline at end of file
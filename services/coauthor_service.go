@@ -0,0 +1,384 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "time"
+)
+
+type CoAuthorRepository interface {
+    Create(invitation *models.CoAuthorInvitation) error
+    GetByID(id string) (*models.CoAuthorInvitation, error)
+    ListPendingByInvitee(inviteeID primitive.ObjectID) ([]*models.CoAuthorInvitation, error)
+    ListPendingByPost(postID primitive.ObjectID) ([]*models.CoAuthorInvitation, error)
+    SetStatus(id primitive.ObjectID, from, to string, at *time.Time) (bool, error)
+    DeletePending(postID, inviteeID primitive.ObjectID) error
+}
+
+// CoAuthorPostRepository is the part of the post storage the co-author
+// service needs to check posts and show them in invitations.
+type CoAuthorPostRepository interface {
+    GetByID(id string) (*models.Post, error)
+    GetByIDs(ids []primitive.ObjectID) ([]*models.Post, error)
+}
+
+// CoAuthorEditor changes the co-authors of posts. It is implemented by the
+// PostService, so that post listeners hear about the change.
+type CoAuthorEditor interface {
+    AddCoAuthor(postID, userID string) (*models.Post, error)
+    RemoveCoAuthor(postID, userID, coAuthorID string) (*models.Post, error)
+    ReorderCoAuthors(postID, userID string, coAuthorIDs []string) (*models.Post, error)
+}
+
+// AuthorRepository is the part of the user storage needed to show who wrote
+// a post.
+type AuthorRepository interface {
+    GetByIDs(ids []primitive.ObjectID) ([]*models.User, error)
+}
+
+// CoAuthorService lets the author of a post invite other users to co-author
+// it. An invited user only becomes a co-author once they accept, and may
+// leave the post later on. Co-authors can read and edit the post, but only
+// its author publishes, archives or deletes it and manages its co-authors.
+type CoAuthorService struct {
+    repo   CoAuthorRepository
+    posts  CoAuthorPostRepository
+    editor CoAuthorEditor
+    users  AuthorRepository
+}
+
+// NewCoAuthorService returns a new CoAuthorService instance, given a
+// CoAuthorRepository holding the invitations, the post storage, the
+// CoAuthorEditor changing the co-authors of posts, and the user storage.
+func NewCoAuthorService(repo CoAuthorRepository, posts CoAuthorPostRepository, editor CoAuthorEditor, users AuthorRepository) *CoAuthorService {
+    return &CoAuthorService{
+        repo:   repo,
+        posts:  posts,
+        editor: editor,
+        users:  users,
+    }
+}
+
+// Invite invites inviteeID to co-author the post with the given ID, on
+// behalf of the post's author, userID.
+//
+// The returned error will be models.ErrInvalidInput if the invitee does not
+// exist or is the author, and models.ErrConflict if they already co-author
+// the post or have a pending invitation to it, or if the post has as many
+// co-authors and pending invitations as models.MaxCoAuthors.
+func (s *CoAuthorService) Invite(postID, userID, inviteeID string) (*models.CoAuthorInvitation, error) {
+    post, err := s.posts.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+    if post.AuthorID.Hex() != userID {
+        return nil, models.ErrForbidden
+    }
+
+    inviteeObjectID, err := primitive.ObjectIDFromHex(inviteeID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: user not found", models.ErrInvalidInput)
+    }
+    if inviteeObjectID == post.AuthorID {
+        return nil, fmt.Errorf("%w: the author of a post cannot co-author it", models.ErrInvalidInput)
+    }
+    if post.IsAuthor(inviteeID) {
+        return nil, fmt.Errorf("%w: the user already co-authors the post", models.ErrConflict)
+    }
+
+    profiles, err := authorProfiles(s.users, []primitive.ObjectID{inviteeObjectID})
+    if err != nil {
+        return nil, err
+    }
+    invitee, ok := profiles[inviteeObjectID]
+    if !ok {
+        return nil, fmt.Errorf("%w: user not found", models.ErrInvalidInput)
+    }
+
+    pending, err := s.repo.ListPendingByPost(post.ID)
+    if err != nil {
+        return nil, err
+    }
+    if len(post.CoAuthors)+len(pending) >= models.MaxCoAuthors {
+        return nil, fmt.Errorf("%w: posts are limited to %d co-authors", models.ErrConflict, models.MaxCoAuthors)
+    }
+
+    invitation := &models.CoAuthorInvitation{
+        PostID:    post.ID,
+        InviterID: post.AuthorID,
+        InviteeID: inviteeObjectID,
+        Status:    models.InvitationStatusPending,
+        CreatedAt: time.Now(),
+    }
+    if err := s.repo.Create(invitation); err != nil {
+        if errors.Is(err, models.ErrConflict) {
+            return nil, fmt.Errorf("%w: the user is already invited", models.ErrConflict)
+        }
+        return nil, err
+    }
+
+    invitation.Invitee = invitee
+    return invitation, nil
+}
+
+// CoAuthors returns the authors of the post with the given ID, in order, and
+// its pending invitations, for one of its authors, viewerID.
+func (s *CoAuthorService) CoAuthors(postID, viewerID string) (*models.PostCoAuthors, error) {
+    post, err := s.posts.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+    if !post.IsAuthor(viewerID) {
+        return nil, models.ErrForbidden
+    }
+
+    invitations, err := s.repo.ListPendingByPost(post.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    ids := append([]primitive.ObjectID{post.AuthorID}, post.CoAuthors...)
+    for _, invitation := range invitations {
+        ids = append(ids, invitation.InviteeID)
+    }
+    profiles, err := authorProfiles(s.users, ids)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, invitation := range invitations {
+        invitation.Invitee = profileOf(profiles, invitation.InviteeID)
+    }
+
+    return &models.PostCoAuthors{
+        PostID:      post.ID,
+        Authors:     postAuthors(post, profiles),
+        Invitations: invitations,
+    }, nil
+}
+
+// Remove removes targetID from the co-authors of the post with the given ID,
+// or withdraws their pending invitation, on behalf of userID. The post's
+// author may remove anyone, and a co-author may leave the post.
+//
+// The returned error will be models.ErrNotFound if targetID neither
+// co-authors the post nor is invited to.
+func (s *CoAuthorService) Remove(postID, userID, targetID string) error {
+    post, err := s.posts.GetByID(postID)
+    if err != nil {
+        return err
+    }
+
+    if targetID != post.AuthorID.Hex() && post.IsAuthor(targetID) {
+        _, err := s.editor.RemoveCoAuthor(postID, userID, targetID)
+        return err
+    }
+
+    if post.AuthorID.Hex() != userID {
+        return models.ErrForbidden
+    }
+    targetObjectID, err := primitive.ObjectIDFromHex(targetID)
+    if err != nil {
+        return models.ErrNotFound
+    }
+    return s.repo.DeletePending(post.ID, targetObjectID)
+}
+
+// Reorder puts the co-authors of the post with the given ID in the given
+// order, on behalf of the post's author, userID, and returns the updated
+// authors.
+func (s *CoAuthorService) Reorder(postID, userID string, coAuthorIDs []string) (*models.PostCoAuthors, error) {
+    if _, err := s.editor.ReorderCoAuthors(postID, userID, coAuthorIDs); err != nil {
+        return nil, err
+    }
+
+    return s.CoAuthors(postID, userID)
+}
+
+// Invitations returns the pending invitations of userID, newest first, with
+// the posts they are about. Invitations to deleted posts are left out.
+func (s *CoAuthorService) Invitations(userID string) ([]*models.CoAuthorInvitation, error) {
+    userObjectID, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, models.ErrForbidden
+    }
+
+    invitations, err := s.repo.ListPendingByInvitee(userObjectID)
+    if err != nil || len(invitations) == 0 {
+        return invitations, err
+    }
+
+    ids := make([]primitive.ObjectID, len(invitations))
+    for i, invitation := range invitations {
+        ids[i] = invitation.PostID
+    }
+    posts, err := s.posts.GetByIDs(ids)
+    if err != nil {
+        return nil, err
+    }
+    byID := make(map[primitive.ObjectID]*models.Post, len(posts))
+    for _, post := range posts {
+        byID[post.ID] = post
+    }
+
+    live := make([]*models.CoAuthorInvitation, 0, len(invitations))
+    for _, invitation := range invitations {
+        post, ok := byID[invitation.PostID]
+        if !ok {
+            continue
+        }
+        invitation.Post = &models.InvitationPost{
+            ID:     post.ID,
+            Title:  post.Title,
+            Slug:   post.Slug,
+            Status: post.Status,
+        }
+        live = append(live, invitation)
+    }
+
+    return live, nil
+}
+
+// Accept makes userID a co-author of the post of the invitation with the
+// given ID, and returns the post.
+//
+// The returned error will be models.ErrNotFound if the invitation is not
+// addressed to userID, and models.ErrConflict if it was already answered or
+// the post has too many co-authors.
+func (s *CoAuthorService) Accept(invitationID, userID string) (*models.Post, error) {
+    invitation, err := s.getPending(invitationID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    if err := s.answer(invitation, models.InvitationStatusAccepted, &now); err != nil {
+        return nil, err
+    }
+
+    post, err := s.editor.AddCoAuthor(invitation.PostID.Hex(), userID)
+    if err != nil {
+        // Leave the invitation open, so that it can be accepted again.
+        s.repo.SetStatus(invitation.ID, models.InvitationStatusAccepted, models.InvitationStatusPending, nil)
+        return nil, err
+    }
+
+    return post, nil
+}
+
+// Decline turns down the invitation with the given ID on behalf of its
+// invitee, userID.
+//
+// The returned error will be models.ErrNotFound if the invitation is not
+// addressed to userID, and models.ErrConflict if it was already answered.
+func (s *CoAuthorService) Decline(invitationID, userID string) error {
+    invitation, err := s.getPending(invitationID, userID)
+    if err != nil {
+        return err
+    }
+
+    now := time.Now()
+    return s.answer(invitation, models.InvitationStatusDeclined, &now)
+}
+
+// getPending loads the invitation with the given ID and checks that it is
+// addressed to userID and still waiting for an answer.
+func (s *CoAuthorService) getPending(invitationID, userID string) (*models.CoAuthorInvitation, error) {
+    invitation, err := s.repo.GetByID(invitationID)
+    if err != nil {
+        return nil, err
+    }
+    if invitation.InviteeID.Hex() != userID {
+        return nil, models.ErrNotFound
+    }
+    if invitation.Status != models.InvitationStatusPending {
+        return nil, fmt.Errorf("%w: the invitation was already answered", models.ErrConflict)
+    }
+
+    return invitation, nil
+}
+
+// answer moves a pending invitation to the given status, failing with
+// models.ErrConflict if it was answered in the meantime.
+func (s *CoAuthorService) answer(invitation *models.CoAuthorInvitation, status string, at *time.Time) error {
+    changed, err := s.repo.SetStatus(invitation.ID, models.InvitationStatusPending, status, at)
+    if err != nil {
+        return err
+    }
+    if !changed {
+        return fmt.Errorf("%w: the invitation was already answered", models.ErrConflict)
+    }
+
+    invitation.Status = status
+    invitation.RespondedAt = at
+    return nil
+}
+
+// attachAuthors sets the Authors of the given posts: their author then their
+// co-authors, in order, with their user names.
+func attachAuthors(users AuthorRepository, posts ...*models.Post) error {
+    var ids []primitive.ObjectID
+    for _, post := range posts {
+        ids = append(ids, post.AuthorID)
+        ids = append(ids, post.CoAuthors...)
+    }
+    if len(ids) == 0 {
+        return nil
+    }
+
+    profiles, err := authorProfiles(users, ids)
+    if err != nil {
+        return err
+    }
+
+    for _, post := range posts {
+        post.Authors = postAuthors(post, profiles)
+    }
+    return nil
+}
+
+// authorProfiles loads the users with the given IDs, keyed by ID. Users that
+// no longer exist are left out.
+func authorProfiles(users AuthorRepository, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.PostAuthor, error) {
+    unique := make([]primitive.ObjectID, 0, len(ids))
+    seen := make(map[primitive.ObjectID]bool, len(ids))
+    for _, id := range ids {
+        if !seen[id] {
+            seen[id] = true
+            unique = append(unique, id)
+        }
+    }
+
+    found, err := users.GetByIDs(unique)
+    if err != nil {
+        return nil, err
+    }
+
+    profiles := make(map[primitive.ObjectID]*models.PostAuthor, len(found))
+    for _, user := range found {
+        profiles[user.ID] = &models.PostAuthor{ID: user.ID, Username: user.Username}
+    }
+    return profiles, nil
+}
+
+// postAuthors returns the authors of the given post, author first, using the
+// given profiles.
+func postAuthors(post *models.Post, profiles map[primitive.ObjectID]*models.PostAuthor) []*models.PostAuthor {
+    authors := make([]*models.PostAuthor, 0, len(post.CoAuthors)+1)
+    authors = append(authors, profileOf(profiles, post.AuthorID))
+    for _, coAuthor := range post.CoAuthors {
+        authors = append(authors, profileOf(profiles, coAuthor))
+    }
+    return authors
+}
+
+// profileOf returns the profile of the user with the given ID, or a profile
+// without a name for a user that no longer exists.
+func profileOf(profiles map[primitive.ObjectID]*models.PostAuthor, id primitive.ObjectID) *models.PostAuthor {
+    if profile, ok := profiles[id]; ok {
+        return profile
+    }
+    return &models.PostAuthor{ID: id}
+}
@@ -0,0 +1,524 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+type CommentRepository interface {
+    Create(comment *models.Comment) error
+    GetByID(id string) (*models.Comment, error)
+    GetBySourceID(sourceID string) (*models.Comment, error)
+    Update(id primitive.ObjectID, updates map[string]interface{}) error
+    Delete(id primitive.ObjectID) error
+    HasReplies(id primitive.ObjectID) (bool, error)
+    ListThreads(postID, viewerID primitive.ObjectID, req models.PageRequest) (*models.CommentPage, error)
+    ListByStatus(status string, req models.PageRequest) (*models.CommentPage, error)
+    GetByIDs(ids []primitive.ObjectID) ([]*models.Comment, error)
+    SetStatus(id primitive.ObjectID, from, to string, moderatorID primitive.ObjectID, at time.Time) (bool, error)
+    CountByAuthor(authorID primitive.ObjectID, status string) (int64, error)
+}
+
+// CommentPostRepository is the part of the post storage the comment service
+// needs to check posts and keep their comment counts.
+type CommentPostRepository interface {
+    GetByID(id string) (*models.Post, error)
+    SetCommentsClosed(id primitive.ObjectID, closed bool) error
+    IncrementCommentCount(id primitive.ObjectID, delta int) error
+}
+
+// CommentModeration holds the rules deciding the status of new comments.
+// Comments by trusted users, or by the authors of the post, are approved
+// right away. Other comments scoring at least SpamThreshold are marked as
+// spam; the rest are approved if their author already has AutoApproveAfter
+// approved comments, and wait for an editor otherwise.
+type CommentModeration struct {
+    TrustedUsers     []string   // IDs of the users whose comments are always approved
+    AutoApproveAfter int        // Approved comments after which a user is trusted, 0 never
+    Scorer           SpamScorer // May be nil to skip spam detection
+    SpamThreshold    float64
+}
+
+// commentStatuses lists the valid comment moderation statuses.
+var commentStatuses = map[string]bool{
+    models.CommentStatusPending:  true,
+    models.CommentStatusApproved: true,
+    models.CommentStatusRejected: true,
+    models.CommentStatusSpam:     true,
+}
+
+type CommentService struct {
+    repo       CommentRepository
+    posts      CommentPostRepository
+    access     PostAccess
+    renderer   ContentRenderer
+    moderation CommentModeration
+    trusted    map[string]bool
+}
+
+// NewCommentService returns a new CommentService instance, given a
+// CommentRepository, the post storage, the PostAccess deciding who may read a
+// post, the ContentRenderer used to turn the Markdown of comments into
+// sanitized HTML, and the moderation rules applied to new comments.
+func NewCommentService(repo CommentRepository, posts CommentPostRepository, access PostAccess, renderer ContentRenderer, moderation CommentModeration) *CommentService {
+    trusted := make(map[string]bool, len(moderation.TrustedUsers))
+    for _, id := range moderation.TrustedUsers {
+        trusted[id] = true
+    }
+
+    return &CommentService{
+        repo:       repo,
+        posts:      posts,
+        access:     access,
+        renderer:   renderer,
+        moderation: moderation,
+        trusted:    trusted,
+    }
+}
+
+// List returns a page of the comment threads of the post with the given ID,
+// oldest first. Approved comments on a post are visible to whoever can read
+// the post, as given by viewer; pending comments only to their author.
+func (s *CommentService) List(postID string, viewer models.Viewer, req models.PageRequest) (*models.CommentPage, error) {
+    post, err := s.getVisiblePost(postID, viewer)
+    if err != nil {
+        return nil, err
+    }
+
+    // An anonymous viewer has no ID, and sees approved comments only.
+    viewerObjectID, _ := primitive.ObjectIDFromHex(viewer.UserID)
+    return s.repo.ListThreads(post.ID, viewerObjectID, req)
+}
+
+// Create adds a comment by the given viewer to the published post with the
+// given ID, which the viewer must be able to read. With a non-empty parentID,
+// the comment is a reply to that comment, which must belong to the same post
+// and be approved. The Markdown body is rendered to sanitized HTML, and the
+// comment gets its moderation status from the CommentModeration rules.
+//
+// The returned error will be models.ErrConflict if comments are closed on
+// the post, and models.ErrInvalidInput for an empty or overly long body, or
+// a reply nested deeper than models.MaxCommentDepth.
+func (s *CommentService) Create(postID string, viewer models.Viewer, parentID, body string) (*models.Comment, error) {
+    post, err := s.getVisiblePost(postID, viewer)
+    if err != nil {
+        return nil, err
+    }
+    if !post.IsPublished() {
+        return nil, models.ErrNotFound
+    }
+    if post.CommentsClosed {
+        return nil, fmt.Errorf("%w: comments are closed on this post", models.ErrConflict)
+    }
+
+    authorObjectID, err := primitive.ObjectIDFromHex(viewer.UserID)
+    if err != nil {
+        return nil, models.ErrForbidden
+    }
+
+    now := time.Now()
+    comment := &models.Comment{
+        ID:        primitive.NewObjectID(),
+        PostID:    post.ID,
+        AuthorID:  authorObjectID,
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+    comment.ThreadID = comment.ID
+
+    if parentID != "" {
+        parent, err := s.repo.GetByID(parentID)
+        if errors.Is(err, models.ErrNotFound) {
+            return nil, fmt.Errorf("%w: parent comment not found", models.ErrInvalidInput)
+        }
+        if err != nil {
+            return nil, err
+        }
+        if parent.PostID != post.ID || parent.DeletedAt != nil || !parent.IsApproved() {
+            return nil, fmt.Errorf("%w: parent comment not found", models.ErrInvalidInput)
+        }
+        if parent.Depth >= models.MaxCommentDepth {
+            return nil, fmt.Errorf("%w: replies cannot be nested more than %d levels deep", models.ErrInvalidInput, models.MaxCommentDepth)
+        }
+
+        comment.ParentID = &parent.ID
+        comment.ThreadID = parent.ThreadID
+        comment.Depth = parent.Depth + 1
+    }
+
+    if err := s.setBody(comment, body); err != nil {
+        return nil, err
+    }
+
+    comment.Status, err = s.moderate(post, comment)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.repo.Create(comment); err != nil {
+        return nil, err
+    }
+
+    if !comment.IsApproved() {
+        return comment, nil
+    }
+    return comment, s.posts.IncrementCommentCount(post.ID, 1)
+}
+
+// Import adds a comment brought over from another blog, unless a comment was
+// already imported from the same source, in which case it is left as it is.
+// The comment must have its SourceID, post, author, body, status and
+// creation date set; with a ParentID, it is a reply to that comment, and
+// replies nested deeper than models.MaxCommentDepth become replies to their
+// parent's parent. Imported comments skip moderation and are accepted on
+// posts of any status, even with comments closed.
+//
+// The returned action is models.ImportCreated or models.ImportUnchanged.
+// Nothing is written in a dry run, where the parent is not looked up.
+func (s *CommentService) Import(comment *models.Comment, dryRun bool) (string, error) {
+    if comment.SourceID == "" {
+        return "", fmt.Errorf("%w: imported comments need a source", models.ErrInvalidInput)
+    }
+    if !commentStatuses[comment.Status] {
+        return "", fmt.Errorf("%w: unknown comment status %q", models.ErrInvalidInput, comment.Status)
+    }
+
+    _, err := s.repo.GetBySourceID(comment.SourceID)
+    if err == nil {
+        return models.ImportUnchanged, nil
+    }
+    if !errors.Is(err, models.ErrNotFound) {
+        return "", err
+    }
+
+    if err := s.setBody(comment, comment.Body); err != nil {
+        return "", err
+    }
+    if dryRun {
+        return models.ImportCreated, nil
+    }
+
+    comment.ID = primitive.NewObjectID()
+    comment.ThreadID = comment.ID
+    comment.Depth = 0
+    if comment.ParentID != nil {
+        parent, err := s.repo.GetByID(comment.ParentID.Hex())
+        if err != nil {
+            return "", err
+        }
+        if parent.PostID != comment.PostID {
+            return "", fmt.Errorf("%w: parent comment not found", models.ErrInvalidInput)
+        }
+        comment.ThreadID = parent.ThreadID
+        comment.Depth = parent.Depth + 1
+        if parent.Depth >= models.MaxCommentDepth {
+            comment.ParentID = parent.ParentID
+            comment.Depth = parent.Depth
+        }
+    }
+    if comment.UpdatedAt.Before(comment.CreatedAt) {
+        comment.UpdatedAt = comment.CreatedAt
+    }
+
+    if err := s.repo.Create(comment); err != nil {
+        return "", err
+    }
+
+    if !comment.IsApproved() {
+        return models.ImportCreated, nil
+    }
+    return models.ImportCreated, s.posts.IncrementCommentCount(comment.PostID, 1)
+}
+
+// Update replaces the body of the comment with the given ID on behalf of
+// userID, who must be its author. The new body is scored again, and the
+// comment is marked as spam if it now looks like spam, so that an approved
+// comment cannot be turned into spam afterwards.
+func (s *CommentService) Update(commentID, userID, body string) (*models.Comment, error) {
+    comment, err := s.getOwned(commentID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.setBody(comment, body); err != nil {
+        return nil, err
+    }
+
+    comment.UpdatedAt = time.Now()
+    updates := map[string]interface{}{
+        "body":       comment.Body,
+        "body_html":  comment.BodyHTML,
+        "updated_at": comment.UpdatedAt,
+    }
+
+    wasApproved := comment.IsApproved()
+    if !s.trusted[userID] {
+        spam, err := s.score(comment)
+        if err != nil {
+            return nil, err
+        }
+        updates["spam_score"] = comment.SpamScore
+        if spam {
+            comment.Status = models.CommentStatusSpam
+            updates["status"] = comment.Status
+        }
+    }
+
+    if err := s.repo.Update(comment.ID, updates); err != nil {
+        return nil, err
+    }
+
+    if wasApproved && !comment.IsApproved() {
+        return comment, s.posts.IncrementCommentCount(comment.PostID, -1)
+    }
+    return comment, nil
+}
+
+// Delete deletes the comment with the given ID on behalf of userID, who must
+// be its author. A comment that has replies is kept as an empty placeholder,
+// so that the thread stays intact.
+func (s *CommentService) Delete(commentID, userID string) error {
+    comment, err := s.getOwned(commentID, userID)
+    if err != nil {
+        return err
+    }
+
+    hasReplies, err := s.repo.HasReplies(comment.ID)
+    if err != nil {
+        return err
+    }
+
+    if hasReplies {
+        now := time.Now()
+        err = s.repo.Update(comment.ID, map[string]interface{}{
+            "body":       "",
+            "body_html":  "",
+            "deleted_at": now,
+            "updated_at": now,
+        })
+    } else {
+        err = s.repo.Delete(comment.ID)
+    }
+    if err != nil {
+        return err
+    }
+
+    if !comment.IsApproved() {
+        return nil
+    }
+    return s.posts.IncrementCommentCount(comment.PostID, -1)
+}
+
+// SetClosed closes or reopens comments on the post with the given ID, on
+// behalf of userID, who must be the post's author. Existing comments stay
+// visible on a post whose comments are closed. This is not an edit of the
+// post: its version is kept and no revision is recorded.
+func (s *CommentService) SetClosed(postID, userID string, closed bool) (*models.Post, error) {
+    post, err := s.posts.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+    if post.AuthorID.Hex() != userID {
+        return nil, models.ErrForbidden
+    }
+
+    if err := s.posts.SetCommentsClosed(post.ID, closed); err != nil {
+        return nil, err
+    }
+
+    post.CommentsClosed = closed
+    return post, nil
+}
+
+// Queue returns a page of the comments with the given moderation status, on
+// any post, oldest first, for editors to review. An empty status lists the
+// pending comments.
+//
+// The returned error will be models.ErrInvalidInput for an unknown status.
+func (s *CommentService) Queue(status string, req models.PageRequest) (*models.CommentPage, error) {
+    if status == "" {
+        status = models.CommentStatusPending
+    }
+    if !commentStatuses[status] {
+        return nil, fmt.Errorf("%w: unknown comment status %q", models.ErrInvalidInput, status)
+    }
+
+    return s.repo.ListByStatus(status, req)
+}
+
+// Moderate gives the comments with the given IDs the given moderation status
+// on behalf of moderatorID, and keeps the comment counts of their posts in
+// step. Unknown IDs, and comments that already have the status, are skipped.
+// It returns the number of comments changed.
+//
+// The returned error will be models.ErrInvalidInput for an unknown status,
+// malformed IDs, or more than models.MaxPageLimit IDs at once.
+func (s *CommentService) Moderate(commentIDs []string, status, moderatorID string) (int64, error) {
+    if !commentStatuses[status] {
+        return 0, fmt.Errorf("%w: unknown comment status %q", models.ErrInvalidInput, status)
+    }
+    if len(commentIDs) == 0 {
+        return 0, fmt.Errorf("%w: no comments given", models.ErrInvalidInput)
+    }
+    if len(commentIDs) > models.MaxPageLimit {
+        return 0, fmt.Errorf("%w: at most %d comments can be moderated at once", models.ErrInvalidInput, models.MaxPageLimit)
+    }
+
+    moderatorObjectID, err := primitive.ObjectIDFromHex(moderatorID)
+    if err != nil {
+        return 0, models.ErrForbidden
+    }
+
+    ids := make([]primitive.ObjectID, len(commentIDs))
+    for i, commentID := range commentIDs {
+        id, err := primitive.ObjectIDFromHex(commentID)
+        if err != nil {
+            return 0, fmt.Errorf("%w: invalid comment ID %q", models.ErrInvalidInput, commentID)
+        }
+        ids[i] = id
+    }
+
+    comments, err := s.repo.GetByIDs(ids)
+    if err != nil {
+        return 0, err
+    }
+
+    approve := status == models.CommentStatusApproved
+    now := time.Now()
+    var changed int64
+    for _, comment := range comments {
+        from := comment.Status
+        if comment.IsApproved() {
+            from = models.CommentStatusApproved
+        }
+        if from == status {
+            continue
+        }
+
+        ok, err := s.repo.SetStatus(comment.ID, from, status, moderatorObjectID, now)
+        if err != nil {
+            return changed, err
+        }
+        if !ok {
+            // Another moderator got there first.
+            continue
+        }
+        changed++
+
+        if approve != comment.IsApproved() {
+            delta := -1
+            if approve {
+                delta = 1
+            }
+            if err := s.posts.IncrementCommentCount(comment.PostID, delta); err != nil {
+                return changed, err
+            }
+        }
+    }
+
+    return changed, nil
+}
+
+// moderate returns the status a new comment on the given post gets from the
+// CommentModeration rules, and records its spam score.
+func (s *CommentService) moderate(post *models.Post, comment *models.Comment) (string, error) {
+    if s.trusted[comment.AuthorID.Hex()] || post.IsAuthor(comment.AuthorID.Hex()) {
+        return models.CommentStatusApproved, nil
+    }
+
+    spam, err := s.score(comment)
+    if err != nil {
+        return "", err
+    }
+    if spam {
+        return models.CommentStatusSpam, nil
+    }
+
+    if s.moderation.AutoApproveAfter > 0 {
+        approved, err := s.repo.CountByAuthor(comment.AuthorID, models.CommentStatusApproved)
+        if err != nil {
+            return "", err
+        }
+        if approved >= int64(s.moderation.AutoApproveAfter) {
+            return models.CommentStatusApproved, nil
+        }
+    }
+
+    return models.CommentStatusPending, nil
+}
+
+// score sets the spam score of the comment, and reports whether it reaches
+// the spam threshold.
+func (s *CommentService) score(comment *models.Comment) (bool, error) {
+    if s.moderation.Scorer == nil {
+        return false, nil
+    }
+
+    score, err := s.moderation.Scorer.Score(comment)
+    if err != nil {
+        return false, err
+    }
+
+    comment.SpamScore = score
+    return score >= s.moderation.SpamThreshold, nil
+}
+
+// setBody validates the given Markdown body and sets it, rendered to
+// sanitized HTML, on the comment.
+func (s *CommentService) setBody(comment *models.Comment, body string) error {
+    body = strings.TrimSpace(body)
+    if body == "" {
+        return fmt.Errorf("%w: comments cannot be empty", models.ErrInvalidInput)
+    }
+    if utf8.RuneCountInString(body) > models.MaxCommentLength {
+        return fmt.Errorf("%w: comments are limited to %d characters", models.ErrInvalidInput, models.MaxCommentLength)
+    }
+
+    html, err := s.renderer.Render(utils.FormatMarkdown, body)
+    if err != nil {
+        return err
+    }
+
+    comment.Body = body
+    comment.BodyHTML = html
+    return nil
+}
+
+// getVisiblePost loads the post with the given ID, applying the same
+// visibility rules as PostService.Get.
+func (s *CommentService) getVisiblePost(postID string, viewer models.Viewer) (*models.Post, error) {
+    post, err := s.posts.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.access.CanRead(post, viewer); err != nil {
+        return nil, err
+    }
+
+    return post, nil
+}
+
+// getOwned loads the comment with the given ID and checks that userID is its
+// author. Deleted placeholders cannot be changed.
+func (s *CommentService) getOwned(commentID, userID string) (*models.Comment, error) {
+    comment, err := s.repo.GetByID(commentID)
+    if err != nil {
+        return nil, err
+    }
+    if comment.DeletedAt != nil {
+        return nil, models.ErrNotFound
+    }
+
+    if comment.AuthorID.Hex() != userID {
+        return nil, models.ErrForbidden
+    }
+
+    return comment, nil
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+    "errors"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "sort"
+    "strings"
+    "testing"
+    "time"
+)
+
+// fakeCommentRepo is an in-memory CommentRepository, which also counts the
+// comment activity of users for the spam scorer.
+type fakeCommentRepo struct {
+    comments map[primitive.ObjectID]*models.Comment
+}
+
+func newFakeCommentRepo() *fakeCommentRepo {
+    return &fakeCommentRepo{comments: map[primitive.ObjectID]*models.Comment{}}
+}
+
+// status returns the moderation status of the given comment, comments
+// without a status being approved.
+func (r *fakeCommentRepo) status(comment *models.Comment) string {
+    if comment.IsApproved() {
+        return models.CommentStatusApproved
+    }
+    return comment.Status
+}
+
+func (r *fakeCommentRepo) Create(comment *models.Comment) error {
+    stored := *comment
+    r.comments[comment.ID] = &stored
+    return nil
+}
+
+func (r *fakeCommentRepo) GetByID(id string) (*models.Comment, error) {
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return nil, models.ErrNotFound
+    }
+    comment, ok := r.comments[objectID]
+    if !ok {
+        return nil, models.ErrNotFound
+    }
+    found := *comment
+    return &found, nil
+}
+
+func (r *fakeCommentRepo) GetBySourceID(sourceID string) (*models.Comment, error) {
+    for _, comment := range r.comments {
+        if comment.SourceID == sourceID {
+            found := *comment
+            return &found, nil
+        }
+    }
+    return nil, models.ErrNotFound
+}
+
+func (r *fakeCommentRepo) Update(id primitive.ObjectID, updates map[string]interface{}) error {
+    comment, ok := r.comments[id]
+    if !ok {
+        return models.ErrNotFound
+    }
+    for key, value := range updates {
+        switch key {
+        case "body":
+            comment.Body = value.(string)
+        case "body_html":
+            comment.BodyHTML = value.(string)
+        case "status":
+            comment.Status = value.(string)
+        case "spam_score":
+            comment.SpamScore = value.(float64)
+        case "updated_at":
+            comment.UpdatedAt = value.(time.Time)
+        case "deleted_at":
+            at := value.(time.Time)
+            comment.DeletedAt = &at
+        }
+    }
+    return nil
+}
+
+func (r *fakeCommentRepo) Delete(id primitive.ObjectID) error {
+    delete(r.comments, id)
+    return nil
+}
+
+func (r *fakeCommentRepo) HasReplies(id primitive.ObjectID) (bool, error) {
+    for _, comment := range r.comments {
+        if comment.ParentID != nil && *comment.ParentID == id {
+            return true, nil
+        }
+    }
+    return false, nil
+}
+
+func (r *fakeCommentRepo) ListThreads(postID, viewerID primitive.ObjectID, req models.PageRequest) (*models.CommentPage, error) {
+    page := &models.CommentPage{Comments: []*models.Comment{}}
+    for _, comment := range r.sorted() {
+        if comment.PostID == postID && comment.ParentID == nil && (comment.IsApproved() || comment.AuthorID == viewerID) {
+            page.Comments = append(page.Comments, comment)
+        }
+    }
+    return page, nil
+}
+
+func (r *fakeCommentRepo) ListByStatus(status string, req models.PageRequest) (*models.CommentPage, error) {
+    page := &models.CommentPage{Comments: []*models.Comment{}}
+    for _, comment := range r.sorted() {
+        if r.status(comment) == status {
+            page.Comments = append(page.Comments, comment)
+        }
+    }
+    return page, nil
+}
+
+// sorted returns copies of all the comments, oldest first.
+func (r *fakeCommentRepo) sorted() []*models.Comment {
+    comments := make([]*models.Comment, 0, len(r.comments))
+    for _, comment := range r.comments {
+        found := *comment
+        comments = append(comments, &found)
+    }
+    sort.Slice(comments, func(i, j int) bool {
+        return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+    })
+    return comments
+}
+
+func (r *fakeCommentRepo) GetByIDs(ids []primitive.ObjectID) ([]*models.Comment, error) {
+    comments := []*models.Comment{}
+    for _, id := range ids {
+        if comment, ok := r.comments[id]; ok {
+            found := *comment
+            comments = append(comments, &found)
+        }
+    }
+    return comments, nil
+}
+
+func (r *fakeCommentRepo) SetStatus(id primitive.ObjectID, from, to string, moderatorID primitive.ObjectID, at time.Time) (bool, error) {
+    comment, ok := r.comments[id]
+    if !ok || r.status(comment) != from {
+        return false, nil
+    }
+    comment.Status = to
+    comment.ModeratedBy = &moderatorID
+    comment.ModeratedAt = &at
+    return true, nil
+}
+
+func (r *fakeCommentRepo) CountByAuthor(authorID primitive.ObjectID, status string) (int64, error) {
+    var count int64
+    for _, comment := range r.comments {
+        if comment.AuthorID == authorID && r.status(comment) == status {
+            count++
+        }
+    }
+    return count, nil
+}
+
+func (r *fakeCommentRepo) CountByAuthorSince(authorID primitive.ObjectID, since time.Time) (int64, error) {
+    var count int64
+    for _, comment := range r.comments {
+        if comment.AuthorID == authorID && !comment.CreatedAt.Before(since) {
+            count++
+        }
+    }
+    return count, nil
+}
+
+// newCommentFixture returns a CommentService on the posts of a postFixture,
+// with the given comment storage and moderation rules.
+func newCommentFixture(t *testing.T, repo CommentRepository, moderation CommentModeration) (*CommentService, *postFixture) {
+    t.Helper()
+
+    f := newPostFixture(t)
+    return NewCommentService(repo, f.posts, f.service, utils.NewContentRenderer(), moderation), f
+}
+
+func TestCommentServiceSetClosed(t *testing.T) {
+    service, f := newCommentFixture(t, nil, CommentModeration{})
+    post := f.create(t, &models.Post{Status: models.PostStatusPublished}, "")
+    version := f.posts.find(post.ID.Hex()).Version
+    revisions := len(f.revisions.of(post.ID))
+
+    if _, err := service.SetClosed(post.ID.Hex(), "someone-else", true); !errors.Is(err, models.ErrForbidden) {
+        t.Fatalf("SetClosed() by another user error = %v, want ErrForbidden", err)
+    }
+
+    for _, closed := range []bool{true, false} {
+        got, err := service.SetClosed(post.ID.Hex(), post.AuthorID.Hex(), closed)
+        if err != nil {
+            t.Fatalf("SetClosed(%v) error = %v", closed, err)
+        }
+        if got.CommentsClosed != closed || got.Version != version {
+            t.Errorf("SetClosed(%v) = closed %v, version %d, want closed %v, version %d", closed, got.CommentsClosed, got.Version, closed, version)
+        }
+
+        stored := f.posts.find(post.ID.Hex())
+        if stored.CommentsClosed != closed || stored.Version != version {
+            t.Errorf("stored post after SetClosed(%v) = closed %v, version %d, want closed %v, version %d", closed, stored.CommentsClosed, stored.Version, closed, version)
+        }
+    }
+
+    if got := len(f.revisions.of(post.ID)); got != revisions {
+        t.Errorf("revisions after SetClosed() = %d, want %d", got, revisions)
+    }
+    if len(f.listener.saved) != 1 {
+        t.Errorf("listener notified %d times, want once for the creation", len(f.listener.saved))
+    }
+}
+
+// fixedScorer is a SpamScorer giving every comment the same score.
+type fixedScorer float64
+
+func (s fixedScorer) Score(comment *models.Comment) (float64, error) {
+    return float64(s), nil
+}
+
+func TestCommentServiceModeration(t *testing.T) {
+    trusted := primitive.NewObjectID()
+    regular := primitive.NewObjectID()
+
+    tests := []struct {
+        name       string
+        author     func(post *models.Post) primitive.ObjectID
+        score      float64
+        approved   int // Comments by the author approved beforehand
+        wantStatus string
+    }{
+        {"trusted user", func(*models.Post) primitive.ObjectID { return trusted }, 0.9, 0, models.CommentStatusApproved},
+        {"post author", func(post *models.Post) primitive.ObjectID { return post.AuthorID }, 0.9, 0, models.CommentStatusApproved},
+        {"below threshold", func(*models.Post) primitive.ObjectID { return regular }, 0.59, 0, models.CommentStatusPending},
+        {"at threshold", func(*models.Post) primitive.ObjectID { return regular }, 0.6, 0, models.CommentStatusSpam},
+        {"spam despite history", func(*models.Post) primitive.ObjectID { return regular }, 0.6, 5, models.CommentStatusSpam},
+        {"history too short", func(*models.Post) primitive.ObjectID { return regular }, 0, 1, models.CommentStatusPending},
+        {"auto-approved", func(*models.Post) primitive.ObjectID { return regular }, 0, 2, models.CommentStatusApproved},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            comments := newFakeCommentRepo()
+            service, f := newCommentFixture(t, comments, CommentModeration{
+                TrustedUsers:     []string{trusted.Hex()},
+                AutoApproveAfter: 2,
+                Scorer:           fixedScorer(tt.score),
+                SpamThreshold:    0.6,
+            })
+            post := f.create(t, &models.Post{Status: models.PostStatusPublished}, "")
+            author := tt.author(post)
+            for i := 0; i < tt.approved; i++ {
+                comments.Create(&models.Comment{ID: primitive.NewObjectID(), AuthorID: author, Status: models.CommentStatusApproved})
+            }
+
+            comment, err := service.Create(post.ID.Hex(), models.Viewer{UserID: author.Hex()}, "", "Nice post!")
+            if err != nil {
+                t.Fatalf("Create() error = %v", err)
+            }
+            if comment.Status != tt.wantStatus {
+                t.Errorf("Create() status = %q, want %q", comment.Status, tt.wantStatus)
+            }
+
+            wantCount := int64(0)
+            if tt.wantStatus == models.CommentStatusApproved {
+                wantCount = 1
+            }
+            if got := f.posts.find(post.ID.Hex()).CommentCount; got != wantCount {
+                t.Errorf("comment count = %d, want %d", got, wantCount)
+            }
+        })
+    }
+}
+
+func TestCommentServiceQueue(t *testing.T) {
+    comments := newFakeCommentRepo()
+    service, f := newCommentFixture(t, comments, CommentModeration{})
+    post := f.create(t, &models.Post{Status: models.PostStatusPublished}, "")
+    moderator := primitive.NewObjectID().Hex()
+
+    start := time.Now().Add(-time.Hour)
+    add := func(status string, minutes int) *models.Comment {
+        comment := &models.Comment{
+            ID:        primitive.NewObjectID(),
+            PostID:    post.ID,
+            AuthorID:  primitive.NewObjectID(),
+            Status:    status,
+            CreatedAt: start.Add(time.Duration(minutes) * time.Minute),
+        }
+        comments.Create(comment)
+        return comment
+    }
+    second := add(models.CommentStatusPending, 2)
+    first := add(models.CommentStatusPending, 1)
+    spam := add(models.CommentStatusSpam, 3)
+    legacy := add("", 0)
+
+    page, err := service.Queue("", models.PageRequest{})
+    if err != nil {
+        t.Fatalf("Queue() error = %v", err)
+    }
+    if got := commentIDs(page.Comments); got != commentIDs([]*models.Comment{first, second}) {
+        t.Errorf("Queue() = %s, want the pending comments oldest first", got)
+    }
+
+    if _, err := service.Queue("unknown", models.PageRequest{}); !errors.Is(err, models.ErrInvalidInput) {
+        t.Errorf("Queue(unknown) error = %v, want ErrInvalidInput", err)
+    }
+
+    // Approving counts the comments on the post; comments that already have
+    // the status, like the one without a status, are skipped.
+    changed, err := service.Moderate([]string{first.ID.Hex(), spam.ID.Hex(), legacy.ID.Hex()}, models.CommentStatusApproved, moderator)
+    if err != nil {
+        t.Fatalf("Moderate(approved) error = %v", err)
+    }
+    if changed != 2 {
+        t.Errorf("Moderate(approved) changed %d comments, want 2", changed)
+    }
+    if got := f.posts.find(post.ID.Hex()).CommentCount; got != 2 {
+        t.Errorf("comment count after approving = %d, want 2", got)
+    }
+    if stored := comments.comments[first.ID]; stored.ModeratedBy == nil || stored.ModeratedBy.Hex() != moderator || stored.ModeratedAt == nil {
+        t.Errorf("approved comment was not marked as moderated by %s", moderator)
+    }
+
+    page, err = service.Queue(models.CommentStatusPending, models.PageRequest{})
+    if err != nil {
+        t.Fatalf("Queue(pending) error = %v", err)
+    }
+    if got := commentIDs(page.Comments); got != commentIDs([]*models.Comment{second}) {
+        t.Errorf("Queue(pending) after approving = %s, want %s", got, second.ID.Hex())
+    }
+
+    // Rejecting an approved comment takes it out of the count again.
+    if changed, err := service.Moderate([]string{legacy.ID.Hex(), second.ID.Hex()}, models.CommentStatusRejected, moderator); err != nil || changed != 2 {
+        t.Fatalf("Moderate(rejected) = %d, %v, want 2, nil", changed, err)
+    }
+    if got := f.posts.find(post.ID.Hex()).CommentCount; got != 1 {
+        t.Errorf("comment count after rejecting = %d, want 1", got)
+    }
+
+    for _, ids := range [][]string{nil, {"not-an-id"}} {
+        if _, err := service.Moderate(ids, models.CommentStatusApproved, moderator); !errors.Is(err, models.ErrInvalidInput) {
+            t.Errorf("Moderate(%v) error = %v, want ErrInvalidInput", ids, err)
+        }
+    }
+    if _, err := service.Moderate([]string{first.ID.Hex()}, "unknown", moderator); !errors.Is(err, models.ErrInvalidInput) {
+        t.Errorf("Moderate(unknown status) error = %v, want ErrInvalidInput", err)
+    }
+}
+
+// commentIDs returns the IDs of the given comments, in order.
+func commentIDs(comments []*models.Comment) string {
+    ids := make([]string, len(comments))
+    for i, comment := range comments {
+        ids[i] = comment.ID.Hex()
+    }
+    return strings.Join(ids, ",")
+}
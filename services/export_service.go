@@ -0,0 +1,304 @@
+package services
+
+import (
+    "bytes"
+    "fmt"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/archive"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "gopkg.in/yaml.v3"
+    "io"
+    "path"
+    "strings"
+    "time"
+)
+
+// ExportPostRepository is the part of the post storage used to go through
+// the published posts.
+type ExportPostRepository interface {
+    List(filter models.PostFilter, req models.PageRequest) (*models.PostPage, error)
+}
+
+// ExportCategoryRepository is the part of the category storage used to name
+// the categories of exported posts.
+type ExportCategoryRepository interface {
+    List() ([]*models.Category, error)
+}
+
+// ExportImageStore reads uploaded images back.
+type ExportImageStore interface {
+    OpenImage(filename string) (io.ReadCloser, error)
+}
+
+// ExportService writes the published posts of the blog out as a static site
+// bundle, for backups or to leave for another platform.
+type ExportService struct {
+    posts      ExportPostRepository
+    categories ExportCategoryRepository
+    users      AuthorRepository
+    images     ExportImageStore
+    imageURL   string
+}
+
+// NewExportService returns a new ExportService instance, given the post,
+// category and user storage, and the ExportImageStore holding uploaded
+// images and the public URL they are served from.
+func NewExportService(posts ExportPostRepository, categories ExportCategoryRepository, users AuthorRepository, images ExportImageStore, imageURL string) *ExportService {
+    if imageURL != "" {
+        imageURL = strings.TrimSuffix(imageURL, "/") + "/"
+    }
+
+    return &ExportService{
+        posts:      posts,
+        categories: categories,
+        users:      users,
+        images:     images,
+        imageURL:   imageURL,
+    }
+}
+
+// hugoFrontMatter is the front matter of an exported post, in the order it is
+// written.
+type hugoFrontMatter struct {
+    Title       string     `yaml:"title"`
+    Slug        string     `yaml:"slug,omitempty"`
+    Date        time.Time  `yaml:"date"`
+    PublishDate *time.Time `yaml:"publishDate,omitempty"`
+    Lastmod     time.Time  `yaml:"lastmod"`
+    Summary     string     `yaml:"summary,omitempty"`
+    Tags        []string   `yaml:"tags,omitempty"`
+    Categories  []string   `yaml:"categories,omitempty"`
+    Authors     []string   `yaml:"authors,omitempty"`
+    Cover       *hugoImage `yaml:"cover,omitempty"`
+    Build       *hugoBuild `yaml:"build,omitempty"`
+}
+
+type hugoImage struct {
+    Image string `yaml:"image"`
+}
+
+// hugoBuild holds the build options of a page. Unlisted posts are built with
+// list set to "never", so that they are left out of list pages and feeds.
+type hugoBuild struct {
+    List string `yaml:"list"`
+}
+
+// ExportHugo writes every published public or unlisted post to w as a Hugo
+// page bundle: content/posts/<slug>/index.md with YAML front matter, next to
+// the images the post uses, and an index.<language>.md per translation.
+// Markdown posts keep their source, other posts are written as index.html
+// with their rendered content. Uploaded images are downloaded and linked relatively;
+// images hosted elsewhere keep their links. Private and password-protected
+// posts are left out, since a static site cannot restrict who reads them.
+//
+// Posts are read a page at a time and images streamed, so the export never
+// holds more than a page of posts in memory. Images that cannot be
+// downloaded are reported as warnings; an error is only returned when the
+// posts cannot be read or w cannot be written, in which case the export is
+// incomplete. w is not closed.
+func (s *ExportService) ExportHugo(w archive.Writer) (*models.ExportReport, error) {
+    categories, err := s.categories.List()
+    if err != nil {
+        return nil, err
+    }
+    categorySlugs := make(map[primitive.ObjectID]string, len(categories))
+    for _, category := range categories {
+        categorySlugs[category.ID] = category.Slug
+    }
+
+    report := &models.ExportReport{}
+    filter := models.PostFilter{
+        Statuses:     []string{models.PostStatusPublished},
+        Visibilities: []string{models.VisibilityPublic, models.VisibilityUnlisted},
+    }
+    req := models.PageRequest{Limit: models.MaxPageLimit}
+    for {
+        page, err := s.posts.List(filter, req)
+        if err != nil {
+            return report, err
+        }
+        if err := attachAuthors(s.users, page.Posts...); err != nil {
+            return report, err
+        }
+
+        for _, post := range page.Posts {
+            if err := s.exportPost(w, post, categorySlugs, report); err != nil {
+                return report, err
+            }
+            report.Posts++
+        }
+
+        if !page.Pagination.HasMore {
+            return report, nil
+        }
+        req.Cursor = page.Pagination.NextCursor
+    }
+}
+
+// exportPost writes the page bundle of the given post, with a page per
+// language: index.md for the post itself and index.<language>.md for each of
+// its variants, as in Hugo's multilingual bundles.
+func (s *ExportService) exportPost(w archive.Writer, post *models.Post, categorySlugs map[primitive.ObjectID]string, report *models.ExportReport) error {
+    bundle := post.Slug
+    if bundle == "" {
+        bundle = post.ID.Hex()
+    }
+    dir := "content/posts/" + bundle + "/"
+
+    // Uploaded images are copied into the bundle, shared by its pages, and
+    // linked by file name.
+    links := append([]string{post.ImageURL}, utils.ImageURLs(post.ContentHTML)...)
+    used := map[string]bool{"index.md": true, "index.html": true}
+    for _, variant := range post.Variants {
+        links = append(links, utils.ImageURLs(variant.ContentHTML)...)
+        used["index."+variant.Language+".md"] = true
+        used["index."+variant.Language+".html"] = true
+    }
+    local := map[string]string{}
+    for _, link := range links {
+        if _, ok := local[link]; ok || !strings.HasPrefix(link, s.imageURL) || s.imageURL == "" || len(link) == len(s.imageURL) {
+            continue
+        }
+        filename := strings.TrimPrefix(link, s.imageURL)
+        file := uniqueName(path.Base(filename), used)
+        if err := s.exportImage(w, dir+file, filename, post.UpdatedAt); err != nil {
+            if _, isImageError := err.(imageError); !isImageError {
+                return err
+            }
+            report.Warnings = append(report.Warnings, fmt.Sprintf("%s: image %s not exported: %v", bundle, link, err))
+            continue
+        }
+        used[file] = true
+        local[link] = file
+        report.Images++
+    }
+
+    front := hugoFrontMatter{
+        Title:   post.Title,
+        Slug:    post.Slug,
+        Date:    post.CreatedAt.UTC().Truncate(time.Second),
+        Lastmod: post.UpdatedAt.UTC().Truncate(time.Second),
+        Tags:    post.Tags,
+    }
+    if post.PublishedAt != nil {
+        published := post.PublishedAt.UTC().Truncate(time.Second)
+        front.PublishDate = &published
+    }
+    if post.CustomExcerpt {
+        front.Summary = post.Excerpt
+    }
+    if post.CategoryID != nil && categorySlugs[*post.CategoryID] != "" {
+        front.Categories = []string{categorySlugs[*post.CategoryID]}
+    }
+    for _, author := range post.Authors {
+        if author.Username == "" {
+            continue
+        }
+        front.Authors = append(front.Authors, author.Username)
+    }
+    if post.ImageURL != "" {
+        cover := post.ImageURL
+        if file, ok := local[cover]; ok {
+            cover = file
+        }
+        front.Cover = &hugoImage{Image: cover}
+    }
+    if post.Visibility == models.VisibilityUnlisted {
+        front.Build = &hugoBuild{List: "never"}
+    }
+
+    if err := writePage(w, dir+"index", post.ContentFormat, post.Content, post.ContentHTML, front, local, post.UpdatedAt); err != nil {
+        return err
+    }
+
+    // Variants share the front matter of the post, but for their title,
+    // slug, summary and dates.
+    for _, variant := range post.Variants {
+        translated := front
+        translated.Title = variant.Title
+        translated.Slug = variant.Slug
+        translated.Summary = ""
+        if variant.CustomExcerpt {
+            translated.Summary = variant.Excerpt
+        }
+        translated.Date = variant.CreatedAt.UTC().Truncate(time.Second)
+        translated.Lastmod = variant.UpdatedAt.UTC().Truncate(time.Second)
+
+        name := dir + "index." + variant.Language
+        if err := writePage(w, name, variant.ContentFormat, variant.Content, variant.ContentHTML, translated, local, variant.UpdatedAt); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// writePage writes a page of a bundle, given its name without extension:
+// the front matter followed by the Markdown source, or by the rendered HTML
+// for content in another format, with the links to the images copied into
+// the bundle replaced by their file names.
+func writePage(w archive.Writer, name, format, source, html string, front hugoFrontMatter, local map[string]string, modified time.Time) error {
+    content, ext := source, ".md"
+    if format != utils.FormatMarkdown {
+        content, ext = html, ".html"
+    }
+    for link, file := range local {
+        content = strings.ReplaceAll(content, link, file)
+    }
+
+    var buf bytes.Buffer
+    buf.WriteString("---\n")
+    encoder := yaml.NewEncoder(&buf)
+    encoder.SetIndent(2)
+    if err := encoder.Encode(front); err != nil {
+        return err
+    }
+    encoder.Close()
+    buf.WriteString("---\n\n")
+    buf.WriteString(content)
+    if !strings.HasSuffix(content, "\n") {
+        buf.WriteString("\n")
+    }
+
+    return w.Write(name+ext, modified, func(out io.Writer) error {
+        _, err := buf.WriteTo(out)
+        return err
+    })
+}
+
+// imageError is an error downloading an image, as opposed to writing it.
+type imageError struct {
+    error
+}
+
+// exportImage streams the uploaded image with the given filename to the file
+// with the given name in w. An image that cannot be downloaded is reported
+// as an imageError before anything is written.
+func (s *ExportService) exportImage(w archive.Writer, name, filename string, modified time.Time) error {
+    image, err := s.images.OpenImage(filename)
+    if err != nil {
+        return imageError{err}
+    }
+    defer image.Close()
+
+    return w.Write(name, modified, func(out io.Writer) error {
+        _, err := io.Copy(out, image)
+        return err
+    })
+}
+
+// uniqueName returns the given file name, or the name with a number added if
+// it is already used.
+func uniqueName(name string, used map[string]bool) string {
+    if !used[name] {
+        return name
+    }
+    ext := path.Ext(name)
+    base := strings.TrimSuffix(name, ext)
+    for i := 2; ; i++ {
+        candidate := fmt.Sprintf("%s-%d%s", base, i, ext)
+        if !used[candidate] {
+            return candidate
+        }
+    }
+}
@@ -0,0 +1,576 @@
+package services
+
+import (
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "sort"
+    "testing"
+    "time"
+)
+
+// fakePostRepo is an in-memory PostRepository. Posts go through BSON on the
+// way in and out, as with MongoDB, so callers never share a post with it.
+type fakePostRepo struct {
+    posts map[primitive.ObjectID]bson.M
+    // getHook, if set, is called with every post GetByID returns, to
+    // simulate concurrent writes.
+    getHook func(post *models.Post)
+    // listedFields records the fields asked for by every ListFields call,
+    // and gotIDs the IDs asked for by every GetByIDs call.
+    listedFields [][]string
+    gotIDs       [][]primitive.ObjectID
+}
+
+func newFakePostRepo() *fakePostRepo {
+    return &fakePostRepo{posts: map[primitive.ObjectID]bson.M{}}
+}
+
+func toDocument(post *models.Post) bson.M {
+    data, err := bson.Marshal(post)
+    if err != nil {
+        panic(err)
+    }
+    var doc bson.M
+    if err := bson.Unmarshal(data, &doc); err != nil {
+        panic(err)
+    }
+    return doc
+}
+
+func toPost(doc bson.M) *models.Post {
+    data, err := bson.Marshal(doc)
+    if err != nil {
+        panic(err)
+    }
+    var post models.Post
+    if err := bson.Unmarshal(data, &post); err != nil {
+        panic(err)
+    }
+    return &post
+}
+
+// put stores the given post as is, giving it an ID if it has none.
+func (r *fakePostRepo) put(post *models.Post) *models.Post {
+    if post.ID.IsZero() {
+        post.ID = primitive.NewObjectID()
+    }
+    r.posts[post.ID] = toDocument(post)
+    return post
+}
+
+// find returns the stored post with the given hex ID, live or deleted.
+func (r *fakePostRepo) find(id string) *models.Post {
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return nil
+    }
+    doc, ok := r.posts[objectID]
+    if !ok {
+        return nil
+    }
+    return toPost(doc)
+}
+
+// set applies the given updates to the stored post, as a MongoDB $set does.
+func (r *fakePostRepo) set(id primitive.ObjectID, updates map[string]interface{}) {
+    doc := toDocument(toPost(r.posts[id]))
+    for key, value := range updates {
+        doc[key] = value
+    }
+    r.posts[id] = toDocument(toPost(doc))
+}
+
+func (r *fakePostRepo) live(id string) (*models.Post, error) {
+    post := r.find(id)
+    if post == nil || post.DeletedAt != nil {
+        return nil, models.ErrNotFound
+    }
+    return post, nil
+}
+
+func (r *fakePostRepo) Create(post *models.Post) error {
+    r.put(post)
+    return nil
+}
+
+func (r *fakePostRepo) GetByID(id string) (*models.Post, error) {
+    post, err := r.live(id)
+    if err == nil && r.getHook != nil {
+        r.getHook(post)
+    }
+    return post, err
+}
+
+func (r *fakePostRepo) GetByIDs(ids []primitive.ObjectID) ([]*models.Post, error) {
+    r.gotIDs = append(r.gotIDs, ids)
+    posts := []*models.Post{}
+    for _, id := range ids {
+        if post, err := r.live(id.Hex()); err == nil {
+            posts = append(posts, post)
+        }
+    }
+    return posts, nil
+}
+
+func (r *fakePostRepo) GetBySlug(slug string) (*models.Post, error) {
+    for id := range r.posts {
+        if post, err := r.live(id.Hex()); err == nil && post.Slug == slug {
+            return post, nil
+        }
+    }
+    return nil, models.ErrNotFound
+}
+
+func (r *fakePostRepo) GetBySourceID(sourceID string) (*models.Post, error) {
+    for _, doc := range r.posts {
+        if post := toPost(doc); post.SourceID == sourceID {
+            return post, nil
+        }
+    }
+    return nil, models.ErrNotFound
+}
+
+func (r *fakePostRepo) GetDeletedByID(id string) (*models.Post, error) {
+    post := r.find(id)
+    if post == nil || post.DeletedAt == nil {
+        return nil, models.ErrNotFound
+    }
+    return post, nil
+}
+
+func (r *fakePostRepo) Update(id string, version *int64, updates map[string]interface{}) error {
+    post, err := r.live(id)
+    if err != nil {
+        return err
+    }
+    if version != nil && *version != post.Version {
+        return models.ErrVersionConflict
+    }
+
+    r.set(post.ID, updates)
+    r.set(post.ID, map[string]interface{}{"version": post.Version + 1})
+    return nil
+}
+
+func (r *fakePostRepo) SoftDelete(id string, version *int64, deletedBy primitive.ObjectID, at time.Time) error {
+    post, err := r.live(id)
+    if err != nil {
+        return err
+    }
+    if version != nil && *version != post.Version {
+        return models.ErrVersionConflict
+    }
+
+    r.set(post.ID, map[string]interface{}{"deleted_at": at, "deleted_by": deletedBy, "version": post.Version + 1})
+    return nil
+}
+
+func (r *fakePostRepo) Restore(id string) error {
+    post, err := r.GetDeletedByID(id)
+    if err != nil {
+        return err
+    }
+
+    doc := r.posts[post.ID]
+    delete(doc, "deleted_at")
+    delete(doc, "deleted_by")
+    doc["version"] = post.Version + 1
+    return nil
+}
+
+// List returns every post matching the status, visibility, author and
+// deletion conditions of the filter, newest first, on a single page.
+func (r *fakePostRepo) List(filter models.PostFilter, req models.PageRequest) (*models.PostPage, error) {
+    page := &models.PostPage{Posts: []*models.Post{}}
+    for _, doc := range r.posts {
+        post := toPost(doc)
+        if (post.DeletedAt != nil) != filter.Deleted || !matchesFilter(post, filter) {
+            continue
+        }
+        page.Posts = append(page.Posts, post)
+    }
+
+    sort.Slice(page.Posts, func(i, j int) bool {
+        return page.Posts[i].CreatedAt.After(page.Posts[j].CreatedAt)
+    })
+    return page, nil
+}
+
+// ListFields is List keeping only the given fields of the posts, besides
+// their _id and created_at, as a MongoDB projection does.
+func (r *fakePostRepo) ListFields(filter models.PostFilter, req models.PageRequest, fields ...string) (*models.PostPage, error) {
+    r.listedFields = append(r.listedFields, fields)
+    page, err := r.List(filter, req)
+    if err != nil || len(fields) == 0 {
+        return page, err
+    }
+
+    keep := map[string]bool{"_id": true, "created_at": true}
+    for _, field := range fields {
+        keep[field] = true
+    }
+    for i, post := range page.Posts {
+        doc := bson.M{}
+        for key, value := range toDocument(post) {
+            if keep[key] {
+                doc[key] = value
+            }
+        }
+        page.Posts[i] = toPost(doc)
+    }
+    return page, nil
+}
+
+func matchesFilter(post *models.Post, filter models.PostFilter) bool {
+    if len(filter.Statuses) > 0 && !containsString(filter.Statuses, post.Status) {
+        return false
+    }
+    if len(filter.Visibilities) > 0 {
+        visibility := post.Visibility
+        if visibility == "" {
+            visibility = models.VisibilityPublic
+        }
+        if !containsString(filter.Visibilities, visibility) {
+            return false
+        }
+    }
+    if !filter.AuthorID.IsZero() && post.AuthorID != filter.AuthorID {
+        if filter.ExcludeCoAuthored || !post.IsAuthor(filter.AuthorID.Hex()) {
+            return false
+        }
+    }
+    for _, tag := range filter.Tags {
+        if !containsString(post.Tags, tag) {
+            return false
+        }
+    }
+    return true
+}
+
+func containsString(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
+
+func (r *fakePostRepo) GetByAuthor(authorID string) ([]*models.Post, error) {
+    objectID, _ := primitive.ObjectIDFromHex(authorID)
+    page, _ := r.List(models.PostFilter{AuthorID: objectID}, models.PageRequest{})
+    return page.Posts, nil
+}
+
+// ClaimDueScheduled publishes the scheduled post due the earliest, as the
+// MongoDB repository does.
+func (r *fakePostRepo) ClaimDueScheduled(now time.Time) (*models.Post, error) {
+    var due *models.Post
+    for _, doc := range r.posts {
+        post := toPost(doc)
+        if post.DeletedAt != nil || post.Status != models.PostStatusScheduled || post.PublishedAt.After(now) {
+            continue
+        }
+        if due == nil || post.PublishedAt.Before(*due.PublishedAt) {
+            due = post
+        }
+    }
+    if due == nil {
+        return nil, nil
+    }
+
+    r.set(due.ID, map[string]interface{}{"status": models.PostStatusPublished, "updated_at": now, "version": due.Version + 1})
+    return r.find(due.ID.Hex()), nil
+}
+
+func (r *fakePostRepo) AddCoAuthor(id, userID primitive.ObjectID) error {
+    post, err := r.live(id.Hex())
+    if err != nil {
+        return err
+    }
+    r.set(id, map[string]interface{}{"co_authors": append(post.CoAuthors, userID)})
+    return nil
+}
+
+func (r *fakePostRepo) RemoveCoAuthor(id, userID primitive.ObjectID) error {
+    post, err := r.live(id.Hex())
+    if err != nil {
+        return err
+    }
+    kept := []primitive.ObjectID{}
+    for _, coAuthor := range post.CoAuthors {
+        if coAuthor != userID {
+            kept = append(kept, coAuthor)
+        }
+    }
+    r.set(id, map[string]interface{}{"co_authors": kept})
+    return nil
+}
+
+func (r *fakePostRepo) SetCoAuthors(id primitive.ObjectID, userIDs []primitive.ObjectID) error {
+    r.set(id, map[string]interface{}{"co_authors": userIDs})
+    return nil
+}
+
+func (r *fakePostRepo) ListUnsummarized(limit int) ([]*models.Post, error) {
+    return []*models.Post{}, nil
+}
+
+func (r *fakePostRepo) SetSummary(id primitive.ObjectID, fields map[string]interface{}) error {
+    r.set(id, fields)
+    return nil
+}
+
+func (r *fakePostRepo) ListWithoutSlug(limit int) ([]*models.Post, error) {
+    posts := []*models.Post{}
+    for _, doc := range r.posts {
+        if post := toPost(doc); post.Slug == "" && len(posts) < limit {
+            posts = append(posts, post)
+        }
+    }
+    return posts, nil
+}
+
+func (r *fakePostRepo) SetSlug(id primitive.ObjectID, slug string) (bool, error) {
+    if toPost(r.posts[id]).Slug != "" {
+        return false, nil
+    }
+    r.set(id, map[string]interface{}{"slug": slug})
+    return true, nil
+}
+
+func (r *fakePostRepo) SetCommentsClosed(id primitive.ObjectID, closed bool) error {
+    if _, err := r.live(id.Hex()); err != nil {
+        return err
+    }
+    r.set(id, map[string]interface{}{"comments_closed": closed})
+    return nil
+}
+
+func (r *fakePostRepo) IncrementCommentCount(id primitive.ObjectID, delta int) error {
+    post, err := r.live(id.Hex())
+    if err != nil {
+        return err
+    }
+    r.set(id, map[string]interface{}{"comment_count": post.CommentCount + int64(delta)})
+    return nil
+}
+
+func (r *fakePostRepo) IncrementReactions(id primitive.ObjectID, deltas map[string]int64) (map[string]int64, error) {
+    post, err := r.live(id.Hex())
+    if err != nil {
+        return nil, err
+    }
+    counts := map[string]int64{}
+    for reactionType, count := range post.Reactions {
+        counts[reactionType] = count
+    }
+    for reactionType, delta := range deltas {
+        counts[reactionType] += delta
+    }
+    r.set(id, map[string]interface{}{"reactions": counts})
+    return counts, nil
+}
+
+func (r *fakePostRepo) ListReactionCounts() (map[primitive.ObjectID]map[string]int64, error) {
+    counts := map[primitive.ObjectID]map[string]int64{}
+    for id, doc := range r.posts {
+        if post := toPost(doc); len(post.Reactions) > 0 {
+            counts[id] = post.Reactions
+        }
+    }
+    return counts, nil
+}
+
+func (r *fakePostRepo) GetReactionCounts(id primitive.ObjectID) (map[string]int64, error) {
+    post := r.find(id.Hex())
+    if post == nil {
+        return nil, models.ErrNotFound
+    }
+    return post.Reactions, nil
+}
+
+func (r *fakePostRepo) SetReactions(id primitive.ObjectID, expected, counts map[string]int64, popularityDelta int64) (bool, error) {
+    r.set(id, map[string]interface{}{"reactions": counts})
+    return true, nil
+}
+
+func (r *fakePostRepo) ListDeletedBefore(before time.Time, limit int) ([]*models.Post, error) {
+    posts := []*models.Post{}
+    for _, doc := range r.posts {
+        if post := toPost(doc); post.DeletedAt != nil && post.DeletedAt.Before(before) && len(posts) < limit {
+            posts = append(posts, post)
+        }
+    }
+    return posts, nil
+}
+
+func (r *fakePostRepo) Delete(id string) error {
+    objectID, _ := primitive.ObjectIDFromHex(id)
+    delete(r.posts, objectID)
+    return nil
+}
+
+func (r *fakePostRepo) ReferencedImages(urls []string) (map[string]bool, error) {
+    return map[string]bool{}, nil
+}
+
+// fakeSlugRepo is an in-memory SlugRepository.
+type fakeSlugRepo struct {
+    owners map[string]primitive.ObjectID
+}
+
+func newFakeSlugRepo() *fakeSlugRepo {
+    return &fakeSlugRepo{owners: map[string]primitive.ObjectID{}}
+}
+
+func (r *fakeSlugRepo) Reserve(slug string, postID primitive.ObjectID) (bool, error) {
+    owner, ok := r.owners[slug]
+    if !ok {
+        r.owners[slug] = postID
+        return true, nil
+    }
+    if owner != postID {
+        return false, models.ErrConflict
+    }
+    return false, nil
+}
+
+func (r *fakeSlugRepo) Release(slug string, postID primitive.ObjectID) error {
+    if r.owners[slug] == postID {
+        delete(r.owners, slug)
+    }
+    return nil
+}
+
+func (r *fakeSlugRepo) FindPostID(slug string) (primitive.ObjectID, error) {
+    owner, ok := r.owners[slug]
+    if !ok {
+        return primitive.NilObjectID, models.ErrNotFound
+    }
+    return owner, nil
+}
+
+func (r *fakeSlugRepo) DeleteByPost(postID primitive.ObjectID) error {
+    for slug, owner := range r.owners {
+        if owner == postID {
+            delete(r.owners, slug)
+        }
+    }
+    return nil
+}
+
+// fakeCategoryRepo is a PostCategoryRepository without any category.
+type fakeCategoryRepo struct{}
+
+func (fakeCategoryRepo) GetByID(id string) (*models.Category, error) {
+    return nil, models.ErrNotFound
+}
+
+func (fakeCategoryRepo) GetBySlug(slug string) (*models.Category, error) {
+    return nil, models.ErrNotFound
+}
+
+func (fakeCategoryRepo) GetDescendantIDs(id primitive.ObjectID) ([]primitive.ObjectID, error) {
+    return nil, nil
+}
+
+// fakeRevisionRepo is an in-memory PostRevisionRepository.
+type fakeRevisionRepo struct {
+    revisions []*models.PostRevision
+}
+
+func (r *fakeRevisionRepo) Create(revision *models.PostRevision) error {
+    revision.Number = len(r.revisions) + 1
+    r.revisions = append(r.revisions, revision)
+    return nil
+}
+
+func (r *fakeRevisionRepo) Exists(postID primitive.ObjectID) (bool, error) {
+    return len(r.of(postID)) > 0, nil
+}
+
+func (r *fakeRevisionRepo) GetByNumber(postID primitive.ObjectID, number int) (*models.PostRevision, error) {
+    for _, revision := range r.of(postID) {
+        if revision.Number == number {
+            return revision, nil
+        }
+    }
+    return nil, models.ErrNotFound
+}
+
+// of returns the revisions of the post with the given ID.
+func (r *fakeRevisionRepo) of(postID primitive.ObjectID) []*models.PostRevision {
+    var revisions []*models.PostRevision
+    for _, revision := range r.revisions {
+        if revision.PostID == postID {
+            revisions = append(revisions, revision)
+        }
+    }
+    return revisions
+}
+
+// fakeAuthorRepo is an AuthorRepository without any user.
+type fakeAuthorRepo struct{}
+
+func (fakeAuthorRepo) GetByIDs(ids []primitive.ObjectID) ([]*models.User, error) {
+    return []*models.User{}, nil
+}
+
+// recordingListener records the posts it is told about.
+type recordingListener struct {
+    saved   []*models.Post
+    deleted []*models.Post
+}
+
+func (l *recordingListener) PostSaved(post *models.Post) {
+    l.saved = append(l.saved, post)
+}
+
+func (l *recordingListener) PostDeleted(post *models.Post) {
+    l.deleted = append(l.deleted, post)
+}
+
+// postFixture is a PostService backed by fakes, with a listener.
+type postFixture struct {
+    service   *PostService
+    posts     *fakePostRepo
+    slugs     *fakeSlugRepo
+    revisions *fakeRevisionRepo
+    listener  *recordingListener
+}
+
+func newPostFixture(t *testing.T) *postFixture {
+    t.Helper()
+
+    f := &postFixture{
+        posts:     newFakePostRepo(),
+        slugs:     newFakeSlugRepo(),
+        revisions: &fakeRevisionRepo{},
+        listener:  &recordingListener{},
+    }
+    f.service = NewPostService(f.posts, f.slugs, fakeCategoryRepo{}, f.revisions, fakeAuthorRepo{}, utils.NewContentRenderer(), "secret", time.Hour)
+    f.service.AddListener(f.listener)
+    return f
+}
+
+// create creates a post through the PostService, failing the test on error.
+func (f *postFixture) create(t *testing.T, post *models.Post, password string) *models.Post {
+    t.Helper()
+
+    if post.AuthorID.IsZero() {
+        post.AuthorID = primitive.NewObjectID()
+    }
+    if post.Title == "" {
+        post.Title = "Hello world"
+    }
+    if post.Content == "" {
+        post.Content = "Some content."
+    }
+    if err := f.service.Create(post, password); err != nil {
+        t.Fatalf("Create() error = %v", err)
+    }
+    return post
+}
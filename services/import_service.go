@@ -0,0 +1,689 @@
+package services
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "io/fs"
+    "net/http"
+    "net/url"
+    "path"
+    "regexp"
+    "sort"
+    "strings"
+    "time"
+)
+
+// maxImportFileSize is the largest file, post or image, read from an import.
+const maxImportFileSize = 32 << 20
+
+// imageDownloadTimeout bounds the download of an image from the blog posts
+// are imported from.
+const imageDownloadTimeout = 30 * time.Second
+
+// importImageTypes maps the extensions of the images that can be imported
+// to their content type.
+var importImageTypes = map[string]string{
+    ".jpg":  "image/jpeg",
+    ".jpeg": "image/jpeg",
+    ".png":  "image/png",
+    ".gif":  "image/gif",
+    ".webp": "image/webp",
+}
+
+// Markdown and HTML image references, Hugo figure shortcodes, and any other
+// Hugo shortcode, in the body of a Hugo post.
+var (
+    markdownImage    = regexp.MustCompile(`(!\[[^\]]*\]\(\s*)(<[^>]*>|[^)\s]+)`)
+    markdownRefImage = regexp.MustCompile(`(?m)^( {0,3}\[[^\]]+\]:\s*)(<[^>]*>|\S+)`)
+    htmlImage        = regexp.MustCompile(`(?i)(<img\b[^>]*?\bsrc\s*=\s*)("[^"]*"|'[^']*')`)
+    figureShortcode  = regexp.MustCompile(`\{\{[<%]\s*figure\s+(.*?)\s*/?[>%]\}\}`)
+    shortcode        = regexp.MustCompile(`\{\{[<%]\s*/?\s*([\w\-./]+)`)
+    shortcodeParam   = regexp.MustCompile(`(\w+)\s*=\s*("[^"]*"|'[^']*'|\S+)`)
+)
+
+// PostImporter creates or updates imported posts; it is implemented by
+// PostService.
+type PostImporter interface {
+    Import(post *models.Post, dryRun bool) (*models.Post, string, error)
+}
+
+// CommentImporter creates imported comments; it is implemented by
+// CommentService.
+type CommentImporter interface {
+    Import(comment *models.Comment, dryRun bool) (string, error)
+}
+
+// ImportImageStore uploads the images of imported posts under names chosen
+// by the import.
+type ImportImageStore interface {
+    UploadImageAs(filename, contentType string, data []byte) (string, error)
+    HasImage(filename string) (bool, error)
+    ImageURL(filename string) string
+}
+
+// ImportUserRepository is the part of the user storage used to find, or
+// create, the authors of imported posts and comments.
+type ImportUserRepository interface {
+    Create(user *models.User) error
+    GetByID(id string) (*models.User, error)
+    GetByEmail(email string) (*models.User, error)
+}
+
+// ImportCategoryRepository is the part of the category storage used to put
+// imported posts in their category.
+type ImportCategoryRepository interface {
+    GetBySlug(slug string) (*models.Category, error)
+}
+
+// ImportCategoryCreator creates the categories of imported posts; it is
+// implemented by CategoryService.
+type ImportCategoryCreator interface {
+    Create(category *models.Category, parentID string) error
+}
+
+// ImportService brings posts over from other blogs. Posts go through the
+// PostService, so they are rendered, summarized and indexed like any other,
+// and images are re-hosted on R2.
+//
+// Imports can be run again: every post and comment remembers its source, so
+// a second run updates the posts that changed instead of duplicating them.
+// Images are stored under a name made from their path, size and modification
+// time, or from their address for downloaded images, so an image is only
+// read and uploaded once however many posts or runs use it.
+type ImportService struct {
+    posts           PostImporter
+    comments        CommentImporter
+    images          ImportImageStore
+    users           ImportUserRepository
+    categories      ImportCategoryRepository
+    categoryCreator ImportCategoryCreator
+    client          *http.Client // Downloads the images of WordPress posts
+}
+
+// NewImportService returns a new ImportService instance, given the
+// PostImporter and CommentImporter creating the posts and their comments,
+// the store re-hosting their images, the user and category storage, and the
+// ImportCategoryCreator creating missing categories.
+func NewImportService(posts PostImporter, comments CommentImporter, images ImportImageStore, users ImportUserRepository, categories ImportCategoryRepository, categoryCreator ImportCategoryCreator) *ImportService {
+    return &ImportService{
+        posts:           posts,
+        comments:        comments,
+        images:          images,
+        users:           users,
+        categories:      categories,
+        categoryCreator: categoryCreator,
+        client:          &http.Client{Timeout: imageDownloadTimeout},
+    }
+}
+
+// importRun is the state shared by every kind of import while it runs.
+type importRun struct {
+    *ImportService
+    opts     models.ImportOptions
+    report   *models.ImportReport
+    // uploaded maps the images already handled, by path or URL, to the URL
+    // of their copy.
+    uploaded map[string]string
+}
+
+// newRun starts an import with the given options.
+func (s *ImportService) newRun(opts models.ImportOptions) *importRun {
+    return &importRun{
+        ImportService: s,
+        opts:          opts,
+        report:        &models.ImportReport{DryRun: opts.DryRun, Items: []*models.ImportItem{}},
+        uploaded:      make(map[string]string),
+    }
+}
+
+// hugoImport is the state of a single Hugo import.
+type hugoImport struct {
+    *importRun
+    site     fs.FS
+    root     string // Directory holding the posts, "content" in a full site
+    authorID primitive.ObjectID
+}
+
+// ImportHugo imports the Markdown posts of a Hugo site, given as a directory
+// or a ZIP archive of the site or of its content directory, on behalf of the
+// author given in opts. Section pages (_index.md) are left out.
+//
+// The YAML ("---") or TOML ("+++") front matter of each post gives its
+// title, date, publishDate and lastmod, tags, categories, slug (or url),
+// draft flag, summary or description, and cover image (cover, image,
+// featured_image or images). Without a slug, the post keeps the name of its
+// file, or of its page bundle, so that its old URL maps to its new slug.
+// Categories become the post's category when one has the same slug, and
+// tags otherwise.
+//
+// Images referenced by a post, in Markdown, HTML or figure shortcodes, or as
+// its cover, are uploaded to R2 and their links rewritten when they are
+// found in the site: next to the post, or under static/ for absolute paths.
+// Other shortcodes are left as they are, with a warning.
+//
+// A file that cannot be imported is reported as failed and does not stop the
+// import. The returned error will be models.ErrInvalidInput if the author
+// does not exist or the site holds no posts.
+func (s *ImportService) ImportHugo(site fs.FS, opts models.ImportOptions) (*models.ImportReport, error) {
+    author, err := s.users.GetByID(opts.AuthorID)
+    if err != nil {
+        if errors.Is(err, models.ErrNotFound) {
+            return nil, fmt.Errorf("%w: the author of the imported posts does not exist", models.ErrInvalidInput)
+        }
+        return nil, err
+    }
+
+    site, err = unwrapSite(site)
+    if err != nil {
+        return nil, err
+    }
+    root := "."
+    if info, err := fs.Stat(site, "content"); err == nil && info.IsDir() {
+        root = "content"
+    }
+
+    var files []string
+    err = fs.WalkDir(site, root, func(name string, entry fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        base := entry.Name()
+        if entry.IsDir() {
+            if name != root && (strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_") || (root == "." && isHugoAssetDir(name))) {
+                return fs.SkipDir
+            }
+            return nil
+        }
+        ext := strings.ToLower(path.Ext(base))
+        if (ext == ".md" || ext == ".markdown") && !strings.HasPrefix(base, "_") && !strings.HasPrefix(base, ".") {
+            files = append(files, name)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, fmt.Errorf("%w: cannot read the site: %v", models.ErrInvalidInput, err)
+    }
+    if len(files) == 0 {
+        return nil, fmt.Errorf("%w: no Markdown posts found", models.ErrInvalidInput)
+    }
+
+    imp := &hugoImport{
+        importRun: s.newRun(opts),
+        site:      site,
+        root:      root,
+        authorID:  author.ID,
+    }
+    for _, name := range files {
+        imp.report.Add(imp.importFile(name))
+    }
+
+    return imp.report, nil
+}
+
+// unwrapSite returns the single directory at the root of the given site if
+// it holds nothing else, as when a ZIP archive was made of the site's
+// directory rather than of its contents.
+func unwrapSite(site fs.FS) (fs.FS, error) {
+    for {
+        entries, err := fs.ReadDir(site, ".")
+        if err != nil {
+            return nil, fmt.Errorf("%w: cannot read the site: %v", models.ErrInvalidInput, err)
+        }
+
+        var dirs []fs.DirEntry
+        for _, entry := range entries {
+            // Archives made on macOS carry resource forks next to the files.
+            if entry.Name() == "__MACOSX" || strings.HasPrefix(entry.Name(), ".") {
+                continue
+            }
+            dirs = append(dirs, entry)
+        }
+        if len(dirs) != 1 || !dirs[0].IsDir() || dirs[0].Name() == "content" {
+            return site, nil
+        }
+
+        site, err = fs.Sub(site, dirs[0].Name())
+        if err != nil {
+            return nil, err
+        }
+    }
+}
+
+// isHugoAssetDir reports whether the given top-level directory of a Hugo
+// site holds something else than posts.
+func isHugoAssetDir(name string) bool {
+    switch strings.SplitN(name, "/", 2)[0] {
+    case "static", "assets", "themes", "layouts", "public", "resources", "data", "i18n", "archetypes", "node_modules":
+        return true
+    }
+    return false
+}
+
+// importFile imports the post in the file with the given name.
+func (imp *hugoImport) importFile(name string) *models.ImportItem {
+    item := &models.ImportItem{Source: strings.TrimPrefix(name, imp.root+"/")}
+    fail := func(err error) *models.ImportItem {
+        item.Action = models.ImportFailed
+        item.Error = err.Error()
+        return item
+    }
+
+    data, err := imp.readFile(name)
+    if err != nil {
+        return fail(err)
+    }
+    meta, body, err := utils.SplitFrontMatter(data)
+    if err != nil {
+        return fail(err)
+    }
+    if meta == nil {
+        meta = map[string]interface{}{}
+    }
+    meta = lowerKeys(meta)
+
+    post, err := imp.hugoPost(name, item, meta, string(body))
+    if err != nil {
+        return fail(err)
+    }
+
+    saved, action, err := imp.posts.Import(post, imp.opts.DryRun)
+    if err != nil {
+        return fail(err)
+    }
+
+    item.Action = action
+    if !saved.ID.IsZero() {
+        item.PostID = saved.ID.Hex()
+    }
+    item.Slug = saved.Slug
+    item.Title = saved.Title
+    return item
+}
+
+// hugoPost builds the post of the Hugo file with the given name from its
+// front matter and body, uploading its images. Warnings are added to item.
+func (imp *hugoImport) hugoPost(name string, item *models.ImportItem, meta map[string]interface{}, body string) (*models.Post, error) {
+    post := &models.Post{
+        Title:         metaString(meta, "title"),
+        ContentFormat: utils.FormatMarkdown,
+        AuthorID:      imp.authorID,
+        SourceID:      "hugo:" + strings.TrimPrefix(name, imp.root+"/"),
+        Status:        models.PostStatusPublished,
+    }
+    if post.Title == "" {
+        return nil, fmt.Errorf("the post has no title")
+    }
+    if metaBool(meta, "draft") {
+        post.Status = models.PostStatusDraft
+    }
+
+    created, hasDate := metaTime(meta, "date")
+    published, hasPublished := metaTime(meta, "publishdate", "pubdate", "published")
+    switch {
+    case hasDate:
+        post.CreatedAt = created
+    case hasPublished:
+        post.CreatedAt = published
+    default:
+        if info, err := fs.Stat(imp.site, name); err == nil {
+            post.CreatedAt = info.ModTime()
+        }
+        item.Warnings = append(item.Warnings, "no date, using the time of the file")
+    }
+    if hasPublished {
+        post.PublishedAt = &published
+    }
+    if modified, ok := metaTime(meta, "lastmod", "modified"); ok {
+        post.UpdatedAt = modified
+    }
+
+    post.Slug = hugoSlug(name, meta)
+
+    if excerpt := metaString(meta, "summary", "description"); excerpt != "" {
+        post.Excerpt = utils.Excerpt(excerpt, maxExcerptLength)
+    }
+
+    post.Tags = metaStrings(meta, "tags")
+    for _, category := range metaStrings(meta, "categories") {
+        if post.CategoryID == nil {
+            found, err := imp.categories.GetBySlug(utils.Slugify(category))
+            if err == nil {
+                post.CategoryID = &found.ID
+                continue
+            }
+            if !errors.Is(err, models.ErrNotFound) {
+                return nil, err
+            }
+        }
+        post.Tags = append(post.Tags, category)
+        item.Warnings = append(item.Warnings, fmt.Sprintf("category %q imported as a tag", category))
+    }
+
+    if cover := hugoCover(meta); cover != "" {
+        post.ImageURL = imp.imageURL(name, cover, item)
+    }
+    post.Content = imp.rewriteBody(name, body, item)
+
+    return post, nil
+}
+
+// hugoSlug returns the slug of the Hugo post in the file with the given
+// name: its slug, the last part of its url, or the name of its file or page
+// bundle, as Hugo would use in its URL.
+func hugoSlug(name string, meta map[string]interface{}) string {
+    slug := metaString(meta, "slug")
+    if slug == "" {
+        if link := strings.Trim(metaString(meta, "url"), "/"); link != "" {
+            slug = path.Base(link)
+        }
+    }
+    if slug == "" {
+        base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+        if base == "index" {
+            base = path.Base(path.Dir(name))
+        }
+        slug = base
+    }
+    return utils.Slugify(slug)
+}
+
+// hugoCover returns the cover image of a Hugo post, as set by the most common
+// themes.
+func hugoCover(meta map[string]interface{}) string {
+    if cover, ok := meta["cover"].(map[string]interface{}); ok {
+        if image := metaString(lowerKeys(cover), "image"); image != "" {
+            return image
+        }
+    }
+    if image := metaString(meta, "cover", "image", "featured_image", "featuredimage", "thumbnail"); image != "" {
+        return image
+    }
+    if images := metaStrings(meta, "images"); len(images) > 0 {
+        return images[0]
+    }
+    return ""
+}
+
+// rewriteBody turns the figure shortcodes of a Hugo post into Markdown images
+// and points the local images of the post to their uploaded copies.
+func (imp *hugoImport) rewriteBody(name, body string, item *models.ImportItem) string {
+    body = figureShortcode.ReplaceAllStringFunc(body, func(figure string) string {
+        params := map[string]string{}
+        for _, param := range shortcodeParam.FindAllStringSubmatch(figureShortcode.FindStringSubmatch(figure)[1], -1) {
+            params[strings.ToLower(param[1])] = strings.Trim(param[2], `"'`)
+        }
+        if params["src"] == "" {
+            return figure
+        }
+        alt := params["alt"]
+        if alt == "" {
+            alt = params["caption"]
+        }
+        image := fmt.Sprintf("![%s](<%s>)", strings.NewReplacer("[", "", "]", "").Replace(alt), params["src"])
+        if caption := params["caption"]; caption != "" {
+            image += "\n*" + caption + "*"
+        }
+        return image
+    })
+
+    var unknown []string
+    seen := map[string]bool{}
+    for _, match := range shortcode.FindAllStringSubmatch(body, -1) {
+        if !seen[match[1]] {
+            seen[match[1]] = true
+            unknown = append(unknown, match[1])
+        }
+    }
+    sort.Strings(unknown)
+    for _, name := range unknown {
+        item.Warnings = append(item.Warnings, fmt.Sprintf("shortcode %q left as is", name))
+    }
+
+    rewrite := func(pattern *regexp.Regexp, quote func(string) string) {
+        body = pattern.ReplaceAllStringFunc(body, func(match string) string {
+            groups := pattern.FindStringSubmatch(match)
+            target := strings.Trim(groups[2], `<>"'`)
+            if !isLocalImage(target) {
+                return match
+            }
+            link := imp.imageURL(name, target, item)
+            if link == target {
+                return match
+            }
+            return groups[1] + quote(link)
+        })
+    }
+    rewrite(markdownImage, func(link string) string { return "<" + link + ">" })
+    rewrite(markdownRefImage, func(link string) string { return "<" + link + ">" })
+    rewrite(htmlImage, func(link string) string { return `"` + link + `"` })
+
+    return body
+}
+
+// isLocalImage reports whether the given link points to an image file of
+// the site rather than to another site or page.
+func isLocalImage(link string) bool {
+    if link == "" || strings.HasPrefix(link, "//") || strings.HasPrefix(link, "#") || strings.Contains(link, ":") {
+        return false
+    }
+    link = strings.SplitN(strings.SplitN(link, "?", 2)[0], "#", 2)[0]
+    _, ok := importImageTypes[strings.ToLower(path.Ext(link))]
+    return ok
+}
+
+// imageURL returns the URL of the uploaded copy of the image the Hugo post in
+// the file with the given name links to, uploading it on first use. Links to
+// other sites and images that cannot be uploaded are returned as they are,
+// the latter with a warning.
+func (imp *hugoImport) imageURL(name, link string, item *models.ImportItem) string {
+    if !isLocalImage(link) {
+        return link
+    }
+
+    found := imp.findImage(name, link)
+    if found == "" {
+        item.Warnings = append(item.Warnings, fmt.Sprintf("image %s not found", link))
+        return link
+    }
+    if uploaded, ok := imp.uploaded[found]; ok {
+        return uploaded
+    }
+
+    uploaded, err := imp.upload(found)
+    if err != nil {
+        item.Warnings = append(item.Warnings, fmt.Sprintf("image %s not uploaded: %v", link, err))
+        return link
+    }
+    imp.uploaded[found] = uploaded
+    return uploaded
+}
+
+// findImage returns the path in the site of the image the Hugo post in the
+// file with the given name links to, or an empty string if it is not there.
+// Relative links are looked up next to the post, as for page bundles, and
+// absolute ones under static/ and assets/.
+func (imp *hugoImport) findImage(name, link string) string {
+    link = strings.SplitN(strings.SplitN(link, "?", 2)[0], "#", 2)[0]
+    if unescaped, err := url.PathUnescape(link); err == nil {
+        link = unescaped
+    }
+
+    var candidates []string
+    if strings.HasPrefix(link, "/") {
+        candidates = []string{path.Join("static", link), path.Join("assets", link), path.Join(imp.root, link)}
+    } else {
+        candidates = []string{path.Join(path.Dir(name), link), path.Join("static", link)}
+    }
+
+    for _, candidate := range candidates {
+        candidate = strings.TrimPrefix(candidate, "/")
+        if info, err := fs.Stat(imp.site, candidate); err == nil && !info.IsDir() {
+            return candidate
+        }
+    }
+    return ""
+}
+
+// upload uploads the image with the given path in the site, unless it was
+// uploaded already, and returns its URL. Images are named after their path,
+// size and modification time, so that they are only read to be uploaded.
+func (imp *hugoImport) upload(name string) (string, error) {
+    info, err := fs.Stat(imp.site, name)
+    if err != nil {
+        return "", err
+    }
+    if info.Size() > maxImportFileSize {
+        return "", fmt.Errorf("%s is larger than %d MB", name, maxImportFileSize>>20)
+    }
+
+    key := fmt.Sprintf("hugo:%s:%d:%d", name, info.Size(), info.ModTime().UnixNano())
+    return imp.storeImage([]byte(key), path.Ext(name), func() ([]byte, error) {
+        return imp.readFile(name)
+    })
+}
+
+// storeImage uploads the image loaded by load under a name made from the
+// hash of key and the given extension, unless an image already has that
+// name, and returns its URL. Nothing is loaded nor uploaded in a dry run,
+// but the returned URL is the one the image will have.
+func (run *importRun) storeImage(key []byte, ext string, load func() ([]byte, error)) (string, error) {
+    ext = strings.ToLower(ext)
+    contentType, ok := importImageTypes[ext]
+    if !ok {
+        return "", fmt.Errorf("unsupported image type %q", ext)
+    }
+    if ext == ".jpeg" {
+        ext = ".jpg"
+    }
+    sum := sha256.Sum256(key)
+    filename := "imports/" + hex.EncodeToString(sum[:]) + ext
+
+    exists, err := run.images.HasImage(filename)
+    if err != nil {
+        return "", err
+    }
+    if exists {
+        return run.images.ImageURL(filename), nil
+    }
+
+    if run.opts.DryRun {
+        run.report.Images++
+        return run.images.ImageURL(filename), nil
+    }
+    data, err := load()
+    if err != nil {
+        return "", err
+    }
+    uploaded, err := run.images.UploadImageAs(filename, contentType, data)
+    if err != nil {
+        return "", err
+    }
+    run.report.Images++
+    return uploaded, nil
+}
+
+// readFile reads the file with the given name from the site, refusing files
+// larger than maxImportFileSize.
+func (imp *hugoImport) readFile(name string) ([]byte, error) {
+    info, err := fs.Stat(imp.site, name)
+    if err != nil {
+        return nil, err
+    }
+    if info.Size() > maxImportFileSize {
+        return nil, fmt.Errorf("%s is larger than %d MB", name, maxImportFileSize>>20)
+    }
+    return fs.ReadFile(imp.site, name)
+}
+
+// lowerKeys returns the given front matter with lowercase keys, as Hugo reads
+// front matter regardless of case.
+func lowerKeys(meta map[string]interface{}) map[string]interface{} {
+    lowered := make(map[string]interface{}, len(meta))
+    for key, value := range meta {
+        lowered[strings.ToLower(key)] = value
+    }
+    return lowered
+}
+
+// metaString returns the first of the given front matter keys holding a
+// non-empty string, trimmed.
+func metaString(meta map[string]interface{}, keys ...string) string {
+    for _, key := range keys {
+        if value, ok := meta[key].(string); ok && strings.TrimSpace(value) != "" {
+            return strings.TrimSpace(value)
+        }
+    }
+    return ""
+}
+
+// metaStrings returns the list of strings held by the given front matter
+// key, which may also hold a single string.
+func metaStrings(meta map[string]interface{}, key string) []string {
+    switch value := meta[key].(type) {
+    case string:
+        if value = strings.TrimSpace(value); value != "" {
+            return []string{value}
+        }
+    case []interface{}:
+        var values []string
+        for _, item := range value {
+            if text, ok := item.(string); ok && strings.TrimSpace(text) != "" {
+                values = append(values, strings.TrimSpace(text))
+            }
+        }
+        return values
+    }
+    return nil
+}
+
+// metaBool returns the boolean held by the given front matter key, which may
+// also be written as a string.
+func metaBool(meta map[string]interface{}, key string) bool {
+    switch value := meta[key].(type) {
+    case bool:
+        return value
+    case string:
+        return strings.EqualFold(strings.TrimSpace(value), "true")
+    }
+    return false
+}
+
+// frontMatterLayouts are the date formats accepted in front matter written
+// as strings.
+var frontMatterLayouts = []string{
+    time.RFC3339,
+    "2006-01-02T15:04:05",
+    "2006-01-02 15:04:05 -0700",
+    "2006-01-02 15:04:05 Z07:00",
+    "2006-01-02 15:04:05",
+    "2006-01-02 15:04",
+    "2006-01-02",
+}
+
+// metaTime returns the time held by the first of the given front matter keys
+// that holds one. YAML timestamps and strings without a time zone are taken
+// as UTC; TOML local dates and times provide AsTime.
+func metaTime(meta map[string]interface{}, keys ...string) (time.Time, bool) {
+    for _, key := range keys {
+        switch value := meta[key].(type) {
+        case time.Time:
+            if !value.IsZero() {
+                return value, true
+            }
+        case string:
+            for _, layout := range frontMatterLayouts {
+                if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+                    return t, true
+                }
+            }
+        case interface{ AsTime(*time.Location) time.Time }:
+            return value.AsTime(time.UTC), true
+        }
+    }
+    return time.Time{}, false
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+    "errors"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "io/fs"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "testing/fstest"
+    "time"
+)
+
+// fakeImportUsers is an in-memory ImportUserRepository.
+type fakeImportUsers struct {
+    users []*models.User
+}
+
+func (r *fakeImportUsers) Create(user *models.User) error {
+    user.ID = primitive.NewObjectID()
+    r.users = append(r.users, user)
+    return nil
+}
+
+func (r *fakeImportUsers) GetByID(id string) (*models.User, error) {
+    for _, user := range r.users {
+        if user.ID.Hex() == id {
+            return user, nil
+        }
+    }
+    return nil, models.ErrNotFound
+}
+
+func (r *fakeImportUsers) GetByEmail(email string) (*models.User, error) {
+    for _, user := range r.users {
+        if strings.EqualFold(user.Email, email) {
+            return user, nil
+        }
+    }
+    return nil, models.ErrNotFound
+}
+
+// fakeImageStore is an in-memory ImportImageStore.
+type fakeImageStore struct {
+    images map[string][]byte
+}
+
+func (s *fakeImageStore) UploadImageAs(filename, contentType string, data []byte) (string, error) {
+    s.images[filename] = data
+    return s.ImageURL(filename), nil
+}
+
+func (s *fakeImageStore) HasImage(filename string) (bool, error) {
+    _, ok := s.images[filename]
+    return ok, nil
+}
+
+func (s *fakeImageStore) ImageURL(filename string) string {
+    return "https://cdn.example.com/" + filename
+}
+
+// countingFS is a site that counts how many times each of its files is
+// opened to be read.
+type countingFS struct {
+    fstest.MapFS
+    opened map[string]int
+}
+
+func (f *countingFS) Open(name string) (fs.File, error) {
+    if file, ok := f.MapFS[name]; ok && !file.Mode.IsDir() {
+        f.opened[name]++
+    }
+    return f.MapFS.Open(name)
+}
+
+func (f *countingFS) ReadFile(name string) ([]byte, error) {
+    if _, ok := f.MapFS[name]; ok {
+        f.opened[name]++
+    }
+    return f.MapFS.ReadFile(name)
+}
+
+// importFixture is an ImportService importing posts through a PostService
+// backed by fakes.
+type importFixture struct {
+    *postFixture
+    service *ImportService
+    users   *fakeImportUsers
+    images  *fakeImageStore
+    author  *models.User
+}
+
+func newImportFixture(t *testing.T) *importFixture {
+    t.Helper()
+
+    f := &importFixture{
+        postFixture: newPostFixture(t),
+        users:       &fakeImportUsers{},
+        images:      &fakeImageStore{images: map[string][]byte{}},
+    }
+    f.author = &models.User{Username: "author", Email: "author@example.com"}
+    f.users.Create(f.author)
+    f.service = NewImportService(f.postFixture.service, nil, f.images, f.users, fakeCategoryRepo{}, nil)
+    return f
+}
+
+// actions returns the action taken on every item of the given report, by
+// source.
+func actions(report *models.ImportReport) map[string]string {
+    found := map[string]string{}
+    for _, item := range report.Items {
+        found[item.Source] = item.Action
+        if item.Error != "" {
+            found[item.Source] += ": " + item.Error
+        }
+    }
+    return found
+}
+
+func TestImportHugo(t *testing.T) {
+    f := newImportFixture(t)
+    site := &countingFS{opened: map[string]int{}, MapFS: fstest.MapFS{
+        "content/posts/hello.md": {Data: []byte("---\ntitle: Hello\ndate: 2023-04-05T10:00:00Z\ntags: [go]\n---\nHello ![cat](cat.png)\n")},
+        "content/posts/cat.png":  {Data: []byte("cat"), ModTime: time.Date(2023, 4, 5, 0, 0, 0, 0, time.UTC)},
+        "content/posts/draft.md": {Data: []byte("+++\ntitle = \"Draft\"\ndraft = true\ndate = 2023-05-01\n+++\nNot yet.\n")},
+        "content/_index.md":      {Data: []byte("---\ntitle: Section\n---\n")},
+        "static/logo.png":        {Data: []byte("logo")},
+    }}
+    run := func(dryRun bool) *models.ImportReport {
+        t.Helper()
+        report, err := f.service.ImportHugo(site, models.ImportOptions{AuthorID: f.author.ID.Hex(), DryRun: dryRun})
+        if err != nil {
+            t.Fatalf("ImportHugo() error = %v", err)
+        }
+        return report
+    }
+    want := func(report *models.ImportReport, wantActions map[string]string, images int) {
+        t.Helper()
+        got := actions(report)
+        for source, action := range wantActions {
+            if got[source] != action {
+                t.Errorf("action on %s = %q, want %q", source, got[source], action)
+            }
+        }
+        if len(got) != len(wantActions) {
+            t.Errorf("report has %d items, want %d", len(got), len(wantActions))
+        }
+        if report.Images != images {
+            t.Errorf("report counts %d images, want %d", report.Images, images)
+        }
+    }
+
+    // A dry run writes nothing, and reads no image.
+    want(run(true), map[string]string{"posts/hello.md": models.ImportCreated, "posts/draft.md": models.ImportCreated}, 1)
+    if len(f.posts.posts) != 0 || len(f.images.images) != 0 {
+        t.Fatalf("dry run stored %d posts and %d images, want none", len(f.posts.posts), len(f.images.images))
+    }
+    if site.opened["content/posts/cat.png"] != 0 {
+        t.Errorf("dry run read the image %d times, want 0", site.opened["content/posts/cat.png"])
+    }
+
+    want(run(false), map[string]string{"posts/hello.md": models.ImportCreated, "posts/draft.md": models.ImportCreated}, 1)
+    if len(f.posts.posts) != 2 || len(f.images.images) != 1 {
+        t.Fatalf("import stored %d posts and %d images, want 2 and 1", len(f.posts.posts), len(f.images.images))
+    }
+    hello, err := f.posts.GetBySourceID("hugo:posts/hello.md")
+    if err != nil {
+        t.Fatalf("GetBySourceID() error = %v", err)
+    }
+    if hello.Slug != "hello" || hello.Status != models.PostStatusPublished || hello.AuthorID != f.author.ID || !strings.Contains(hello.Content, "https://cdn.example.com/imports/") {
+        t.Errorf("imported post = slug %q, status %q, content %q", hello.Slug, hello.Status, hello.Content)
+    }
+    draft, _ := f.posts.GetBySourceID("hugo:posts/draft.md")
+    if draft == nil || draft.Status != models.PostStatusDraft {
+        t.Errorf("draft imported as %+v, want a draft", draft)
+    }
+
+    // Running the import again changes nothing, and uploads no image again.
+    revisions := len(f.revisions.of(hello.ID))
+    want(run(false), map[string]string{"posts/hello.md": models.ImportUnchanged, "posts/draft.md": models.ImportUnchanged}, 0)
+    if site.opened["content/posts/cat.png"] != 1 {
+        t.Errorf("image read %d times, want once", site.opened["content/posts/cat.png"])
+    }
+
+    // A changed post is updated, in a dry run only reported.
+    site.MapFS["content/posts/hello.md"] = &fstest.MapFile{Data: []byte("---\ntitle: Hello again\ndate: 2023-04-05T10:00:00Z\ntags: [go]\n---\nHello ![cat](cat.png)\n")}
+    want(run(true), map[string]string{"posts/hello.md": models.ImportUpdated, "posts/draft.md": models.ImportUnchanged}, 0)
+    if got, _ := f.posts.GetBySourceID("hugo:posts/hello.md"); got.Title != "Hello" {
+        t.Errorf("dry run changed the title to %q", got.Title)
+    }
+    want(run(false), map[string]string{"posts/hello.md": models.ImportUpdated, "posts/draft.md": models.ImportUnchanged}, 0)
+    updated, _ := f.posts.GetBySourceID("hugo:posts/hello.md")
+    if updated.ID != hello.ID || updated.Title != "Hello again" {
+        t.Errorf("updated post = %s %q, want %s %q", updated.ID.Hex(), updated.Title, hello.ID.Hex(), "Hello again")
+    }
+    if got := len(f.revisions.of(hello.ID)); got != revisions+1 {
+        t.Errorf("revisions after the update = %d, want %d", got, revisions+1)
+    }
+    if len(f.posts.posts) != 2 {
+        t.Errorf("imports stored %d posts, want 2", len(f.posts.posts))
+    }
+}
+
+func TestImportHugoUnknownAuthor(t *testing.T) {
+    f := newImportFixture(t)
+    site := fstest.MapFS{"hello.md": {Data: []byte("---\ntitle: Hello\n---\n")}}
+
+    _, err := f.service.ImportHugo(site, models.ImportOptions{AuthorID: primitive.NewObjectID().Hex()})
+    if !errors.Is(err, models.ErrInvalidInput) {
+        t.Errorf("ImportHugo() error = %v, want ErrInvalidInput", err)
+    }
+}
+
+// wxrExport is a WordPress export of a blog at siteURL, with a published post
+// titled title and its comment, a draft page, a post in the trash and an
+// attachment.
+func wxrExport(siteURL, title string) string {
+    return strings.ReplaceAll(strings.ReplaceAll(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+    xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/"
+    xmlns:content="http://purl.org/rss/1.0/modules/content/"
+    xmlns:dc="http://purl.org/dc/elements/1.1/"
+    xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+    <link>SITE</link>
+    <wp:base_site_url>SITE</wp:base_site_url>
+    <wp:author>
+        <wp:author_id>1</wp:author_id>
+        <wp:author_login>admin</wp:author_login>
+        <wp:author_email>author@example.com</wp:author_email>
+    </wp:author>
+    <item>
+        <title>TITLE</title>
+        <link>SITE/2023/04/hello/</link>
+        <dc:creator>admin</dc:creator>
+        <content:encoded><![CDATA[First paragraph.
+
+<img src="SITE/wp-content/uploads/cat.png" srcset="SITE/wp-content/uploads/cat-300.png 300w">]]></content:encoded>
+        <excerpt:encoded><![CDATA[]]></excerpt:encoded>
+        <wp:post_id>10</wp:post_id>
+        <wp:post_date_gmt>2023-04-05 10:00:00</wp:post_date_gmt>
+        <wp:comment_status>open</wp:comment_status>
+        <wp:post_name>hello-wordpress</wp:post_name>
+        <wp:status>publish</wp:status>
+        <wp:post_type>post</wp:post_type>
+        <category domain="category" nicename="uncategorized"><![CDATA[Uncategorized]]></category>
+        <category domain="post_tag" nicename="go"><![CDATA[Go]]></category>
+        <wp:comment>
+            <wp:comment_id>5</wp:comment_id>
+            <wp:comment_author>Reader</wp:comment_author>
+            <wp:comment_author_email>reader@example.com</wp:comment_author_email>
+            <wp:comment_date_gmt>2023-04-06 10:00:00</wp:comment_date_gmt>
+            <wp:comment_content>Nice post!</wp:comment_content>
+            <wp:comment_approved>1</wp:comment_approved>
+            <wp:comment_type>comment</wp:comment_type>
+            <wp:comment_parent>0</wp:comment_parent>
+            <wp:comment_user_id>0</wp:comment_user_id>
+        </wp:comment>
+    </item>
+    <item>
+        <title>About</title>
+        <dc:creator>admin</dc:creator>
+        <content:encoded><![CDATA[<p>About this blog.</p>]]></content:encoded>
+        <wp:post_id>11</wp:post_id>
+        <wp:post_date_gmt>2023-03-01 09:00:00</wp:post_date_gmt>
+        <wp:post_name>about</wp:post_name>
+        <wp:status>draft</wp:status>
+        <wp:post_type>page</wp:post_type>
+    </item>
+    <item>
+        <title>Gone</title>
+        <dc:creator>admin</dc:creator>
+        <content:encoded><![CDATA[Deleted.]]></content:encoded>
+        <wp:post_id>12</wp:post_id>
+        <wp:status>trash</wp:status>
+        <wp:post_type>post</wp:post_type>
+    </item>
+    <item>
+        <title>cat</title>
+        <wp:post_id>13</wp:post_id>
+        <wp:post_type>attachment</wp:post_type>
+        <wp:attachment_url>SITE/wp-content/uploads/cat.png</wp:attachment_url>
+    </item>
+</channel>
+</rss>
+`, "SITE", siteURL), "TITLE", title)
+}
+
+func TestImportWordPress(t *testing.T) {
+    f := newImportFixture(t)
+    comments := newFakeCommentRepo()
+    commentService := NewCommentService(comments, f.posts, f.postFixture.service, utils.NewContentRenderer(), CommentModeration{})
+    f.service = NewImportService(f.postFixture.service, commentService, f.images, f.users, fakeCategoryRepo{}, nil)
+
+    downloads := 0
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/wp-content/uploads/cat.png" {
+            http.NotFound(w, r)
+            return
+        }
+        downloads++
+        w.Write([]byte("cat"))
+    }))
+    defer server.Close()
+    f.service.client = server.Client()
+
+    run := func(title string, dryRun bool) *models.ImportReport {
+        t.Helper()
+        report, err := f.service.ImportWordPress(strings.NewReader(wxrExport(server.URL, title)), models.ImportOptions{DryRun: dryRun})
+        if err != nil {
+            t.Fatalf("ImportWordPress() error = %v", err)
+        }
+        return report
+    }
+    want := func(report *models.ImportReport, post, page string, counts [4]int) {
+        t.Helper()
+        got := actions(report)
+        wantActions := map[string]string{"post 10": post, "page 11": page, "post 12": models.ImportSkipped + ": trash posts are not imported"}
+        for source, action := range wantActions {
+            if got[source] != action {
+                t.Errorf("action on %s = %q, want %q", source, got[source], action)
+            }
+        }
+        if len(got) != len(wantActions) {
+            t.Errorf("report has %d items, want %d", len(got), len(wantActions))
+        }
+        if gotCounts := [4]int{report.Users, report.Comments, report.Images, downloads}; gotCounts != counts {
+            t.Errorf("users, comments, images and downloads = %v, want %v", gotCounts, counts)
+        }
+    }
+
+    // A dry run writes nothing, and downloads nothing.
+    want(run("Hello", true), models.ImportCreated, models.ImportCreated, [4]int{1, 1, 1, 0})
+    if len(f.posts.posts) != 0 || len(comments.comments) != 0 || len(f.users.users) != 1 || len(f.images.images) != 0 {
+        t.Fatalf("dry run stored %d posts, %d comments, %d users and %d images, want none", len(f.posts.posts), len(comments.comments), len(f.users.users)-1, len(f.images.images))
+    }
+
+    want(run("Hello", false), models.ImportCreated, models.ImportCreated, [4]int{1, 1, 1, 1})
+    post, err := f.posts.GetBySourceID("wordpress:" + strings.TrimPrefix(server.URL, "http://") + "/10")
+    if err != nil {
+        t.Fatalf("GetBySourceID() error = %v", err)
+    }
+    if post.Slug != "hello-wordpress" || post.Status != models.PostStatusPublished || post.AuthorID != f.author.ID || post.CommentCount != 1 {
+        t.Errorf("imported post = slug %q, status %q, author %s, %d comments", post.Slug, post.Status, post.AuthorID.Hex(), post.CommentCount)
+    }
+    if !strings.Contains(post.Content, `src="https://cdn.example.com/imports/`) || strings.Contains(post.Content, "srcset") {
+        t.Errorf("imported content = %q, want the re-hosted image without srcset", post.Content)
+    }
+    if len(f.users.users) != 2 || !f.users.users[1].Placeholder {
+        t.Errorf("import created %d users, want a placeholder for the commenter", len(f.users.users)-1)
+    }
+
+    // Running the import again changes nothing, and downloads nothing again.
+    revisions := len(f.revisions.of(post.ID))
+    want(run("Hello", false), models.ImportUnchanged, models.ImportUnchanged, [4]int{0, 0, 0, 1})
+    if len(comments.comments) != 1 || len(f.users.users) != 2 {
+        t.Errorf("imports stored %d comments and %d users, want 1 and 2", len(comments.comments), len(f.users.users))
+    }
+
+    // A changed post is updated, in a dry run only reported.
+    want(run("Hello again", true), models.ImportUpdated, models.ImportUnchanged, [4]int{0, 0, 0, 1})
+    if got, _ := f.posts.GetByID(post.ID.Hex()); got.Title != "Hello" {
+        t.Errorf("dry run changed the title to %q", got.Title)
+    }
+    want(run("Hello again", false), models.ImportUpdated, models.ImportUnchanged, [4]int{0, 0, 0, 1})
+    if got, _ := f.posts.GetByID(post.ID.Hex()); got.Title != "Hello again" {
+        t.Errorf("updated title = %q, want %q", got.Title, "Hello again")
+    }
+    if got := len(f.revisions.of(post.ID)); got != revisions+1 {
+        t.Errorf("revisions after the update = %d, want %d", got, revisions+1)
+    }
+    if len(f.posts.posts) != 2 || len(comments.comments) != 1 {
+        t.Errorf("imports stored %d posts and %d comments, want 2 and 1", len(f.posts.posts), len(comments.comments))
+    }
+}
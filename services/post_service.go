@@ -1,79 +1,1347 @@
 package services
 
 import (
+    "errors"
+    "fmt"
     "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "log"
+    "strings"
     "time"
+    "unicode/utf8"
 )
 
 type PostRepository interface {
     Create(post *models.Post) error
     GetByID(id string) (*models.Post, error)
-    Update(id string, updates map[string]interface{}) error
-    Delete(id string) error
-    List(page, limit int) ([]*models.Post, error)
+    GetBySlug(slug string) (*models.Post, error)
+    Update(id string, version *int64, updates map[string]interface{}) error
+    SoftDelete(id string, version *int64, deletedBy primitive.ObjectID, at time.Time) error
+    GetDeletedByID(id string) (*models.Post, error)
+    Restore(id string) error
+    List(filter models.PostFilter, req models.PageRequest) (*models.PostPage, error)
     GetByAuthor(authorID string) ([]*models.Post, error)
+    ClaimDueScheduled(now time.Time) (*models.Post, error)
+    AddCoAuthor(id, userID primitive.ObjectID) error
+    RemoveCoAuthor(id, userID primitive.ObjectID) error
+    SetCoAuthors(id primitive.ObjectID, userIDs []primitive.ObjectID) error
+    GetBySourceID(sourceID string) (*models.Post, error)
+    ListUnsummarized(limit int) ([]*models.Post, error)
+    SetSummary(id primitive.ObjectID, fields map[string]interface{}) error
+    ListWithoutSlug(limit int) ([]*models.Post, error)
+    SetSlug(id primitive.ObjectID, slug string) (bool, error)
 }
 
+type SlugRepository interface {
+    Reserve(slug string, postID primitive.ObjectID) (bool, error)
+    Release(slug string, postID primitive.ObjectID) error
+    FindPostID(slug string) (primitive.ObjectID, error)
+    DeleteByPost(postID primitive.ObjectID) error
+}
+
+// PostCategoryRepository is the part of the category storage the post service
+// needs to validate and expand categories.
+type PostCategoryRepository interface {
+    GetByID(id string) (*models.Category, error)
+    GetBySlug(slug string) (*models.Category, error)
+    GetDescendantIDs(id primitive.ObjectID) ([]primitive.ObjectID, error)
+}
+
+// PostRevisionRepository stores the revision history of posts.
+type PostRevisionRepository interface {
+    Create(revision *models.PostRevision) error
+    Exists(postID primitive.ObjectID) (bool, error)
+    GetByNumber(postID primitive.ObjectID, number int) (*models.PostRevision, error)
+}
+
+type ContentRenderer interface {
+    IsValidFormat(format string) bool
+    Render(format, source string) (string, error)
+}
+
+// PostListener is notified after a post has been written through the
+// PostService, so that data derived from posts, such as a search index, can
+// be kept up to date. Listeners are called synchronously and handle their own
+// errors.
+type PostListener interface {
+    PostSaved(post *models.Post)
+    PostDeleted(post *models.Post)
+}
+
+// maxSlugAttempts is the number of numbered variants ("title-2", "title-3",
+// ...) tried before falling back to a slug suffixed with the post ID.
+const maxSlugAttempts = 20
+
+// summaryBatchSize is the number of posts SummarizeExisting loads at a time.
+const summaryBatchSize = 100
+
+// excerptLength is the approximate length, in characters, of the excerpts
+// derived from post content, and maxExcerptLength the longest excerpt an
+// author may write.
+const (
+    excerptLength    = 200
+    maxExcerptLength = 500
+)
+
 type PostService struct {
-    repo PostRepository
+    repo       PostRepository
+    slugs      SlugRepository
+    categories PostCategoryRepository
+    revisions  PostRevisionRepository
+    users      AuthorRepository
+    renderer   ContentRenderer
+    accessKey  []byte
+    accessTTL  time.Duration
+    listeners  []PostListener
 }
 
-// NewPostService returns a new PostService instance, given a PostRepository.
-func NewPostService(repo PostRepository) *PostService {
+// NewPostService returns a new PostService instance, given a PostRepository,
+// the SlugRepository that keeps track of the slugs used by posts, the
+// category storage used to validate post categories, the repository keeping
+// the revision history of posts, the user storage used to show who wrote a
+// post, the ContentRenderer used to turn post content into sanitized HTML,
+// and the secret and lifetime of the access tokens of password-protected
+// posts.
+func NewPostService(repo PostRepository, slugs SlugRepository, categories PostCategoryRepository, revisions PostRevisionRepository, users AuthorRepository, renderer ContentRenderer, accessSecret string, accessTTL time.Duration) *PostService {
     return &PostService{
-        repo: repo,
+        repo:       repo,
+        slugs:      slugs,
+        categories: categories,
+        revisions:  revisions,
+        users:      users,
+        renderer:   renderer,
+        accessKey:  accessKey(accessSecret),
+        accessTTL:  accessTTL,
     }
 }
 
+// AddListener registers a PostListener to be notified of every post written
+// from now on. It must be called before the service is used concurrently.
+func (s *PostService) AddListener(listener PostListener) {
+    s.listeners = append(s.listeners, listener)
+}
+
 // Create creates a new post in the "posts" collection in the MongoDB database.
 //
 // The created_at and updated_at fields are automatically set to the current time.
+// Posts without a status are created as drafts. A published post gets its
+// published_at set to the current time, while a scheduled post must carry a
+// published_at in the future.
+//
+// The post gets a unique slug: the one already set on the post if any, or one
+// generated from the title otherwise. The content is rendered to sanitized
+// HTML according to its format, which defaults to Markdown, and summarized:
+// the post gets a word count, a reading time, a table of contents of its
+// headings and, unless the author wrote one, an excerpt. Tags are normalized,
+// and the category, if any, must exist. The post is recorded as its first
+// revision.
+//
+// Posts without a visibility are public. Password-protected posts need a
+// password, which is stored hashed; other posts take none.
 //
 // The returned error will be non-nil if any error occurred during the create
 // process.
-func (s *PostService) Create(post *models.Post) error {
-    post.CreatedAt = time.Now()
-    post.UpdatedAt = time.Now()
-    return s.repo.Create(post)
+func (s *PostService) Create(post *models.Post, password string) error {
+    now := time.Now()
+
+    if err := setVisibility(post, post.Visibility, password); err != nil {
+        return err
+    }
+
+    if post.Status == "" {
+        post.Status = models.PostStatusDraft
+    }
+
+    switch post.Status {
+    case models.PostStatusDraft, models.PostStatusArchived:
+        post.PublishedAt = nil
+    case models.PostStatusPublished:
+        post.PublishedAt = &now
+    case models.PostStatusScheduled:
+        if post.PublishedAt == nil || !post.PublishedAt.After(now) {
+            return fmt.Errorf("%w: scheduled posts need a publish time in the future", models.ErrInvalidInput)
+        }
+    default:
+        return fmt.Errorf("%w: unknown status %q", models.ErrInvalidInput, post.Status)
+    }
+
+    post.CreatedAt = now
+    post.UpdatedAt = now
+    return s.insert(post)
 }
 
-// Get returns a post by the given ID.
+// Import creates the given post, brought over from another blog, or brings
+// the post previously imported from the same source, as given by its
+// SourceID, up to date with it. It returns the resulting post together with
+// what was done: models.ImportCreated, models.ImportUpdated or
+// models.ImportUnchanged.
+//
+// Unlike Create, Import keeps the dates of the post: its created_at, its
+// updated_at if later, and the published_at of a published post, which
+// defaults to its created_at. Posts are imported as drafts unless published;
+// a published post dated in the future is scheduled.
+//
+// A post imported again is only written if its title, content, excerpt,
+// image, tags, category, slug, status or publish time changed, and the
+// change is recorded as a revision like any update. With dryRun, nothing is
+// written: the returned post is the existing post, or the one that would be
+// created.
+//
+// The returned error will be models.ErrInvalidInput for a post without a
+// source or with an invalid field, and models.ErrConflict if the post
+// imported from the same source is in the trash.
+func (s *PostService) Import(post *models.Post, dryRun bool) (*models.Post, string, error) {
+    if post.SourceID == "" {
+        return nil, "", fmt.Errorf("%w: imported posts need a source", models.ErrInvalidInput)
+    }
+
+    now := time.Now()
+    if post.CreatedAt.IsZero() {
+        post.CreatedAt = now
+    }
+    if post.UpdatedAt.Before(post.CreatedAt) {
+        post.UpdatedAt = post.CreatedAt
+    }
+
+    switch post.Status {
+    case "", models.PostStatusDraft:
+        post.Status = models.PostStatusDraft
+        post.PublishedAt = nil
+    case models.PostStatusPublished:
+        if post.PublishedAt == nil {
+            publishedAt := post.CreatedAt
+            post.PublishedAt = &publishedAt
+        }
+        if post.PublishedAt.After(now) {
+            post.Status = models.PostStatusScheduled
+        }
+    default:
+        return nil, "", fmt.Errorf("%w: imported posts are drafts or published, not %q", models.ErrInvalidInput, post.Status)
+    }
+
+    if err := setExcerpt(post, post.Excerpt); err != nil {
+        return nil, "", err
+    }
+    if post.ContentFormat == "" {
+        post.ContentFormat = utils.FormatMarkdown
+    }
+    if !s.renderer.IsValidFormat(post.ContentFormat) {
+        return nil, "", fmt.Errorf("%w: unknown content format %q", models.ErrInvalidInput, post.ContentFormat)
+    }
+    if post.Slug != "" && !utils.IsValidSlug(post.Slug) {
+        return nil, "", fmt.Errorf("%w: slugs may only contain lowercase letters, digits and dashes", models.ErrInvalidInput)
+    }
+    post.Tags = utils.NormalizeTags(post.Tags)
+
+    existing, err := s.repo.GetBySourceID(post.SourceID)
+    if errors.Is(err, models.ErrNotFound) {
+        if dryRun {
+            return post, models.ImportCreated, nil
+        }
+        if err := s.insert(post); err != nil {
+            return nil, "", err
+        }
+        return post, models.ImportCreated, nil
+    }
+    if err != nil {
+        return nil, "", err
+    }
+    if existing.DeletedAt != nil {
+        return nil, "", fmt.Errorf("%w: the post imported from %s is in the trash", models.ErrConflict, post.SourceID)
+    }
+
+    updates := importChanges(existing, post)
+    statusChanged := existing.Status != post.Status || !sameTime(existing.PublishedAt, post.PublishedAt)
+    if len(updates) == 0 && !statusChanged {
+        return existing, models.ImportUnchanged, nil
+    }
+    if dryRun {
+        return existing, models.ImportUpdated, nil
+    }
+
+    updated := existing
+    if len(updates) > 0 {
+        updated, err = s.Update(existing.ID.Hex(), existing.AuthorID.Hex(), &existing.Version, updates)
+        if err != nil {
+            return nil, "", err
+        }
+    }
+    if statusChanged {
+        updated.Status = post.Status
+        updated.PublishedAt = post.PublishedAt
+        if err := s.setStatus(updated, now); err != nil {
+            return nil, "", err
+        }
+    }
+
+    return updated, models.ImportUpdated, nil
+}
+
+// insert renders, summarizes and stores the given new post, whose status and
+// dates are already set, and records it as its first revision.
+func (s *PostService) insert(post *models.Post) error {
+    if err := setExcerpt(post, post.Excerpt); err != nil {
+        return err
+    }
+    language, err := normalizeLanguage(post.Language)
+    if err != nil {
+        return err
+    }
+    post.Language = language
+    if err := s.renderContent(post); err != nil {
+        return err
+    }
+
+    post.Tags = utils.NormalizeTags(post.Tags)
+    if post.CategoryID != nil {
+        if err := s.checkCategory(post.CategoryID.Hex()); err != nil {
+            return err
+        }
+    }
+
+    post.ID = primitive.NewObjectID()
+    if _, err := s.assignSlug(post, post.Slug); err != nil {
+        return err
+    }
+
+    post.Version = 1
+    if err := s.repo.Create(post); err != nil {
+        s.slugs.DeleteByPost(post.ID)
+        return err
+    }
+
+    s.recordRevision(post, post.AuthorID)
+    s.notifySaved(post)
+    return nil
+}
+
+// Get returns a post by the given ID to the given viewer.
+//
+// Posts that are not published are only returned to their author and
+// co-authors. Published posts are returned according to their visibility:
+// private posts to editors and admins too, and password-protected ones also
+// to viewers holding an access token from Unlock. Other viewers, including
+// anonymous readers, get models.ErrNotFound, or models.ErrPasswordRequired
+// for a password-protected post. The returned post carries the names of its
+// authors.
+//
+// A post with variants is localized to the first of the given languages,
+// most preferred first, it can be read in, and lists its translations.
 //
 // The returned error will be non-nil if any error occurred during the get
 // process.
-func (s *PostService) Get(postID string) (*models.Post, error) {
-    return s.repo.GetByID(postID)
+func (s *PostService) Get(postID string, viewer models.Viewer, languages []string) (*models.Post, error) {
+    post, err := s.repo.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.CanRead(post, viewer); err != nil {
+        return nil, err
+    }
+
+    localize(post, languages)
+    return post, attachAuthors(s.users, post)
+}
+
+// GetBySlug returns a post by its slug, applying the same visibility rules as
+// Get. The slug of a variant returns the post localized to the variant's
+// language, and the slug of the post itself the post in its own language.
+//
+// If the slug used to belong to the post but has since been replaced, the
+// post is returned together with its current slug so that the caller can
+// redirect. For a current slug, the returned redirect slug is empty.
+func (s *PostService) GetBySlug(slug string, viewer models.Viewer) (*models.Post, string, error) {
+    post, err := s.repo.GetBySlug(slug)
+    if err == nil {
+        if err := s.CanRead(post, viewer); err != nil {
+            return nil, "", err
+        }
+        post.Localize("")
+        return post, "", attachAuthors(s.users, post)
+    }
+    if !errors.Is(err, models.ErrNotFound) {
+        return nil, "", err
+    }
+
+    postID, err := s.slugs.FindPostID(slug)
+    if err != nil {
+        return nil, "", err
+    }
+
+    post, err = s.repo.GetByID(postID.Hex())
+    if err != nil {
+        return nil, "", err
+    }
+    if err := s.CanRead(post, viewer); err != nil {
+        return nil, "", err
+    }
+
+    language := ""
+    for _, variant := range post.Variants {
+        if variant.Slug == slug {
+            language = variant.Language
+        }
+    }
+    post.Localize(language)
+    if err := attachAuthors(s.users, post); err != nil {
+        return nil, "", err
+    }
+
+    if language != "" || post.Slug == "" || post.Slug == slug {
+        return post, "", nil
+    }
+    return post, post.Slug, nil
 }
 
-// Update updates the fields of the post with the given ID in the "posts" collection.
+// Update updates the fields of the post with the given ID in the "posts"
+// collection on behalf of userID, who must be the post's author or one of its
+// co-authors.
 //
 // The updates parameter is a map of key-value pairs where the key is the field name
 // and the value is the new value for that field. The updated_at field is automatically
 // set to the current time.
 //
-// The returned error will be non-nil if any error occurred during the update process.
-func (s *PostService) Update(postID string, updates map[string]interface{}) error {
+// Slugs are stable: a post only gets a new slug when "slug" is part of the
+// updates, or when it has none yet. The previous slug keeps pointing to the
+// post, so old links can be redirected.
+//
+// Only the fields listed in models.PostPatchFields may be updated; any other
+// key is rejected with models.ErrInvalidInput, so that fields such as
+// author_id or created_at cannot be overwritten.
+//
+// When the content or its format changes, the stored HTML is rendered and
+// summarized again. An empty "excerpt" goes back to the excerpt derived from
+// the content. Tags are normalized, and a "category_id" entry must be the hex ID of an
+// existing category, or empty to remove the post from its category. A
+// "language" entry must be a BCP 47 tag the post has no variant in, or empty.
+//
+// Only the post's author may change its "visibility" or "password". The
+// password is the new plain-text password of a password-protected post,
+// which is stored hashed; changing it revokes the access tokens given for
+// the previous one. Leaving the password visibility drops the password.
+//
+// Every update is recorded as a new revision. Posts written before revisions
+// were kept get their current state recorded first, so that nothing is lost.
+//
+// If version is not nil, the post is only updated if it is still at that
+// version, which keeps concurrent editors from overwriting each other's
+// changes. The returned post reflects the update, including its new version.
+//
+// The returned error will be models.ErrVersionConflict if the post is at
+// another version, and non-nil if any other error occurred during the update
+// process.
+func (s *PostService) Update(postID, userID string, version *int64, updates map[string]interface{}) (*models.Post, error) {
+    if err := checkPatchFields(updates, models.PostPatchFields); err != nil {
+        return nil, err
+    }
+
+    slug, hasSlug := updates["slug"].(string)
+    delete(updates, "slug")
+
+    post, err := s.getEditable(postID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if version != nil && *version != post.Version {
+        return nil, models.ErrVersionConflict
+    }
+
+    hasRevisions, err := s.revisions.Exists(post.ID)
+    if err != nil {
+        return nil, err
+    }
+    if !hasRevisions {
+        baseline := newRevision(post, post.AuthorID)
+        baseline.CreatedAt = post.UpdatedAt
+        if err := s.revisions.Create(baseline); err != nil {
+            return nil, err
+        }
+    }
+
+    excerpt, hasExcerpt := updates["excerpt"].(string)
+    if hasExcerpt {
+        if err := setExcerpt(post, excerpt); err != nil {
+            return nil, err
+        }
+    }
+
+    content, hasContent := updates["content"].(string)
+    format, hasFormat := updates["content_format"].(string)
+    if hasContent || hasFormat {
+        if hasContent {
+            post.Content = content
+        }
+        if hasFormat {
+            post.ContentFormat = format
+        }
+        if err := s.renderContent(post); err != nil {
+            return nil, err
+        }
+        updates["content_format"] = post.ContentFormat
+    } else if hasExcerpt {
+        summarize(post)
+    }
+    if hasContent || hasFormat || hasExcerpt {
+        for key, value := range summaryFields(post) {
+            updates[key] = value
+        }
+    }
+
+    if tags, ok := updates["tags"].([]string); ok {
+        updates["tags"] = utils.NormalizeTags(tags)
+    }
+
+    visibility, hasVisibility := updates["visibility"].(string)
+    password, hasPassword := updates["password"].(string)
+    delete(updates, "password")
+    if hasVisibility || hasPassword {
+        if post.AuthorID.Hex() != userID {
+            return nil, fmt.Errorf("%w: only the author of a post may change who can read it", models.ErrForbidden)
+        }
+        if !hasVisibility {
+            visibility = post.Visibility
+        }
+        if err := setVisibility(post, visibility, password); err != nil {
+            return nil, err
+        }
+        updates["visibility"] = post.Visibility
+        updates["password_hash"] = post.PasswordHash
+    }
+
+    if language, ok := updates["language"].(string); ok {
+        language, err := normalizeLanguage(language)
+        if err != nil {
+            return nil, err
+        }
+        if language != "" && post.Variant(language) != nil {
+            return nil, fmt.Errorf("%w: the post has a %s translation", models.ErrConflict, language)
+        }
+        updates["language"] = language
+    }
+
+    if categoryID, ok := updates["category_id"].(string); ok {
+        if categoryID == "" {
+            updates["category_id"] = nil
+        } else {
+            if err := s.checkCategory(categoryID); err != nil {
+                return nil, err
+            }
+            objectID, _ := primitive.ObjectIDFromHex(categoryID)
+            updates["category_id"] = objectID
+        }
+    }
+
+    reserved := false
+    if hasSlug || post.Slug == "" {
+        if title, ok := updates["title"].(string); ok {
+            post.Title = title
+        }
+        reserved, err = s.assignSlug(post, slug)
+        if err != nil {
+            return nil, err
+        }
+        updates["slug"] = post.Slug
+    }
+
     updates["updated_at"] = time.Now()
-    return s.repo.Update(postID, updates)
+    if err := s.repo.Update(postID, version, updates); err != nil {
+        // A slug new to the post would otherwise stay taken by it
+        if reserved {
+            s.slugs.Release(post.Slug, post.ID)
+        }
+        return nil, err
+    }
+
+    updated, err := s.repo.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+
+    editorID, _ := primitive.ObjectIDFromHex(userID)
+    s.recordRevision(updated, editorID)
+    s.notifySaved(updated)
+    return updated, nil
+}
+
+// NotifyChanged records a new revision, made by editorID, of each of the
+// posts with the given IDs, and tells the listeners that they were saved. It
+// is meant for posts changed in bulk outside of Update, such as by a tag
+// rename, so that derived data like the search index follows. Posts in the
+// trash are skipped; they are handled when restored.
+//
+// The returned error will be non-nil if any error occurred while loading
+// the posts.
+func (s *PostService) NotifyChanged(postIDs []primitive.ObjectID, editorID string) error {
+    editorObjectID, _ := primitive.ObjectIDFromHex(editorID)
+    for _, postID := range postIDs {
+        post, err := s.repo.GetByID(postID.Hex())
+        if errors.Is(err, models.ErrNotFound) {
+            continue
+        }
+        if err != nil {
+            return err
+        }
+
+        s.recordRevision(post, editorObjectID)
+        s.notifySaved(post)
+    }
+    return nil
 }
 
-// Delete deletes the post with the given ID from the "posts" collection in the
-// MongoDB database.
+// RestoreRevision brings the title, content, image, tags and category of the
+// post with the given ID back to those of one of its revisions, on behalf of
+// userID, who must be the post's author or one of its co-authors. The slug
+// and status of the post are left as they are.
 //
-// The returned error will be non-nil if any error occurred during the delete
+// Restoring is an update like any other: it is recorded as a new revision, so
+// it can be undone in turn. The returned post reflects the restored state.
+//
+// The returned error will be models.ErrNotFound if the post has no revision
+// with the given number.
+func (s *PostService) RestoreRevision(postID, userID string, number int) (*models.Post, error) {
+    post, err := s.getEditable(postID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    revision, err := s.revisions.GetByNumber(post.ID, number)
+    if err != nil {
+        return nil, err
+    }
+
+    categoryID := ""
+    if revision.CategoryID != nil {
+        categoryID = revision.CategoryID.Hex()
+    }
+    tags := revision.Tags
+    if tags == nil {
+        tags = []string{}
+    }
+
+    return s.Update(postID, userID, nil, map[string]interface{}{
+        "title":          revision.Title,
+        "content":        revision.Content,
+        "content_format": revision.ContentFormat,
+        "image_url":      revision.ImageURL,
+        "tags":           tags,
+        "category_id":    categoryID,
+    })
+}
+
+// Delete moves the post with the given ID to the trash on behalf of userID,
+// who must be the post's author or an admin, as given by role. Deleted posts
+// disappear from every listing and lookup, but keep their slugs and revisions
+// so that they can be restored until the TrashService purges them.
+//
+// As with Update, a non-nil version makes the deletion conditional.
+//
+// The returned error will be models.ErrVersionConflict if the post is at
+// another version, and non-nil if any other error occurred during the delete
 // process.
-func (s *PostService) Delete(postID string) error {
-    return s.repo.Delete(postID)
+func (s *PostService) Delete(postID, userID, role string, version *int64) error {
+    post, err := s.repo.GetByID(postID)
+    if err != nil {
+        return err
+    }
+    if !canManage(post, userID, role) {
+        return models.ErrForbidden
+    }
+
+    deletedBy, _ := primitive.ObjectIDFromHex(userID)
+    now := time.Now()
+    if err := s.repo.SoftDelete(postID, version, deletedBy, now); err != nil {
+        return err
+    }
+
+    post.DeletedAt = &now
+    post.DeletedBy = &deletedBy
+    s.notifyDeleted(post)
+    return nil
 }
 
-// List returns a slice of posts, sorted by created_at in descending order,
-// limited to the given number of items, and starting from the given page.
+// Restore takes the post with the given ID out of the trash on behalf of
+// userID, who must be the post's author or an admin, as given by role. The
+// post comes back with the status it had when it was deleted.
 //
-// The page and limit parameters are 1-indexed, so the first page should have
-// page = 1 and limit = 10 to get the first 10 results.
+// The returned error will be models.ErrNotFound if the post is not in the
+// trash.
+func (s *PostService) Restore(postID, userID, role string) (*models.Post, error) {
+    post, err := s.repo.GetDeletedByID(postID)
+    if err != nil {
+        return nil, err
+    }
+    if !canManage(post, userID, role) {
+        return nil, models.ErrForbidden
+    }
+
+    if err := s.repo.Restore(postID); err != nil {
+        return nil, err
+    }
+
+    post, err = s.repo.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+
+    s.notifySaved(post)
+    return post, nil
+}
+
+// ListTrash returns a page of the deleted posts userID may restore: their own
+// posts, leaving out the posts they only co-author, or every deleted post for
+// an admin, as given by role.
 //
 // The returned error will be non-nil if any error occurred during the find
 // process.
-func (s *PostService) List(page, limit int) ([]*models.Post, error) {
-    return s.repo.List(page, limit)
+func (s *PostService) ListTrash(userID, role string, req models.PageRequest) (*models.PostPage, error) {
+    filter := models.PostFilter{Deleted: true, ExcludeCoAuthored: true}
+    if role != models.RoleAdmin {
+        authorID, err := primitive.ObjectIDFromHex(userID)
+        if err != nil {
+            return nil, models.ErrForbidden
+        }
+        filter.AuthorID = authorID
+    }
+
+    page, err := s.repo.List(filter, req)
+    if err != nil {
+        return nil, err
+    }
+    for _, post := range page.Posts {
+        post.TrimForList()
+    }
+    return page, attachAuthors(s.users, page.Posts...)
+}
+
+// List returns a page of published posts matching the given filter, sorted
+// by created_at in descending order. Pages are selected by cursor or by page
+// number, see models.PageRequest. Listed posts carry their excerpt instead of
+// their content.
+//
+// Tags in the filter are normalized, and a category slug also matches the
+// posts of all its subcategories. Only published public posts are listed:
+// unlisted, private and password-protected posts are left out. Posts with
+// variants are localized to the given languages, as for Get.
+//
+// The returned error will be models.ErrInvalidInput if the filter asks for
+// another status or visibility, and non-nil if any other error occurred during the find
+// process.
+func (s *PostService) List(filter models.PostFilter, req models.PageRequest, languages []string) (*models.PostPage, error) {
+    for _, status := range filter.Statuses {
+        if status != models.PostStatusPublished {
+            return nil, fmt.Errorf("%w: only published posts are listed publicly", models.ErrInvalidInput)
+        }
+    }
+    filter.Statuses = []string{models.PostStatusPublished}
+    for _, visibility := range filter.Visibilities {
+        if visibility != models.VisibilityPublic {
+            return nil, fmt.Errorf("%w: only public posts are listed publicly", models.ErrInvalidInput)
+        }
+    }
+    filter.Visibilities = []string{models.VisibilityPublic}
+
+    return s.list(filter, req, languages)
+}
+
+// ListByAuthor returns a page of the posts written or co-written by the given
+// author, matching the given filter. Posts in any status and visibility are
+// included unless the filter restricts them, so authors can see their own
+// drafts, scheduled, archived and private posts.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (s *PostService) ListByAuthor(authorID string, filter models.PostFilter, req models.PageRequest) (*models.PostPage, error) {
+    objectID, err := primitive.ObjectIDFromHex(authorID)
+    if err != nil {
+        return nil, models.ErrForbidden
+    }
+
+    filter.AuthorID = objectID
+    return s.list(filter, req, nil)
+}
+
+// list normalizes the tags of the filter, expands its category into the
+// category and its descendants, and returns the matching page of posts with
+// the names of their authors and without their content, localized to the
+// given languages.
+func (s *PostService) list(filter models.PostFilter, req models.PageRequest, languages []string) (*models.PostPage, error) {
+    filter.Tags = utils.NormalizeTags(filter.Tags)
+
+    if filter.Category != "" {
+        category, err := s.categories.GetBySlug(filter.Category)
+        if errors.Is(err, models.ErrNotFound) {
+            return &models.PostPage{Posts: []*models.Post{}}, nil
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        descendants, err := s.categories.GetDescendantIDs(category.ID)
+        if err != nil {
+            return nil, err
+        }
+        filter.CategoryIDs = append([]primitive.ObjectID{category.ID}, descendants...)
+    }
+
+    page, err := s.repo.List(filter, req)
+    if err != nil {
+        return nil, err
+    }
+    for _, post := range page.Posts {
+        localize(post, languages)
+        post.TrimForList()
+    }
+    return page, attachAuthors(s.users, page.Posts...)
+}
+
+// Publish publishes the post with the given ID on behalf of userID, who must
+// be the post's author.
+//
+// If publishAt is nil or not in the future, the post goes live immediately.
+// Otherwise it is scheduled and will be published by PublishScheduled once
+// publishAt has passed.
+//
+// The returned post reflects the new status.
+func (s *PostService) Publish(postID, userID string, publishAt *time.Time) (*models.Post, error) {
+    post, err := s.getOwned(postID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    if publishAt != nil && publishAt.After(now) {
+        post.Status = models.PostStatusScheduled
+        post.PublishedAt = publishAt
+    } else {
+        post.Status = models.PostStatusPublished
+        post.PublishedAt = &now
+    }
+
+    return post, s.setStatus(post, now)
+}
+
+// Unpublish moves the post with the given ID back to draft on behalf of
+// userID, who must be the post's author. This also cancels a pending schedule.
+func (s *PostService) Unpublish(postID, userID string) (*models.Post, error) {
+    post, err := s.getOwned(postID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    post.Status = models.PostStatusDraft
+    post.PublishedAt = nil
+    return post, s.setStatus(post, time.Now())
+}
+
+// Archive hides the post with the given ID from readers on behalf of userID,
+// who must be the post's author. The original published_at is kept.
+func (s *PostService) Archive(postID, userID string) (*models.Post, error) {
+    post, err := s.getOwned(postID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    post.Status = models.PostStatusArchived
+    return post, s.setStatus(post, time.Now())
+}
+
+// PublishScheduled publishes every scheduled post whose publish time has
+// passed and returns how many posts went live. It is meant to be run
+// periodically and is safe to run from several server instances at once.
+func (s *PostService) PublishScheduled() (int, error) {
+    published := 0
+    for {
+        post, err := s.repo.ClaimDueScheduled(time.Now())
+        if err != nil {
+            return published, err
+        }
+        if post == nil {
+            return published, nil
+        }
+        s.notifySaved(post)
+        published++
+    }
+}
+
+// AddCoAuthor appends userID to the co-authors of the post with the given ID
+// and returns the updated post. Nobody becomes a co-author without consent:
+// this is only meant to be called once userID accepted an invitation from
+// the post's author, see CoAuthorService.
+//
+// The returned error will be models.ErrConflict if the post already has
+// models.MaxCoAuthors co-authors.
+func (s *PostService) AddCoAuthor(postID, userID string) (*models.Post, error) {
+    id, err := primitive.ObjectIDFromHex(postID)
+    if err != nil {
+        return nil, models.ErrNotFound
+    }
+    userObjectID, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, models.ErrForbidden
+    }
+
+    if err := s.repo.AddCoAuthor(id, userObjectID); err != nil {
+        return nil, err
+    }
+
+    return s.reloadCoAuthors(postID)
+}
+
+// RemoveCoAuthor removes coAuthorID from the co-authors of the post with the
+// given ID on behalf of userID, who must be the post's author or the
+// co-author leaving the post, and returns the updated post.
+//
+// The returned error will be models.ErrNotFound if coAuthorID does not
+// co-author the post.
+func (s *PostService) RemoveCoAuthor(postID, userID, coAuthorID string) (*models.Post, error) {
+    post, err := s.repo.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+    if post.AuthorID.Hex() != userID && coAuthorID != userID {
+        return nil, models.ErrForbidden
+    }
+
+    coAuthorObjectID, err := primitive.ObjectIDFromHex(coAuthorID)
+    if err != nil {
+        return nil, models.ErrNotFound
+    }
+    if err := s.repo.RemoveCoAuthor(post.ID, coAuthorObjectID); err != nil {
+        return nil, err
+    }
+
+    return s.reloadCoAuthors(postID)
+}
+
+// ReorderCoAuthors puts the co-authors of the post with the given ID in the
+// given order on behalf of userID, who must be the post's author, and
+// returns the updated post. coAuthorIDs must list every co-author exactly
+// once.
+//
+// The returned error will be models.ErrInvalidInput if coAuthorIDs is not a
+// reordering of the co-authors, and models.ErrConflict if they changed in
+// the meantime.
+func (s *PostService) ReorderCoAuthors(postID, userID string, coAuthorIDs []string) (*models.Post, error) {
+    post, err := s.getOwned(postID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    current := make(map[primitive.ObjectID]bool, len(post.CoAuthors))
+    for _, coAuthor := range post.CoAuthors {
+        current[coAuthor] = true
+    }
+
+    ordered := make([]primitive.ObjectID, 0, len(coAuthorIDs))
+    for _, coAuthorID := range coAuthorIDs {
+        objectID, err := primitive.ObjectIDFromHex(coAuthorID)
+        if err != nil || !current[objectID] {
+            return nil, fmt.Errorf("%w: %s does not co-author the post", models.ErrInvalidInput, coAuthorID)
+        }
+        delete(current, objectID)
+        ordered = append(ordered, objectID)
+    }
+    if len(current) > 0 {
+        return nil, fmt.Errorf("%w: every co-author must be listed once", models.ErrInvalidInput)
+    }
+
+    if err := s.repo.SetCoAuthors(post.ID, ordered); err != nil {
+        return nil, err
+    }
+
+    return s.reloadCoAuthors(postID)
+}
+
+// reloadCoAuthors returns the post with the given ID after a change of its
+// co-authors, with the names of its authors, and tells the listeners about
+// it.
+func (s *PostService) reloadCoAuthors(postID string) (*models.Post, error) {
+    post, err := s.repo.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+
+    s.notifySaved(post)
+    return post, attachAuthors(s.users, post)
+}
+
+// checkCategory returns models.ErrInvalidInput if no category exists with the
+// given ID.
+func (s *PostService) checkCategory(categoryID string) error {
+    _, err := s.categories.GetByID(categoryID)
+    if errors.Is(err, models.ErrNotFound) {
+        return fmt.Errorf("%w: category not found", models.ErrInvalidInput)
+    }
+    return err
+}
+
+// renderContent validates the content format of the given post, sets its
+// ContentHTML to the rendered, sanitized content and summarizes it.
+func (s *PostService) renderContent(post *models.Post) error {
+    if post.ContentFormat == "" {
+        post.ContentFormat = utils.FormatMarkdown
+    }
+    if !s.renderer.IsValidFormat(post.ContentFormat) {
+        return fmt.Errorf("%w: unknown content format %q", models.ErrInvalidInput, post.ContentFormat)
+    }
+
+    html, err := s.renderer.Render(post.ContentFormat, post.Content)
+    if err != nil {
+        return err
+    }
+
+    post.ContentHTML = html
+    summarize(post)
+    return nil
+}
+
+// SummarizeExisting summarizes the posts written before posts carried an
+// excerpt, a word count, a reading time and a table of contents, deleted
+// posts included, and returns how many posts were summarized. It works from
+// the stored HTML and does not change the version of the posts, so it is
+// safe to run on every start-up and from several server instances at once.
+func (s *PostService) SummarizeExisting() (int, error) {
+    summarized := 0
+    for {
+        posts, err := s.repo.ListUnsummarized(summaryBatchSize)
+        if err != nil {
+            return summarized, err
+        }
+        if len(posts) == 0 {
+            return summarized, nil
+        }
+
+        for _, post := range posts {
+            summarize(post)
+            if err := s.repo.SetSummary(post.ID, summaryFields(post)); err != nil {
+                return summarized, err
+            }
+            summarized++
+        }
+    }
+}
+
+// SlugExisting gives a slug to the posts written before posts had one,
+// deleted posts included, and returns how many posts got one. The slug is
+// generated from the title, as for a new post. It does not change the version
+// of the posts and a post keeps the slug it got first, so it is safe to run
+// on every start-up and from several server instances at once.
+func (s *PostService) SlugExisting() (int, error) {
+    slugged := 0
+    for {
+        posts, err := s.repo.ListWithoutSlug(summaryBatchSize)
+        if err != nil {
+            return slugged, err
+        }
+        if len(posts) == 0 {
+            return slugged, nil
+        }
+
+        for _, post := range posts {
+            reserved, err := s.assignSlug(post, "")
+            if err != nil {
+                return slugged, err
+            }
+
+            set, err := s.repo.SetSlug(post.ID, post.Slug)
+            if err != nil || !set {
+                // The post got a slug meanwhile, or keeps having none
+                if reserved {
+                    s.slugs.Release(post.Slug, post.ID)
+                }
+                if err != nil {
+                    return slugged, err
+                }
+                continue
+            }
+            slugged++
+        }
+    }
+}
+
+// setExcerpt sets the excerpt written by an author on the given post, with
+// its runs of spaces collapsed. An empty excerpt lets the post go back to the
+// excerpt derived from its content.
+//
+// The returned error will be models.ErrInvalidInput if the excerpt is longer
+// than maxExcerptLength characters.
+func setExcerpt(post *models.Post, excerpt string) error {
+    excerpt = strings.Join(strings.Fields(excerpt), " ")
+    if utf8.RuneCountInString(excerpt) > maxExcerptLength {
+        return fmt.Errorf("%w: excerpts are limited to %d characters", models.ErrInvalidInput, maxExcerptLength)
+    }
+
+    post.Excerpt = excerpt
+    post.CustomExcerpt = excerpt != ""
+    return nil
+}
+
+// summarize gives the headings of the rendered content of the given post an
+// anchor, and sets its table of contents, word count, reading time and,
+// unless the author wrote one, its excerpt.
+func summarize(post *models.Post) {
+    html, headings := utils.AnchorHeadings(post.ContentHTML)
+    post.ContentHTML = html
+
+    post.TOC = nil
+    for _, heading := range headings {
+        post.TOC = append(post.TOC, models.TOCEntry{Level: heading.Level, Text: heading.Text, Anchor: heading.Anchor})
+    }
+
+    text := utils.StripHTML(html)
+    post.WordCount = utils.CountWords(text)
+    post.ReadingTime = utils.ReadingMinutes(post.WordCount)
+    if !post.CustomExcerpt {
+        post.Excerpt = utils.Excerpt(text, excerptLength)
+    }
+}
+
+// summaryFields returns the stored fields set by summarize, as updates.
+func summaryFields(post *models.Post) map[string]interface{} {
+    return map[string]interface{}{
+        "content_html":   post.ContentHTML,
+        "excerpt":        post.Excerpt,
+        "custom_excerpt": post.CustomExcerpt,
+        "word_count":     post.WordCount,
+        "reading_time":   post.ReadingTime,
+        "toc":            post.TOC,
+    }
+}
+
+// assignSlug reserves a slug for the given post and sets it on the post. It
+// reports whether the slug is new to the post, and so is to be released if
+// the post cannot be saved.
+//
+// If requested is not empty, it must be a valid slug that is free or already
+// belongs to the post; otherwise models.ErrConflict is returned. If requested
+// is empty, a slug is generated from the title, numbering it ("title-2",
+// "title-3", ...) until a free one is found. The slugs of the post's variants
+// are taken.
+func (s *PostService) assignSlug(post *models.Post, requested string) (bool, error) {
+    taken := map[string]bool{}
+    for _, variant := range post.Variants {
+        taken[variant.Slug] = true
+    }
+
+    slug, reserved, err := s.reserveSlug(post.ID, post.Title, requested, taken)
+    if err != nil {
+        return false, err
+    }
+    post.Slug = slug
+    return reserved, nil
+}
+
+// reserveSlug reserves the requested slug, or one generated from the given
+// title, for the post with the given ID, as described for assignSlug, and
+// returns it and whether it is new to the post. The taken slugs already
+// belong to the post in another language and are treated as belonging to
+// another post.
+func (s *PostService) reserveSlug(postID primitive.ObjectID, title, requested string, taken map[string]bool) (string, bool, error) {
+    if requested != "" {
+        if !utils.IsValidSlug(requested) {
+            return "", false, fmt.Errorf("%w: slugs may only contain lowercase letters, digits and dashes", models.ErrInvalidInput)
+        }
+        if taken[requested] {
+            return "", false, fmt.Errorf("%w: the slug %q is used by another language of the post", models.ErrConflict, requested)
+        }
+        reserved, err := s.slugs.Reserve(requested, postID)
+        if err != nil {
+            return "", false, err
+        }
+        return requested, reserved, nil
+    }
+
+    base := utils.Slugify(title)
+    if base == "" {
+        base = "post"
+    }
+
+    for i := 1; i <= maxSlugAttempts; i++ {
+        candidate := base
+        if i > 1 {
+            candidate = fmt.Sprintf("%s-%d", base, i)
+        }
+        if taken[candidate] {
+            continue
+        }
+
+        reserved, err := s.slugs.Reserve(candidate, postID)
+        if err == nil {
+            return candidate, reserved, nil
+        }
+        if !errors.Is(err, models.ErrConflict) {
+            return "", false, err
+        }
+    }
+
+    // The post ID is unique, so this slug cannot be taken, except by
+    // another language of the post.
+    candidate := fmt.Sprintf("%s-%s", base, postID.Hex())
+    for i := 2; taken[candidate]; i++ {
+        candidate = fmt.Sprintf("%s-%s-%d", base, postID.Hex(), i)
+    }
+    reserved, err := s.slugs.Reserve(candidate, postID)
+    if err != nil {
+        return "", false, err
+    }
+    return candidate, reserved, nil
+}
+
+// importChanges returns the updates that bring the existing post up to date
+// with the given imported post, leaving out its status and publish time.
+func importChanges(existing, imported *models.Post) map[string]interface{} {
+    updates := map[string]interface{}{}
+    if existing.Title != imported.Title {
+        updates["title"] = imported.Title
+    }
+    if existing.Content != imported.Content {
+        updates["content"] = imported.Content
+    }
+    if existing.ContentFormat != imported.ContentFormat {
+        updates["content_format"] = imported.ContentFormat
+    }
+
+    excerpt := ""
+    if existing.CustomExcerpt {
+        excerpt = existing.Excerpt
+    }
+    if excerpt != imported.Excerpt {
+        updates["excerpt"] = imported.Excerpt
+    }
+
+    if existing.ImageURL != imported.ImageURL {
+        updates["image_url"] = imported.ImageURL
+    }
+    if strings.Join(existing.Tags, ",") != strings.Join(imported.Tags, ",") {
+        updates["tags"] = imported.Tags
+    }
+
+    switch {
+    case imported.CategoryID == nil && existing.CategoryID != nil:
+        updates["category_id"] = ""
+    case imported.CategoryID != nil && (existing.CategoryID == nil || *existing.CategoryID != *imported.CategoryID):
+        updates["category_id"] = imported.CategoryID.Hex()
+    }
+
+    if imported.Slug != "" && imported.Slug != existing.Slug {
+        updates["slug"] = imported.Slug
+    }
+    return updates
+}
+
+// sameTime reports whether both times are unset, or equal to the millisecond
+// MongoDB stores.
+func sameTime(a, b *time.Time) bool {
+    if a == nil || b == nil {
+        return a == b
+    }
+    return a.Truncate(time.Millisecond).Equal(b.Truncate(time.Millisecond))
+}
+
+// checkPatchFields returns models.ErrInvalidInput if updates holds a key that
+// is not one of the given patchable fields.
+func checkPatchFields(updates map[string]interface{}, fields map[string]models.PatchField) error {
+    for key := range updates {
+        if _, ok := fields[key]; !ok {
+            return fmt.Errorf("%w: field %q cannot be updated", models.ErrInvalidInput, key)
+        }
+    }
+    return nil
+}
+
+// canManage reports whether userID, whose role is given, may delete and
+// restore the given post: its author and admins may.
+func canManage(post *models.Post, userID, role string) bool {
+    return post.AuthorID.Hex() == userID || role == models.RoleAdmin
+}
+
+// getEditable loads the post with the given ID and checks that userID is its
+// author or one of its co-authors.
+func (s *PostService) getEditable(postID, userID string) (*models.Post, error) {
+    post, err := s.repo.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+
+    if !post.IsAuthor(userID) {
+        return nil, models.ErrForbidden
+    }
+
+    return post, nil
+}
+
+// getOwned loads the post with the given ID and checks that userID is its
+// author.
+func (s *PostService) getOwned(postID, userID string) (*models.Post, error) {
+    post, err := s.repo.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+
+    if post.AuthorID.Hex() != userID {
+        return nil, models.ErrForbidden
+    }
+
+    return post, nil
+}
+
+// setStatus persists the status and published_at of the given post. The
+// write is conditional on the version the post was read at, so a concurrent
+// change is reported as models.ErrVersionConflict rather than overwritten.
+func (s *PostService) setStatus(post *models.Post, now time.Time) error {
+    post.UpdatedAt = now
+    err := s.repo.Update(post.ID.Hex(), &post.Version, map[string]interface{}{
+        "status":       post.Status,
+        "published_at": post.PublishedAt,
+        "updated_at":   post.UpdatedAt,
+    })
+    if err != nil {
+        return err
+    }
+    post.Version++
+
+    s.notifySaved(post)
+    return nil
+}
+
+// recordRevision records the current state of the given post as a new
+// revision made by userID. The write to the post has already happened, so a
+// failure is logged rather than reported.
+func (s *PostService) recordRevision(post *models.Post, userID primitive.ObjectID) {
+    if err := s.revisions.Create(newRevision(post, userID)); err != nil {
+        log.Printf("Cannot record revision of post %s: %v", post.ID.Hex(), err)
+    }
+}
+
+// newRevision returns a snapshot of the editable fields of the given post,
+// made by userID.
+func newRevision(post *models.Post, userID primitive.ObjectID) *models.PostRevision {
+    return &models.PostRevision{
+        PostID:        post.ID,
+        AuthorID:      userID,
+        Title:         post.Title,
+        Content:       post.Content,
+        ContentFormat: post.ContentFormat,
+        ImageURL:      post.ImageURL,
+        Tags:          post.Tags,
+        CategoryID:    post.CategoryID,
+        CreatedAt:     time.Now(),
+    }
+}
+
+// notifySaved tells every listener that the given post was created or
+// updated.
+func (s *PostService) notifySaved(post *models.Post) {
+    for _, listener := range s.listeners {
+        listener.PostSaved(post)
+    }
+}
+
+// notifyDeleted tells every listener that the given post was deleted.
+func (s *PostService) notifyDeleted(post *models.Post) {
+    for _, listener := range s.listeners {
+        listener.PostDeleted(post)
+    }
 }
\ No newline at end of file
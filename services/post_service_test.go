@@ -0,0 +1,303 @@
+package services
+
+import (
+    "errors"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "testing"
+    "time"
+)
+
+func TestPostServiceCreateStatus(t *testing.T) {
+    future := time.Now().Add(time.Hour)
+    past := time.Now().Add(-time.Hour)
+
+    tests := []struct {
+        name          string
+        status        string
+        publishedAt   *time.Time
+        wantStatus    string
+        wantPublished bool
+        wantErr       error
+    }{
+        {name: "default draft", wantStatus: models.PostStatusDraft},
+        {name: "draft", status: models.PostStatusDraft, publishedAt: &past, wantStatus: models.PostStatusDraft},
+        {name: "published", status: models.PostStatusPublished, wantStatus: models.PostStatusPublished, wantPublished: true},
+        {name: "scheduled", status: models.PostStatusScheduled, publishedAt: &future, wantStatus: models.PostStatusScheduled, wantPublished: true},
+        {name: "scheduled in the past", status: models.PostStatusScheduled, publishedAt: &past, wantErr: models.ErrInvalidInput},
+        {name: "scheduled without time", status: models.PostStatusScheduled, wantErr: models.ErrInvalidInput},
+        {name: "unknown status", status: "hidden", wantErr: models.ErrInvalidInput},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f := newPostFixture(t)
+            post := &models.Post{Title: "Hello", Content: "Text", Status: tt.status, PublishedAt: tt.publishedAt}
+
+            err := f.service.Create(post, "")
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Create() error = %v", err)
+            }
+
+            stored := f.posts.find(post.ID.Hex())
+            if stored.Status != tt.wantStatus {
+                t.Errorf("status = %q, want %q", stored.Status, tt.wantStatus)
+            }
+            if (stored.PublishedAt != nil) != tt.wantPublished {
+                t.Errorf("published_at = %v, want set %v", stored.PublishedAt, tt.wantPublished)
+            }
+            if stored.Version != 1 {
+                t.Errorf("version = %d, want 1", stored.Version)
+            }
+        })
+    }
+}
+
+func TestPostServiceStatusTransitions(t *testing.T) {
+    future := time.Now().Add(time.Hour)
+    past := time.Now().Add(-time.Hour)
+
+    publish := func(publishAt *time.Time) func(*PostService, string, string) (*models.Post, error) {
+        return func(s *PostService, postID, userID string) (*models.Post, error) {
+            return s.Publish(postID, userID, publishAt)
+        }
+    }
+    unpublish := func(s *PostService, postID, userID string) (*models.Post, error) {
+        return s.Unpublish(postID, userID)
+    }
+    archive := func(s *PostService, postID, userID string) (*models.Post, error) {
+        return s.Archive(postID, userID)
+    }
+
+    tests := []struct {
+        name   string
+        from   string
+        action func(*PostService, string, string) (*models.Post, error)
+        want   string
+        // wantPublishedAt is nil for no publish time, "now" for about the
+        // current time, and "kept" for the publish time the post had.
+        wantPublishedAt string
+    }{
+        {"publish draft now", models.PostStatusDraft, publish(nil), models.PostStatusPublished, "now"},
+        {"publish draft in the past", models.PostStatusDraft, publish(&past), models.PostStatusPublished, "now"},
+        {"schedule draft", models.PostStatusDraft, publish(&future), models.PostStatusScheduled, "future"},
+        {"publish scheduled now", models.PostStatusScheduled, publish(nil), models.PostStatusPublished, "now"},
+        {"republish archived", models.PostStatusArchived, publish(nil), models.PostStatusPublished, "now"},
+        {"unpublish published", models.PostStatusPublished, unpublish, models.PostStatusDraft, ""},
+        {"cancel schedule", models.PostStatusScheduled, unpublish, models.PostStatusDraft, ""},
+        {"archive published", models.PostStatusPublished, archive, models.PostStatusArchived, "kept"},
+        {"archive draft", models.PostStatusDraft, archive, models.PostStatusArchived, ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f := newPostFixture(t)
+            post := &models.Post{Title: "Hello", Content: "Text", Status: tt.from}
+            if tt.from == models.PostStatusScheduled {
+                later := time.Now().Add(2 * time.Hour)
+                post.PublishedAt = &later
+            }
+            if tt.from == models.PostStatusArchived {
+                post.Status = models.PostStatusPublished
+            }
+            f.create(t, post, "")
+            if tt.from == models.PostStatusArchived {
+                f.posts.set(post.ID, map[string]interface{}{"status": models.PostStatusArchived})
+            }
+            before := f.posts.find(post.ID.Hex())
+            saved := len(f.listener.saved)
+
+            got, err := tt.action(f.service, post.ID.Hex(), post.AuthorID.Hex())
+            if err != nil {
+                t.Fatalf("transition error = %v", err)
+            }
+
+            stored := f.posts.find(post.ID.Hex())
+            if got.Status != tt.want || stored.Status != tt.want {
+                t.Errorf("status = %q, stored %q, want %q", got.Status, stored.Status, tt.want)
+            }
+            if stored.Version != before.Version+1 || got.Version != stored.Version {
+                t.Errorf("version = %d, stored %d, want %d", got.Version, stored.Version, before.Version+1)
+            }
+            if len(f.listener.saved) != saved+1 {
+                t.Errorf("listeners told %d times, want once", len(f.listener.saved)-saved)
+            }
+
+            switch tt.wantPublishedAt {
+            case "":
+                if stored.PublishedAt != nil {
+                    t.Errorf("published_at = %v, want none", stored.PublishedAt)
+                }
+            case "now":
+                if stored.PublishedAt == nil || time.Since(*stored.PublishedAt) > time.Minute {
+                    t.Errorf("published_at = %v, want now", stored.PublishedAt)
+                }
+            case "future":
+                if stored.PublishedAt == nil || !stored.PublishedAt.Equal(future.Truncate(time.Millisecond)) {
+                    t.Errorf("published_at = %v, want %v", stored.PublishedAt, future)
+                }
+            case "kept":
+                if stored.PublishedAt == nil || !stored.PublishedAt.Equal(*before.PublishedAt) {
+                    t.Errorf("published_at = %v, want %v", stored.PublishedAt, before.PublishedAt)
+                }
+            }
+        })
+    }
+}
+
+func TestPostServiceTransitionsNeedAuthor(t *testing.T) {
+    f := newPostFixture(t)
+    coAuthor := primitive.NewObjectID()
+    post := f.create(t, &models.Post{}, "")
+    f.posts.set(post.ID, map[string]interface{}{"co_authors": []interface{}{coAuthor}})
+
+    for _, userID := range []string{coAuthor.Hex(), primitive.NewObjectID().Hex(), ""} {
+        if _, err := f.service.Publish(post.ID.Hex(), userID, nil); !errors.Is(err, models.ErrForbidden) {
+            t.Errorf("Publish() by %q error = %v, want %v", userID, err, models.ErrForbidden)
+        }
+        if _, err := f.service.Unpublish(post.ID.Hex(), userID); !errors.Is(err, models.ErrForbidden) {
+            t.Errorf("Unpublish() by %q error = %v, want %v", userID, err, models.ErrForbidden)
+        }
+        if _, err := f.service.Archive(post.ID.Hex(), userID); !errors.Is(err, models.ErrForbidden) {
+            t.Errorf("Archive() by %q error = %v, want %v", userID, err, models.ErrForbidden)
+        }
+    }
+
+    if got := f.posts.find(post.ID.Hex()).Status; got != models.PostStatusDraft {
+        t.Errorf("status = %q, want %q", got, models.PostStatusDraft)
+    }
+}
+
+func TestPostServicePublishScheduled(t *testing.T) {
+    f := newPostFixture(t)
+
+    due := f.create(t, &models.Post{Status: models.PostStatusScheduled, PublishedAt: timeIn(time.Hour)}, "")
+    alsoDue := f.create(t, &models.Post{Status: models.PostStatusScheduled, PublishedAt: timeIn(time.Hour)}, "")
+    notDue := f.create(t, &models.Post{Status: models.PostStatusScheduled, PublishedAt: timeIn(time.Hour)}, "")
+    draft := f.create(t, &models.Post{}, "")
+    deleted := f.create(t, &models.Post{Status: models.PostStatusScheduled, PublishedAt: timeIn(time.Hour)}, "")
+
+    // Scheduled posts cannot be created in the past, so their publish time
+    // passes by moving it back.
+    for _, post := range []*models.Post{due, alsoDue, deleted} {
+        f.posts.set(post.ID, map[string]interface{}{"published_at": time.Now().Add(-time.Minute)})
+    }
+    if err := f.service.Delete(deleted.ID.Hex(), deleted.AuthorID.Hex(), models.RoleUser, nil); err != nil {
+        t.Fatal(err)
+    }
+    saved := len(f.listener.saved)
+
+    published, err := f.service.PublishScheduled()
+    if err != nil {
+        t.Fatalf("PublishScheduled() error = %v", err)
+    }
+    if published != 2 {
+        t.Errorf("PublishScheduled() = %d, want 2", published)
+    }
+    if len(f.listener.saved) != saved+2 {
+        t.Errorf("listeners told %d times, want 2", len(f.listener.saved)-saved)
+    }
+
+    wantStatus := map[*models.Post]string{
+        due:     models.PostStatusPublished,
+        alsoDue: models.PostStatusPublished,
+        notDue:  models.PostStatusScheduled,
+        draft:   models.PostStatusDraft,
+        deleted: models.PostStatusScheduled,
+    }
+    for post, want := range wantStatus {
+        if got := f.posts.find(post.ID.Hex()).Status; got != want {
+            t.Errorf("post %s status = %q, want %q", post.ID.Hex(), got, want)
+        }
+    }
+
+    // Nothing is left to claim
+    published, err = f.service.PublishScheduled()
+    if err != nil || published != 0 {
+        t.Errorf("PublishScheduled() again = %d, %v, want 0, nil", published, err)
+    }
+}
+
+func timeIn(d time.Duration) *time.Time {
+    at := time.Now().Add(d)
+    return &at
+}
+
+func TestPostServiceVersionConflicts(t *testing.T) {
+    tests := []struct {
+        name    string
+        version func(current int64) *int64
+        wantErr error
+    }{
+        {"unconditional", func(int64) *int64 { return nil }, nil},
+        {"current version", func(current int64) *int64 { return &current }, nil},
+        {"stale version", func(current int64) *int64 { stale := current - 1; return &stale }, models.ErrVersionConflict},
+        {"future version", func(current int64) *int64 { future := current + 1; return &future }, models.ErrVersionConflict},
+    }
+
+    for _, tt := range tests {
+        t.Run("update "+tt.name, func(t *testing.T) {
+            f := newPostFixture(t)
+            post := f.create(t, &models.Post{Title: "Before"}, "")
+            revisions := len(f.revisions.revisions)
+
+            updated, err := f.service.Update(post.ID.Hex(), post.AuthorID.Hex(), tt.version(post.Version), map[string]interface{}{"title": "After"})
+            if !errors.Is(err, tt.wantErr) {
+                t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+            }
+
+            stored := f.posts.find(post.ID.Hex())
+            if tt.wantErr != nil {
+                if stored.Title != "Before" || stored.Version != post.Version {
+                    t.Errorf("stored post = %q at version %d, want it untouched", stored.Title, stored.Version)
+                }
+                if len(f.revisions.revisions) != revisions {
+                    t.Errorf("recorded %d revisions, want none", len(f.revisions.revisions)-revisions)
+                }
+                return
+            }
+            if stored.Title != "After" || stored.Version != post.Version+1 || updated.Version != stored.Version {
+                t.Errorf("stored post = %q at version %d, returned version %d, want %q at version %d", stored.Title, stored.Version, updated.Version, "After", post.Version+1)
+            }
+        })
+
+        t.Run("delete "+tt.name, func(t *testing.T) {
+            f := newPostFixture(t)
+            post := f.create(t, &models.Post{}, "")
+
+            err := f.service.Delete(post.ID.Hex(), post.AuthorID.Hex(), models.RoleUser, tt.version(post.Version))
+            if !errors.Is(err, tt.wantErr) {
+                t.Fatalf("Delete() error = %v, want %v", err, tt.wantErr)
+            }
+            if deleted := f.posts.find(post.ID.Hex()).DeletedAt != nil; deleted != (tt.wantErr == nil) {
+                t.Errorf("deleted = %v, want %v", deleted, tt.wantErr == nil)
+            }
+        })
+    }
+}
+
+func TestPostServiceStatusChangeConflicts(t *testing.T) {
+    f := newPostFixture(t)
+    post := f.create(t, &models.Post{}, "")
+
+    // An editor saves between the read and the write of the status change
+    f.posts.getHook = func(p *models.Post) {
+        f.posts.getHook = nil
+        if _, err := f.service.Update(post.ID.Hex(), post.AuthorID.Hex(), nil, map[string]interface{}{"title": "Edited"}); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    if _, err := f.service.Publish(post.ID.Hex(), post.AuthorID.Hex(), nil); !errors.Is(err, models.ErrVersionConflict) {
+        t.Fatalf("Publish() error = %v, want %v", err, models.ErrVersionConflict)
+    }
+    if got := f.posts.find(post.ID.Hex()); got.Status != models.PostStatusDraft || got.Title != "Edited" {
+        t.Errorf("stored post = %q, %q, want the edit kept and the post unpublished", got.Title, got.Status)
+    }
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+    "fmt"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "time"
+)
+
+// SetTranslation creates or replaces the variant of the post with the given
+// ID in the language of the given variant, on behalf of userID, who must be
+// the post's author or one of its co-authors. Only the language, title,
+// slug, content, content format and excerpt of the variant are used.
+//
+// The variant's content is rendered and summarized as for posts; its format
+// defaults to the post's. Its slug is reserved as post slugs are: the
+// requested one, the one the variant already has, or one generated from its
+// title. The post's version is incremented, and a non-nil version makes the
+// change conditional, as for Update. The returned post is localized to the
+// variant.
+//
+// The returned error will be models.ErrInvalidInput for a malformed language
+// or an invalid field, and models.ErrConflict if the post itself is written
+// in that language or the slug is taken.
+func (s *PostService) SetTranslation(postID, userID string, version *int64, variant *models.PostVariant) (*models.Post, error) {
+    language, err := normalizeLanguage(variant.Language)
+    if err != nil {
+        return nil, err
+    }
+    if language == "" || variant.Title == "" || variant.Content == "" {
+        return nil, fmt.Errorf("%w: translations need a language, a title and content", models.ErrInvalidInput)
+    }
+
+    post, err := s.getEditable(postID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if version != nil && *version != post.Version {
+        return nil, models.ErrVersionConflict
+    }
+    if language == post.Language {
+        return nil, fmt.Errorf("%w: the post itself is written in %s", models.ErrConflict, language)
+    }
+
+    // The variant is rendered and summarized as a post of its own.
+    draft := &models.Post{
+        Title:         variant.Title,
+        Content:       variant.Content,
+        ContentFormat: variant.ContentFormat,
+    }
+    if draft.ContentFormat == "" {
+        draft.ContentFormat = post.ContentFormat
+    }
+    if err := setExcerpt(draft, variant.Excerpt); err != nil {
+        return nil, err
+    }
+    if err := s.renderContent(draft); err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    existing := post.Variant(language)
+    slug := variant.Slug
+    if slug == "" && existing != nil {
+        slug = existing.Slug
+    }
+    slug, reserved, err := s.reserveSlug(post.ID, draft.Title, slug, languageSlugs(post, language))
+    if err != nil {
+        return nil, err
+    }
+
+    translated := &models.PostVariant{
+        Language:      language,
+        Title:         draft.Title,
+        Slug:          slug,
+        Content:       draft.Content,
+        ContentFormat: draft.ContentFormat,
+        ContentHTML:   draft.ContentHTML,
+        Excerpt:       draft.Excerpt,
+        CustomExcerpt: draft.CustomExcerpt,
+        WordCount:     draft.WordCount,
+        ReadingTime:   draft.ReadingTime,
+        TOC:           draft.TOC,
+        CreatedAt:     now,
+        UpdatedAt:     now,
+    }
+
+    variants := make([]*models.PostVariant, 0, len(post.Variants)+1)
+    for _, other := range post.Variants {
+        if other.Language == language {
+            translated.CreatedAt = other.CreatedAt
+            continue
+        }
+        variants = append(variants, other)
+    }
+    variants = append(variants, translated)
+
+    updated, err := s.setVariants(post, version, variants, language, now)
+    if err != nil && reserved {
+        // As for Update, a slug new to the post must not stay taken by it
+        s.slugs.Release(slug, post.ID)
+    }
+    return updated, err
+}
+
+// DeleteTranslation removes the variant of the post with the given ID in the
+// given language, on behalf of userID, who must be the post's author or one
+// of its co-authors. The slug of the variant keeps pointing to the post, so
+// old links are redirected to it. As for Update, a non-nil version makes the
+// change conditional.
+//
+// The returned error will be models.ErrNotFound if the post has no variant
+// in that language.
+func (s *PostService) DeleteTranslation(postID, userID, language string, version *int64) (*models.Post, error) {
+    language, err := normalizeLanguage(language)
+    if err != nil {
+        return nil, err
+    }
+
+    post, err := s.getEditable(postID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if version != nil && *version != post.Version {
+        return nil, models.ErrVersionConflict
+    }
+    if post.Variant(language) == nil {
+        return nil, fmt.Errorf("%w: the post has no %s translation", models.ErrNotFound, language)
+    }
+
+    variants := make([]*models.PostVariant, 0, len(post.Variants))
+    for _, other := range post.Variants {
+        if other.Language != language {
+            variants = append(variants, other)
+        }
+    }
+
+    return s.setVariants(post, version, variants, "", time.Now())
+}
+
+// Sitemap returns the sitemap entries of a page of published public posts:
+// one for every language a post can be read in, each listing the hreflang
+// alternates of the post. Posts are sorted and paged as for List.
+//
+// The returned error will be non-nil if any error occurred during the find
+// process.
+func (s *PostService) Sitemap(req models.PageRequest) (*models.SitemapPage, error) {
+    page, err := s.repo.List(models.PostFilter{Statuses: []string{models.PostStatusPublished}, Visibilities: []string{models.VisibilityPublic}}, req)
+    if err != nil {
+        return nil, err
+    }
+
+    entries := []*models.SitemapEntry{}
+    for _, post := range page.Posts {
+        entries = append(entries, post.SitemapEntries()...)
+    }
+    return &models.SitemapPage{Entries: entries, Pagination: page.Pagination}, nil
+}
+
+// setVariants stores the given variants of the post, at the version it was
+// read at unless version is set, and returns the updated post localized to
+// the given language.
+func (s *PostService) setVariants(post *models.Post, version *int64, variants []*models.PostVariant, language string, now time.Time) (*models.Post, error) {
+    if version == nil {
+        version = &post.Version
+    }
+
+    err := s.repo.Update(post.ID.Hex(), version, map[string]interface{}{
+        "variants":   variants,
+        "updated_at": now,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    updated, err := s.repo.GetByID(post.ID.Hex())
+    if err != nil {
+        return nil, err
+    }
+    s.notifySaved(updated)
+
+    localized := *updated
+    localized.Localize(language)
+    return &localized, attachAuthors(s.users, &localized)
+}
+
+// languageSlugs returns the slugs of the post and its variants, except for
+// the variant in the given language, which other languages may not use.
+func languageSlugs(post *models.Post, except string) map[string]bool {
+    slugs := map[string]bool{}
+    if post.Slug != "" {
+        slugs[post.Slug] = true
+    }
+    for _, variant := range post.Variants {
+        if variant.Language != except {
+            slugs[variant.Slug] = true
+        }
+    }
+    return slugs
+}
+
+// localize turns the given post into its variant in the first of the given
+// languages, most preferred first, that it can be read in. The post stays in
+// its own language if it suits the reader best, or if none does.
+func localize(post *models.Post, languages []string) {
+    post.Localize(utils.MatchLanguage(languages, post.Languages()))
+}
+
+// normalizeLanguage returns the canonical form of the given language tag, or
+// an empty string for an empty tag.
+//
+// The returned error will be models.ErrInvalidInput if the tag is malformed.
+func normalizeLanguage(tag string) (string, error) {
+    if tag == "" {
+        return "", nil
+    }
+    language, err := utils.NormalizeLanguage(tag)
+    if err != nil {
+        return "", fmt.Errorf("%w: invalid language %q", models.ErrInvalidInput, tag)
+    }
+    return language, nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "go-blog-backend/models"
+    "time"
+
+    "github.com/golang-jwt/jwt/v4"
+    "golang.org/x/crypto/bcrypt"
+)
+
+// PostAccess decides who may read a post. It is implemented by PostService,
+// so that the services acting on posts on behalf of readers, such as
+// comments and reactions, follow the visibility rules of PostService.Get.
+type PostAccess interface {
+    CanRead(post *models.Post, viewer models.Viewer) error
+}
+
+// visibilities lists the visibilities a post may have.
+var visibilities = map[string]bool{
+    models.VisibilityPublic:   true,
+    models.VisibilityUnlisted: true,
+    models.VisibilityPrivate:  true,
+    models.VisibilityPassword: true,
+}
+
+// Unlock exchanges the password of the published password-protected post
+// with the given ID for an access token, to be sent along when reading the
+// post. The token is valid for the post only, until it expires or the
+// password of the post changes.
+//
+// The returned error will be models.ErrNotFound if no published post exists
+// with that ID, models.ErrInvalidInput if the post is not protected by a
+// password, and models.ErrForbidden if the password is wrong.
+func (s *PostService) Unlock(postID, password string) (*models.PostAccessToken, error) {
+    post, err := s.repo.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+    if post.Status != models.PostStatusPublished {
+        return nil, models.ErrNotFound
+    }
+    if post.Visibility != models.VisibilityPassword {
+        if post.Visibility == models.VisibilityPrivate {
+            return nil, models.ErrNotFound
+        }
+        return nil, fmt.Errorf("%w: the post is not protected by a password", models.ErrInvalidInput)
+    }
+
+    if err := bcrypt.CompareHashAndPassword([]byte(post.PasswordHash), []byte(password)); err != nil {
+        return nil, fmt.Errorf("%w: wrong password", models.ErrForbidden)
+    }
+
+    expiresAt := time.Now().Add(s.accessTTL)
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+        "post_id":  post.ID.Hex(),
+        "password": passwordFingerprint(post),
+        "exp":      expiresAt.Unix(),
+    })
+    signed, err := token.SignedString(s.accessKey)
+    if err != nil {
+        return nil, err
+    }
+
+    return &models.PostAccessToken{Token: signed, ExpiresAt: expiresAt}, nil
+}
+
+// CanRead checks that the given viewer may read the given post. Authors and
+// co-authors may read their posts in any status. Other viewers may read
+// published posts that are public or unlisted; private ones if they are
+// editors or admins; and password-protected ones if they are editors or
+// admins, or hold an access token for the post.
+//
+// The returned error will be models.ErrPasswordRequired for a
+// password-protected post the viewer holds no valid token for, and
+// models.ErrNotFound for any other post the viewer may not read, so that its
+// existence is not revealed.
+func (s *PostService) CanRead(post *models.Post, viewer models.Viewer) error {
+    if post.IsPublic() || post.IsAuthor(viewer.UserID) {
+        return nil
+    }
+    if !post.IsPublished() {
+        return models.ErrNotFound
+    }
+
+    switch post.Visibility {
+    case models.VisibilityPrivate:
+        if viewer.IsEditor() {
+            return nil
+        }
+    case models.VisibilityPassword:
+        if viewer.IsEditor() || s.checkAccessToken(post, viewer.AccessToken) {
+            return nil
+        }
+        return fmt.Errorf("%w: the post is protected by a password", models.ErrPasswordRequired)
+    }
+    return models.ErrNotFound
+}
+
+// checkAccessToken reports whether the given token, from Unlock, is valid for
+// the given post.
+func (s *PostService) checkAccessToken(post *models.Post, token string) bool {
+    if token == "" {
+        return false
+    }
+
+    parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+        if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+            return nil, errors.New("unexpected signing method")
+        }
+        return s.accessKey, nil
+    })
+    if err != nil || !parsed.Valid {
+        return false
+    }
+
+    claims, ok := parsed.Claims.(jwt.MapClaims)
+    return ok && claims["post_id"] == post.ID.Hex() && claims["password"] == passwordFingerprint(post)
+}
+
+// setVisibility validates the given visibility of the post, which defaults
+// to public, and the password of a password-protected post, which is stored
+// hashed. An empty password keeps the post's current one. Leaving the
+// password visibility drops the password.
+//
+// The returned error will be models.ErrInvalidInput for an unknown
+// visibility, a password-protected post without a password or a password
+// given for another visibility.
+func setVisibility(post *models.Post, visibility, password string) error {
+    if visibility == "" {
+        visibility = models.VisibilityPublic
+    }
+    if !visibilities[visibility] {
+        return fmt.Errorf("%w: unknown visibility %q", models.ErrInvalidInput, visibility)
+    }
+
+    if visibility != models.VisibilityPassword {
+        if password != "" {
+            return fmt.Errorf("%w: only password-protected posts have a password", models.ErrInvalidInput)
+        }
+        post.Visibility = visibility
+        post.PasswordHash = ""
+        return nil
+    }
+
+    if password != "" {
+        hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+        if err != nil {
+            return err
+        }
+        post.PasswordHash = string(hash)
+    }
+    if post.PasswordHash == "" {
+        return fmt.Errorf("%w: password-protected posts need a password", models.ErrInvalidInput)
+    }
+    post.Visibility = visibility
+    return nil
+}
+
+// passwordFingerprint identifies the current password of the given post in
+// its access tokens, without revealing its hash, so that changing the
+// password revokes the tokens given for the previous one.
+func passwordFingerprint(post *models.Post) string {
+    sum := sha256.Sum256([]byte(post.PasswordHash))
+    return hex.EncodeToString(sum[:8])
+}
+
+// accessKey derives the key signing the access tokens of password-protected
+// posts from the JWT secret, so that they cannot be used as login tokens.
+func accessKey(secret string) []byte {
+    sum := sha256.Sum256([]byte("post-access:" + secret))
+    return sum[:]
+}
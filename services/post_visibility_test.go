@@ -0,0 +1,330 @@
+package services
+
+import (
+    "errors"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "sort"
+    "strings"
+    "testing"
+)
+
+// fakeReactionRepo is an in-memory ReactionRepository.
+type fakeReactionRepo struct {
+    types map[[2]primitive.ObjectID]string
+}
+
+func (r *fakeReactionRepo) Set(postID, userID primitive.ObjectID, reactionType string) (string, error) {
+    key := [2]primitive.ObjectID{postID, userID}
+    previous := r.types[key]
+    r.types[key] = reactionType
+    return previous, nil
+}
+
+func (r *fakeReactionRepo) Delete(postID, userID primitive.ObjectID, reactionType string) error {
+    key := [2]primitive.ObjectID{postID, userID}
+    if r.types[key] != reactionType {
+        return models.ErrNotFound
+    }
+    delete(r.types, key)
+    return nil
+}
+
+func (r *fakeReactionRepo) GetType(postID, userID primitive.ObjectID) (string, error) {
+    return r.types[[2]primitive.ObjectID{postID, userID}], nil
+}
+
+func (r *fakeReactionRepo) CountAll() (map[primitive.ObjectID]map[string]int64, error) {
+    counts := map[primitive.ObjectID]map[string]int64{}
+    for key, reactionType := range r.types {
+        if counts[key[0]] == nil {
+            counts[key[0]] = map[string]int64{}
+        }
+        counts[key[0]][reactionType]++
+    }
+    return counts, nil
+}
+
+func (r *fakeReactionRepo) CountByPost(postID primitive.ObjectID) (map[string]int64, error) {
+    counts, _ := r.CountAll()
+    return counts[postID], nil
+}
+
+// fakeSearchIndex matches every post it was given, in order.
+type fakeSearchIndex struct {
+    hits []*models.SearchHit
+}
+
+func (i *fakeSearchIndex) Index(post *models.Post) error {
+    return nil
+}
+
+func (i *fakeSearchIndex) Remove(postID primitive.ObjectID) error {
+    return nil
+}
+
+func (i *fakeSearchIndex) Search(query models.SearchQuery) ([]*models.SearchHit, int64, error) {
+    return i.hits, int64(len(i.hits)), nil
+}
+
+// visibilityFixture holds a post of every visibility, and a draft, by the
+// same author, and the services reading them on behalf of viewers.
+type visibilityFixture struct {
+    *postFixture
+    posts     map[string]*models.Post // By title
+    viewers   map[string]models.Viewer
+    comments  *CommentService
+    reactions *ReactionService
+    bookmarks *BookmarkService
+    related   *RelatedService
+    search    *SearchService
+}
+
+func newVisibilityFixture(t *testing.T) *visibilityFixture {
+    t.Helper()
+
+    f := &visibilityFixture{postFixture: newPostFixture(t), posts: map[string]*models.Post{}}
+    authorID := primitive.NewObjectID()
+    index := &fakeSearchIndex{}
+    for _, post := range []*models.Post{
+        {Title: "public", Visibility: models.VisibilityPublic},
+        {Title: "other public", Visibility: models.VisibilityPublic},
+        {Title: "unlisted", Visibility: models.VisibilityUnlisted},
+        {Title: "private", Visibility: models.VisibilityPrivate},
+        {Title: "password", Visibility: models.VisibilityPassword},
+        {Title: "draft", Visibility: models.VisibilityPublic, Status: models.PostStatusDraft},
+    } {
+        if post.Status == "" {
+            post.Status = models.PostStatusPublished
+        }
+        post.AuthorID = authorID
+        post.Content = "Goroutines and channels make concurrency simple."
+        post.Tags = []string{"golang"}
+        password := ""
+        if post.Visibility == models.VisibilityPassword {
+            password = "secret"
+        }
+        f.posts[post.Title] = f.create(t, post, password)
+        index.hits = append(index.hits, &models.SearchHit{PostID: post.ID, Score: 1})
+    }
+
+    token, err := f.service.Unlock(f.posts["password"].ID.Hex(), "secret")
+    if err != nil {
+        t.Fatalf("Unlock() error = %v", err)
+    }
+    f.viewers = map[string]models.Viewer{
+        "anonymous":    {},
+        "reader":       {UserID: primitive.NewObjectID().Hex(), Role: models.RoleUser},
+        "token holder": {UserID: primitive.NewObjectID().Hex(), Role: models.RoleUser, AccessToken: token.Token},
+        "author":       {UserID: authorID.Hex(), Role: models.RoleUser},
+        "editor":       {UserID: primitive.NewObjectID().Hex(), Role: models.RoleEditor},
+        "admin":        {UserID: primitive.NewObjectID().Hex(), Role: models.RoleAdmin},
+    }
+
+    f.comments = NewCommentService(newFakeCommentRepo(), f.postFixture.posts, f.service, utils.NewContentRenderer(), CommentModeration{})
+    f.reactions = NewReactionService(&fakeReactionRepo{types: map[[2]primitive.ObjectID]string{}}, f.postFixture.posts, f.service)
+    f.bookmarks = NewBookmarkService(&fakeBookmarkRepo{}, f.postFixture.posts, f.service)
+    f.related = NewRelatedService(&fakeRelatedRepo{related: map[primitive.ObjectID]*models.RelatedPosts{}}, f.postFixture.posts, f.service)
+    f.search = NewSearchService(index, f.postFixture.posts)
+    return f
+}
+
+// titles returns the sorted titles of the given posts.
+func titles(posts []*models.Post) string {
+    var names []string
+    for _, post := range posts {
+        names = append(names, post.Title)
+    }
+    sort.Strings(names)
+    return strings.Join(names, ",")
+}
+
+// errName names the visibility error err is, for comparing outcomes.
+func errName(err error) string {
+    switch {
+    case err == nil:
+        return "ok"
+    case errors.Is(err, models.ErrNotFound):
+        return "not found"
+    case errors.Is(err, models.ErrPasswordRequired):
+        return "password required"
+    case errors.Is(err, models.ErrForbidden):
+        return "forbidden"
+    default:
+        return err.Error()
+    }
+}
+
+// TestPostVisibilityRead checks who may read each post, through every
+// service reading a single post on behalf of a viewer.
+func TestPostVisibilityRead(t *testing.T) {
+    f := newVisibilityFixture(t)
+
+    const (
+        ok       = "ok"
+        notFound = "not found"
+        password = "password required"
+    )
+    // want gives the outcome of reading each post for each viewer.
+    want := map[string]map[string]string{
+        "public":   {"anonymous": ok, "reader": ok, "token holder": ok, "author": ok, "editor": ok, "admin": ok},
+        "unlisted": {"anonymous": ok, "reader": ok, "token holder": ok, "author": ok, "editor": ok, "admin": ok},
+        "private":  {"anonymous": notFound, "reader": notFound, "token holder": notFound, "author": ok, "editor": ok, "admin": ok},
+        "password": {"anonymous": password, "reader": password, "token holder": ok, "author": ok, "editor": ok, "admin": ok},
+        "draft":    {"anonymous": notFound, "reader": notFound, "token holder": notFound, "author": ok, "editor": notFound, "admin": notFound},
+    }
+
+    for title, byViewer := range want {
+        post := f.posts[title]
+        for name, outcome := range byViewer {
+            viewer := f.viewers[name]
+            t.Run(title+" by "+name, func(t *testing.T) {
+                _, err := f.service.Get(post.ID.Hex(), viewer, nil)
+                if got := errName(err); got != outcome {
+                    t.Errorf("Get() = %s, want %s", got, outcome)
+                }
+                _, _, err = f.service.GetBySlug(post.Slug, viewer)
+                if got := errName(err); got != outcome {
+                    t.Errorf("GetBySlug() = %s, want %s", got, outcome)
+                }
+                _, err = f.comments.List(post.ID.Hex(), viewer, models.PageRequest{})
+                if got := errName(err); got != outcome {
+                    t.Errorf("comments List() = %s, want %s", got, outcome)
+                }
+                _, err = f.related.Related(post.ID.Hex(), viewer)
+                if got := errName(err); got != outcome {
+                    t.Errorf("Related() = %s, want %s", got, outcome)
+                }
+
+                // Reacting and bookmarking need a signed-in viewer, and only
+                // published posts can be reacted to.
+                signedIn, reactable := outcome, outcome
+                if viewer.UserID == "" {
+                    signedIn, reactable = "forbidden", "forbidden"
+                } else if post.Status != models.PostStatusPublished && outcome == ok {
+                    reactable = notFound
+                }
+                _, err = f.reactions.React(post.ID.Hex(), viewer, models.ReactionLike)
+                if got := errName(err); got != reactable {
+                    t.Errorf("React() = %s, want %s", got, reactable)
+                }
+                _, err = f.bookmarks.Bookmark(viewer, post.ID.Hex())
+                if got := errName(err); got != signedIn {
+                    t.Errorf("Bookmark() = %s, want %s", got, signedIn)
+                }
+            })
+        }
+    }
+}
+
+// TestPostVisibilityTokens checks that an access token only unlocks the
+// password-protected post it was given for, with its current password.
+func TestPostVisibilityTokens(t *testing.T) {
+    f := newVisibilityFixture(t)
+    holder := f.viewers["token holder"]
+
+    other := f.create(t, &models.Post{Title: "other password", Status: models.PostStatusPublished, Visibility: models.VisibilityPassword}, "secret")
+    if _, err := f.service.Get(other.ID.Hex(), holder, nil); !errors.Is(err, models.ErrPasswordRequired) {
+        t.Errorf("Get() of another password-protected post error = %v, want ErrPasswordRequired", err)
+    }
+
+    if _, err := f.service.Unlock(f.posts["password"].ID.Hex(), "wrong"); !errors.Is(err, models.ErrForbidden) {
+        t.Errorf("Unlock() with a wrong password error = %v, want ErrForbidden", err)
+    }
+    if _, err := f.service.Unlock(f.posts["private"].ID.Hex(), "secret"); !errors.Is(err, models.ErrNotFound) {
+        t.Errorf("Unlock() of a private post error = %v, want ErrNotFound", err)
+    }
+    if _, err := f.service.Unlock(f.posts["public"].ID.Hex(), "secret"); !errors.Is(err, models.ErrInvalidInput) {
+        t.Errorf("Unlock() of a public post error = %v, want ErrInvalidInput", err)
+    }
+
+    post := f.posts["password"]
+    if _, err := f.service.Update(post.ID.Hex(), post.AuthorID.Hex(), nil, map[string]interface{}{"password": "changed"}); err != nil {
+        t.Fatalf("Update() error = %v", err)
+    }
+    if _, err := f.service.Get(post.ID.Hex(), holder, nil); !errors.Is(err, models.ErrPasswordRequired) {
+        t.Errorf("Get() with a token for the previous password error = %v, want ErrPasswordRequired", err)
+    }
+}
+
+// TestPostVisibilityLists checks that only public posts are listed, searched
+// and related, whoever the viewer, and that bookmarks keep only the posts
+// their owner may read.
+func TestPostVisibilityLists(t *testing.T) {
+    f := newVisibilityFixture(t)
+    public := "other public,public"
+
+    page, err := f.service.List(models.PostFilter{}, models.PageRequest{}, nil)
+    if err != nil {
+        t.Fatalf("List() error = %v", err)
+    }
+    if got := titles(page.Posts); got != public {
+        t.Errorf("List() = %q, want %q", got, public)
+    }
+    for _, visibility := range []string{models.VisibilityUnlisted, models.VisibilityPrivate, models.VisibilityPassword} {
+        if _, err := f.service.List(models.PostFilter{Visibilities: []string{visibility}}, models.PageRequest{}, nil); !errors.Is(err, models.ErrInvalidInput) {
+            t.Errorf("List(%s) error = %v, want ErrInvalidInput", visibility, err)
+        }
+    }
+
+    results, _, err := f.search.Search(models.SearchQuery{Text: "goroutines"})
+    if err != nil {
+        t.Fatalf("Search() error = %v", err)
+    }
+    var found []*models.Post
+    for _, result := range results {
+        found = append(found, result.Post)
+    }
+    if got := titles(found); got != public {
+        t.Errorf("Search() = %q, want %q", got, public)
+    }
+
+    if _, err := f.related.RefreshAll(); err != nil {
+        t.Fatalf("RefreshAll() error = %v", err)
+    }
+    for _, title := range []string{"public", "unlisted", "private", "password", "draft"} {
+        related, err := f.related.Related(f.posts[title].ID.Hex(), f.viewers["author"])
+        if err != nil {
+            t.Fatalf("Related(%s) error = %v", title, err)
+        }
+        if len(related) == 0 {
+            t.Errorf("Related(%s) = none, want the public posts", title)
+        }
+        for _, r := range related {
+            if r.Post.Title == title || !r.Post.IsListed() {
+                t.Errorf("Related(%s) returned %q, want public posts other than itself", title, r.Post.Title)
+            }
+        }
+    }
+
+    for name, want := range map[string]string{
+        "reader":       "other public,public,unlisted",
+        "token holder": "other public,password,public,unlisted",
+        "author":       "draft,other public,password,private,public,unlisted",
+        "editor":       "other public,password,private,public,unlisted",
+    } {
+        viewer := f.viewers[name]
+        userID, _ := primitive.ObjectIDFromHex(viewer.UserID)
+        for _, post := range f.posts {
+            f.bookmarks.repo.Create(userID, post.ID)
+        }
+
+        page, err := f.bookmarks.List(viewer, models.PageRequest{})
+        if err != nil {
+            t.Fatalf("bookmarks List() by %s error = %v", name, err)
+        }
+        var posts []*models.Post
+        for _, bookmark := range page.Bookmarks {
+            if bookmark.Post != nil {
+                posts = append(posts, bookmark.Post)
+            }
+        }
+        if got := titles(posts); got != want {
+            t.Errorf("bookmarks List() by %s = %q, want %q", name, got, want)
+        }
+        if len(page.Bookmarks) != len(f.posts) {
+            t.Errorf("bookmarks List() by %s returned %d bookmarks, want %d", name, len(page.Bookmarks), len(f.posts))
+        }
+    }
+}
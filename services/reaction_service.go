@@ -0,0 +1,279 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReactionRepository interface {
+    Set(postID, userID primitive.ObjectID, reactionType string) (string, error)
+    Delete(postID, userID primitive.ObjectID, reactionType string) error
+    GetType(postID, userID primitive.ObjectID) (string, error)
+    CountAll() (map[primitive.ObjectID]map[string]int64, error)
+    CountByPost(postID primitive.ObjectID) (map[string]int64, error)
+}
+
+// ReactionPostRepository is the part of the post storage the reaction service
+// needs to check posts and keep their reaction counts.
+type ReactionPostRepository interface {
+    GetByID(id string) (*models.Post, error)
+    IncrementReactions(id primitive.ObjectID, deltas map[string]int64) (map[string]int64, error)
+    ListReactionCounts() (map[primitive.ObjectID]map[string]int64, error)
+    GetReactionCounts(id primitive.ObjectID) (map[string]int64, error)
+    SetReactions(id primitive.ObjectID, expected, counts map[string]int64, popularityDelta int64) (bool, error)
+}
+
+// ReactionService lets readers react to posts. Every post keeps the number of
+// reactions of each type, which also count towards its popularity.
+type ReactionService struct {
+    repo   ReactionRepository
+    posts  ReactionPostRepository
+    access PostAccess
+}
+
+// NewReactionService returns a new ReactionService instance, given a
+// ReactionRepository, the post storage and the PostAccess deciding who may
+// read a post.
+func NewReactionService(repo ReactionRepository, posts ReactionPostRepository, access PostAccess) *ReactionService {
+    return &ReactionService{
+        repo:   repo,
+        posts:  posts,
+        access: access,
+    }
+}
+
+// React sets the reaction of the given viewer to the post with the given ID,
+// replacing any other reaction of the viewer to the post, and returns the
+// post's updated reactions. Only published posts the viewer can read can be
+// reacted to.
+//
+// The returned error will be models.ErrInvalidInput for an unknown reaction
+// type.
+func (s *ReactionService) React(postID string, viewer models.Viewer, reactionType string) (*models.PostReactions, error) {
+    post, userObjectID, err := s.prepare(postID, viewer, reactionType)
+    if err != nil {
+        return nil, err
+    }
+
+    previous, err := s.repo.Set(post.ID, userObjectID, reactionType)
+    if err != nil {
+        return nil, err
+    }
+
+    deltas := map[string]int64{}
+    if previous != reactionType {
+        deltas[reactionType] = 1
+        if previous != "" {
+            deltas[previous] = -1
+        }
+    }
+
+    return s.update(post.ID, deltas, reactionType)
+}
+
+// Unreact removes the reaction of the given type of the given viewer to the
+// post with the given ID, and returns the post's updated reactions.
+//
+// The returned error will be models.ErrNotFound if the user has not reacted
+// to the post with that type, and models.ErrInvalidInput for an unknown
+// reaction type.
+func (s *ReactionService) Unreact(postID string, viewer models.Viewer, reactionType string) (*models.PostReactions, error) {
+    post, userObjectID, err := s.prepare(postID, viewer, reactionType)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.repo.Delete(post.ID, userObjectID, reactionType); err != nil {
+        return nil, err
+    }
+
+    return s.update(post.ID, map[string]int64{reactionType: -1}, "")
+}
+
+// Reacted returns, for every reaction type, whether userID reacted to the
+// post with the given ID with it. A user reacts with a single type at most.
+func (s *ReactionService) Reacted(postID primitive.ObjectID, userID string) (map[string]bool, error) {
+    userObjectID, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, models.ErrForbidden
+    }
+
+    reactionType, err := s.repo.GetType(postID, userObjectID)
+    if err != nil {
+        return nil, err
+    }
+
+    return reactedFlags(reactionType), nil
+}
+
+// Reconcile recounts the reactions to every post and repairs the counts, and
+// popularity, of the posts whose stored counts drifted, for instance because
+// the server stopped between storing a reaction and counting it. It returns
+// the number of posts repaired.
+//
+// Reactions keep coming in while Reconcile runs, so the drifts found in a
+// first pass over every post are only candidates, each checked again by
+// repair before it is fixed.
+func (s *ReactionService) Reconcile() (int64, error) {
+    actual, err := s.repo.CountAll()
+    if err != nil {
+        return 0, err
+    }
+
+    stored, err := s.posts.ListReactionCounts()
+    if err != nil {
+        return 0, err
+    }
+
+    postIDs := make(map[primitive.ObjectID]bool, len(actual)+len(stored))
+    for postID := range actual {
+        postIDs[postID] = true
+    }
+    for postID := range stored {
+        postIDs[postID] = true
+    }
+
+    var repaired int64
+    for postID := range postIDs {
+        if sameReactionCounts(actual[postID], stored[postID]) {
+            continue
+        }
+
+        ok, err := s.repair(postID, reactionDrift(actual[postID], stored[postID]))
+        if err != nil {
+            return repaired, err
+        }
+        if ok {
+            repaired++
+        }
+    }
+
+    return repaired, nil
+}
+
+// repair recounts the reactions to the post with the given ID and repairs
+// its stored counts if they still drift from the reactions by the given
+// drift, found by Reconcile. A reaction in flight, stored but not counted
+// yet, shows as a drift that changes or disappears in between, and is left
+// alone. The repair is conditional on the counts it was computed from, so
+// that a reaction counted in the meantime is neither lost nor counted twice;
+// posts left alone are checked again on the next run. It reports whether the
+// post was repaired.
+func (s *ReactionService) repair(postID primitive.ObjectID, drift map[string]int64) (bool, error) {
+    actual, err := s.repo.CountByPost(postID)
+    if err != nil {
+        return false, err
+    }
+    stored, err := s.posts.GetReactionCounts(postID)
+    if errors.Is(err, models.ErrNotFound) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+
+    if !sameReactionCounts(reactionDrift(actual, stored), drift) {
+        return false, nil
+    }
+
+    var delta int64
+    for _, count := range actual {
+        delta += count
+    }
+    for _, count := range stored {
+        delta -= count
+    }
+
+    return s.posts.SetReactions(postID, stored, actual, delta)
+}
+
+// prepare validates a reaction of the given viewer to the post with the given
+// ID.
+func (s *ReactionService) prepare(postID string, viewer models.Viewer, reactionType string) (*models.Post, primitive.ObjectID, error) {
+    if _, ok := models.ReactionEmoji[reactionType]; !ok {
+        return nil, primitive.NilObjectID, fmt.Errorf("%w: unknown reaction type %q", models.ErrInvalidInput, reactionType)
+    }
+
+    userObjectID, err := primitive.ObjectIDFromHex(viewer.UserID)
+    if err != nil {
+        return nil, primitive.NilObjectID, models.ErrForbidden
+    }
+
+    post, err := s.posts.GetByID(postID)
+    if err != nil {
+        return nil, primitive.NilObjectID, err
+    }
+    if err := s.access.CanRead(post, viewer); err != nil {
+        return nil, primitive.NilObjectID, err
+    }
+    if !post.IsPublished() {
+        return nil, primitive.NilObjectID, models.ErrNotFound
+    }
+
+    return post, userObjectID, nil
+}
+
+// update applies the given deltas to the reaction counts of the post with the
+// given ID, and returns its reactions as seen by a user who reacted with the
+// given type.
+func (s *ReactionService) update(postID primitive.ObjectID, deltas map[string]int64, reacted string) (*models.PostReactions, error) {
+    counts, err := s.posts.IncrementReactions(postID, deltas)
+    if err != nil {
+        return nil, err
+    }
+    if counts == nil {
+        counts = map[string]int64{}
+    }
+
+    return &models.PostReactions{
+        PostID:    postID,
+        Reactions: counts,
+        Reacted:   reactedFlags(reacted),
+    }, nil
+}
+
+// reactedFlags returns the reacted flags of a user who reacted with the given
+// type, or did not react if it is empty.
+func reactedFlags(reactionType string) map[string]bool {
+    flags := make(map[string]bool, len(models.ReactionEmoji))
+    for t := range models.ReactionEmoji {
+        flags[t] = t == reactionType
+    }
+    return flags
+}
+
+// sameReactionCounts reports whether two sets of reaction counts are equal,
+// ignoring zero counts.
+func sameReactionCounts(a, b map[string]int64) bool {
+    for t, count := range a {
+        if b[t] != count {
+            return false
+        }
+    }
+    for t, count := range b {
+        if a[t] != count {
+            return false
+        }
+    }
+    return true
+}
+
+// reactionDrift returns by how much the actual reaction counts of a post
+// exceed its stored counts, for every reaction type they differ on.
+func reactionDrift(actual, stored map[string]int64) map[string]int64 {
+    drift := map[string]int64{}
+    for t, count := range actual {
+        drift[t] += count
+    }
+    for t, count := range stored {
+        drift[t] -= count
+    }
+    for t, count := range drift {
+        if count == 0 {
+            delete(drift, t)
+        }
+    }
+    return drift
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+    "crypto/rand"
+    "encoding/base64"
+    "fmt"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+// Longest reading list name and description accepted, in characters.
+const (
+    maxReadingListName        = 100
+    maxReadingListDescription = 1000
+)
+
+type ReadingListRepository interface {
+    Create(list *models.ReadingList) error
+    GetByID(id string) (*models.ReadingList, error)
+    GetByShareToken(token string) (*models.ReadingList, error)
+    ListByOwner(ownerID primitive.ObjectID) ([]*models.ReadingList, error)
+    Update(id primitive.ObjectID, updates map[string]interface{}) error
+    SetSharing(id primitive.ObjectID, token string) error
+    Delete(id primitive.ObjectID) error
+    AddItem(id primitive.ObjectID, item models.ReadingListItem) error
+    RemoveItem(id, postID primitive.ObjectID) error
+    SetItems(id primitive.ObjectID, items []models.ReadingListItem) error
+}
+
+// ReadingListService manages named, ordered lists of posts. Lists are
+// private to their owner unless shared, in which case anyone with the share
+// token can read them. A post in the trash is hidden from the lists holding
+// it, and removed from them when it is purged.
+type ReadingListService struct {
+    repo   ReadingListRepository
+    posts  BookmarkPostRepository
+    access PostAccess
+}
+
+// NewReadingListService returns a new ReadingListService instance, given a
+// ReadingListRepository, the post storage and the PostAccess deciding who
+// may read a post.
+func NewReadingListService(repo ReadingListRepository, posts BookmarkPostRepository, access PostAccess) *ReadingListService {
+    return &ReadingListService{
+        repo:   repo,
+        posts:  posts,
+        access: access,
+    }
+}
+
+// Create creates an empty reading list owned by userID, and shares it right
+// away if shared is true.
+//
+// The returned error will be models.ErrInvalidInput for an empty or overly
+// long name or description.
+func (s *ReadingListService) Create(userID, name, description string, shared bool) (*models.ReadingList, error) {
+    ownerID, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, models.ErrForbidden
+    }
+
+    name, description, err = validateReadingList(name, description)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    list := &models.ReadingList{
+        OwnerID:     ownerID,
+        Name:        name,
+        Description: description,
+        Items:       []models.ReadingListItem{},
+        CreatedAt:   now,
+        UpdatedAt:   now,
+    }
+    if shared {
+        list.Shared = true
+        if list.ShareToken, err = newShareToken(); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := s.repo.Create(list); err != nil {
+        return nil, err
+    }
+
+    return list, nil
+}
+
+// List returns the reading lists of userID, newest first, without their
+// posts.
+func (s *ReadingListService) List(userID string) ([]*models.ReadingList, error) {
+    ownerID, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, models.ErrForbidden
+    }
+
+    return s.repo.ListByOwner(ownerID)
+}
+
+// Get returns the reading list with the given ID, with the posts the given
+// viewer can read in order. Only the list's owner may read it this way.
+func (s *ReadingListService) Get(listID string, viewer models.Viewer) (*models.ReadingList, error) {
+    list, err := s.getOwned(listID, viewer.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    return list, s.attachPosts(list, viewer)
+}
+
+// GetShared returns the shared reading list with the given share token, with
+// its posts in order. Posts anonymous readers cannot read, such as drafts and
+// private posts, are left out.
+//
+// The returned error will be models.ErrNotFound if no list is shared under
+// that token.
+func (s *ReadingListService) GetShared(token string) (*models.ReadingList, error) {
+    list, err := s.repo.GetByShareToken(token)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.attachPosts(list, models.Viewer{}); err != nil {
+        return nil, err
+    }
+
+    items := list.Items[:0]
+    for _, item := range list.Items {
+        if item.Post != nil {
+            items = append(items, item)
+        }
+    }
+    list.Items = items
+    return list, nil
+}
+
+// Update changes the name, description or sharing of the reading list with
+// the given ID, on behalf of its owner, the given viewer. Nil values are left
+// unchanged. Sharing a list gives it a new share token; unsharing it revokes
+// the token.
+//
+// The returned error will be models.ErrInvalidInput for an empty or overly
+// long name or description.
+func (s *ReadingListService) Update(listID string, viewer models.Viewer, name, description *string, shared *bool) (*models.ReadingList, error) {
+    list, err := s.getOwned(listID, viewer.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    if name != nil || description != nil {
+        newName, newDescription := list.Name, list.Description
+        if name != nil {
+            newName = *name
+        }
+        if description != nil {
+            newDescription = *description
+        }
+        if newName, newDescription, err = validateReadingList(newName, newDescription); err != nil {
+            return nil, err
+        }
+
+        list.Name, list.Description, list.UpdatedAt = newName, newDescription, time.Now()
+        err = s.repo.Update(list.ID, map[string]interface{}{
+            "name":        list.Name,
+            "description": list.Description,
+            "updated_at":  list.UpdatedAt,
+        })
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if shared != nil && *shared != list.Shared {
+        token := ""
+        if *shared {
+            if token, err = newShareToken(); err != nil {
+                return nil, err
+            }
+        }
+        if err := s.repo.SetSharing(list.ID, token); err != nil {
+            return nil, err
+        }
+        list.Shared, list.ShareToken = *shared, token
+    }
+
+    return list, s.attachPosts(list, viewer)
+}
+
+// Delete deletes the reading list with the given ID, on behalf of its owner,
+// userID. The posts themselves are not affected.
+func (s *ReadingListService) Delete(listID, userID string) error {
+    list, err := s.getOwned(listID, userID)
+    if err != nil {
+        return err
+    }
+
+    return s.repo.Delete(list.ID)
+}
+
+// AddPost appends the post with the given ID, which the given viewer must be
+// able to read, to the end of the reading list with the given ID, on behalf
+// of its owner, the viewer. Adding a post already in the list leaves it in
+// place.
+//
+// The returned error will be models.ErrConflict if the list is full.
+func (s *ReadingListService) AddPost(listID string, viewer models.Viewer, postID string) (*models.ReadingList, error) {
+    list, err := s.getOwned(listID, viewer.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    post, err := getReadablePost(s.posts, s.access, postID, viewer)
+    if err != nil {
+        return nil, err
+    }
+
+    err = s.repo.AddItem(list.ID, models.ReadingListItem{PostID: post.ID, AddedAt: time.Now()})
+    if err != nil {
+        return nil, err
+    }
+
+    return s.Get(listID, viewer)
+}
+
+// RemovePost removes the post with the given ID from the reading list with
+// the given ID, on behalf of its owner, the given viewer.
+//
+// The returned error will be models.ErrNotFound if the post is not in the
+// list.
+func (s *ReadingListService) RemovePost(listID string, viewer models.Viewer, postID string) (*models.ReadingList, error) {
+    list, err := s.getOwned(listID, viewer.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    postObjectID, err := primitive.ObjectIDFromHex(postID)
+    if err != nil {
+        return nil, models.ErrNotFound
+    }
+
+    if err := s.repo.RemoveItem(list.ID, postObjectID); err != nil {
+        return nil, err
+    }
+
+    return s.Get(listID, viewer)
+}
+
+// Reorder puts the posts of the reading list with the given ID in the given
+// order, on behalf of its owner, the given viewer. postIDs must list every post of the
+// list exactly once.
+//
+// The returned error will be models.ErrInvalidInput if postIDs is not a
+// reordering of the list's posts, and models.ErrConflict if the list changed
+// in the meantime.
+func (s *ReadingListService) Reorder(listID string, viewer models.Viewer, postIDs []string) (*models.ReadingList, error) {
+    list, err := s.getOwned(listID, viewer.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    current := make(map[string]models.ReadingListItem, len(list.Items))
+    for _, item := range list.Items {
+        current[item.PostID.Hex()] = item
+    }
+
+    invalid := fmt.Errorf("%w: post_ids must list every post of the reading list exactly once", models.ErrInvalidInput)
+    if len(postIDs) != len(list.Items) {
+        return nil, invalid
+    }
+
+    items := make([]models.ReadingListItem, 0, len(postIDs))
+    for _, postID := range postIDs {
+        item, ok := current[postID]
+        if !ok {
+            return nil, invalid
+        }
+        delete(current, postID)
+        items = append(items, item)
+    }
+
+    if err := s.repo.SetItems(list.ID, items); err != nil {
+        return nil, err
+    }
+
+    return s.Get(listID, viewer)
+}
+
+// getOwned loads the reading list with the given ID and checks that userID
+// owns it. Other users are told the list does not exist.
+func (s *ReadingListService) getOwned(listID, userID string) (*models.ReadingList, error) {
+    list, err := s.repo.GetByID(listID)
+    if err != nil {
+        return nil, err
+    }
+
+    if list.OwnerID.Hex() != userID {
+        return nil, models.ErrNotFound
+    }
+
+    return list, nil
+}
+
+// attachPosts sets the post of every item of the list that the given viewer
+// can read.
+func (s *ReadingListService) attachPosts(list *models.ReadingList, viewer models.Viewer) error {
+    ids := make([]primitive.ObjectID, len(list.Items))
+    for i, item := range list.Items {
+        ids[i] = item.PostID
+    }
+
+    posts, err := getReadablePosts(s.posts, s.access, ids, viewer)
+    if err != nil {
+        return err
+    }
+
+    for i := range list.Items {
+        list.Items[i].Post = posts[list.Items[i].PostID]
+    }
+    return nil
+}
+
+// validateReadingList trims and checks the name and description of a reading
+// list.
+func validateReadingList(name, description string) (string, string, error) {
+    name = strings.TrimSpace(name)
+    description = strings.TrimSpace(description)
+
+    if name == "" {
+        return "", "", fmt.Errorf("%w: reading lists need a name", models.ErrInvalidInput)
+    }
+    if utf8.RuneCountInString(name) > maxReadingListName {
+        return "", "", fmt.Errorf("%w: reading list names are limited to %d characters", models.ErrInvalidInput, maxReadingListName)
+    }
+    if utf8.RuneCountInString(description) > maxReadingListDescription {
+        return "", "", fmt.Errorf("%w: reading list descriptions are limited to %d characters", models.ErrInvalidInput, maxReadingListDescription)
+    }
+
+    return name, description, nil
+}
+
+// newShareToken returns a random, unguessable share token.
+func newShareToken() (string, error) {
+    token := make([]byte, 18)
+    if _, err := rand.Read(token); err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(token), nil
+}
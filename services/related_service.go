@@ -0,0 +1,573 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "log"
+    "math"
+    "sort"
+    "sync"
+    "time"
+)
+
+// RelatedRepository caches the related posts of every post.
+type RelatedRepository interface {
+    Get(postID primitive.ObjectID) (*models.RelatedPosts, error)
+    Save(related *models.RelatedPosts) error
+    DeleteByPost(postID primitive.ObjectID) error
+}
+
+// RelatedPostRepository is the part of the post storage the related posts
+// service needs to read posts and compare them with each other.
+type RelatedPostRepository interface {
+    GetByID(id string) (*models.Post, error)
+    GetByIDs(ids []primitive.ObjectID) ([]*models.Post, error)
+    List(filter models.PostFilter, req models.PageRequest) (*models.PostPage, error)
+    ListFields(filter models.PostFilter, req models.PageRequest, fields ...string) (*models.PostPage, error)
+}
+
+// The score of a related post is the weighted sum of the overlap of its tags
+// and of the similarity of its text, both between 0 and 1. Posts scoring
+// below minRelatedScore are not considered related at all.
+const (
+    relatedTagWeight  = 0.4
+    relatedTextWeight = 0.6
+    minRelatedScore   = 0.05
+)
+
+// Words of a title count as relatedTitleWeight words of the content, and only
+// the relatedTermsPerPost most distinctive words of a post are compared.
+const (
+    relatedTitleWeight  = 3
+    relatedTermsPerPost = 50
+)
+
+// relatedDebounce is how long Run waits after a post is queued before ranking
+// the queued posts, so that a burst of saves leads to a single ranking.
+const relatedDebounce = 5 * time.Second
+
+// relatedFields are the fields of the posts loaded to compare them.
+var relatedFields = []string{"title", "content", "content_html", "tags", "created_at"}
+
+// RelatedService recommends posts to the readers of a post. Published posts
+// are ranked by how many tags they share with it and by the TF-IDF cosine
+// similarity of their title and content; when too few posts are similar
+// enough, recent posts by the same author fill the list.
+//
+// Rankings are computed in the background and cached. It is a PostListener:
+// posts are ranked again by Run after every write, and RefreshAll ranks every
+// post, so that older posts also pick up newer ones. The words and tags of
+// every post are kept in memory between rankings, and only the posts written
+// since are loaded again.
+type RelatedService struct {
+    repo   RelatedRepository
+    posts  RelatedPostRepository
+    access PostAccess
+
+    mu      sync.Mutex
+    pending map[primitive.ObjectID]bool
+    wake    chan struct{}
+
+    // rankMu serializes the rankings, which share entries.
+    rankMu  sync.Mutex
+    entries map[primitive.ObjectID]*relatedEntry // nil until the first ranking
+}
+
+// NewRelatedService returns a new RelatedService instance, given a
+// RelatedRepository, the post storage and the PostAccess deciding who may
+// read a post. Run must be started for posts to be ranked after they are
+// written.
+func NewRelatedService(repo RelatedRepository, posts RelatedPostRepository, access PostAccess) *RelatedService {
+    return &RelatedService{
+        repo:    repo,
+        posts:   posts,
+        access:  access,
+        pending: make(map[primitive.ObjectID]bool),
+        wake:    make(chan struct{}, 1),
+    }
+}
+
+// Related returns up to models.MaxRelatedPosts published posts related to the
+// post with the given ID, best first, trimmed for listing. The post itself
+// follows the visibility rules of PostService.Get.
+//
+// A post that was not ranked yet is queued for ranking, and gets recent posts
+// by the same author in the meantime.
+//
+// The returned error will be models.ErrNotFound if the post does not exist
+// or the given viewer may not read it, and models.ErrPasswordRequired if the
+// post is protected by a password the viewer did not unlock it with.
+func (s *RelatedService) Related(postID string, viewer models.Viewer) ([]*models.RelatedPost, error) {
+    post, err := s.posts.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.access.CanRead(post, viewer); err != nil {
+        return nil, err
+    }
+
+    related := []*models.RelatedPost{}
+    cached, err := s.repo.Get(post.ID)
+    switch {
+    case err == nil:
+        related, err = s.loadRanked(cached.Scores)
+        if err != nil {
+            return nil, err
+        }
+    case errors.Is(err, models.ErrNotFound):
+        if post.IsPublished() {
+            s.enqueue(post.ID)
+        }
+    default:
+        return nil, err
+    }
+
+    if len(related) < models.MaxRelatedPosts {
+        return s.fillByAuthor(post, related)
+    }
+    return related, nil
+}
+
+// PostSaved queues the saved post to be ranked again, or to be left out of
+// the rankings if it is no longer published and public.
+func (s *RelatedService) PostSaved(post *models.Post) {
+    s.enqueue(post.ID)
+}
+
+// PostDeleted drops the cached ranking of the deleted post, and queues it to
+// be left out of the rankings.
+func (s *RelatedService) PostDeleted(post *models.Post) {
+    if err := s.repo.DeleteByPost(post.ID); err != nil {
+        log.Printf("Cannot delete related posts of post %s: %v", post.ID.Hex(), err)
+    }
+    s.enqueue(post.ID)
+}
+
+// Run ranks the queued posts until ctx is cancelled. It waits for
+// relatedDebounce after a post is queued, and posts queued in the meantime,
+// or while a ranking is in progress, are ranked together. A failed ranking
+// is logged, and caught up by the next RefreshAll.
+//
+// Run blocks, so it is normally started in its own goroutine.
+func (s *RelatedService) Run(ctx context.Context) {
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-s.wake:
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(relatedDebounce):
+        }
+
+        s.mu.Lock()
+        queued := s.pending
+        s.pending = make(map[primitive.ObjectID]bool)
+        s.mu.Unlock()
+
+        if _, err := s.rank(queued); err != nil {
+            log.Printf("Cannot rank related posts: %v", err)
+        }
+    }
+}
+
+// RefreshAll loads and ranks every published post again and returns how many
+// posts were ranked. It is meant to be run periodically.
+func (s *RelatedService) RefreshAll() (int, error) {
+    return s.rank(nil)
+}
+
+// enqueue queues the post with the given ID to be ranked by Run.
+func (s *RelatedService) enqueue(postID primitive.ObjectID) {
+    s.mu.Lock()
+    s.pending[postID] = true
+    s.mu.Unlock()
+
+    select {
+    case s.wake <- struct{}{}:
+    default:
+        // Run is already due to wake up.
+    }
+}
+
+// rank compares the posts with the given IDs, or every post if ids is nil,
+// with all the published posts, and caches their rankings. Posts that are no
+// longer published are skipped.
+func (s *RelatedService) rank(ids map[primitive.ObjectID]bool) (int, error) {
+    s.rankMu.Lock()
+    defer s.rankMu.Unlock()
+
+    if err := s.refreshEntries(ids); err != nil {
+        return 0, err
+    }
+
+    // Newest first, as loaded by loadPublished, so that ties go to the
+    // newest post.
+    entries := make([]*relatedEntry, 0, len(s.entries))
+    for _, entry := range s.entries {
+        entries = append(entries, entry)
+    }
+    sort.Slice(entries, func(i, j int) bool {
+        if !entries[i].createdAt.Equal(entries[j].createdAt) {
+            return entries[i].createdAt.After(entries[j].createdAt)
+        }
+        return entries[i].id.Hex() > entries[j].id.Hex()
+    })
+
+    corpus := newRelatedCorpus(entries)
+    now := time.Now()
+    ranked := 0
+    for i, entry := range entries {
+        if ids != nil && !ids[entry.id] {
+            continue
+        }
+
+        err := s.repo.Save(&models.RelatedPosts{
+            PostID:     entry.id,
+            Scores:     corpus.related(i, models.MaxRelatedPosts),
+            ComputedAt: now,
+        })
+        if err != nil {
+            return ranked, err
+        }
+        ranked++
+    }
+
+    return ranked, nil
+}
+
+// refreshEntries brings the cached entries up to date: every published
+// public post is loaded again if ids is nil or nothing is cached yet, and
+// only the posts with the given IDs otherwise.
+func (s *RelatedService) refreshEntries(ids map[primitive.ObjectID]bool) error {
+    if ids == nil || s.entries == nil {
+        posts, err := s.loadPublished()
+        if err != nil {
+            return err
+        }
+
+        s.entries = make(map[primitive.ObjectID]*relatedEntry, len(posts))
+        for _, post := range posts {
+            s.entries[post.ID] = newRelatedEntry(post)
+        }
+        return nil
+    }
+
+    changed := make([]primitive.ObjectID, 0, len(ids))
+    for id := range ids {
+        changed = append(changed, id)
+    }
+    posts, err := s.posts.GetByIDs(changed)
+    if err != nil {
+        return err
+    }
+
+    // Posts not found were deleted.
+    for _, id := range changed {
+        delete(s.entries, id)
+    }
+    for _, post := range posts {
+        if post.IsListed() {
+            s.entries[post.ID] = newRelatedEntry(post)
+        }
+    }
+    return nil
+}
+
+// loadPublished returns every published public post, newest first, with
+// only the fields needed to compare them.
+func (s *RelatedService) loadPublished() ([]*models.Post, error) {
+    filter := models.PostFilter{Statuses: []string{models.PostStatusPublished}, Visibilities: []string{models.VisibilityPublic}}
+    req := models.PageRequest{Limit: models.MaxPageLimit}
+
+    var posts []*models.Post
+    for {
+        page, err := s.posts.ListFields(filter, req, relatedFields...)
+        if err != nil {
+            return nil, err
+        }
+        posts = append(posts, page.Posts...)
+
+        if !page.Pagination.HasMore {
+            return posts, nil
+        }
+        req.Cursor = page.Pagination.NextCursor
+    }
+}
+
+// loadRanked returns the posts of the given ranking that are still
+// published and public, in order.
+func (s *RelatedService) loadRanked(scores []models.RelatedScore) ([]*models.RelatedPost, error) {
+    related := []*models.RelatedPost{}
+    if len(scores) == 0 {
+        return related, nil
+    }
+
+    ids := make([]primitive.ObjectID, len(scores))
+    for i, score := range scores {
+        ids[i] = score.PostID
+    }
+
+    posts, err := s.posts.GetByIDs(ids)
+    if err != nil {
+        return nil, err
+    }
+
+    byID := make(map[primitive.ObjectID]*models.Post, len(posts))
+    for _, post := range posts {
+        byID[post.ID] = post
+    }
+
+    for _, score := range scores {
+        post := byID[score.PostID]
+        if post == nil || !post.IsListed() {
+            continue
+        }
+        post.TrimForList()
+        related = append(related, &models.RelatedPost{
+            Post:   post,
+            Score:  score.Score,
+            Reason: models.RelatedReasonSimilar,
+        })
+    }
+
+    return related, nil
+}
+
+// fillByAuthor appends recent published public posts by the author of the given
+// post to related, up to models.MaxRelatedPosts posts.
+func (s *RelatedService) fillByAuthor(post *models.Post, related []*models.RelatedPost) ([]*models.RelatedPost, error) {
+    listed := map[primitive.ObjectID]bool{post.ID: true}
+    for _, item := range related {
+        listed[item.Post.ID] = true
+    }
+
+    page, err := s.posts.List(
+        models.PostFilter{AuthorID: post.AuthorID, Statuses: []string{models.PostStatusPublished}, Visibilities: []string{models.VisibilityPublic}},
+        models.PageRequest{Limit: models.MaxRelatedPosts + len(listed)},
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    for _, candidate := range page.Posts {
+        if len(related) == models.MaxRelatedPosts {
+            break
+        }
+        if listed[candidate.ID] {
+            continue
+        }
+        candidate.TrimForList()
+        related = append(related, &models.RelatedPost{
+            Post:   candidate,
+            Reason: models.RelatedReasonSameAuthor,
+        })
+    }
+
+    return related, nil
+}
+
+// relatedEntry is what a relatedCorpus needs to know of a post.
+type relatedEntry struct {
+    id        primitive.ObjectID
+    createdAt time.Time
+    tags      []string
+    // count maps the words of the post to how often they appear in it,
+    // words of the title counting as relatedTitleWeight words.
+    count map[string]float64
+}
+
+// newRelatedEntry counts the words of the given post.
+func newRelatedEntry(post *models.Post) *relatedEntry {
+    text := post.Content
+    if post.ContentHTML != "" {
+        text = utils.StripHTML(post.ContentHTML)
+    }
+
+    count := make(map[string]float64)
+    for _, term := range utils.Terms(post.Title) {
+        count[term] += relatedTitleWeight
+    }
+    for _, term := range utils.Terms(text) {
+        count[term]++
+    }
+
+    return &relatedEntry{
+        id:        post.ID,
+        createdAt: post.CreatedAt,
+        tags:      post.Tags,
+        count:     count,
+    }
+}
+
+// relatedDoc is a post as compared by a relatedCorpus.
+type relatedDoc struct {
+    id   primitive.ObjectID
+    tags map[string]bool
+    // terms maps the most distinctive words of the post to their TF-IDF
+    // weight, scaled so that the vector has unit length.
+    terms map[string]float64
+}
+
+// relatedCorpus compares a set of posts with each other.
+type relatedCorpus struct {
+    docs []*relatedDoc
+    // byTerm and byTag map each word and tag to the indexes of the posts
+    // that have it, so that only posts with something in common are scored.
+    byTerm map[string][]int
+    byTag  map[string][]int
+}
+
+// newRelatedCorpus weighs the words of the given posts by TF-IDF: a word
+// weighs more the more often it appears in a post, and the fewer posts it
+// appears in.
+func newRelatedCorpus(entries []*relatedEntry) *relatedCorpus {
+    corpus := &relatedCorpus{
+        docs:   make([]*relatedDoc, len(entries)),
+        byTerm: make(map[string][]int),
+        byTag:  make(map[string][]int),
+    }
+
+    postsWith := make(map[string]int)
+    for _, entry := range entries {
+        for term := range entry.count {
+            postsWith[term]++
+        }
+    }
+
+    total := float64(len(entries))
+    for i, entry := range entries {
+        doc := &relatedDoc{
+            id:    entry.id,
+            tags:  make(map[string]bool, len(entry.tags)),
+            terms: topTerms(entry.count, postsWith, total),
+        }
+        for _, tag := range entry.tags {
+            if !doc.tags[tag] {
+                doc.tags[tag] = true
+                corpus.byTag[tag] = append(corpus.byTag[tag], i)
+            }
+        }
+        for term := range doc.terms {
+            corpus.byTerm[term] = append(corpus.byTerm[term], i)
+        }
+        corpus.docs[i] = doc
+    }
+
+    return corpus
+}
+
+// topTerms returns the relatedTermsPerPost words of a post with the highest
+// TF-IDF weight, given how often each word appears in the post, how many of
+// the total posts each word appears in, with weights scaled to a unit vector.
+// Words found in every post carry no weight and are left out.
+func topTerms(count map[string]float64, postsWith map[string]int, total float64) map[string]float64 {
+    type weighted struct {
+        term   string
+        weight float64
+    }
+
+    var weights []weighted
+    for term, n := range count {
+        weight := (1 + math.Log(n)) * math.Log(total/float64(postsWith[term]))
+        if weight > 0 {
+            weights = append(weights, weighted{term, weight})
+        }
+    }
+    sort.Slice(weights, func(i, j int) bool {
+        if weights[i].weight != weights[j].weight {
+            return weights[i].weight > weights[j].weight
+        }
+        return weights[i].term < weights[j].term
+    })
+    if len(weights) > relatedTermsPerPost {
+        weights = weights[:relatedTermsPerPost]
+    }
+
+    var norm float64
+    for _, w := range weights {
+        norm += w.weight * w.weight
+    }
+    norm = math.Sqrt(norm)
+
+    terms := make(map[string]float64, len(weights))
+    for _, w := range weights {
+        terms[w.term] = w.weight / norm
+    }
+    return terms
+}
+
+// related returns up to limit posts of the corpus most related to the post
+// at index i, best first. Ties go to the post listed first, which is the
+// newest one.
+func (c *relatedCorpus) related(i, limit int) []models.RelatedScore {
+    doc := c.docs[i]
+
+    // The cosine similarity of unit vectors is their dot product.
+    similarity := make(map[int]float64)
+    for term, weight := range doc.terms {
+        for _, j := range c.byTerm[term] {
+            if j != i {
+                similarity[j] += weight * c.docs[j].terms[term]
+            }
+        }
+    }
+    sharedTags := make(map[int]int)
+    for tag := range doc.tags {
+        for _, j := range c.byTag[tag] {
+            if j != i {
+                sharedTags[j]++
+            }
+        }
+    }
+
+    type candidate struct {
+        index int
+        score float64
+    }
+    var candidates []candidate
+    score := func(j int) {
+        var tagOverlap float64
+        if shared := sharedTags[j]; shared > 0 {
+            // Jaccard index of the two sets of tags.
+            tagOverlap = float64(shared) / float64(len(doc.tags)+len(c.docs[j].tags)-shared)
+        }
+        total := relatedTagWeight*tagOverlap + relatedTextWeight*similarity[j]
+        if total >= minRelatedScore {
+            candidates = append(candidates, candidate{j, total})
+        }
+    }
+    for j := range similarity {
+        score(j)
+    }
+    for j := range sharedTags {
+        if _, scored := similarity[j]; !scored {
+            score(j)
+        }
+    }
+
+    sort.Slice(candidates, func(a, b int) bool {
+        if candidates[a].score != candidates[b].score {
+            return candidates[a].score > candidates[b].score
+        }
+        return candidates[a].index < candidates[b].index
+    })
+    if len(candidates) > limit {
+        candidates = candidates[:limit]
+    }
+
+    scores := make([]models.RelatedScore, len(candidates))
+    for k, candidate := range candidates {
+        scores[k] = models.RelatedScore{
+            PostID: c.docs[candidate.index].id,
+            Score:  math.Round(candidate.score*1000) / 1000,
+        }
+    }
+    return scores
+}
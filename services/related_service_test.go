@@ -0,0 +1,102 @@
+package services
+
+import (
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "strings"
+    "testing"
+)
+
+// fakeRelatedRepo is an in-memory RelatedRepository.
+type fakeRelatedRepo struct {
+    related map[primitive.ObjectID]*models.RelatedPosts
+}
+
+func (r *fakeRelatedRepo) Get(postID primitive.ObjectID) (*models.RelatedPosts, error) {
+    related, ok := r.related[postID]
+    if !ok {
+        return nil, models.ErrNotFound
+    }
+    return related, nil
+}
+
+func (r *fakeRelatedRepo) Save(related *models.RelatedPosts) error {
+    r.related[related.PostID] = related
+    return nil
+}
+
+func (r *fakeRelatedRepo) DeleteByPost(postID primitive.ObjectID) error {
+    delete(r.related, postID)
+    return nil
+}
+
+func TestRelatedServiceRank(t *testing.T) {
+    f := newPostFixture(t)
+    repo := &fakeRelatedRepo{related: map[primitive.ObjectID]*models.RelatedPosts{}}
+    service := NewRelatedService(repo, f.posts, f.service)
+
+    posts := map[string]*models.Post{}
+    for _, post := range []*models.Post{
+        {Title: "go", Content: "Goroutines and channels make concurrency simple.", Tags: []string{"golang"}},
+        {Title: "channels", Content: "Buffered channels and goroutines, with a worker pool.", Tags: []string{"golang"}},
+        {Title: "bread", Content: "Sourdough needs flour, water, salt and patience.", Tags: []string{"baking"}},
+        {Title: "cake", Content: "A sponge cake needs flour, eggs and sugar.", Tags: []string{"baking"}},
+    } {
+        post.Status = models.PostStatusPublished
+        posts[post.Title] = f.create(t, post, "")
+    }
+
+    // related returns the titles of the posts ranked for the given one.
+    related := func(title string) string {
+        t.Helper()
+        cached, ok := repo.related[posts[title].ID]
+        if !ok {
+            return "unranked"
+        }
+        var titles []string
+        for _, score := range cached.Scores {
+            titles = append(titles, f.posts.find(score.PostID.Hex()).Title)
+        }
+        return strings.Join(titles, ",")
+    }
+
+    if ranked, err := service.RefreshAll(); err != nil || ranked != 4 {
+        t.Fatalf("RefreshAll() = %d, %v, want 4, nil", ranked, err)
+    }
+    if len(f.posts.listedFields) != 1 || strings.Join(f.posts.listedFields[0], ",") != strings.Join(relatedFields, ",") {
+        t.Errorf("RefreshAll() listed the fields %v, want %v", f.posts.listedFields, relatedFields)
+    }
+    if got := related("go"); got != "channels" {
+        t.Errorf("related(go) = %q, want %q", got, "channels")
+    }
+    if got := related("bread"); got != "cake" {
+        t.Errorf("related(bread) = %q, want %q", got, "cake")
+    }
+
+    // Ranking saved posts only loads them, and uses the cached words of the
+    // others.
+    f.posts.set(posts["cake"].ID, map[string]interface{}{
+        "content_html": "<p>Goroutines and channels bake a concurrency cake.</p>",
+        "tags":         []string{"golang"},
+    })
+    f.posts.set(posts["channels"].ID, map[string]interface{}{"status": models.PostStatusDraft})
+    f.posts.gotIDs = nil
+    ranked, err := service.rank(map[primitive.ObjectID]bool{posts["cake"].ID: true, posts["channels"].ID: true})
+    if err != nil || ranked != 1 {
+        t.Fatalf("rank() = %d, %v, want 1, nil", ranked, err)
+    }
+    if len(f.posts.listedFields) != 1 || len(f.posts.gotIDs) != 1 || len(f.posts.gotIDs[0]) != 2 {
+        t.Errorf("rank() loaded every post, want only the 2 saved ones")
+    }
+    if got := related("cake"); got != "go" {
+        t.Errorf("related(cake) after saving = %q, want %q", got, "go")
+    }
+
+    // The unpublished post is left out of the next rankings.
+    if _, err := service.rank(map[primitive.ObjectID]bool{posts["go"].ID: true}); err != nil {
+        t.Fatalf("rank() error = %v", err)
+    }
+    if got := related("go"); got != "cake" {
+        t.Errorf("related(go) after unpublishing channels = %q, want %q", got, "cake")
+    }
+}
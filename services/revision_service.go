@@ -0,0 +1,129 @@
+package services
+
+import (
+    "fmt"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RevisionRepository interface {
+    List(postID primitive.ObjectID) ([]*models.PostRevision, error)
+    GetByNumber(postID primitive.ObjectID, number int) (*models.PostRevision, error)
+}
+
+// RevisionPostRepository is the part of the post storage the revision service
+// needs to check who may read the history of a post.
+type RevisionPostRepository interface {
+    GetByID(id string) (*models.Post, error)
+}
+
+// RevisionService gives access to the revision history of posts. Revisions
+// are recorded, and restored, by the PostService.
+type RevisionService struct {
+    repo  RevisionRepository
+    posts RevisionPostRepository
+}
+
+// NewRevisionService returns a new RevisionService instance, given a
+// RevisionRepository and the post storage.
+func NewRevisionService(repo RevisionRepository, posts RevisionPostRepository) *RevisionService {
+    return &RevisionService{
+        repo:  repo,
+        posts: posts,
+    }
+}
+
+// List returns the revisions of the post with the given ID, newest first,
+// without their content. Only the post's author and co-authors may read them.
+func (s *RevisionService) List(postID, userID string) ([]*models.PostRevision, error) {
+    post, err := s.getOwned(postID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.repo.List(post.ID)
+}
+
+// Get returns the revision with the given number of the post with the given
+// ID, including its content. Only the post's author and co-authors may read
+// it.
+//
+// The returned error will be models.ErrNotFound if there is no such revision.
+func (s *RevisionService) Get(postID, userID string, number int) (*models.PostRevision, error) {
+    post, err := s.getOwned(postID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.repo.GetByNumber(post.ID, number)
+}
+
+// Diff compares the revisions from and to of the post with the given ID. The
+// content is compared line by line or word by word according to mode, which
+// defaults to models.DiffModeLine; titles are always compared word by word.
+// Only the post's author and co-authors may compare revisions.
+//
+// The returned error will be models.ErrInvalidInput for an unknown mode, and
+// models.ErrNotFound if either revision does not exist.
+func (s *RevisionService) Diff(postID, userID string, from, to int, mode string) (*models.RevisionDiff, error) {
+    if mode == "" {
+        mode = models.DiffModeLine
+    }
+
+    var diffContent func(a, b string) []utils.DiffOp
+    switch mode {
+    case models.DiffModeLine:
+        diffContent = utils.DiffLines
+    case models.DiffModeWord:
+        diffContent = utils.DiffWords
+    default:
+        return nil, fmt.Errorf("%w: unknown diff mode %q", models.ErrInvalidInput, mode)
+    }
+
+    post, err := s.getOwned(postID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    older, err := s.repo.GetByNumber(post.ID, from)
+    if err != nil {
+        return nil, err
+    }
+    newer, err := s.repo.GetByNumber(post.ID, to)
+    if err != nil {
+        return nil, err
+    }
+
+    return &models.RevisionDiff{
+        From:    from,
+        To:      to,
+        Mode:    mode,
+        Title:   toDiffOps(utils.DiffWords(older.Title, newer.Title)),
+        Content: toDiffOps(diffContent(older.Content, newer.Content)),
+    }, nil
+}
+
+// getOwned loads the post with the given ID and checks that userID is its
+// author or one of its co-authors.
+func (s *RevisionService) getOwned(postID, userID string) (*models.Post, error) {
+    post, err := s.posts.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+
+    if !post.IsAuthor(userID) {
+        return nil, models.ErrForbidden
+    }
+
+    return post, nil
+}
+
+// toDiffOps converts diff operations to their models counterpart.
+func toDiffOps(ops []utils.DiffOp) []models.DiffOp {
+    result := make([]models.DiffOp, len(ops))
+    for i, op := range ops {
+        result[i] = models.DiffOp{Op: op.Op, Text: op.Text}
+    }
+    return result
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+    "fmt"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "log"
+    "strings"
+)
+
+// SearchIndex is a full-text index of the published posts. Implementations
+// that keep their own copy of the posts are kept up to date through Index and
+// Remove; implementations backed by the post storage itself may ignore them.
+type SearchIndex interface {
+    Index(post *models.Post) error
+    Remove(postID primitive.ObjectID) error
+    Search(query models.SearchQuery) ([]*models.SearchHit, int64, error)
+}
+
+// SearchPostRepository is the part of the post storage the search service
+// needs to load matched posts and rebuild the index.
+type SearchPostRepository interface {
+    GetByIDs(ids []primitive.ObjectID) ([]*models.Post, error)
+    List(filter models.PostFilter, req models.PageRequest) (*models.PostPage, error)
+}
+
+// snippetLength is the approximate length, in characters, of the excerpt
+// returned with each search result.
+const snippetLength = 200
+
+type SearchService struct {
+    index SearchIndex
+    posts SearchPostRepository
+}
+
+// NewSearchService returns a new SearchService instance, given the SearchIndex
+// to query and the post storage to load matched posts from.
+//
+// The returned service is a PostListener; register it on the PostService so
+// that the index follows post changes.
+func NewSearchService(index SearchIndex, posts SearchPostRepository) *SearchService {
+    return &SearchService{
+        index: index,
+        posts: posts,
+    }
+}
+
+// Search runs the given query against the search index and returns the
+// matching published posts, best matches first, with a highlighted snippet
+// for each, together with the total number of matches.
+//
+// The returned error will be models.ErrInvalidInput if the query text is
+// empty.
+func (s *SearchService) Search(query models.SearchQuery) ([]*models.SearchResult, int64, error) {
+    query.Text = strings.TrimSpace(query.Text)
+    if query.Text == "" {
+        return nil, 0, fmt.Errorf("%w: the search query is empty", models.ErrInvalidInput)
+    }
+    query.Tags = utils.NormalizeTags(query.Tags)
+
+    hits, total, err := s.index.Search(query)
+    if err != nil {
+        return nil, 0, err
+    }
+    if len(hits) == 0 {
+        return []*models.SearchResult{}, total, nil
+    }
+
+    ids := make([]primitive.ObjectID, len(hits))
+    for i, hit := range hits {
+        ids[i] = hit.PostID
+    }
+
+    posts, err := s.posts.GetByIDs(ids)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    byID := make(map[primitive.ObjectID]*models.Post, len(posts))
+    for _, post := range posts {
+        byID[post.ID] = post
+    }
+
+    terms := utils.SearchTerms(query.Text)
+    results := make([]*models.SearchResult, 0, len(hits))
+    for _, hit := range hits {
+        post := byID[hit.PostID]
+        // An index kept outside MongoDB may briefly lag behind deletions
+        // and status and visibility changes.
+        if post == nil || !post.IsListed() {
+            continue
+        }
+
+        text := post.Content
+        if post.ContentHTML != "" {
+            text = utils.StripHTML(post.ContentHTML)
+        }
+
+        snippet := utils.Highlight(text, terms, snippetLength)
+        post.TrimForList()
+
+        results = append(results, &models.SearchResult{
+            Post:    post,
+            Score:   hit.Score,
+            Snippet: snippet,
+        })
+    }
+
+    return results, total, nil
+}
+
+// Rebuild indexes every published public post and returns how many posts
+// were indexed. It is used to fill a new index from the existing posts.
+func (s *SearchService) Rebuild() (int, error) {
+    filter := models.PostFilter{Statuses: []string{models.PostStatusPublished}, Visibilities: []string{models.VisibilityPublic}}
+    req := models.PageRequest{Limit: models.MaxPageLimit}
+
+    indexed := 0
+    for {
+        page, err := s.posts.List(filter, req)
+        if err != nil {
+            return indexed, err
+        }
+
+        for _, post := range page.Posts {
+            if err := s.index.Index(post); err != nil {
+                return indexed, err
+            }
+            indexed++
+        }
+
+        if !page.Pagination.HasMore {
+            return indexed, nil
+        }
+        req.Cursor = page.Pagination.NextCursor
+    }
+}
+
+// PostSaved adds published public posts to the index and removes the others.
+func (s *SearchService) PostSaved(post *models.Post) {
+    var err error
+    if post.IsListed() {
+        err = s.index.Index(post)
+    } else {
+        err = s.index.Remove(post.ID)
+    }
+    if err != nil {
+        log.Printf("Cannot update search index for post %s: %v", post.ID.Hex(), err)
+    }
+}
+
+// PostDeleted removes the deleted post from the index.
+func (s *SearchService) PostDeleted(post *models.Post) {
+    if err := s.index.Remove(post.ID); err != nil {
+        log.Printf("Cannot remove post %s from search index: %v", post.ID.Hex(), err)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,423 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+// Longest series title and description accepted, in characters.
+const (
+    maxSeriesTitle       = 200
+    maxSeriesDescription = 2000
+)
+
+type SeriesRepository interface {
+    Create(series *models.Series) error
+    GetByID(id string) (*models.Series, error)
+    GetByPost(postID primitive.ObjectID) (*models.Series, error)
+    ListByAuthor(authorID primitive.ObjectID) ([]*models.Series, error)
+    Update(id primitive.ObjectID, updates map[string]interface{}) error
+    Delete(id primitive.ObjectID) error
+    AddPost(id, postID primitive.ObjectID, position int) error
+    RemovePost(id, postID primitive.ObjectID) error
+    SetPosts(id primitive.ObjectID, postIDs []primitive.ObjectID) error
+}
+
+// SeriesPostRepository is the part of the post storage the series service
+// needs to check and list the posts of a series.
+type SeriesPostRepository interface {
+    GetByID(id string) (*models.Post, error)
+    GetByIDs(ids []primitive.ObjectID) ([]*models.Post, error)
+}
+
+// SeriesService groups posts into ordered series. Only the author of a
+// series manages it, and only with their own posts. A post in the trash stays
+// in its series, hidden, and only leaves it when it is purged.
+type SeriesService struct {
+    repo  SeriesRepository
+    posts SeriesPostRepository
+}
+
+// NewSeriesService returns a new SeriesService instance, given a
+// SeriesRepository and the post storage.
+func NewSeriesService(repo SeriesRepository, posts SeriesPostRepository) *SeriesService {
+    return &SeriesService{
+        repo:  repo,
+        posts: posts,
+    }
+}
+
+// Create creates a series by userID with the posts with the given IDs, in
+// order. Every post must be written by userID and belong to no other series.
+//
+// The returned error will be models.ErrInvalidInput for an empty or overly
+// long title or description, or a post given twice, and models.ErrConflict
+// for a post already in another series.
+func (s *SeriesService) Create(userID, title, description string, postIDs []string) (*models.Series, error) {
+    authorID, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, models.ErrForbidden
+    }
+
+    title, description, err = validateSeries(title, description)
+    if err != nil {
+        return nil, err
+    }
+    if len(postIDs) > models.MaxSeriesPosts {
+        return nil, fmt.Errorf("%w: series are limited to %d posts", models.ErrInvalidInput, models.MaxSeriesPosts)
+    }
+
+    ids := make([]primitive.ObjectID, 0, len(postIDs))
+    seen := make(map[string]bool, len(postIDs))
+    for _, postID := range postIDs {
+        if seen[postID] {
+            return nil, fmt.Errorf("%w: post %s is given twice", models.ErrInvalidInput, postID)
+        }
+        seen[postID] = true
+
+        post, err := s.getAddable(nil, postID, userID)
+        if err != nil {
+            return nil, err
+        }
+        ids = append(ids, post.ID)
+    }
+
+    now := time.Now()
+    series := &models.Series{
+        AuthorID:    authorID,
+        Title:       title,
+        Description: description,
+        PostIDs:     ids,
+        CreatedAt:   now,
+        UpdatedAt:   now,
+    }
+    if err := s.repo.Create(series); err != nil {
+        return nil, err
+    }
+
+    return series, s.attachPosts(series, userID)
+}
+
+// Get returns the series with the given ID with its posts, in order. Posts
+// that are not published are only listed for the series' author.
+func (s *SeriesService) Get(seriesID, viewerID string) (*models.Series, error) {
+    series, err := s.repo.GetByID(seriesID)
+    if err != nil {
+        return nil, err
+    }
+
+    return series, s.attachPosts(series, viewerID)
+}
+
+// List returns the series of userID, newest first, with their posts.
+func (s *SeriesService) List(userID string) ([]*models.Series, error) {
+    authorID, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, models.ErrForbidden
+    }
+
+    series, err := s.repo.ListByAuthor(authorID)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, one := range series {
+        if err := s.attachPosts(one, userID); err != nil {
+            return nil, err
+        }
+    }
+    return series, nil
+}
+
+// Update changes the title or description of the series with the given ID,
+// on behalf of its author, userID. Nil values are left unchanged.
+//
+// The returned error will be models.ErrInvalidInput for an empty or overly
+// long title or description.
+func (s *SeriesService) Update(seriesID, userID string, title, description *string) (*models.Series, error) {
+    series, err := s.getOwned(seriesID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    newTitle, newDescription := series.Title, series.Description
+    if title != nil {
+        newTitle = *title
+    }
+    if description != nil {
+        newDescription = *description
+    }
+    if newTitle, newDescription, err = validateSeries(newTitle, newDescription); err != nil {
+        return nil, err
+    }
+
+    series.Title, series.Description, series.UpdatedAt = newTitle, newDescription, time.Now()
+    err = s.repo.Update(series.ID, map[string]interface{}{
+        "title":       series.Title,
+        "description": series.Description,
+        "updated_at":  series.UpdatedAt,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return series, s.attachPosts(series, userID)
+}
+
+// Delete deletes the series with the given ID, on behalf of its author,
+// userID. Its posts are left untouched.
+func (s *SeriesService) Delete(seriesID, userID string) error {
+    series, err := s.getOwned(seriesID, userID)
+    if err != nil {
+        return err
+    }
+
+    return s.repo.Delete(series.ID)
+}
+
+// AddPost inserts the post with the given ID into the series with the given
+// ID at the given 1-indexed position, or at the end if position is 0 or past
+// the end, on behalf of the series' author, userID. The posts from that
+// position on move down one position.
+//
+// The returned error will be models.ErrConflict if the post belongs to
+// another series or the series is full.
+func (s *SeriesService) AddPost(seriesID, userID, postID string, position int) (*models.Series, error) {
+    series, err := s.getOwned(seriesID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    post, err := s.getAddable(series, postID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.repo.AddPost(series.ID, post.ID, position-1); err != nil {
+        return nil, err
+    }
+
+    return s.Get(seriesID, userID)
+}
+
+// RemovePost removes the post with the given ID from the series with the
+// given ID, on behalf of the series' author, userID. The posts after it move
+// up one position.
+//
+// The returned error will be models.ErrNotFound if the post is not in the
+// series.
+func (s *SeriesService) RemovePost(seriesID, userID, postID string) (*models.Series, error) {
+    series, err := s.getOwned(seriesID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    postObjectID, err := primitive.ObjectIDFromHex(postID)
+    if err != nil {
+        return nil, models.ErrNotFound
+    }
+
+    if err := s.repo.RemovePost(series.ID, postObjectID); err != nil {
+        return nil, err
+    }
+
+    return s.Get(seriesID, userID)
+}
+
+// Reorder puts the posts of the series with the given ID in the given order,
+// on behalf of its author, userID. postIDs must list every post of the
+// series exactly once, except the posts in the trash, which keep their place.
+//
+// The returned error will be models.ErrInvalidInput if postIDs is not a
+// reordering of the series' posts, and models.ErrConflict if the series
+// changed in the meantime.
+func (s *SeriesService) Reorder(seriesID, userID string, postIDs []string) (*models.Series, error) {
+    series, err := s.getOwned(seriesID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    found, err := s.posts.GetByIDs(series.PostIDs)
+    if err != nil {
+        return nil, err
+    }
+    current := make(map[string]primitive.ObjectID, len(found))
+    for _, post := range found {
+        current[post.ID.Hex()] = post.ID
+    }
+
+    invalid := fmt.Errorf("%w: post_ids must list every post of the series exactly once", models.ErrInvalidInput)
+    if len(postIDs) != len(current) {
+        return nil, invalid
+    }
+
+    ordered := make([]primitive.ObjectID, 0, len(postIDs))
+    for _, postID := range postIDs {
+        id, ok := current[postID]
+        if !ok {
+            return nil, invalid
+        }
+        delete(current, postID)
+        ordered = append(ordered, id)
+    }
+
+    // The posts in the trash keep their place, the others fill the
+    // remaining places in the given order.
+    live := make(map[primitive.ObjectID]bool, len(ordered))
+    for _, id := range ordered {
+        live[id] = true
+    }
+    ids := make([]primitive.ObjectID, len(series.PostIDs))
+    for i, id := range series.PostIDs {
+        if live[id] {
+            id, ordered = ordered[0], ordered[1:]
+        }
+        ids[i] = id
+    }
+
+    if err := s.repo.SetPosts(series.ID, ids); err != nil {
+        return nil, err
+    }
+
+    return s.Get(seriesID, userID)
+}
+
+// ForPost returns where the given post stands in its series, as seen by
+// viewerID, or nil if the post is in no series.
+func (s *SeriesService) ForPost(post *models.Post, viewerID string) (*models.PostSeries, error) {
+    series, err := s.repo.GetByPost(post.ID)
+    if errors.Is(err, models.ErrNotFound) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.attachPosts(series, viewerID); err != nil {
+        return nil, err
+    }
+
+    info := &models.PostSeries{
+        ID:    series.ID,
+        Title: series.Title,
+        Total: len(series.Posts),
+    }
+    for i, seriesPost := range series.Posts {
+        if seriesPost.ID != post.ID {
+            continue
+        }
+        info.Position = seriesPost.Position
+        if i > 0 {
+            info.Previous = series.Posts[i-1]
+        }
+        if i < len(series.Posts)-1 {
+            info.Next = series.Posts[i+1]
+        }
+    }
+
+    return info, nil
+}
+
+// getOwned loads the series with the given ID and checks that userID is its
+// author.
+func (s *SeriesService) getOwned(seriesID, userID string) (*models.Series, error) {
+    series, err := s.repo.GetByID(seriesID)
+    if err != nil {
+        return nil, err
+    }
+
+    if series.AuthorID.Hex() != userID {
+        return nil, models.ErrForbidden
+    }
+
+    return series, nil
+}
+
+// getAddable loads the post with the given ID and checks that userID may add
+// it to the given series, or to a new series if series is nil: the post must
+// be written by userID, and belong to no other series.
+func (s *SeriesService) getAddable(series *models.Series, postID, userID string) (*models.Post, error) {
+    post, err := s.posts.GetByID(postID)
+    if errors.Is(err, models.ErrNotFound) {
+        return nil, fmt.Errorf("%w: post %s not found", models.ErrInvalidInput, postID)
+    }
+    if err != nil {
+        return nil, err
+    }
+    if post.AuthorID.Hex() != userID {
+        return nil, models.ErrForbidden
+    }
+
+    other, err := s.repo.GetByPost(post.ID)
+    if errors.Is(err, models.ErrNotFound) {
+        return post, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    if series == nil || other.ID != series.ID {
+        return nil, fmt.Errorf("%w: post %s already belongs to the series %q", models.ErrConflict, postID, other.Title)
+    }
+
+    return post, nil
+}
+
+// attachPosts lists the posts of the series that viewerID can see, in order
+// and numbered from 1. The series' author sees every post; other readers
+// only see published public posts.
+func (s *SeriesService) attachPosts(series *models.Series, viewerID string) error {
+    series.Posts = []*models.SeriesPost{}
+    if len(series.PostIDs) == 0 {
+        return nil
+    }
+
+    // Posts in the trash are not found, and so are hidden.
+    found, err := s.posts.GetByIDs(series.PostIDs)
+    if err != nil {
+        return err
+    }
+    byID := make(map[primitive.ObjectID]*models.Post, len(found))
+    for _, post := range found {
+        byID[post.ID] = post
+    }
+
+    isAuthor := series.AuthorID.Hex() == viewerID
+    for _, id := range series.PostIDs {
+        post, ok := byID[id]
+        if !ok || !(post.IsListed() || isAuthor) {
+            continue
+        }
+        series.Posts = append(series.Posts, &models.SeriesPost{
+            ID:       post.ID,
+            Title:    post.Title,
+            Slug:     post.Slug,
+            Status:   post.Status,
+            Position: len(series.Posts) + 1,
+        })
+    }
+
+    return nil
+}
+
+// validateSeries trims and checks the title and description of a series.
+func validateSeries(title, description string) (string, string, error) {
+    title = strings.TrimSpace(title)
+    description = strings.TrimSpace(description)
+
+    if title == "" {
+        return "", "", fmt.Errorf("%w: series need a title", models.ErrInvalidInput)
+    }
+    if utf8.RuneCountInString(title) > maxSeriesTitle {
+        return "", "", fmt.Errorf("%w: series titles are limited to %d characters", models.ErrInvalidInput, maxSeriesTitle)
+    }
+    if utf8.RuneCountInString(description) > maxSeriesDescription {
+        return "", "", fmt.Errorf("%w: series descriptions are limited to %d characters", models.ErrInvalidInput, maxSeriesDescription)
+    }
+
+    return title, description, nil
+}
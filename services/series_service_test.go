@@ -0,0 +1,186 @@
+package services
+
+import (
+    "fmt"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "strings"
+    "testing"
+)
+
+// fakeSeriesRepo is an in-memory SeriesRepository.
+type fakeSeriesRepo struct {
+    series map[primitive.ObjectID]*models.Series
+}
+
+func newFakeSeriesRepo() *fakeSeriesRepo {
+    return &fakeSeriesRepo{series: map[primitive.ObjectID]*models.Series{}}
+}
+
+// copyOf returns a copy of the given series that does not share its posts.
+func (r *fakeSeriesRepo) copyOf(series *models.Series) *models.Series {
+    found := *series
+    found.PostIDs = append([]primitive.ObjectID{}, series.PostIDs...)
+    return &found
+}
+
+func (r *fakeSeriesRepo) Create(series *models.Series) error {
+    series.ID = primitive.NewObjectID()
+    r.series[series.ID] = r.copyOf(series)
+    return nil
+}
+
+func (r *fakeSeriesRepo) GetByID(id string) (*models.Series, error) {
+    objectID, _ := primitive.ObjectIDFromHex(id)
+    series, ok := r.series[objectID]
+    if !ok {
+        return nil, models.ErrNotFound
+    }
+    return r.copyOf(series), nil
+}
+
+func (r *fakeSeriesRepo) GetByPost(postID primitive.ObjectID) (*models.Series, error) {
+    for _, series := range r.series {
+        for _, id := range series.PostIDs {
+            if id == postID {
+                return r.copyOf(series), nil
+            }
+        }
+    }
+    return nil, models.ErrNotFound
+}
+
+func (r *fakeSeriesRepo) ListByAuthor(authorID primitive.ObjectID) ([]*models.Series, error) {
+    list := []*models.Series{}
+    for _, series := range r.series {
+        if series.AuthorID == authorID {
+            list = append(list, r.copyOf(series))
+        }
+    }
+    return list, nil
+}
+
+func (r *fakeSeriesRepo) Update(id primitive.ObjectID, updates map[string]interface{}) error {
+    return nil
+}
+
+func (r *fakeSeriesRepo) Delete(id primitive.ObjectID) error {
+    delete(r.series, id)
+    return nil
+}
+
+func (r *fakeSeriesRepo) AddPost(id, postID primitive.ObjectID, position int) error {
+    series := r.series[id]
+    if position < 0 || position > len(series.PostIDs) {
+        position = len(series.PostIDs)
+    }
+    series.PostIDs = append(series.PostIDs[:position], append([]primitive.ObjectID{postID}, series.PostIDs[position:]...)...)
+    return nil
+}
+
+func (r *fakeSeriesRepo) RemovePost(id, postID primitive.ObjectID) error {
+    series := r.series[id]
+    for i, existing := range series.PostIDs {
+        if existing == postID {
+            series.PostIDs = append(series.PostIDs[:i], series.PostIDs[i+1:]...)
+            return nil
+        }
+    }
+    return models.ErrNotFound
+}
+
+func (r *fakeSeriesRepo) SetPosts(id primitive.ObjectID, postIDs []primitive.ObjectID) error {
+    r.series[id].PostIDs = append([]primitive.ObjectID{}, postIDs...)
+    return nil
+}
+
+func (r *fakeSeriesRepo) DeleteByPost(postID primitive.ObjectID) error {
+    for _, series := range r.series {
+        for i, existing := range series.PostIDs {
+            if existing == postID {
+                series.PostIDs = append(series.PostIDs[:i], series.PostIDs[i+1:]...)
+                break
+            }
+        }
+    }
+    return nil
+}
+
+// seriesTitles returns the titles of the posts listed in the given series,
+// with their positions.
+func seriesTitles(series *models.Series) string {
+    titles := make([]string, len(series.Posts))
+    for i, post := range series.Posts {
+        titles[i] = fmt.Sprintf("%d.%s", post.Position, post.Title)
+    }
+    return strings.Join(titles, ",")
+}
+
+func TestSeriesServiceTrashedPost(t *testing.T) {
+    f := newPostFixture(t)
+    repo := newFakeSeriesRepo()
+    service := NewSeriesService(repo, f.posts)
+    trash := NewTrashService(f.posts, nil, "", 0, repo)
+
+    author := primitive.NewObjectID()
+    var ids []string
+    posts := map[string]*models.Post{}
+    for _, title := range []string{"one", "two", "three"} {
+        post := f.create(t, &models.Post{AuthorID: author, Title: title, Status: models.PostStatusPublished}, "")
+        ids = append(ids, post.ID.Hex())
+        posts[title] = post
+    }
+    series, err := service.Create(author.Hex(), "Series", "", ids)
+    if err != nil {
+        t.Fatalf("Create() error = %v", err)
+    }
+
+    get := func() string {
+        t.Helper()
+        series, err := service.Get(series.ID.Hex(), author.Hex())
+        if err != nil {
+            t.Fatalf("Get() error = %v", err)
+        }
+        return seriesTitles(series)
+    }
+
+    // A post in the trash is hidden, and the others are numbered without
+    // gaps.
+    two := posts["two"]
+    if err := f.service.Delete(two.ID.Hex(), author.Hex(), models.RoleUser, nil); err != nil {
+        t.Fatalf("Delete() error = %v", err)
+    }
+    if got := get(); got != "1.one,2.three" {
+        t.Errorf("Get() after Delete() = %q, want %q", got, "1.one,2.three")
+    }
+
+    // Reordering lists the visible posts only, and the trashed one keeps its
+    // place.
+    if _, err := service.Reorder(series.ID.Hex(), author.Hex(), ids); err == nil {
+        t.Errorf("Reorder() listing the trashed post succeeded, want ErrInvalidInput")
+    }
+    if _, err := service.Reorder(series.ID.Hex(), author.Hex(), []string{posts["three"].ID.Hex(), posts["one"].ID.Hex()}); err != nil {
+        t.Fatalf("Reorder() error = %v", err)
+    }
+
+    if _, err := f.service.Restore(two.ID.Hex(), author.Hex(), models.RoleUser); err != nil {
+        t.Fatalf("Restore() error = %v", err)
+    }
+    if got := get(); got != "1.three,2.two,3.one" {
+        t.Errorf("Get() after Restore() = %q, want %q", got, "1.three,2.two,3.one")
+    }
+
+    // Purging the post from the trash removes it from the series.
+    if err := f.service.Delete(two.ID.Hex(), author.Hex(), models.RoleUser, nil); err != nil {
+        t.Fatalf("Delete() error = %v", err)
+    }
+    if purged, err := trash.Purge(); err != nil || purged != 1 {
+        t.Fatalf("Purge() = %d, %v, want 1, nil", purged, err)
+    }
+    if stored := repo.series[series.ID]; len(stored.PostIDs) != 2 {
+        t.Errorf("series holds %d posts after Purge(), want 2", len(stored.PostIDs))
+    }
+    if got := get(); got != "1.three,2.one" {
+        t.Errorf("Get() after Purge() = %q, want %q", got, "1.three,2.one")
+    }
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "regexp"
+    "strings"
+    "time"
+    "unicode"
+)
+
+// SpamScorer rates how likely a new comment is to be spam, from 0 (surely
+// not) to 1 (surely spam). The comment has not been stored yet when it is
+// scored.
+type SpamScorer interface {
+    Score(comment *models.Comment) (float64, error)
+}
+
+// CommentActivity is the part of the comment storage the heuristic spam
+// scorer needs to measure how fast a user is posting.
+type CommentActivity interface {
+    CountByAuthorSince(authorID primitive.ObjectID, since time.Time) (int64, error)
+}
+
+// SpamRules configures the HeuristicSpamScorer. Zero values disable the
+// corresponding check.
+type SpamRules struct {
+    MaxLinks       int           // Links allowed in a comment before it looks suspicious
+    Blocklist      []string      // Words or phrases that only spam contains, matched case-insensitively
+    VelocityLimit  int           // Comments a user may post within VelocityWindow
+    VelocityWindow time.Duration
+}
+
+// Weights of the signals of the HeuristicSpamScorer. A single blocklisted
+// word is enough to reach the default threshold of 0.6, while too many links
+// or posting too fast only get there together, or with many links.
+const (
+    spamWeightLinks     = 0.4
+    spamWeightExtraLink = 0.1
+    spamWeightBlocklist = 0.6
+    spamWeightVelocity  = 0.4
+)
+
+// linkPattern matches the start of a link in the Markdown source of a
+// comment, whether written bare or inside a Markdown link.
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)`)
+
+type HeuristicSpamScorer struct {
+    activity CommentActivity
+    rules    SpamRules
+    words    map[string]bool
+    phrases  []string
+}
+
+// NewHeuristicSpamScorer returns a SpamScorer that adds up simple signals:
+// more links than rules.MaxLinks, blocklisted words, and more comments than
+// rules.VelocityLimit within rules.VelocityWindow, counted with the given
+// CommentActivity.
+func NewHeuristicSpamScorer(activity CommentActivity, rules SpamRules) *HeuristicSpamScorer {
+    s := &HeuristicSpamScorer{
+        activity: activity,
+        rules:    rules,
+        words:    make(map[string]bool),
+    }
+
+    for _, entry := range rules.Blocklist {
+        entry = strings.ToLower(strings.TrimSpace(entry))
+        if entry == "" {
+            continue
+        }
+        if strings.ContainsFunc(entry, unicode.IsSpace) {
+            s.phrases = append(s.phrases, entry)
+        } else {
+            s.words[entry] = true
+        }
+    }
+
+    return s
+}
+
+// Score implements SpamScorer.
+func (s *HeuristicSpamScorer) Score(comment *models.Comment) (float64, error) {
+    score := 0.0
+
+    if s.rules.MaxLinks > 0 {
+        if extra := len(linkPattern.FindAllStringIndex(comment.Body, -1)) - s.rules.MaxLinks; extra > 0 {
+            score += spamWeightLinks + spamWeightExtraLink*float64(extra-1)
+        }
+    }
+
+    score += spamWeightBlocklist * float64(s.blocklisted(comment.Body))
+
+    if s.rules.VelocityLimit > 0 && s.rules.VelocityWindow > 0 {
+        recent, err := s.activity.CountByAuthorSince(comment.AuthorID, comment.CreatedAt.Add(-s.rules.VelocityWindow))
+        if err != nil {
+            return 0, err
+        }
+        if recent >= int64(s.rules.VelocityLimit) {
+            score += spamWeightVelocity
+        }
+    }
+
+    if score > 1 {
+        score = 1
+    }
+    return score, nil
+}
+
+// blocklisted returns the number of distinct blocklisted words and phrases
+// found in the given text.
+func (s *HeuristicSpamScorer) blocklisted(text string) int {
+    text = strings.ToLower(text)
+    found := make(map[string]bool)
+
+    for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    }) {
+        if s.words[word] {
+            found[word] = true
+        }
+    }
+
+    count := len(found)
+    for _, phrase := range s.phrases {
+        if strings.Contains(text, phrase) {
+            count++
+        }
+    }
+    return count
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "math"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestHeuristicSpamScorer(t *testing.T) {
+    rules := SpamRules{
+        MaxLinks:       2,
+        Blocklist:      []string{"Casino", " cheap pills ", ""},
+        VelocityLimit:  3,
+        VelocityWindow: time.Minute,
+    }
+    link := "see https://example.com "
+
+    tests := []struct {
+        name   string
+        body   string
+        recent int // Comments by the author within the velocity window
+        want   float64
+    }{
+        {"clean", "Thanks for sharing.", 0, 0},
+        {"links at the limit", strings.Repeat(link, 2), 0, 0},
+        {"one link too many", strings.Repeat(link, 3), 0, 0.4},
+        {"three links too many", strings.Repeat(link, 5), 0, 0.6},
+        {"www link", "www.example.com www.example.org http://example.net", 0, 0.4},
+        {"blocklisted word", "Best CASINO in town", 0, 0.6},
+        {"blocklisted word repeated", "casino casino", 0, 0.6},
+        {"word inside another", "casinos are fun", 0, 0},
+        {"blocklisted phrase", "Buy Cheap Pills here", 0, 0.6},
+        {"two blocklisted entries", "casino and cheap pills", 0, 1},
+        {"below velocity limit", "Hello again.", 2, 0},
+        {"at velocity limit", "Hello again.", 3, 0.4},
+        {"links and velocity", strings.Repeat(link, 3), 3, 0.8},
+        {"capped", strings.Repeat(link, 3) + "casino", 3, 1},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            comments := newFakeCommentRepo()
+            author := primitive.NewObjectID()
+            now := time.Now()
+            for i := 0; i < tt.recent; i++ {
+                comments.Create(&models.Comment{ID: primitive.NewObjectID(), AuthorID: author, CreatedAt: now.Add(-30 * time.Second)})
+            }
+            // Comments outside the window, or by other users, do not count.
+            comments.Create(&models.Comment{ID: primitive.NewObjectID(), AuthorID: author, CreatedAt: now.Add(-2 * time.Minute)})
+            comments.Create(&models.Comment{ID: primitive.NewObjectID(), AuthorID: primitive.NewObjectID(), CreatedAt: now})
+
+            scorer := NewHeuristicSpamScorer(comments, rules)
+            got, err := scorer.Score(&models.Comment{AuthorID: author, Body: tt.body, CreatedAt: now})
+            if err != nil {
+                t.Fatalf("Score() error = %v", err)
+            }
+            if math.Abs(got-tt.want) > 1e-9 {
+                t.Errorf("Score(%q) = %v, want %v", tt.body, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestHeuristicSpamScorerDisabledRules(t *testing.T) {
+    // Zero rules disable every check, so the activity is never consulted.
+    scorer := NewHeuristicSpamScorer(nil, SpamRules{})
+    got, err := scorer.Score(&models.Comment{Body: strings.Repeat("https://example.com ", 20)})
+    if err != nil {
+        t.Fatalf("Score() error = %v", err)
+    }
+    if got != 0 {
+        t.Errorf("Score() = %v, want 0", got)
+    }
+}
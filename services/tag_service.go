@@ -0,0 +1,132 @@
+package services
+
+import (
+    "fmt"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TagRepository interface {
+    ListTags() ([]*models.TagCount, error)
+    CountByTag(tag string) (int64, error)
+    RenameTag(from, to string) ([]primitive.ObjectID, error)
+}
+
+// TagPostNotifier is the part of the PostService that records the posts
+// whose tags were changed in bulk and tells its listeners, such as the
+// search index, about them.
+type TagPostNotifier interface {
+    NotifyChanged(postIDs []primitive.ObjectID, editorID string) error
+}
+
+type TagService struct {
+    repo  TagRepository
+    posts TagPostNotifier
+}
+
+// NewTagService returns a new TagService instance, given a TagRepository and
+// the TagPostNotifier told about the posts a rename or merge changes.
+//
+// Tags are stored on the posts themselves, so the TagRepository is normally
+// the post repository, and the TagPostNotifier the PostService.
+func NewTagService(repo TagRepository, posts TagPostNotifier) *TagService {
+    return &TagService{
+        repo:  repo,
+        posts: posts,
+    }
+}
+
+// List returns every tag used by published posts with its post count, most
+// used first.
+func (s *TagService) List() ([]*models.TagCount, error) {
+    return s.repo.ListTags()
+}
+
+// Rename renames the tag from to the tag to on every post, on behalf of
+// userID, and returns the number of posts changed. Both tags are normalized
+// first. The changed posts get a new revision and are reindexed, as for
+// PostService.Update.
+//
+// The returned error will be models.ErrNotFound if no post uses the tag, and
+// models.ErrConflict if the new tag is already used; use Merge in that case.
+func (s *TagService) Rename(from, to, userID string) (int64, error) {
+    from, to, err := s.normalizePair(from, to)
+    if err != nil {
+        return 0, err
+    }
+
+    if err := s.requireUsed(from); err != nil {
+        return 0, err
+    }
+
+    count, err := s.repo.CountByTag(to)
+    if err != nil {
+        return 0, err
+    }
+    if count > 0 {
+        return 0, fmt.Errorf("%w: tag %q already exists, merge the tags instead", models.ErrConflict, to)
+    }
+
+    return s.rename(from, to, userID)
+}
+
+// Merge folds the tag from into the existing tag into on every post, on
+// behalf of userID, and returns the number of posts changed. Posts that had
+// both tags keep one. The changed posts are handled as for Rename.
+//
+// The returned error will be models.ErrNotFound if either tag is unused.
+func (s *TagService) Merge(from, into, userID string) (int64, error) {
+    from, into, err := s.normalizePair(from, into)
+    if err != nil {
+        return 0, err
+    }
+
+    if err := s.requireUsed(from); err != nil {
+        return 0, err
+    }
+    if err := s.requireUsed(into); err != nil {
+        return 0, err
+    }
+
+    return s.rename(from, into, userID)
+}
+
+// rename replaces the tag from with the tag to on every post, and notifies
+// the PostService of the posts changed, including those renamed before a
+// failure.
+func (s *TagService) rename(from, to, userID string) (int64, error) {
+    postIDs, err := s.repo.RenameTag(from, to)
+    if notifyErr := s.posts.NotifyChanged(postIDs, userID); err == nil {
+        err = notifyErr
+    }
+    if err != nil {
+        return 0, err
+    }
+    return int64(len(postIDs)), nil
+}
+
+// normalizePair normalizes the source and target tags of a rename or merge,
+// and checks that they are usable and different.
+func (s *TagService) normalizePair(from, to string) (string, string, error) {
+    from, to = utils.Slugify(from), utils.Slugify(to)
+    if from == "" || to == "" {
+        return "", "", fmt.Errorf("%w: tags must contain letters or digits", models.ErrInvalidInput)
+    }
+    if from == to {
+        return "", "", fmt.Errorf("%w: both tags are the same", models.ErrInvalidInput)
+    }
+    return from, to, nil
+}
+
+// requireUsed returns models.ErrNotFound if no post uses the given tag.
+func (s *TagService) requireUsed(tag string) error {
+    count, err := s.repo.CountByTag(tag)
+    if err != nil {
+        return err
+    }
+    if count == 0 {
+        return models.ErrNotFound
+    }
+    return nil
+}
\ No newline at end of file
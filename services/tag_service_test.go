@@ -0,0 +1,196 @@
+package services
+
+import (
+    "errors"
+    "go-blog-backend/models"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "reflect"
+    "testing"
+)
+
+// fakeTagRepo is an in-memory TagRepository over the tags of a few posts.
+type fakeTagRepo struct {
+    tags      map[primitive.ObjectID][]string
+    renameErr error
+}
+
+func (r *fakeTagRepo) ListTags() ([]*models.TagCount, error) {
+    return nil, nil
+}
+
+func (r *fakeTagRepo) CountByTag(tag string) (int64, error) {
+    var count int64
+    for _, tags := range r.tags {
+        if containsString(tags, tag) {
+            count++
+        }
+    }
+    return count, nil
+}
+
+func (r *fakeTagRepo) RenameTag(from, to string) ([]primitive.ObjectID, error) {
+    changed := []primitive.ObjectID{}
+    for id, tags := range r.tags {
+        if !containsString(tags, from) {
+            continue
+        }
+        renamed := []string{}
+        for _, tag := range tags {
+            if tag == from {
+                tag = to
+            }
+            if !containsString(renamed, tag) {
+                renamed = append(renamed, tag)
+            }
+        }
+        r.tags[id] = renamed
+        changed = append(changed, id)
+        if r.renameErr != nil {
+            return changed, r.renameErr
+        }
+    }
+    return changed, nil
+}
+
+// fakeTagNotifier records the posts it is told about.
+type fakeTagNotifier struct {
+    postIDs  []primitive.ObjectID
+    editorID string
+}
+
+func (n *fakeTagNotifier) NotifyChanged(postIDs []primitive.ObjectID, editorID string) error {
+    n.postIDs = append(n.postIDs, postIDs...)
+    n.editorID = editorID
+    return nil
+}
+
+func TestTagServiceRenameAndMerge(t *testing.T) {
+    goPost, webPost, bothPost := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+    editor := primitive.NewObjectID().Hex()
+
+    tests := []struct {
+        name     string
+        merge    bool
+        from, to string
+        want     int64
+        wantTags map[primitive.ObjectID][]string
+        wantErr  error
+    }{
+        {
+            name: "rename",
+            from: "Go", to: "Golang",
+            want: 2,
+            wantTags: map[primitive.ObjectID][]string{
+                goPost:   {"golang", "news"},
+                webPost:  {"web"},
+                bothPost: {"web", "golang"},
+            },
+        },
+        {
+            name:  "merge",
+            merge: true,
+            from:  "go", to: "web",
+            want:  2,
+            wantTags: map[primitive.ObjectID][]string{
+                goPost:   {"web", "news"},
+                webPost:  {"web"},
+                bothPost: {"web"},
+            },
+        },
+        {name: "rename onto used tag", from: "go", to: "web", wantErr: models.ErrConflict},
+        {name: "rename unused tag", from: "rust", to: "rustlang", wantErr: models.ErrNotFound},
+        {name: "merge into unused tag", merge: true, from: "go", to: "rust", wantErr: models.ErrNotFound},
+        {name: "merge unused tag", merge: true, from: "rust", to: "go", wantErr: models.ErrNotFound},
+        {name: "same tag", from: "Go", to: "go", wantErr: models.ErrInvalidInput},
+        {name: "empty tag", from: "go", to: "!!", wantErr: models.ErrInvalidInput},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            repo := &fakeTagRepo{tags: map[primitive.ObjectID][]string{
+                goPost:   {"go", "news"},
+                webPost:  {"web"},
+                bothPost: {"web", "go"},
+            }}
+            notifier := &fakeTagNotifier{}
+            service := NewTagService(repo, notifier)
+
+            var got int64
+            var err error
+            if tt.merge {
+                got, err = service.Merge(tt.from, tt.to, editor)
+            } else {
+                got, err = service.Rename(tt.from, tt.to, editor)
+            }
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Fatalf("error = %v, want %v", err, tt.wantErr)
+                }
+                if len(notifier.postIDs) > 0 {
+                    t.Errorf("notified of %d posts, want none", len(notifier.postIDs))
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("error = %v", err)
+            }
+
+            if got != tt.want {
+                t.Errorf("changed %d posts, want %d", got, tt.want)
+            }
+            if !reflect.DeepEqual(repo.tags, tt.wantTags) {
+                t.Errorf("tags = %v, want %v", repo.tags, tt.wantTags)
+            }
+            if int64(len(notifier.postIDs)) != tt.want || notifier.editorID != editor {
+                t.Errorf("notified of %v by %q, want %d posts by %q", notifier.postIDs, notifier.editorID, tt.want, editor)
+            }
+        })
+    }
+}
+
+func TestTagServiceNotifiesPartialRename(t *testing.T) {
+    failure := errors.New("connection lost")
+    repo := &fakeTagRepo{
+        tags: map[primitive.ObjectID][]string{
+            primitive.NewObjectID(): {"go"},
+            primitive.NewObjectID(): {"go"},
+        },
+        renameErr: failure,
+    }
+    notifier := &fakeTagNotifier{}
+
+    _, err := NewTagService(repo, notifier).Rename("go", "golang", primitive.NewObjectID().Hex())
+    if !errors.Is(err, failure) {
+        t.Fatalf("Rename() error = %v, want %v", err, failure)
+    }
+    if len(notifier.postIDs) != 1 {
+        t.Errorf("notified of %d posts, want the one renamed", len(notifier.postIDs))
+    }
+}
+
+func TestPostServiceNotifyChanged(t *testing.T) {
+    f := newPostFixture(t)
+    live := f.create(t, &models.Post{Tags: []string{"golang"}}, "")
+    deleted := f.create(t, &models.Post{Tags: []string{"golang"}}, "")
+    if err := f.service.Delete(deleted.ID.Hex(), deleted.AuthorID.Hex(), models.RoleUser, nil); err != nil {
+        t.Fatal(err)
+    }
+    editor := primitive.NewObjectID()
+    revisions, saved := len(f.revisions.revisions), len(f.listener.saved)
+
+    err := f.service.NotifyChanged([]primitive.ObjectID{live.ID, deleted.ID, primitive.NewObjectID()}, editor.Hex())
+    if err != nil {
+        t.Fatalf("NotifyChanged() error = %v", err)
+    }
+
+    if len(f.listener.saved) != saved+1 || f.listener.saved[saved].ID != live.ID {
+        t.Errorf("listeners told about %d posts, want the live post only", len(f.listener.saved)-saved)
+    }
+    if len(f.revisions.revisions) != revisions+1 {
+        t.Fatalf("recorded %d revisions, want 1", len(f.revisions.revisions)-revisions)
+    }
+    revision := f.revisions.revisions[revisions]
+    if revision.PostID != live.ID || revision.AuthorID != editor {
+        t.Errorf("revision of %v by %v, want of %v by %v", revision.PostID, revision.AuthorID, live.ID, editor)
+    }
+}
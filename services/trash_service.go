@@ -0,0 +1,142 @@
+package services
+
+import (
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "log"
+    "strings"
+    "time"
+)
+
+// purgeBatchSize is the number of deleted posts loaded at a time by Purge.
+const purgeBatchSize = 100
+
+// TrashPostRepository is the part of the post storage the trash service needs
+// to purge deleted posts.
+type TrashPostRepository interface {
+    ListDeletedBefore(before time.Time, limit int) ([]*models.Post, error)
+    Delete(id string) error
+    ReferencedImages(urls []string) (map[string]bool, error)
+}
+
+// PostDataRepository stores data that belongs to a post and goes away with
+// it, such as its slugs or revisions.
+type PostDataRepository interface {
+    DeleteByPost(postID primitive.ObjectID) error
+}
+
+// ImageStore deletes uploaded images.
+type ImageStore interface {
+    DeleteImage(filename string) error
+}
+
+type TrashService struct {
+    posts     TrashPostRepository
+    data      []PostDataRepository
+    images    ImageStore
+    imageURL  string
+    retention time.Duration
+}
+
+// NewTrashService returns a new TrashService instance, given the post
+// storage, the ImageStore holding uploaded images and the public URL they are
+// served from, how long deleted posts stay in the trash, and the repositories
+// of the data to delete together with a post.
+func NewTrashService(posts TrashPostRepository, images ImageStore, imageURL string, retention time.Duration, data ...PostDataRepository) *TrashService {
+    if imageURL != "" {
+        imageURL = strings.TrimSuffix(imageURL, "/") + "/"
+    }
+
+    return &TrashService{
+        posts:     posts,
+        data:      data,
+        images:    images,
+        imageURL:  imageURL,
+        retention: retention,
+    }
+}
+
+// Purge permanently deletes the posts that have been in the trash for longer
+// than the retention period, together with their data, and returns how many
+// posts were purged.
+//
+// Uploaded images used by a purged post are deleted too, unless another post,
+// live or still in the trash, uses them. It is meant to be run periodically.
+func (s *TrashService) Purge() (int, error) {
+    cutoff := time.Now().Add(-s.retention)
+    purged := 0
+
+    for {
+        posts, err := s.posts.ListDeletedBefore(cutoff, purgeBatchSize)
+        if err != nil {
+            return purged, err
+        }
+        if len(posts) == 0 {
+            return purged, nil
+        }
+
+        for _, post := range posts {
+            if err := s.purge(post); err != nil {
+                return purged, err
+            }
+            purged++
+        }
+    }
+}
+
+// purge permanently deletes the given post, its data, and the images only it
+// used.
+func (s *TrashService) purge(post *models.Post) error {
+    if err := s.posts.Delete(post.ID.Hex()); err != nil {
+        return err
+    }
+
+    for _, repo := range s.data {
+        if err := repo.DeleteByPost(post.ID); err != nil {
+            return err
+        }
+    }
+
+    urls := s.uploadedImages(post)
+    referenced, err := s.posts.ReferencedImages(urls)
+    if err != nil {
+        return err
+    }
+    for _, url := range urls {
+        if referenced[url] {
+            continue
+        }
+        // The post is gone already, so a failure only leaves an orphaned
+        // image behind.
+        if err := s.images.DeleteImage(strings.TrimPrefix(url, s.imageURL)); err != nil {
+            log.Printf("Cannot delete image %s of purged post %s: %v", url, post.ID.Hex(), err)
+        }
+    }
+
+    return nil
+}
+
+// uploadedImages returns the URLs of the uploaded images the given post uses,
+// as its cover image, in its content or in the content of its translations.
+// Images hosted elsewhere are left out.
+func (s *TrashService) uploadedImages(post *models.Post) []string {
+    if s.imageURL == "" {
+        return nil
+    }
+
+    links := append([]string{post.ImageURL}, utils.ImageURLs(post.ContentHTML)...)
+    for _, variant := range post.Variants {
+        links = append(links, utils.ImageURLs(variant.ContentHTML)...)
+    }
+
+    var urls []string
+    seen := map[string]bool{}
+    for _, url := range links {
+        if strings.HasPrefix(url, s.imageURL) && len(url) > len(s.imageURL) && !seen[url] {
+            seen[url] = true
+            urls = append(urls, url)
+        }
+    }
+    return urls
+}
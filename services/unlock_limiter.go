@@ -0,0 +1,152 @@
+package services
+
+import (
+    "strings"
+    "sync"
+    "time"
+)
+
+// UnlockLimits bounds how fast the password of a post can be guessed. Once a
+// client or a post has had more wrong passwords than allowed, every further
+// one makes it wait before the next attempt, starting with Backoff and
+// doubling up to MaxBackoff. Wrong passwords are forgotten once MaxBackoff
+// has passed without any.
+type UnlockLimits struct {
+    IPAttempts   int           // Wrong passwords from an IP address before it has to wait, 0 for no limit
+    PostAttempts int           // Wrong passwords on a post, from anywhere, before it has to wait, 0 for no limit
+    Backoff      time.Duration // First wait once the attempts are used up
+    MaxBackoff   time.Duration // Longest wait
+}
+
+// unlockFailures counts the recent wrong passwords of an IP address or on a
+// post.
+type unlockFailures struct {
+    count int
+    last  time.Time
+    until time.Time // Attempts are refused until then
+}
+
+// UnlockLimiter limits the attempts at unlocking password-protected posts,
+// per IP address and per post, so that passwords cannot be guessed by brute
+// force. Like the view buffer, it is kept in memory: it starts empty when
+// the server starts, and every server instance limits its own clients.
+type UnlockLimiter struct {
+    limits UnlockLimits
+    now    func() time.Time
+
+    mu     sync.Mutex
+    byIP   map[string]*unlockFailures
+    byPost map[string]*unlockFailures
+}
+
+// NewUnlockLimiter returns a new UnlockLimiter instance, given the limits
+// to enforce.
+func NewUnlockLimiter(limits UnlockLimits) *UnlockLimiter {
+    return &UnlockLimiter{
+        limits: limits,
+        now:    time.Now,
+        byIP:   make(map[string]*unlockFailures),
+        byPost: make(map[string]*unlockFailures),
+    }
+}
+
+// Wait returns how long the client at the given IP address has to wait
+// before trying a password on the post with the given ID, or 0 if it may try
+// now.
+func (l *UnlockLimiter) Wait(ip, postID string) time.Duration {
+    now := l.now()
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    var wait time.Duration
+    for _, f := range []*unlockFailures{l.byIP[ip], l.byPost[postKey(postID)]} {
+        if f != nil && f.until.Sub(now) > wait {
+            wait = f.until.Sub(now)
+        }
+    }
+    return wait
+}
+
+// Failed records a wrong password from the given IP address on the post with
+// the given ID.
+func (l *UnlockLimiter) Failed(ip, postID string) {
+    now := l.now()
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    l.fail(l.byIP, ip, l.limits.IPAttempts, now)
+    l.fail(l.byPost, postKey(postID), l.limits.PostAttempts, now)
+}
+
+// Succeeded forgets the wrong passwords from the given IP address, once it
+// has unlocked a post. Those on the post are kept, as a client guessing
+// among many addresses may have found the password.
+func (l *UnlockLimiter) Succeeded(ip string) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    delete(l.byIP, ip)
+}
+
+// Prune drops the wrong passwords that have been forgotten, so that the
+// limiter does not grow with every client ever seen. It returns the number
+// of IP addresses and posts dropped.
+func (l *UnlockLimiter) Prune() int {
+    now := l.now()
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    pruned := 0
+    for _, failures := range []map[string]*unlockFailures{l.byIP, l.byPost} {
+        for key, f := range failures {
+            if l.forgotten(f, now) {
+                delete(failures, key)
+                pruned++
+            }
+        }
+    }
+    return pruned
+}
+
+// fail counts a wrong password under the given key, making it wait once it
+// has had more than the given attempts. It must be called with l.mu held.
+func (l *UnlockLimiter) fail(failures map[string]*unlockFailures, key string, attempts int, now time.Time) {
+    if attempts <= 0 {
+        return
+    }
+
+    f := failures[key]
+    if f == nil || l.forgotten(f, now) {
+        f = &unlockFailures{}
+        failures[key] = f
+    }
+    f.count++
+    f.last = now
+
+    if f.count <= attempts {
+        return
+    }
+    wait := l.limits.Backoff
+    for i := attempts + 1; i < f.count && wait < l.limits.MaxBackoff; i++ {
+        wait *= 2
+    }
+    if wait > l.limits.MaxBackoff {
+        wait = l.limits.MaxBackoff
+    }
+    f.until = now.Add(wait)
+}
+
+// forgotten reports whether MaxBackoff has passed since the last wrong
+// password counted in f.
+func (l *UnlockLimiter) forgotten(f *unlockFailures, now time.Time) bool {
+    return now.Sub(f.last) >= l.limits.MaxBackoff && !now.Before(f.until)
+}
+
+// postKey normalizes a post ID, so that a post cannot be given more attempts
+// by spelling its ID in upper case.
+func postKey(postID string) string {
+    return strings.ToLower(postID)
+}
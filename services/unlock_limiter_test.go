@@ -0,0 +1,102 @@
+package services
+
+import (
+    "testing"
+    "time"
+)
+
+func TestUnlockLimiter(t *testing.T) {
+    now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+    newLimiter := func() *UnlockLimiter {
+        l := NewUnlockLimiter(UnlockLimits{IPAttempts: 3, PostAttempts: 5, Backoff: time.Second, MaxBackoff: 8 * time.Second})
+        l.now = func() time.Time { return now }
+        return l
+    }
+    const post = "64b7f0c2a1b2c3d4e5f60718"
+
+    t.Run("backs off an IP address", func(t *testing.T) {
+        l := newLimiter()
+        var waits []time.Duration
+        for i := 0; i < 7; i++ {
+            l.Failed("10.0.0.1", post+string(rune('a'+i))) // Different posts, so only the IP counts
+            waits = append(waits, l.Wait("10.0.0.1", post))
+        }
+        want := []time.Duration{0, 0, 0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}
+        for i := range want {
+            if waits[i] != want[i] {
+                t.Errorf("wait after %d wrong passwords = %v, want %v", i+1, waits[i], want[i])
+            }
+        }
+
+        l.Failed("10.0.0.1", post)
+        if got := l.Wait("10.0.0.1", post); got != 8*time.Second {
+            t.Errorf("wait past the longest = %v, want %v", got, 8*time.Second)
+        }
+        if got := l.Wait("10.0.0.2", post+"z"); got != 0 {
+            t.Errorf("another IP address waits %v", got)
+        }
+    })
+
+    t.Run("backs off a post from every IP address", func(t *testing.T) {
+        l := newLimiter()
+        for i := 0; i < 6; i++ {
+            l.Failed("10.0.0."+string(rune('1'+i)), post)
+        }
+        if got := l.Wait("10.0.1.1", "64B7F0C2A1B2C3D4E5F60718"); got != time.Second {
+            t.Errorf("wait of a new IP address on the post = %v, want %v", got, time.Second)
+        }
+        if got := l.Wait("10.0.0.1", post+"z"); got != 0 {
+            t.Errorf("wait on another post = %v, want 0", got)
+        }
+    })
+
+    t.Run("success forgets the IP address only", func(t *testing.T) {
+        l := newLimiter()
+        for i := 0; i < 6; i++ {
+            l.Failed("10.0.0.1", post)
+        }
+        l.Succeeded("10.0.0.1")
+        if got := l.Wait("10.0.0.1", post+"z"); got != 0 {
+            t.Errorf("wait of the IP address after a success = %v, want 0", got)
+        }
+        if got := l.Wait("10.0.0.2", post); got != time.Second {
+            t.Errorf("wait on the post after a success = %v, want %v", got, time.Second)
+        }
+    })
+
+    t.Run("forgets and prunes old wrong passwords", func(t *testing.T) {
+        l := newLimiter()
+        start := now
+        defer func() { now = start }()
+
+        for i := 0; i < 4; i++ {
+            l.Failed("10.0.0.1", post)
+        }
+        now = now.Add(time.Second)
+        if got := l.Wait("10.0.0.1", post); got != 0 {
+            t.Errorf("wait after the backoff = %v, want 0", got)
+        }
+        if got := l.Prune(); got != 0 {
+            t.Errorf("pruned %d before the wrong passwords were forgotten", got)
+        }
+
+        now = now.Add(8 * time.Second)
+        if got := l.Prune(); got != 2 {
+            t.Errorf("pruned %d, want the IP address and the post", got)
+        }
+        l.Failed("10.0.0.1", post)
+        if got := l.Wait("10.0.0.1", post); got != 0 {
+            t.Errorf("wait after forgetting = %v, want 0", got)
+        }
+    })
+
+    t.Run("no limit", func(t *testing.T) {
+        l := NewUnlockLimiter(UnlockLimits{Backoff: time.Second, MaxBackoff: time.Minute})
+        for i := 0; i < 100; i++ {
+            l.Failed("10.0.0.1", post)
+        }
+        if got := l.Wait("10.0.0.1", post); got != 0 {
+            t.Errorf("wait without limits = %v, want 0", got)
+        }
+    })
+}
@@ -2,9 +2,11 @@ package services
 
 import (
 	"fmt"
+    "io"
     "mime/multipart"
     "go-blog-backend/pkg/cloudflare"
     "go-blog-backend/pkg/utils"
+    "time"
 )
 
 type UploadService struct {
@@ -41,13 +43,50 @@ func (s *UploadService) UploadImage(file *multipart.FileHeader) (*cloudflare.Fil
         return nil, err
     }
 
-    newFile := &multipart.FileHeader{
-        Filename: file.Filename,
-        Header:   file.Header,
-        Size:     int64(len(processedImage)),
+    filename := fmt.Sprintf("%d-%s", time.Now().UnixNano(), file.Filename)
+    return s.r2Client.UploadAs(filename, file.Header.Get("Content-Type"), processedImage)
+}
+
+// UploadImageAs uploads the given image to the Cloudflare R2 bucket under the
+// given filename, and returns its public URL. JPEG and PNG images are resized
+// and recompressed as by UploadImage, while GIF and WebP images are uploaded
+// as they are, so that animations are kept. Any other type is rejected.
+func (s *UploadService) UploadImageAs(filename, contentType string, data []byte) (string, error) {
+    switch contentType {
+    case "image/jpeg", "image/png":
+        processed, err := s.imageProcessor.Process(data)
+        if err != nil {
+            return "", err
+        }
+        data = processed
+    case "image/gif", "image/webp":
+    default:
+        return "", fmt.Errorf("invalid image type %q", contentType)
+    }
+
+    upload, err := s.r2Client.UploadAs(filename, contentType, data)
+    if err != nil {
+        return "", err
     }
+    return upload.URL, nil
+}
+
+// HasImage reports whether an image is stored under the given filename in
+// the Cloudflare R2 bucket.
+func (s *UploadService) HasImage(filename string) (bool, error) {
+    return s.r2Client.Exists(filename)
+}
+
+// OpenImage returns the contents of the image with the given filename, to be
+// read as they are downloaded from the Cloudflare R2 bucket. The caller must
+// close it.
+func (s *UploadService) OpenImage(filename string) (io.ReadCloser, error) {
+    return s.r2Client.Open(filename)
+}
 
-    return s.r2Client.UploadFile(newFile)
+// ImageURL returns the public URL of the image with the given filename.
+func (s *UploadService) ImageURL(filename string) string {
+    return s.r2Client.URL(filename)
 }
 
 // DeleteImage deletes the image with the given filename from the Cloudflare R2
@@ -6,13 +6,18 @@ import (
     "github.com/golang-jwt/jwt/v4"
     "time"
     "errors"
+    "fmt"
+    "net/mail"
 )
 
+// minPasswordLength is the minimum length of a new password.
+const minPasswordLength = 6
+
 type UserRepository interface {
     Create(user *models.User) error
     GetByEmail(email string) (*models.User, error)
     GetByID(id string) (*models.User, error)
-    Update(id string, updates map[string]interface{}) error
+    Update(id string, version *int64, updates map[string]interface{}) error
     Delete(id string) error
 }
 
@@ -62,6 +67,8 @@ func (s *UserService) Register(username, email, password string) (*models.User,
         Username:  username,
         Email:     email,
         Password:  string(hashedPassword),
+        Role:      models.RoleUser,
+        Version:   1,
         CreatedAt: time.Now(),
         UpdatedAt: time.Now(),
     }
@@ -90,10 +97,16 @@ func (s *UserService) Login(email, password string) (string, error) {
         return "", errors.New("invalid credentials")
     }
 
+    role := user.Role
+    if role == "" {
+        role = models.RoleUser
+    }
+
     // Generate JWT token
     token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
         "user_id": user.ID.Hex(),
         "email":   user.Email,
+        "role":    role,
         "exp":     time.Now().Add(time.Hour * 24).Unix(),
     })
 
@@ -111,18 +124,44 @@ func (s *UserService) Login(email, password string) (string, error) {
 // and the value is the new value for that field. The updated_at field is automatically
 // set to the current time.
 //
-// The returned error will be non-nil if any error occurred during the update process.
-func (s *UserService) Update(userID string, updates map[string]interface{}) error {
+// Only the fields listed in models.UserPatchFields may be updated, so that
+// the role or the password hash cannot be written directly. A new password
+// is hashed before it is stored, and a new email must be a valid address.
+//
+// If version is not nil, the user is only updated if it is still at that
+// version. The returned user reflects the update, including its new version.
+//
+// The returned error will be models.ErrVersionConflict if the user is at
+// another version, and non-nil if any other error occurred during the update
+// process.
+func (s *UserService) Update(userID string, version *int64, updates map[string]interface{}) (*models.User, error) {
+    if err := checkPatchFields(updates, models.UserPatchFields); err != nil {
+        return nil, err
+    }
+
+    if email, ok := updates["email"].(string); ok {
+        if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+            return nil, fmt.Errorf("%w: invalid email address", models.ErrInvalidInput)
+        }
+    }
+
     if password, ok := updates["password"].(string); ok {
+        if len(password) < minPasswordLength {
+            return nil, fmt.Errorf("%w: passwords must be at least %d characters long", models.ErrInvalidInput, minPasswordLength)
+        }
         hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
         if err != nil {
-            return err
+            return nil, err
         }
         updates["password"] = string(hashedPassword)
     }
 
     updates["updated_at"] = time.Now()
-    return s.repo.Update(userID, updates)
+    if err := s.repo.Update(userID, version, updates); err != nil {
+        return nil, err
+    }
+
+    return s.repo.GetByID(userID)
 }
 
 // Delete deletes the user with the given ID from the "users" collection in the
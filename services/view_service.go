@@ -0,0 +1,330 @@
+package services
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "log"
+    "net/url"
+    "strings"
+    "sync"
+    "time"
+)
+
+type ViewRepository interface {
+    Salt(day time.Time) (string, error)
+    AddVisitors(visitors []models.ViewVisitor) ([]bool, error)
+    IncrementDaily(counts []*models.DailyViews) error
+    Series(filter models.ViewFilter) ([]*models.ViewPoint, error)
+    TopPosts(filter models.ViewFilter, limit int) ([]*models.PostViews, error)
+    TopReferrers(filter models.ViewFilter, limit int) ([]*models.ReferrerViews, error)
+}
+
+// ViewPostRepository is the part of the post storage the view service needs
+// to check who may read the statistics of a post.
+type ViewPostRepository interface {
+    GetByID(id string) (*models.Post, error)
+    GetByIDs(ids []primitive.ObjectID) ([]*models.Post, error)
+}
+
+// viewKey identifies the daily view counts of a post coming from a referrer.
+type viewKey struct {
+    postID   primitive.ObjectID
+    day      time.Time
+    referrer string
+}
+
+// viewBuffer holds the views recorded since the last flush.
+type viewBuffer struct {
+    counts   map[viewKey]*models.DailyViews
+    // visitors maps every visitor seen to the counts of their first view.
+    visitors map[models.ViewVisitor]viewKey
+    size     int
+}
+
+func newViewBuffer() *viewBuffer {
+    return &viewBuffer{
+        counts:   make(map[viewKey]*models.DailyViews),
+        visitors: make(map[models.ViewVisitor]viewKey),
+    }
+}
+
+// ViewService counts the views of posts without cookies or stored personal
+// data. Visitors are told apart by a hash of their IP address and user agent,
+// salted with a random value that changes every day.
+//
+// Views are buffered in memory and written in batches by Flush, so that
+// reading a post does not cost a database write. Views still in the buffer
+// are lost if the server stops.
+type ViewService struct {
+    repo       ViewRepository
+    posts      ViewPostRepository
+    bufferSize int
+
+    mu       sync.Mutex
+    buffer   *viewBuffer
+    saltDay  time.Time
+    salt     string
+    flushing sync.Mutex
+}
+
+// NewViewService returns a new ViewService instance, given a ViewRepository,
+// the post storage, and the number of buffered views that triggers a flush
+// before the next scheduled one.
+func NewViewService(repo ViewRepository, posts ViewPostRepository, bufferSize int) *ViewService {
+    return &ViewService{
+        repo:       repo,
+        posts:      posts,
+        bufferSize: bufferSize,
+        buffer:     newViewBuffer(),
+    }
+}
+
+// Record counts a view of the given post by a visitor with the given IP
+// address and user agent, coming from the given Referer. Views of posts that
+// are not published, views by the post's authors, and views by bots are not
+// counted. Only the host of the referrer is kept, and referrers on the given
+// host of the blog itself count as direct visits.
+//
+// Record never fails: errors are logged, and the view is dropped.
+func (s *ViewService) Record(post *models.Post, viewerID, ip, userAgent, referrer, host string) {
+    if !post.IsPublic() || post.IsAuthor(viewerID) || utils.IsBot(userAgent) {
+        return
+    }
+
+    day := time.Now().UTC().Truncate(24 * time.Hour)
+    salt, err := s.saltFor(day)
+    if err != nil {
+        log.Printf("Cannot record view of post %s: %v", post.ID.Hex(), err)
+        return
+    }
+
+    hash := sha256.Sum256([]byte(salt + "\x00" + ip + "\x00" + userAgent))
+    visitor := models.ViewVisitor{PostID: post.ID, Day: day, Visitor: hex.EncodeToString(hash[:])}
+    key := viewKey{postID: post.ID, day: day, referrer: referrerHost(referrer, host)}
+
+    s.mu.Lock()
+    counts, ok := s.buffer.counts[key]
+    if !ok {
+        counts = &models.DailyViews{PostID: post.ID, AuthorID: post.AuthorID, Day: day, Referrer: key.referrer}
+        s.buffer.counts[key] = counts
+    }
+    counts.Views++
+    if _, seen := s.buffer.visitors[visitor]; !seen {
+        s.buffer.visitors[visitor] = key
+    }
+    s.buffer.size++
+    full := s.bufferSize > 0 && s.buffer.size >= s.bufferSize
+    s.mu.Unlock()
+
+    if full {
+        go func() {
+            if _, err := s.Flush(); err != nil {
+                log.Printf("Cannot flush views: %v", err)
+            }
+        }()
+    }
+}
+
+// Flush writes the buffered views to the daily view counts, and returns the
+// number of views written. Visitors that were already counted for the same
+// post and day, possibly by another server instance, are not counted as
+// unique again.
+func (s *ViewService) Flush() (int64, error) {
+    s.flushing.Lock()
+    defer s.flushing.Unlock()
+
+    s.mu.Lock()
+    buffer := s.buffer
+    s.buffer = newViewBuffer()
+    s.mu.Unlock()
+
+    if buffer.size == 0 {
+        return 0, nil
+    }
+
+    visitors := make([]models.ViewVisitor, 0, len(buffer.visitors))
+    for visitor := range buffer.visitors {
+        visitors = append(visitors, visitor)
+    }
+
+    isNew, err := s.repo.AddVisitors(visitors)
+    if err != nil {
+        return 0, err
+    }
+    for i, visitor := range visitors {
+        if isNew[i] {
+            buffer.counts[buffer.visitors[visitor]].Uniques++
+        }
+    }
+
+    counts := make([]*models.DailyViews, 0, len(buffer.counts))
+    for _, count := range buffer.counts {
+        counts = append(counts, count)
+    }
+    if err := s.repo.IncrementDaily(counts); err != nil {
+        return 0, err
+    }
+
+    return int64(buffer.size), nil
+}
+
+// Series returns the daily views of the post with the given ID between two
+// days, for its author or an admin.
+func (s *ViewService) Series(postID, userID, role string, from, to time.Time) ([]*models.ViewPoint, error) {
+    post, err := s.posts.GetByID(postID)
+    if err != nil {
+        return nil, err
+    }
+    if post.AuthorID.Hex() != userID && role != models.RoleAdmin {
+        return nil, models.ErrForbidden
+    }
+
+    filter, err := viewFilter(from, to)
+    if err != nil {
+        return nil, err
+    }
+    filter.PostID = post.ID
+
+    return s.repo.Series(filter)
+}
+
+// TopPosts returns the most viewed posts between two days, with their
+// titles: the posts of userID, or every post for an admin.
+func (s *ViewService) TopPosts(userID, role string, from, to time.Time, limit int) ([]*models.PostViews, error) {
+    filter, err := s.scopedFilter(userID, role, "", from, to)
+    if err != nil {
+        return nil, err
+    }
+
+    top, err := s.repo.TopPosts(filter, limit)
+    if err != nil || len(top) == 0 {
+        return top, err
+    }
+
+    ids := make([]primitive.ObjectID, len(top))
+    for i, views := range top {
+        ids[i] = views.PostID
+    }
+    posts, err := s.posts.GetByIDs(ids)
+    if err != nil {
+        return nil, err
+    }
+    titles := make(map[primitive.ObjectID]string, len(posts))
+    for _, post := range posts {
+        titles[post.ID] = post.Title
+    }
+    for _, views := range top {
+        views.Title = titles[views.PostID]
+    }
+
+    return top, nil
+}
+
+// Referrers returns the referrers bringing the most views between two days:
+// to the post with the given ID if not empty, otherwise to the posts of
+// userID, or to every post for an admin.
+func (s *ViewService) Referrers(userID, role, postID string, from, to time.Time, limit int) ([]*models.ReferrerViews, error) {
+    filter, err := s.scopedFilter(userID, role, postID, from, to)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.repo.TopReferrers(filter, limit)
+}
+
+// scopedFilter returns the filter selecting the views userID may see between
+// two days, narrowed down to a post if postID is not empty.
+func (s *ViewService) scopedFilter(userID, role, postID string, from, to time.Time) (models.ViewFilter, error) {
+    filter, err := viewFilter(from, to)
+    if err != nil {
+        return filter, err
+    }
+
+    if postID != "" {
+        post, err := s.posts.GetByID(postID)
+        if err != nil {
+            return filter, err
+        }
+        if post.AuthorID.Hex() != userID && role != models.RoleAdmin {
+            return filter, models.ErrForbidden
+        }
+        filter.PostID = post.ID
+        return filter, nil
+    }
+
+    if role != models.RoleAdmin {
+        authorID, err := primitive.ObjectIDFromHex(userID)
+        if err != nil {
+            return filter, models.ErrForbidden
+        }
+        filter.AuthorID = authorID
+    }
+
+    return filter, nil
+}
+
+// saltFor returns the salt of the given day, loading it once per day.
+func (s *ViewService) saltFor(day time.Time) (string, error) {
+    s.mu.Lock()
+    if s.saltDay.Equal(day) {
+        salt := s.salt
+        s.mu.Unlock()
+        return salt, nil
+    }
+    s.mu.Unlock()
+
+    salt, err := s.repo.Salt(day)
+    if err != nil {
+        return "", err
+    }
+
+    s.mu.Lock()
+    s.saltDay, s.salt = day, salt
+    s.mu.Unlock()
+    return salt, nil
+}
+
+// viewFilter returns the filter selecting the views between two days,
+// inclusive. The returned error wraps models.ErrInvalidInput if the range is
+// reversed or longer than models.MaxViewRange.
+func viewFilter(from, to time.Time) (models.ViewFilter, error) {
+    from = from.UTC().Truncate(24 * time.Hour)
+    to = to.UTC().Truncate(24 * time.Hour)
+
+    if to.Before(from) {
+        return models.ViewFilter{}, fmt.Errorf("%w: the end of the period is before its start", models.ErrInvalidInput)
+    }
+    if to.Sub(from) > models.MaxViewRange {
+        return models.ViewFilter{}, fmt.Errorf("%w: periods are limited to %d days", models.ErrInvalidInput, int(models.MaxViewRange.Hours()/24))
+    }
+
+    return models.ViewFilter{From: from, To: to}, nil
+}
+
+// referrerHost returns the host name of the given Referer, or an empty string
+// for direct visits, malformed referrers and links within the blog itself.
+func referrerHost(referrer, host string) string {
+    if referrer == "" {
+        return ""
+    }
+
+    u, err := url.Parse(referrer)
+    if err != nil || u.Hostname() == "" {
+        return ""
+    }
+
+    name := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+    own := host
+    if h, _, found := strings.Cut(host, ":"); found {
+        own = h
+    }
+    if name == strings.TrimPrefix(strings.ToLower(own), "www.") {
+        return ""
+    }
+
+    return name
+}
@@ -0,0 +1,749 @@
+package services
+
+import (
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "go-blog-backend/models"
+    "go-blog-backend/pkg/utils"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "io"
+    "net/http"
+    "net/url"
+    "path"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// WordPress post statuses and how they are imported.
+var wordpressStatuses = map[string]string{
+    "publish": models.PostStatusPublished,
+    "future":  models.PostStatusPublished, // Scheduled again if still in the future
+    "draft":   models.PostStatusDraft,
+    "pending": models.PostStatusDraft,
+    "private": models.PostStatusDraft,
+}
+
+// wordpressInternalTypes are the post types WordPress uses for its own needs,
+// which are left out of imports without being reported.
+var wordpressInternalTypes = map[string]bool{
+    "attachment":          true,
+    "revision":            true,
+    "nav_menu_item":       true,
+    "custom_css":          true,
+    "customize_changeset": true,
+    "oembed_cache":        true,
+    "user_request":        true,
+    "wp_block":            true,
+    "wp_template":         true,
+    "wp_template_part":    true,
+    "wp_global_styles":    true,
+    "wp_navigation":       true,
+    "wp_font_family":      true,
+    "wp_font_face":        true,
+}
+
+// wordpressUncategorized is the slug of the category WordPress gives posts
+// without one; it is not imported.
+const wordpressUncategorized = "uncategorized"
+
+// Parts of the HTML content of WordPress posts.
+var (
+    wordpressBlock      = regexp.MustCompile(`(?i)^<(p|div|h[1-6]|ul|ol|li|dl|blockquote|pre|table|figure|hr|iframe|form|address|section|article|aside|header|footer|nav|script|style|!--)\b`)
+    wordpressCaption    = regexp.MustCompile(`(?s)\[caption[^\]]*\](.*?)\[/caption\]`)
+    wordpressCaptioned  = regexp.MustCompile(`(?s)^\s*((?:<a\b[^>]*>\s*)?<img\b[^>]*>(?:\s*</a>)?)(.*)$`)
+    wordpressShortcode  = regexp.MustCompile(`\[(gallery|embed|video|audio|playlist)\b[^\]]*\]|\[/([a-z][\w-]*)\]`)
+    wordpressImageLink  = regexp.MustCompile(`(?i)(\b(?:src|href)\s*=\s*)("[^"]*"|'[^']*')`)
+    wordpressResponsive = regexp.MustCompile(`(?i)\s(?:srcset|sizes)\s*=\s*("[^"]*"|'[^']*')`)
+    wordpressParagraph  = regexp.MustCompile(`\n\s*\n`)
+)
+
+// wordpressDateLayout is the layout of the dates in WordPress exports.
+const wordpressDateLayout = "2006-01-02 15:04:05"
+
+// wxrDocument is a WordPress eXtended RSS export. Elements are matched by
+// their local name, so that exports of every WXR version can be read.
+type wxrDocument struct {
+    Channel wxrChannel `xml:"channel"`
+}
+
+type wxrChannel struct {
+    Link        string        `xml:"link"`
+    BaseSiteURL string        `xml:"base_site_url"`
+    Authors     []wxrAuthor   `xml:"author"`
+    Categories  []wxrCategory `xml:"category"`
+    Items       []wxrItem     `xml:"item"`
+}
+
+type wxrAuthor struct {
+    ID          string `xml:"author_id"`
+    Login       string `xml:"author_login"`
+    Email       string `xml:"author_email"`
+    DisplayName string `xml:"author_display_name"`
+}
+
+type wxrCategory struct {
+    Slug        string `xml:"category_nicename"`
+    Parent      string `xml:"category_parent"`
+    Name        string `xml:"cat_name"`
+    Description string `xml:"category_description"`
+}
+
+type wxrItem struct {
+    Title         string       `xml:"title"`
+    Link          string       `xml:"link"`
+    Creator       string       `xml:"creator"`
+    Encoded       []wxrEncoded `xml:"encoded"` // Content and excerpt, told apart by namespace
+    ID            string       `xml:"post_id"`
+    Date          string       `xml:"post_date"`
+    DateGMT       string       `xml:"post_date_gmt"`
+    ModifiedGMT   string       `xml:"post_modified_gmt"`
+    CommentStatus string       `xml:"comment_status"`
+    Name          string       `xml:"post_name"`
+    Status        string       `xml:"status"`
+    Type          string       `xml:"post_type"`
+    AttachmentURL string       `xml:"attachment_url"`
+    Terms         []wxrTerm    `xml:"category"`
+    Meta          []wxrMeta    `xml:"postmeta"`
+    Comments      []wxrComment `xml:"comment"`
+}
+
+type wxrEncoded struct {
+    XMLName xml.Name
+    Text    string `xml:",chardata"`
+}
+
+type wxrTerm struct {
+    Domain   string `xml:"domain,attr"`
+    Nicename string `xml:"nicename,attr"`
+    Name     string `xml:",chardata"`
+}
+
+type wxrMeta struct {
+    Key   string `xml:"meta_key"`
+    Value string `xml:"meta_value"`
+}
+
+type wxrComment struct {
+    ID          string `xml:"comment_id"`
+    Author      string `xml:"comment_author"`
+    AuthorEmail string `xml:"comment_author_email"`
+    Date        string `xml:"comment_date"`
+    DateGMT     string `xml:"comment_date_gmt"`
+    Content     string `xml:"comment_content"`
+    Approved    string `xml:"comment_approved"`
+    Type        string `xml:"comment_type"`
+    Parent      string `xml:"comment_parent"`
+    UserID      string `xml:"comment_user_id"`
+}
+
+// encoded returns the content:encoded or excerpt:encoded element of the
+// item, as named by the given part of its namespace.
+func (item *wxrItem) encoded(namespace string) string {
+    for _, encoded := range item.Encoded {
+        if strings.Contains(encoded.XMLName.Space, namespace) {
+            return encoded.Text
+        }
+    }
+    return ""
+}
+
+// meta returns the value of the custom field of the item with the given key.
+func (item *wxrItem) meta(key string) string {
+    for _, meta := range item.Meta {
+        if meta.Key == key {
+            return meta.Value
+        }
+    }
+    return ""
+}
+
+// wordpressImport is the state of a single WordPress import.
+type wordpressImport struct {
+    *importRun
+    site    string   // Host and path of the blog, identifying it in sources
+    siteURL *url.URL // Address of the blog, against which links are resolved
+    // authors maps the logins and the IDs of the WordPress authors to their
+    // user; userIDs maps emails to the users found or created for them.
+    authors map[string]primitive.ObjectID
+    userIDs map[string]primitive.ObjectID
+    // categoryIDs maps the slugs of WordPress categories to their category,
+    // and declared lists those of the export to create them with their
+    // parent.
+    categoryIDs map[string]*primitive.ObjectID
+    declared    map[string]wxrCategory
+    // attachments maps the IDs of WordPress attachments to their URL, which
+    // may be on another host than the blog.
+    attachments    map[string]string
+    attachmentURLs map[string]bool
+    fallback       primitive.ObjectID // Author of the posts whose author is missing from the export
+}
+
+// ImportWordPress imports the posts and pages of a WordPress eXtended RSS
+// export, with their categories, tags, comments and images.
+//
+// Authors and commenters are matched to the users with the same email, and
+// get a placeholder account, which cannot log in, otherwise. Posts whose
+// author is missing from the export belong to the author given in opts, if
+// any. Categories are matched by slug, and created with their parents when
+// missing; a post's first category becomes its category, the others tags.
+// Pages are imported as posts tagged "page". Private posts are imported as
+// drafts, and posts in the trash left out.
+//
+// Images of the blog, attachments as well as the images linked from posts,
+// are downloaded and re-hosted on R2, and the links of posts rewritten.
+// Their content is kept as HTML, with paragraphs added to posts written
+// without them and captions turned into figures; other shortcodes are left
+// as they are, with a warning.
+//
+// Every post item of the report gives the post's address on WordPress, so
+// that redirects can be set up. A post that cannot be imported is reported
+// as failed and does not stop the import. The returned error will be
+// models.ErrInvalidInput if the export cannot be read or the author in opts
+// does not exist.
+func (s *ImportService) ImportWordPress(export io.Reader, opts models.ImportOptions) (*models.ImportReport, error) {
+    var doc wxrDocument
+    if err := xml.NewDecoder(export).Decode(&doc); err != nil {
+        return nil, fmt.Errorf("%w: cannot read the WordPress export: %v", models.ErrInvalidInput, err)
+    }
+    channel := &doc.Channel
+
+    site := channel.BaseSiteURL
+    if site == "" {
+        site = channel.Link
+    }
+    siteURL, err := url.Parse(strings.TrimSpace(site))
+    if err != nil || siteURL.Host == "" {
+        return nil, fmt.Errorf("%w: the WordPress export does not give the address of the blog", models.ErrInvalidInput)
+    }
+
+    imp := &wordpressImport{
+        importRun:      s.newRun(opts),
+        site:           strings.ToLower(siteURL.Host) + strings.TrimSuffix(siteURL.Path, "/"),
+        siteURL:        siteURL,
+        authors:        make(map[string]primitive.ObjectID),
+        userIDs:        make(map[string]primitive.ObjectID),
+        categoryIDs:    make(map[string]*primitive.ObjectID),
+        declared:       make(map[string]wxrCategory),
+        attachments:    make(map[string]string),
+        attachmentURLs: make(map[string]bool),
+    }
+    if opts.AuthorID != "" {
+        author, err := s.users.GetByID(opts.AuthorID)
+        if errors.Is(err, models.ErrNotFound) {
+            return nil, fmt.Errorf("%w: the author of the imported posts does not exist", models.ErrInvalidInput)
+        }
+        if err != nil {
+            return nil, err
+        }
+        imp.fallback = author.ID
+    }
+
+    for _, author := range channel.Authors {
+        userID, err := imp.user(author.Login, author.Email)
+        if err != nil {
+            return nil, err
+        }
+        imp.authors[author.Login] = userID
+        if author.ID != "" {
+            imp.authors["#"+author.ID] = userID
+        }
+    }
+    for _, category := range channel.Categories {
+        imp.declared[category.Slug] = category
+    }
+    for _, item := range channel.Items {
+        if item.Type == "attachment" && item.AttachmentURL != "" {
+            imp.attachments[item.ID] = strings.TrimSpace(item.AttachmentURL)
+            imp.attachmentURLs[strings.TrimSpace(item.AttachmentURL)] = true
+        }
+    }
+
+    // Posts are imported in the order they were written, whatever the order
+    // of the export.
+    items := make([]*wxrItem, 0, len(channel.Items))
+    for i := range channel.Items {
+        item := &channel.Items[i]
+        if wordpressInternalTypes[item.Type] {
+            continue
+        }
+        items = append(items, item)
+    }
+    sort.SliceStable(items, func(i, j int) bool {
+        return wordpressID(items[i].ID) < wordpressID(items[j].ID)
+    })
+    for _, item := range items {
+        imp.report.Add(imp.importItem(item))
+    }
+
+    return imp.report, nil
+}
+
+// wordpressID returns the numeric WordPress ID given, or 0.
+func wordpressID(id string) int64 {
+    n, _ := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+    return n
+}
+
+// importItem imports the given post or page with its comments.
+func (imp *wordpressImport) importItem(entry *wxrItem) *models.ImportItem {
+    item := &models.ImportItem{
+        Source: entry.Type + " " + entry.ID,
+        OldURL: strings.TrimSpace(entry.Link),
+    }
+    skip := func(reason string) *models.ImportItem {
+        item.Action = models.ImportSkipped
+        item.Error = reason
+        return item
+    }
+    fail := func(err error) *models.ImportItem {
+        item.Action = models.ImportFailed
+        item.Error = err.Error()
+        return item
+    }
+
+    if entry.Type != "post" && entry.Type != "page" {
+        return skip(fmt.Sprintf("%s entries are not imported", entry.Type))
+    }
+    status, ok := wordpressStatuses[entry.Status]
+    if !ok {
+        return skip(fmt.Sprintf("%s posts are not imported", entry.Status))
+    }
+    if entry.Status == "private" {
+        item.Warnings = append(item.Warnings, "private post imported as a draft")
+    }
+
+    post, err := imp.wordpressPost(entry, status, item)
+    if err != nil {
+        return fail(err)
+    }
+
+    saved, action, err := imp.posts.Import(post, imp.opts.DryRun)
+    if err != nil {
+        return fail(err)
+    }
+    item.Action = action
+    if !saved.ID.IsZero() {
+        item.PostID = saved.ID.Hex()
+    }
+    item.Slug = saved.Slug
+    item.Title = saved.Title
+
+    imp.importComments(saved, entry, item)
+    return item
+}
+
+// wordpressPost builds the post of the given WordPress entry, re-hosting its
+// images. Warnings are added to item.
+func (imp *wordpressImport) wordpressPost(entry *wxrItem, status string, item *models.ImportItem) (*models.Post, error) {
+    authorID, ok := imp.authors[entry.Creator]
+    if !ok {
+        if imp.fallback.IsZero() {
+            return nil, fmt.Errorf("the author %q is not in the export", entry.Creator)
+        }
+        authorID = imp.fallback
+    }
+
+    post := &models.Post{
+        Title:          strings.TrimSpace(entry.Title),
+        ContentFormat:  utils.FormatHTML,
+        AuthorID:       authorID,
+        SourceID:       "wordpress:" + imp.site + "/" + entry.ID,
+        Status:         status,
+        CommentsClosed: entry.CommentStatus == "closed",
+    }
+    if post.Title == "" {
+        post.Title = "Untitled"
+        item.Warnings = append(item.Warnings, "no title, imported as \"Untitled\"")
+    }
+
+    if created, ok := wordpressDate(entry.DateGMT, entry.Date); ok {
+        post.CreatedAt = created
+    }
+    if modified, ok := wordpressDate(entry.ModifiedGMT, ""); ok {
+        post.UpdatedAt = modified
+    }
+
+    if name, err := url.PathUnescape(entry.Name); err == nil && name != "" {
+        post.Slug = utils.Slugify(name)
+    }
+
+    if excerpt := utils.StripHTML(entry.encoded("excerpt")); strings.TrimSpace(excerpt) != "" {
+        post.Excerpt = utils.Excerpt(excerpt, maxExcerptLength)
+    }
+
+    for _, term := range entry.Terms {
+        switch term.Domain {
+        case "post_tag":
+            post.Tags = append(post.Tags, strings.TrimSpace(term.Name))
+        case "category":
+            if term.Nicename == wordpressUncategorized {
+                continue
+            }
+            if post.CategoryID == nil {
+                categoryID, err := imp.category(term.Nicename, strings.TrimSpace(term.Name))
+                if err != nil {
+                    return nil, err
+                }
+                if categoryID != nil {
+                    post.CategoryID = categoryID
+                    continue
+                }
+            }
+            post.Tags = append(post.Tags, strings.TrimSpace(term.Name))
+            item.Warnings = append(item.Warnings, fmt.Sprintf("category %q imported as a tag", strings.TrimSpace(term.Name)))
+        }
+    }
+    if entry.Type == "page" {
+        post.Tags = append(post.Tags, "page")
+    }
+
+    if thumbnail := imp.attachments[entry.meta("_thumbnail_id")]; thumbnail != "" {
+        post.ImageURL = imp.imageURL(thumbnail, item)
+    }
+    post.Content = imp.rewriteContent(entry.encoded("content"), item)
+
+    return post, nil
+}
+
+// wordpressDate returns the first of the given WordPress dates that is set.
+// Dates are taken as UTC: the GMT dates of WordPress are, and its local dates
+// carry no time zone.
+func wordpressDate(dates ...string) (time.Time, bool) {
+    for _, date := range dates {
+        t, err := time.Parse(wordpressDateLayout, strings.TrimSpace(date))
+        if err == nil && t.Year() > 1 {
+            return t, true
+        }
+    }
+    return time.Time{}, false
+}
+
+// user returns the ID of the user with the given email, creating a
+// placeholder account with the given name if there is none. Users created in
+// a dry run have no ID.
+func (imp *wordpressImport) user(name, email string) (primitive.ObjectID, error) {
+    email = strings.ToLower(strings.TrimSpace(email))
+    name = strings.TrimSpace(name)
+    if email == "" {
+        handle := utils.Slugify(name)
+        if handle == "" {
+            handle = "anonymous"
+        }
+        // Reserved for examples, so that it never reaches anyone.
+        email = handle + "@" + imp.siteURL.Hostname() + ".invalid"
+    }
+    if userID, ok := imp.userIDs[email]; ok {
+        return userID, nil
+    }
+
+    user, err := imp.users.GetByEmail(email)
+    if err == nil {
+        imp.userIDs[email] = user.ID
+        return user.ID, nil
+    }
+    if !errors.Is(err, models.ErrNotFound) {
+        return primitive.NilObjectID, err
+    }
+
+    imp.report.Users++
+    if imp.opts.DryRun {
+        imp.userIDs[email] = primitive.NilObjectID
+        return primitive.NilObjectID, nil
+    }
+
+    if name == "" {
+        name = strings.SplitN(email, "@", 2)[0]
+    }
+    now := time.Now()
+    user = &models.User{
+        Username:    name,
+        Email:       email,
+        Role:        models.RoleUser,
+        Placeholder: true,
+        Version:     1,
+        CreatedAt:   now,
+        UpdatedAt:   now,
+    }
+    if err := imp.users.Create(user); err != nil {
+        return primitive.NilObjectID, err
+    }
+    imp.userIDs[email] = user.ID
+    return user.ID, nil
+}
+
+// category returns the ID of the category with the given WordPress slug,
+// creating it, below its parents, if it does not exist. It returns nil for a
+// category created in a dry run.
+func (imp *wordpressImport) category(nicename, name string) (*primitive.ObjectID, error) {
+    if categoryID, ok := imp.categoryIDs[nicename]; ok {
+        return categoryID, nil
+    }
+
+    declared, ok := imp.declared[nicename]
+    if !ok {
+        declared = wxrCategory{Slug: nicename, Name: name}
+    }
+    slug := nicename
+    if unescaped, err := url.PathUnescape(nicename); err == nil {
+        slug = unescaped
+    }
+    slug = utils.Slugify(slug)
+    if slug == "" {
+        slug = utils.Slugify(declared.Name)
+    }
+
+    found, err := imp.categories.GetBySlug(slug)
+    if err == nil {
+        imp.categoryIDs[nicename] = &found.ID
+        return &found.ID, nil
+    }
+    if !errors.Is(err, models.ErrNotFound) {
+        return nil, err
+    }
+
+    // Mark the category as seen before creating its parents, in case the
+    // export has a loop.
+    imp.categoryIDs[nicename] = nil
+    parentID := ""
+    if declared.Parent != "" && declared.Parent != nicename {
+        parent, err := imp.category(declared.Parent, "")
+        if err != nil {
+            return nil, err
+        }
+        if parent != nil {
+            parentID = parent.Hex()
+        }
+    }
+
+    imp.report.Categories++
+    if imp.opts.DryRun {
+        return nil, nil
+    }
+    category := &models.Category{
+        Name:        strings.TrimSpace(declared.Name),
+        Slug:        slug,
+        Description: strings.TrimSpace(declared.Description),
+    }
+    if category.Name == "" {
+        category.Name = slug
+    }
+    if err := imp.categoryCreator.Create(category, parentID); err != nil {
+        return nil, err
+    }
+    imp.categoryIDs[nicename] = &category.ID
+    return &category.ID, nil
+}
+
+// importComments imports the comments of the given WordPress entry on the
+// post imported from it, parents first. Pingbacks, trackbacks and comments in
+// the trash are left out. Comments that cannot be imported are added to the
+// warnings of item.
+func (imp *wordpressImport) importComments(post *models.Post, entry *wxrItem, item *models.ImportItem) {
+    comments := make([]*wxrComment, 0, len(entry.Comments))
+    for i := range entry.Comments {
+        comment := &entry.Comments[i]
+        if comment.Approved == "trash" || (comment.Type != "" && comment.Type != "comment") {
+            continue
+        }
+        comments = append(comments, comment)
+    }
+    sort.SliceStable(comments, func(i, j int) bool {
+        return wordpressID(comments[i].ID) < wordpressID(comments[j].ID)
+    })
+
+    imported := make(map[string]primitive.ObjectID, len(comments))
+    for _, entryComment := range comments {
+        comment, err := imp.wordpressComment(post, entryComment, imported)
+        if err == nil {
+            var action string
+            action, err = imp.comments.Import(comment, imp.opts.DryRun)
+            if action == models.ImportCreated {
+                imp.report.Comments++
+            }
+        }
+        if err != nil {
+            item.Warnings = append(item.Warnings, fmt.Sprintf("comment %s not imported: %v", entryComment.ID, err))
+            continue
+        }
+        imported[entryComment.ID] = comment.ID
+    }
+}
+
+// wordpressComment builds the comment of the given WordPress comment on the
+// given post. Replies are attached to the comments already imported, as
+// given by their WordPress ID.
+func (imp *wordpressImport) wordpressComment(post *models.Post, entry *wxrComment, imported map[string]primitive.ObjectID) (*models.Comment, error) {
+    comment := &models.Comment{
+        PostID:   post.ID,
+        Body:     strings.TrimSpace(entry.Content),
+        SourceID: "wordpress:" + imp.site + "/comment/" + entry.ID,
+    }
+
+    switch entry.Approved {
+    case "1":
+        comment.Status = models.CommentStatusApproved
+    case "spam":
+        comment.Status = models.CommentStatusSpam
+    default:
+        comment.Status = models.CommentStatusPending
+    }
+
+    if created, ok := wordpressDate(entry.DateGMT, entry.Date); ok {
+        comment.CreatedAt = created
+        comment.UpdatedAt = created
+    }
+
+    if userID, ok := imp.authors["#"+entry.UserID]; ok && entry.UserID != "0" {
+        comment.AuthorID = userID
+    } else {
+        userID, err := imp.user(entry.Author, entry.AuthorEmail)
+        if err != nil {
+            return nil, err
+        }
+        comment.AuthorID = userID
+    }
+
+    if parentID, ok := imported[entry.Parent]; ok && !parentID.IsZero() {
+        comment.ParentID = &parentID
+    }
+    return comment, nil
+}
+
+// rewriteContent adds paragraphs to the given WordPress content when it has
+// none, turns its captions into figures, and points its images to their
+// re-hosted copies. Warnings are added to item.
+func (imp *wordpressImport) rewriteContent(content string, item *models.ImportItem) string {
+    content = wordpressCaption.ReplaceAllStringFunc(content, func(caption string) string {
+        inner := wordpressCaption.FindStringSubmatch(caption)[1]
+        parts := wordpressCaptioned.FindStringSubmatch(inner)
+        if parts == nil {
+            return inner
+        }
+        return "<figure>" + parts[1] + "<figcaption>" + strings.TrimSpace(parts[2]) + "</figcaption></figure>"
+    })
+
+    seen := map[string]bool{}
+    for _, match := range wordpressShortcode.FindAllStringSubmatch(content, -1) {
+        name := match[1] + match[2]
+        if !seen[name] {
+            seen[name] = true
+            item.Warnings = append(item.Warnings, fmt.Sprintf("shortcode %q left as is", name))
+        }
+    }
+
+    content = autop(content)
+    content = wordpressResponsive.ReplaceAllString(content, "")
+    return wordpressImageLink.ReplaceAllStringFunc(content, func(match string) string {
+        groups := wordpressImageLink.FindStringSubmatch(match)
+        link := strings.Trim(groups[2], `"'`)
+        rehosted := imp.imageURL(link, item)
+        if rehosted == link {
+            return match
+        }
+        return groups[1] + `"` + rehosted + `"`
+    })
+}
+
+// autop wraps the paragraphs of WordPress content written in the classic
+// editor, separated by blank lines, in <p> elements and keeps their line
+// breaks, as WordPress does when displaying it. Content that already has
+// paragraphs is returned as is.
+func autop(content string) string {
+    content = strings.ReplaceAll(content, "\r\n", "\n")
+    if strings.Contains(strings.ToLower(content), "<p>") || strings.Contains(strings.ToLower(content), "<p ") {
+        return content
+    }
+
+    var blocks []string
+    for _, block := range wordpressParagraph.Split(strings.TrimSpace(content), -1) {
+        // Blank lines in preformatted text do not end the block.
+        if n := len(blocks); n > 0 && strings.Count(strings.ToLower(blocks[n-1]), "<pre") > strings.Count(strings.ToLower(blocks[n-1]), "</pre") {
+            blocks[n-1] += "\n\n" + block
+            continue
+        }
+        blocks = append(blocks, block)
+    }
+
+    var b strings.Builder
+    for _, block := range blocks {
+        block = strings.TrimSpace(block)
+        if block == "" {
+            continue
+        }
+        if wordpressBlock.MatchString(block) {
+            b.WriteString(block)
+        } else {
+            b.WriteString("<p>")
+            b.WriteString(strings.ReplaceAll(block, "\n", "<br>\n"))
+            b.WriteString("</p>")
+        }
+        b.WriteString("\n")
+    }
+    return b.String()
+}
+
+// imageURL returns the URL of the re-hosted copy of the image of the blog,
+// or attachment, at the given URL, downloading it on first use. Links to
+// other sites and to other files are returned as they are, and so are images
+// that cannot be downloaded, with a warning.
+func (imp *wordpressImport) imageURL(link string, item *models.ImportItem) string {
+    parsed, err := url.Parse(strings.TrimSpace(link))
+    if err != nil {
+        return link
+    }
+    target := imp.siteURL.ResolveReference(parsed)
+    if !imp.attachmentURLs[strings.TrimSpace(link)] && !sameHost(target.Hostname(), imp.siteURL.Hostname()) {
+        return link
+    }
+    if _, ok := importImageTypes[strings.ToLower(path.Ext(target.Path))]; !ok {
+        return link
+    }
+
+    // The same image may be linked over http and https.
+    key := strings.ToLower(target.Host) + target.Path
+    if uploaded, ok := imp.uploaded[key]; ok {
+        return uploaded
+    }
+
+    uploaded, err := imp.storeImage([]byte(key), path.Ext(target.Path), func() ([]byte, error) {
+        return imp.download(target.String())
+    })
+    if err != nil {
+        item.Warnings = append(item.Warnings, fmt.Sprintf("image %s not re-hosted: %v", link, err))
+        return link
+    }
+    imp.uploaded[key] = uploaded
+    return uploaded
+}
+
+// sameHost reports whether the given host names are the same, with or
+// without "www.".
+func sameHost(a, b string) bool {
+    return strings.EqualFold(strings.TrimPrefix(strings.ToLower(a), "www."), strings.TrimPrefix(strings.ToLower(b), "www."))
+}
+
+// download returns the file at the given URL, refusing files larger than
+// maxImportFileSize.
+func (imp *wordpressImport) download(link string) ([]byte, error) {
+    resp, err := imp.client.Get(link)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("download failed with status %s", resp.Status)
+    }
+    data, err := io.ReadAll(io.LimitReader(resp.Body, maxImportFileSize+1))
+    if err != nil {
+        return nil, err
+    }
+    if len(data) > maxImportFileSize {
+        return nil, fmt.Errorf("the image is larger than %d MB", maxImportFileSize>>20)
+    }
+    return data, nil
+}